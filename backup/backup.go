@@ -0,0 +1,196 @@
+// Package backup copies SQLite databases while they are in use, keeps a
+// rotation of verified and compressed copies, and restores them.
+package backup
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ErrUnsupported is returned for databases other than SQLite, which have
+// their own backup tools.
+var ErrUnsupported = errors.New("backups are only supported for SQLite databases, use pg_dump or mysqldump for PostgreSQL and MySQL")
+
+// compressedExt ends the names of compressed backups.
+const compressedExt = ".gz"
+
+// Create writes a consistent copy of the database to path with VACUUM INTO,
+// which can run while the server is serving requests, and verifies it. The
+// copy is compressed with gzip when path ends with .gz. The path must not
+// exist yet.
+func Create(db *gorm.DB, path string) error {
+	if db.Dialector.Name() != string(database.SQLite) {
+		return ErrUnsupported
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	compress := strings.HasSuffix(path, compressedExt)
+	copyPath := path
+	if compress {
+		copyPath = strings.TrimSuffix(path, compressedExt) + ".tmp"
+		defer os.Remove(copyPath)
+	}
+	if err := db.Exec("VACUUM INTO ?", copyPath).Error; err != nil {
+		return err
+	}
+	if err := Verify(copyPath); err != nil {
+		os.Remove(copyPath)
+		return err
+	}
+	if compress {
+		return compressFile(copyPath, path)
+	}
+	return nil
+}
+
+// Verify checks that a database file is intact and holds a Pixis database.
+func Verify(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := gorm.Open(sqlite.Open("file:"+path+"?mode=ro"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return fmt.Errorf("%s is not an SQLite database: %w", path, err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	var result string
+	if err := db.Raw("PRAGMA integrity_check").Scan(&result).Error; err != nil {
+		return fmt.Errorf("%s is not an SQLite database: %w", path, err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("%s is corrupt: %s", path, result)
+	}
+	if !db.Migrator().HasTable(&database.AppliedMigration{}) {
+		return fmt.Errorf("%s is not a Pixis database", path)
+	}
+	return nil
+}
+
+// checkSchema checks that the migrations applied to a database file are
+// known to this version of Pixis and unchanged, so that the server can
+// start on it and bring it up to date.
+func checkSchema(path string) error {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	if err := migrator.Verify(); err != nil {
+		return fmt.Errorf("the backup cannot be used by this version of Pixis: %w", err)
+	}
+	return nil
+}
+
+// Restore replaces the SQLite database of a data source name with a backup,
+// compressed or not, after verifying its integrity and its schema version.
+// The server must be stopped, since it would keep using the replaced file.
+func Restore(path, dsn string) error {
+	target, ok := database.SQLitePath(dsn)
+	if !ok {
+		return ErrUnsupported
+	}
+	// The backup is copied next to the database and renamed over it only
+	// once complete and checked, so that a failed restore leaves the
+	// database as it was.
+	tmp, err := os.CreateTemp(filepath.Dir(target), filepath.Base(target)+".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := copyBackup(tmp, path); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := Verify(tmp.Name()); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if err := checkSchema(tmp.Name()); err != nil {
+		return err
+	}
+	// The journal of the replaced database would otherwise be applied to
+	// the backup.
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(target + suffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+// copyBackup copies a backup to dst, decompressing it if its name ends with
+// .gz.
+func copyBackup(dst *os.File, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	var r io.Reader = src
+	if strings.HasSuffix(path, compressedExt) {
+		gz, err := gzip.NewReader(src)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return dst.Sync()
+}
+
+// compressFile writes the gzip compression of src to dst, which only
+// appears once complete.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".tmp"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/models"
+	"gorm.io/gorm"
+)
+
+// openDatabase returns a migrated SQLite database holding one department.
+func openDatabase(t *testing.T) (*gorm.DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "pixis.db")
+	db, err := database.Connect(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.Create(&models.Department{Label: "1st Battalion"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, path
+}
+
+// departments returns the labels of the departments of a database file.
+func departments(t *testing.T, path string) []string {
+	t.Helper()
+	db, err := database.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+	var labels []string
+	if err := db.Model(&models.Department{}).Order("id").Pluck("label", &labels).Error; err != nil {
+		t.Fatal(err)
+	}
+	return labels
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	for _, name := range []string{"pixis.db", "pixis.db.gz"} {
+		t.Run(name, func(t *testing.T) {
+			db, path := openDatabase(t)
+			backupPath := filepath.Join(t.TempDir(), name)
+			if err := Create(db, backupPath); err != nil {
+				t.Fatal(err)
+			}
+			if err := Create(db, backupPath); err == nil {
+				t.Error("expected an existing backup not to be overwritten")
+			}
+			if err := db.Create(&models.Department{Label: "2nd Battalion"}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			target := filepath.Join(t.TempDir(), "restored.db")
+			if err := Restore(backupPath, target); err != nil {
+				t.Fatal(err)
+			}
+			if labels := departments(t, target); len(labels) != 1 || labels[0] != "1st Battalion" {
+				t.Errorf("expected the department of the backup, got %q", labels)
+			}
+			if labels := departments(t, path); len(labels) != 2 {
+				t.Errorf("expected the database to be left as it was, got %q", labels)
+			}
+		})
+	}
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "pixis.db")
+	if err := os.WriteFile(target, []byte("current"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(garbage, target); err == nil {
+		t.Error("expected a file that is not a database to be rejected")
+	}
+
+	// A backup taken by a later version has migrations unknown to this one.
+	db, _ := openDatabase(t)
+	if err := db.Create(&database.AppliedMigration{Version: 9999, Name: "future", Checksum: "0"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	future := filepath.Join(dir, "future.db")
+	if err := Create(db, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := Restore(future, target); !errors.Is(err, database.ErrUnknownMigration) {
+		t.Errorf("expected the unknown migration to be reported, got %v", err)
+	}
+
+	if content, _ := os.ReadFile(target); string(content) != "current" {
+		t.Errorf("expected the database to be left as it was, got %q", content)
+	}
+	if err := Restore(garbage, "postgres://localhost/pixis"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("expected other databases to be unsupported, got %v", err)
+	}
+}
+
+func TestManagerRotation(t *testing.T) {
+	db, _ := openDatabase(t)
+	dir := t.TempDir()
+	manager, err := NewManager(db, Options{Dir: dir, Keep: 2, MaxAge: time.Hour, Compress: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A stale backup is removed by age, and files of other names are kept.
+	stale := namePrefix + time.Now().Add(-2*time.Hour).UTC().Format(nameLayout) + nameExt
+	for _, name := range []string{stale, "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var taken []Info
+	for range 3 {
+		info, err := manager.Backup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size == 0 || filepath.Ext(info.Name) != compressedExt {
+			t.Errorf("expected a compressed backup, got %+v", info)
+		}
+		taken = append(taken, info)
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	backups, err := manager.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || backups[0].Name != taken[2].Name || backups[1].Name != taken[1].Name {
+		t.Errorf("expected the two most recent backups, got %+v", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("expected other files to be kept, got %v", err)
+	}
+	if err := Verify(manager.Path(backups[0])); err == nil {
+		t.Error("expected a compressed backup to need decompressing before verifying")
+	}
+	if err := Restore(manager.Path(backups[0]), filepath.Join(t.TempDir(), "restored.db")); err != nil {
+		t.Errorf("expected the backup to restore, got %v", err)
+	}
+}
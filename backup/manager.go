@@ -0,0 +1,161 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"gorm.io/gorm"
+)
+
+// Options configures the backups of a Manager.
+type Options struct {
+	// Dir holds the backups, and is created if needed.
+	Dir string
+	// Keep is the number of most recent backups kept.
+	Keep int
+	// MaxAge removes older backups, except the most recent one, unless 0.
+	MaxAge time.Duration
+	// Compress compresses the backups with gzip.
+	Compress bool
+}
+
+// Info describes a backup of a Manager.
+type Info struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Backups are named after the time they were taken, which orders them.
+const (
+	namePrefix = "pixis-"
+	nameLayout = "20060102T150405.000Z"
+	nameExt    = ".db"
+)
+
+// Manager writes backups of a database into a directory and keeps a
+// rotation of them.
+type Manager struct {
+	db      *gorm.DB
+	options Options
+	mu      sync.Mutex
+}
+
+// NewManager returns the backup manager of an SQLite database.
+func NewManager(db *gorm.DB, options Options) (*Manager, error) {
+	if db.Dialector.Name() != string(database.SQLite) {
+		return nil, ErrUnsupported
+	}
+	return &Manager{db: db, options: options}, nil
+}
+
+// Backup writes a verified backup of the database, see Create, and then
+// removes the backups that fall out of the rotation.
+func (m *Manager) Backup() (Info, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := os.MkdirAll(m.options.Dir, 0o750); err != nil {
+		return Info{}, err
+	}
+	name := namePrefix + time.Now().UTC().Format(nameLayout) + nameExt
+	if m.options.Compress {
+		name += compressedExt
+	}
+	path := filepath.Join(m.options.Dir, name)
+	if err := Create(m.db, path); err != nil {
+		return Info{}, err
+	}
+	stat, err := os.Stat(path)
+	if err != nil {
+		return Info{}, err
+	}
+	info, _ := parseName(name)
+	info.Size = stat.Size()
+	return info, m.prune()
+}
+
+// List returns the backups in the directory, the most recent first.
+func (m *Manager) List() ([]Info, error) {
+	entries, err := os.ReadDir(m.options.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []Info{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	backups := []Info{}
+	for _, entry := range entries {
+		info, ok := parseName(entry.Name())
+		if !ok || !entry.Type().IsRegular() {
+			continue
+		}
+		if stat, err := entry.Info(); err == nil {
+			info.Size = stat.Size()
+		}
+		backups = append(backups, info)
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// Path returns the file of a backup.
+func (m *Manager) Path(info Info) string {
+	return filepath.Join(m.options.Dir, info.Name)
+}
+
+// prune removes the backups beyond the Keep most recent ones and those
+// older than MaxAge, always keeping the most recent one.
+func (m *Manager) prune() error {
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i, info := range backups {
+		tooOld := m.options.MaxAge > 0 && time.Since(info.CreatedAt) > m.options.MaxAge
+		if i > 0 && (i >= m.options.Keep || tooOld) {
+			errs = append(errs, os.Remove(m.Path(info)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run takes a backup every interval until the context is done, reporting
+// each one.
+func (m *Manager) Run(ctx context.Context, interval time.Duration, report func(Info, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report(m.Backup())
+		}
+	}
+}
+
+// parseName reads the time of a backup from its name.
+func parseName(name string) (Info, bool) {
+	stamp, ok := strings.CutPrefix(name, namePrefix)
+	if !ok {
+		return Info{}, false
+	}
+	stamp = strings.TrimSuffix(stamp, compressedExt)
+	stamp, ok = strings.CutSuffix(stamp, nameExt)
+	if !ok {
+		return Info{}, false
+	}
+	at, err := time.Parse(nameLayout, stamp)
+	if err != nil {
+		return Info{}, false
+	}
+	return Info{Name: name, CreatedAt: at}, true
+}
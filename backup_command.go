@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/alexandrosraikos/pixis/backup"
+	"github.com/alexandrosraikos/pixis/config"
+)
+
+// backupOptions returns the options of the backup manager.
+func backupOptions(c config.Backup) backup.Options {
+	return backup.Options{Dir: c.Dir, Keep: c.Keep, MaxAge: c.MaxAge, Compress: c.Compress}
+}
+
+// runBackup implements `pixis backup [FILE]`, which copies the SQLite
+// database, also while the server is running, into the backup directory
+// with its rotation, or to FILE, compressed if it ends with .gz.
+func runBackup(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	loader := config.Bind(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(stderr, "usage: pixis backup [-config FILE] [flags] [FILE]")
+		return 2
+	}
+	cfg, err := loader.Load()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "invalid configuration:", err)
+		return 1
+	}
+	db, err := connect(loader)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if flags.NArg() == 1 {
+		if err := backup.Create(db, flags.Arg(0)); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "backed up the database to %s\n", flags.Arg(0))
+		return 0
+	}
+	manager, err := backup.NewManager(db, backupOptions(cfg.Backup))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	info, err := manager.Backup()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "backed up the database to %s (%d bytes)\n", manager.Path(info), info.Size)
+	return 0
+}
+
+// runRestore implements `pixis restore FILE`, which replaces the SQLite
+// database with the backup in FILE once the server is stopped.
+func runRestore(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	loader := config.Bind(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: pixis restore [-config FILE] [flags] FILE")
+		return 2
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := backup.Restore(flags.Arg(0), cfg.Database.DSN); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "restored %s into %s\n", flags.Arg(0), cfg.Database.DSN)
+	return 0
+}
@@ -0,0 +1,92 @@
+// Package calendar writes RFC 5545 iCalendar feeds.
+package calendar
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// ProductID identifies Pixis as the producer of the feeds.
+const ProductID = "-//Pixis//Duty Schedule//EN"
+
+// utcLayout is the RFC 5545 form of a UTC date-time.
+const utcLayout = "20060102T150405Z"
+
+// Event is a single VEVENT of a feed. UID must stay the same across updates
+// of the event so that calendar apps replace it instead of duplicating it.
+type Event struct {
+	UID          string
+	Summary      string
+	Description  string
+	Start        time.Time
+	End          time.Time
+	LastModified time.Time
+}
+
+// Calendar is a named VCALENDAR holding events.
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+// Write serializes the calendar with CRLF line endings and lines folded at
+// 75 octets, as required by RFC 5545.
+func (cal Calendar) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeFolded(bw, name+":"+value)
+	}
+	stamp := time.Now().UTC().Format(utcLayout)
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", ProductID)
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	if cal.Name != "" {
+		line("X-WR-CALNAME", escapeText(cal.Name))
+	}
+	for _, event := range cal.Events {
+		line("BEGIN", "VEVENT")
+		line("UID", event.UID)
+		line("DTSTAMP", stamp)
+		line("DTSTART", event.Start.UTC().Format(utcLayout))
+		line("DTEND", event.End.UTC().Format(utcLayout))
+		line("SUMMARY", escapeText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION", escapeText(event.Description))
+		}
+		if !event.LastModified.IsZero() {
+			line("LAST-MODIFIED", event.LastModified.UTC().Format(utcLayout))
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return bw.Flush()
+}
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// escapeText escapes a TEXT property value.
+func escapeText(s string) string {
+	return textEscaper.Replace(s)
+}
+
+// writeFolded writes a content line, folding it so that no line exceeds 75
+// octets without splitting UTF-8 sequences.
+func writeFolded(w *bufio.Writer, s string) {
+	const limit = 75
+	width := 0
+	for _, r := range s {
+		size := len(string(r))
+		if width+size > limit {
+			w.WriteString("\r\n ")
+			width = 1
+		}
+		w.WriteRune(r)
+		width += size
+	}
+	w.WriteString("\r\n")
+}
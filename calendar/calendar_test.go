@@ -0,0 +1,34 @@
+package calendar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteFoldsLongLines(t *testing.T) {
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	cal := Calendar{Events: []Event{{
+		UID:         "event-1@pixis",
+		Summary:     "Φρουρά πύλης",
+		Description: strings.Repeat("Σκοπιά; ", 30),
+		Start:       start,
+		End:         start.Add(8 * time.Hour),
+	}}}
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		t.Fatalf("failed to write calendar: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("expected lines of at most 75 octets, got %d: %q", len(line), line)
+		}
+	}
+	if !strings.Contains(buf.String(), "DTSTART:20250310T080000Z\r\n") {
+		t.Errorf("expected UTC start time, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "Σκοπιά; ") {
+		t.Errorf("expected semicolons to be escaped")
+	}
+}
@@ -0,0 +1,356 @@
+// Package config loads the configuration of Pixis from defaults, a YAML or
+// TOML file, environment variables and command-line flags, in increasing
+// order of precedence.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/logging"
+)
+
+// Config is the effective configuration of the server and the commands.
+type Config struct {
+	Server    Server
+	Database  Database
+	Auth      Auth
+	Bootstrap Bootstrap
+	Backup    Backup
+	Metrics   Metrics
+	Log       Log
+}
+
+// Server configures the HTTP server.
+type Server struct {
+	// Address is the host:port the server listens on.
+	Address string
+	// ReadTimeout limits reading a request, body included.
+	ReadTimeout time.Duration
+	// WriteTimeout limits handling a request and writing its response.
+	WriteTimeout time.Duration
+	// IdleTimeout closes keep-alive connections idle for longer.
+	IdleTimeout time.Duration
+	// ShutdownTimeout is how long the requests in flight are given to
+	// complete on SIGTERM or SIGINT before the server exits anyway.
+	ShutdownTimeout time.Duration
+}
+
+// Database configures the database, see database.ParseDSN.
+type Database struct {
+	DSN string
+}
+
+// Auth configures the tokens issued at login.
+type Auth struct {
+	// JWTSecret signs the tokens. It has no default and must be at least
+	// MinSecretLength bytes long.
+	JWTSecret string
+	// TokenLifetime is how long tokens stay valid.
+	TokenLifetime time.Duration
+}
+
+// Bootstrap gives the credentials of the first administrator, created when
+// the server starts on a database without administrators and ignored once
+// one exists.
+type Bootstrap struct {
+	AdminUsername string
+	AdminPassword string
+}
+
+// Backup configures the backups of SQLite databases, see backup.Manager.
+type Backup struct {
+	// Dir holds the backups.
+	Dir string
+	// Interval between scheduled backups, which are disabled when 0.
+	Interval time.Duration
+	// Keep is the number of most recent backups kept.
+	Keep int
+	// MaxAge removes older backups, except the most recent one, unless 0.
+	MaxAge time.Duration
+	// Compress compresses the backups with gzip.
+	Compress bool
+}
+
+// Metrics configures the Prometheus metrics served at /metrics.
+type Metrics struct {
+	// Interval between collections of the active conscripts and open
+	// slots, which query the database.
+	Interval time.Duration
+	// Token is required as a bearer token to read the metrics, unless
+	// empty.
+	Token string
+}
+
+// Log configures the logs of the server, see logging.New.
+type Log struct {
+	// Level is the lowest level logged: debug, info, warn or error.
+	Level string
+	// Format is json or text.
+	Format string
+}
+
+// MinPasswordLength is the minimum length of the bootstrap password.
+const MinPasswordLength = 12
+
+// MinSecretLength is the minimum length of the JWT secret, 256 bits for
+// HS256.
+const MinSecretLength = 32
+
+// Default returns the configuration used for the settings that are not set.
+func Default() Config {
+	return Config{
+		Server: Server{
+			Address:         ":8080",
+			ReadTimeout:     30 * time.Second,
+			WriteTimeout:    60 * time.Second,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Database: Database{DSN: database.DefaultDSN},
+		Auth:     Auth{TokenLifetime: 24 * time.Hour},
+		Backup:   Backup{Dir: "backups", Keep: 7, Compress: true},
+		Metrics:  Metrics{Interval: time.Minute},
+		Log:      Log{Level: "info", Format: logging.FormatJSON},
+	}
+}
+
+// setting is a configuration value that can be set from the file, under its
+// key, from the environment and, unless it is a secret, from a flag.
+type setting struct {
+	key    string
+	env    string
+	flag   string
+	usage  string
+	secret bool
+	get    func(c *Config) string
+	set    func(c *Config, value string) error
+}
+
+func stringSetting(key, env, flag, usage string, field func(c *Config) *string) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		get: func(c *Config) string { return *field(c) },
+		set: func(c *Config, value string) error {
+			*field(c) = value
+			return nil
+		},
+	}
+}
+
+func durationSetting(key, env, flag, usage string, field func(c *Config) *time.Duration) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		get: func(c *Config) string { return field(c).String() },
+		set: func(c *Config, value string) error {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q, expected e.g. 30m or 24h", value)
+			}
+			*field(c) = d
+			return nil
+		},
+	}
+}
+
+func intSetting(key, env, flag, usage string, field func(c *Config) *int) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		get: func(c *Config) string { return strconv.Itoa(*field(c)) },
+		set: func(c *Config, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", value)
+			}
+			*field(c) = n
+			return nil
+		},
+	}
+}
+
+func boolSetting(key, env, flag, usage string, field func(c *Config) *bool) setting {
+	return setting{
+		key: key, env: env, flag: flag, usage: usage,
+		get: func(c *Config) string { return strconv.FormatBool(*field(c)) },
+		set: func(c *Config, value string) error {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("invalid boolean %q, expected true or false", value)
+			}
+			*field(c) = b
+			return nil
+		},
+	}
+}
+
+func secret(s setting) setting {
+	s.secret = true
+	s.flag = ""
+	return s
+}
+
+// settings lists every setting, in the order they are printed.
+var settings = []setting{
+	stringSetting("server.address", "PIXIS_ADDRESS", "addr", "host:port to listen on",
+		func(c *Config) *string { return &c.Server.Address }),
+	durationSetting("server.read_timeout", "PIXIS_READ_TIMEOUT", "read-timeout", "how long reading a request may take",
+		func(c *Config) *time.Duration { return &c.Server.ReadTimeout }),
+	durationSetting("server.write_timeout", "PIXIS_WRITE_TIMEOUT", "write-timeout", "how long handling a request and writing its response may take",
+		func(c *Config) *time.Duration { return &c.Server.WriteTimeout }),
+	durationSetting("server.idle_timeout", "PIXIS_IDLE_TIMEOUT", "idle-timeout", "how long idle keep-alive connections are kept",
+		func(c *Config) *time.Duration { return &c.Server.IdleTimeout }),
+	durationSetting("server.shutdown_timeout", "PIXIS_SHUTDOWN_TIMEOUT", "shutdown-timeout", "how long requests in flight may take to complete on shutdown",
+		func(c *Config) *time.Duration { return &c.Server.ShutdownTimeout }),
+	stringSetting("database.dsn", "PIXIS_DATABASE", "database", "SQLite path or postgres:// or mysql:// URL of the database",
+		func(c *Config) *string { return &c.Database.DSN }),
+	secret(stringSetting("auth.jwt_secret", "PIXIS_JWT_SECRET", "", "secret signing the tokens",
+		func(c *Config) *string { return &c.Auth.JWTSecret })),
+	durationSetting("auth.token_lifetime", "PIXIS_TOKEN_LIFETIME", "token-lifetime", "how long tokens stay valid",
+		func(c *Config) *time.Duration { return &c.Auth.TokenLifetime }),
+	stringSetting("bootstrap.admin_username", "PIXIS_ADMIN_USERNAME", "admin-username", "username of the first administrator, created if none exists",
+		func(c *Config) *string { return &c.Bootstrap.AdminUsername }),
+	secret(stringSetting("bootstrap.admin_password", "PIXIS_ADMIN_PASSWORD", "", "password of the first administrator",
+		func(c *Config) *string { return &c.Bootstrap.AdminPassword })),
+	stringSetting("backup.dir", "PIXIS_BACKUP_DIR", "backup-dir", "directory of the backups",
+		func(c *Config) *string { return &c.Backup.Dir }),
+	durationSetting("backup.interval", "PIXIS_BACKUP_INTERVAL", "backup-interval", "interval between scheduled backups, 0 to disable them",
+		func(c *Config) *time.Duration { return &c.Backup.Interval }),
+	intSetting("backup.keep", "PIXIS_BACKUP_KEEP", "backup-keep", "number of most recent backups kept",
+		func(c *Config) *int { return &c.Backup.Keep }),
+	durationSetting("backup.max_age", "PIXIS_BACKUP_MAX_AGE", "backup-max-age", "age after which backups are removed, 0 to keep them",
+		func(c *Config) *time.Duration { return &c.Backup.MaxAge }),
+	boolSetting("backup.compress", "PIXIS_BACKUP_COMPRESS", "backup-compress", "compress the backups with gzip",
+		func(c *Config) *bool { return &c.Backup.Compress }),
+	durationSetting("metrics.interval", "PIXIS_METRICS_INTERVAL", "metrics-interval", "interval between collections of the active conscripts and open slots",
+		func(c *Config) *time.Duration { return &c.Metrics.Interval }),
+	secret(stringSetting("metrics.token", "PIXIS_METRICS_TOKEN", "", "bearer token required to read the metrics, none if empty",
+		func(c *Config) *string { return &c.Metrics.Token })),
+	stringSetting("log.level", "PIXIS_LOG_LEVEL", "log-level", "lowest level logged: debug, info, warn or error",
+		func(c *Config) *string { return &c.Log.Level }),
+	stringSetting("log.format", "PIXIS_LOG_FORMAT", "log-format", "format of the logs: json or text",
+		func(c *Config) *string { return &c.Log.Format }),
+}
+
+// lookup returns the setting of a file key.
+func lookup(key string) (setting, bool) {
+	for _, s := range settings {
+		if s.key == key {
+			return s, true
+		}
+	}
+	return setting{}, false
+}
+
+// Validate reports every invalid setting of the configuration.
+func (c Config) Validate() error {
+	var errs []error
+	if _, port, err := net.SplitHostPort(c.Server.Address); err != nil {
+		errs = append(errs, fmt.Errorf("server.address: %v", err))
+	} else if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		errs = append(errs, fmt.Errorf("server.address: invalid port %q", port))
+	}
+	timeouts := []struct {
+		key   string
+		value time.Duration
+	}{
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive", timeout.key))
+		}
+	}
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database.dsn: must be set"))
+	} else if _, _, err := database.ParseDSN(c.Database.DSN); err != nil {
+		errs = append(errs, fmt.Errorf("database.dsn: %v", err))
+	}
+	switch {
+	case c.Auth.JWTSecret == "":
+		errs = append(errs, errors.New("auth.jwt_secret: must be set, e.g. with PIXIS_JWT_SECRET or PIXIS_JWT_SECRET_FILE"))
+	case len(c.Auth.JWTSecret) < MinSecretLength:
+		errs = append(errs, fmt.Errorf("auth.jwt_secret: must be at least %d bytes long", MinSecretLength))
+	}
+	if c.Auth.TokenLifetime <= 0 {
+		errs = append(errs, errors.New("auth.token_lifetime: must be positive"))
+	}
+	if c.Backup.Dir == "" {
+		errs = append(errs, errors.New("backup.dir: must be set"))
+	}
+	if c.Backup.Interval < 0 {
+		errs = append(errs, errors.New("backup.interval: must not be negative"))
+	} else if _, sqlite := database.SQLitePath(c.Database.DSN); c.Backup.Interval > 0 && !sqlite {
+		errs = append(errs, errors.New("backup.interval: scheduled backups need an SQLite database"))
+	}
+	if c.Backup.Keep < 1 {
+		errs = append(errs, errors.New("backup.keep: must be at least 1"))
+	}
+	if c.Backup.MaxAge < 0 {
+		errs = append(errs, errors.New("backup.max_age: must not be negative"))
+	}
+	if c.Metrics.Interval <= 0 {
+		errs = append(errs, errors.New("metrics.interval: must be positive"))
+	}
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %v", err))
+	}
+	if c.Log.Format != logging.FormatJSON && c.Log.Format != logging.FormatText {
+		errs = append(errs, fmt.Errorf("log.format: expected %s or %s", logging.FormatJSON, logging.FormatText))
+	}
+	switch {
+	case c.Bootstrap.AdminUsername == "" && c.Bootstrap.AdminPassword != "":
+		errs = append(errs, errors.New("bootstrap.admin_username: must be set with bootstrap.admin_password"))
+	case c.Bootstrap.AdminUsername != "" && len(c.Bootstrap.AdminPassword) < MinPasswordLength:
+		errs = append(errs, fmt.Errorf("bootstrap.admin_password: must be at least %d characters long", MinPasswordLength))
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted returns the configuration with its secrets, and the password of
+// the database, replaced by a placeholder.
+func (c Config) Redacted() Config {
+	for _, s := range settings {
+		if s.secret && s.get(&c) != "" {
+			s.set(&c, redacted)
+		}
+	}
+	c.Database.DSN = redactDSN(c.Database.DSN)
+	return c
+}
+
+const redacted = "<redacted>"
+
+// redactDSN replaces the password in the user information of a database URL
+// and the values of its query parameters naming a password, such as
+// ?password= or ?sslpassword=.
+func redactDSN(dsn string) string {
+	scheme, rest, found := strings.Cut(dsn, "://")
+	if !found {
+		return dsn
+	}
+	rest, query, hasQuery := strings.Cut(rest, "?")
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		if user, _, found := strings.Cut(rest[:at], ":"); found {
+			rest = user + ":" + redacted + rest[at:]
+		}
+	}
+	if !hasQuery {
+		return scheme + "://" + rest
+	}
+	params := strings.Split(query, "&")
+	for i, param := range params {
+		key, _, found := strings.Cut(param, "=")
+		if found && strings.Contains(strings.ToLower(key), "password") {
+			params[i] = key + "=" + redacted
+		}
+	}
+	return scheme + "://" + rest + "?" + strings.Join(params, "&")
+}
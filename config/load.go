@@ -0,0 +1,178 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the configuration file when the -config flag is not given.
+const FileEnv = "PIXIS_CONFIG"
+
+// Loader loads the configuration of a command from its flags.
+type Loader struct {
+	flags *flag.FlagSet
+	path  *string
+}
+
+// Bind defines -config and a flag per setting on a command's flag set. The
+// configuration is loaded with Load once the flags are parsed.
+func Bind(flags *flag.FlagSet) *Loader {
+	l := &Loader{flags: flags}
+	l.path = flags.String("config", "", "YAML or TOML configuration file (default $"+FileEnv+")")
+	defaults := Default()
+	for _, s := range settings {
+		if s.flag != "" {
+			flags.String(s.flag, s.get(&defaults), s.usage+" ($"+s.env+")")
+		}
+	}
+	return l
+}
+
+// Load returns the configuration: the defaults, overridden by the file, by
+// the environment and by the flags set on the command line. It does not
+// validate the configuration, see Config.Validate.
+func (l *Loader) Load() (Config, error) {
+	c := Default()
+	path := *l.path
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		if err := loadFile(&c, path); err != nil {
+			return c, err
+		}
+	}
+	if err := loadEnv(&c); err != nil {
+		return c, err
+	}
+	var err error
+	l.flags.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if err == nil && s.flag == f.Name {
+				if setErr := s.set(&c, f.Value.String()); setErr != nil {
+					err = fmt.Errorf("-%s: %w", f.Name, setErr)
+				}
+			}
+		}
+	})
+	return c, err
+}
+
+// loadFile applies the settings of a YAML or TOML file, picked by its
+// extension. Settings are grouped in sections, e.g. auth.token_lifetime is
+// token_lifetime under auth, and unknown settings are rejected.
+func loadFile(c *Config, path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	values := map[string]any{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(raw, &values)
+	case ".toml":
+		err = toml.Unmarshal(raw, &values)
+	default:
+		return fmt.Errorf("%s: unsupported configuration file, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	flat := map[string]string{}
+	if err := flatten("", values, flat); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s, ok := lookup(key)
+		if !ok {
+			return fmt.Errorf("%s: unknown setting %s", path, key)
+		}
+		if err := s.set(c, flat[key]); err != nil {
+			return fmt.Errorf("%s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// flatten collects the scalar values of nested sections under their dotted
+// keys.
+func flatten(prefix string, values map[string]any, flat map[string]string) error {
+	for name, value := range values {
+		key := prefix + name
+		switch value := value.(type) {
+		case map[string]any:
+			if err := flatten(key+".", value, flat); err != nil {
+				return err
+			}
+		case string, bool, int, int64, uint64, float64:
+			flat[key] = fmt.Sprint(value)
+		default:
+			return fmt.Errorf("%s: expected a single value", key)
+		}
+	}
+	return nil
+}
+
+// loadEnv applies the settings given by environment variables. Every
+// variable can instead name a file holding the value with a _FILE suffix,
+// e.g. PIXIS_JWT_SECRET_FILE for Docker or Kubernetes secrets; a trailing
+// newline in the file is ignored.
+func loadEnv(c *Config) error {
+	for _, s := range settings {
+		value, set := os.LookupEnv(s.env)
+		if path, fromFile := os.LookupEnv(s.env + "_FILE"); fromFile {
+			if set {
+				return fmt.Errorf("%s and %s_FILE are both set", s.env, s.env)
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s_FILE: %w", s.env, err)
+			}
+			value, set = strings.TrimRight(string(raw), "\r\n"), true
+		}
+		if !set {
+			continue
+		}
+		if err := s.set(c, value); err != nil {
+			return fmt.Errorf("%s: %w", s.env, err)
+		}
+	}
+	return nil
+}
+
+// Print writes the configuration as a YAML file, in the format Load reads.
+func (c Config) Print(w io.Writer) error {
+	var b bytes.Buffer
+	// JSON strings are valid YAML double-quoted scalars.
+	quote := json.NewEncoder(&b)
+	quote.SetEscapeHTML(false)
+	section := ""
+	for _, s := range settings {
+		name, key, _ := strings.Cut(s.key, ".")
+		if name != section {
+			fmt.Fprintf(&b, "%s:\n", name)
+			section = name
+		}
+		fmt.Fprintf(&b, "  %s: ", key)
+		if err := quote.Encode(s.get(&c)); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/alexandrosraikos/pixis/config"
+)
+
+const configUsage = "usage: pixis config print [-config FILE] [flags]"
+
+// runConfig implements `pixis config print`, which writes the effective
+// configuration with its secrets redacted and reports whether it is valid.
+func runConfig(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(stderr, configUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	loader := config.Bind(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(stderr, configUsage)
+		return 2
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := cfg.Redacted().Print(stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(stderr, "invalid configuration:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,346 @@
+// Package conflicts detects scheduling conflicts between duty assignments:
+// overlapping duties of a conscript, insufficient rest between duties,
+// duties staffed beyond their capacity, duties during approved absences,
+// duties requiring qualifications the conscript does not hold and duties
+// after the discharge of the conscript.
+package conflicts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// MinimumRest is the minimum time a conscript must rest between two duties.
+var MinimumRest = 8 * time.Hour
+
+// Kind is the kind of a scheduling conflict.
+type Kind string
+
+const (
+	KindOverlap  Kind = "overlap"
+	KindRest     Kind = "rest"
+	KindCapacity Kind = "capacity"
+	KindAbsence  Kind = "absence"
+	// KindQualification is an assignment to a duty requiring qualifications
+	// the conscript does not hold.
+	KindQualification Kind = "qualification"
+	// KindDischarge is an assignment ending after the discharge of the conscript.
+	KindDischarge Kind = "discharge"
+)
+
+// ErrInvalidWindow is returned for assignments that do not end after they start.
+var ErrInvalidWindow = errors.New("EndTime must be after StartTime")
+
+// Conflict describes why an assignment cannot be booked, along with the
+// existing assignments or absences it conflicts with, or the qualifications
+// it lacks.
+type Conflict struct {
+	Kind           Kind                   `json:"kind"`
+	Message        string                 `json:"message"`
+	Assignments    []models.ConscriptDuty `json:"assignments"`
+	Absences       []models.Absence       `json:"absences,omitempty"`
+	Qualifications []models.Qualification `json:"qualifications,omitempty"`
+}
+
+// ValidateWindow checks that the assignment ends after it starts.
+func ValidateWindow(a models.ConscriptDuty) error {
+	if !a.EndTime.After(a.StartTime) {
+		return ErrInvalidWindow
+	}
+	return nil
+}
+
+// Records are the stored records the conflicts of assignments are found
+// among, loaded by the repository: see Window for those Check needs and
+// ReportWindow for those Report needs.
+type Records struct {
+	// Assignments are the stored assignments near the checked ones.
+	Assignments []models.ConscriptDuty
+	// Absences are the approved absences of the conscripts.
+	Absences []models.Absence
+	// Conscripts are the conscripts of the assignments, or at least those
+	// of them who are discharged.
+	Conscripts []models.Conscript
+	// Duties are the duties of the assignments, with the qualifications they
+	// require, or at least those with a capacity or qualifications.
+	Duties []models.Duty
+	// Qualifications are the qualifications held by the conscripts.
+	Qualifications []models.ConscriptQualification
+}
+
+// Window returns the range of the stored records that Check needs for an
+// assignment: the assignments of its conscript starting before the end and
+// ending after the start, the assignments of its duty and the approved
+// absences of its conscript overlapping the assignment, its conscript, its
+// duty and the qualifications of its conscript.
+func Window(a models.ConscriptDuty) (start, end time.Time) {
+	return a.StartTime.UTC().Add(-MinimumRest), a.EndTime.UTC().Add(MinimumRest)
+}
+
+// Check returns the conflicts that booking the assignment would cause with
+// the records, which are loaded as documented by Window. The assignment
+// itself is ignored, so that updates can be checked too.
+func Check(a models.ConscriptDuty, records Records) ([]Conflict, error) {
+	if err := ValidateWindow(a); err != nil {
+		return nil, err
+	}
+	start, end := a.StartTime, a.EndTime
+
+	var overlapping, resting, concurrent []models.ConscriptDuty
+	for _, other := range sorted(records.Assignments) {
+		if other.ID == a.ID && a.ID != 0 {
+			continue
+		}
+		overlaps := other.StartTime.Before(end) && other.EndTime.After(start)
+		if other.ConscriptID == a.ConscriptID {
+			switch {
+			case overlaps:
+				overlapping = append(overlapping, other)
+			case other.StartTime.Before(end.Add(MinimumRest)) && other.EndTime.After(start.Add(-MinimumRest)):
+				resting = append(resting, other)
+			}
+		}
+		if other.DutyID == a.DutyID && overlaps {
+			concurrent = append(concurrent, other)
+		}
+	}
+
+	var result []Conflict
+	if len(overlapping) > 0 {
+		result = append(result, Conflict{
+			Kind:        KindOverlap,
+			Message:     "The conscript already has a duty at that time",
+			Assignments: overlapping,
+		})
+	}
+	if len(resting) > 0 {
+		result = append(result, Conflict{
+			Kind:        KindRest,
+			Message:     fmt.Sprintf("The conscript must rest at least %s between duties", MinimumRest),
+			Assignments: resting,
+		})
+	}
+
+	var absences []models.Absence
+	for _, absence := range records.Absences {
+		if absence.ConscriptID == a.ConscriptID && absence.Status == models.AbsenceApproved && absence.StartTime.Before(end) && absence.EndTime.After(start) {
+			absences = append(absences, absence)
+		}
+	}
+	if len(absences) > 0 {
+		sort.SliceStable(absences, func(i, j int) bool { return absences[i].StartTime.Before(absences[j].StartTime) })
+		result = append(result, Conflict{
+			Kind:        KindAbsence,
+			Message:     "The conscript is absent at that time",
+			Assignments: []models.ConscriptDuty{},
+			Absences:    absences,
+		})
+	}
+
+	for _, conscript := range records.Conscripts {
+		if conscript.ID == a.ConscriptID && conscript.Status == models.StatusDischarged && dischargedBefore(conscript, end) {
+			result = append(result, Conflict{
+				Kind:        KindDischarge,
+				Message:     "The conscript is discharged",
+				Assignments: []models.ConscriptDuty{},
+			})
+		}
+	}
+
+	duty, ok := findDuty(records.Duties, a.DutyID)
+	if !ok {
+		return result, nil
+	}
+	if missing := Missing(duty, a, records.Qualifications); len(missing) > 0 {
+		result = append(result, Conflict{
+			Kind:           KindQualification,
+			Message:        "The conscript lacks qualifications the duty requires",
+			Assignments:    []models.ConscriptDuty{},
+			Qualifications: missing,
+		})
+	}
+	if duty.Capacity > 0 && peakOccupancy(concurrent, start, end) >= duty.Capacity {
+		result = append(result, Conflict{
+			Kind:        KindCapacity,
+			Message:     fmt.Sprintf("The duty is already fully staffed (capacity %d)", duty.Capacity),
+			Assignments: concurrent,
+		})
+	}
+	return result, nil
+}
+
+// sorted returns the assignments ordered by start time.
+func sorted(assignments []models.ConscriptDuty) []models.ConscriptDuty {
+	result := append([]models.ConscriptDuty(nil), assignments...)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].StartTime.Before(result[j].StartTime) })
+	return result
+}
+
+func findDuty(duties []models.Duty, id uint) (models.Duty, bool) {
+	for _, duty := range duties {
+		if duty.ID == id {
+			return duty, true
+		}
+	}
+	return models.Duty{}, false
+}
+
+// dischargedBefore reports whether the discharged conscript leaves before t.
+// A discharge without a date applies to every duty.
+func dischargedBefore(conscript models.Conscript, t time.Time) bool {
+	return conscript.DischargeDate == nil || conscript.DischargeDate.Before(t)
+}
+
+// peakOccupancy returns the maximum number of the assignments that are
+// active at the same time within [start, end).
+func peakOccupancy(assignments []models.ConscriptDuty, start, end time.Time) int {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	for _, a := range assignments {
+		from, to := a.StartTime, a.EndTime
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		edges = append(edges, edge{from, 1}, edge{to, -1})
+	}
+	// Assignments ending when others start do not overlap.
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+	peak, current := 0, 0
+	for _, e := range edges {
+		current += e.delta
+		if current > peak {
+			peak = current
+		}
+	}
+	return peak
+}
+
+// ReportWindow returns the range of the stored records that Report needs
+// for the conflicts in [from, to): the assignments starting before the end
+// and ending after the start, the approved absences overlapping [from, to),
+// the discharged conscripts, the duties with a capacity or qualifications
+// and the qualifications held by the conscripts of the assignments.
+func ReportWindow(from, to time.Time) (start, end time.Time) {
+	return from.UTC().Add(-MinimumRest), to.UTC().Add(MinimumRest)
+}
+
+// Report lists the conflicts between the assignments overlapping [from, to),
+// each one reported once, among the records, which are loaded as documented
+// by ReportWindow.
+func Report(from, to time.Time, records Records) []Conflict {
+	var result []Conflict
+	byConscript := map[uint][]models.ConscriptDuty{}
+	byDuty := map[uint][]models.ConscriptDuty{}
+	for _, a := range sorted(records.Assignments) {
+		byConscript[a.ConscriptID] = append(byConscript[a.ConscriptID], a)
+		byDuty[a.DutyID] = append(byDuty[a.DutyID], a)
+	}
+	inRange := func(a models.ConscriptDuty) bool {
+		return a.StartTime.Before(to) && a.EndTime.After(from)
+	}
+
+	for _, list := range byConscript {
+		for i, a := range list {
+			for _, b := range list[i+1:] {
+				if !inRange(a) && !inRange(b) {
+					continue
+				}
+				pair := []models.ConscriptDuty{a, b}
+				switch {
+				case b.StartTime.Before(a.EndTime) && b.EndTime.After(a.StartTime):
+					result = append(result, Conflict{Kind: KindOverlap, Message: "The conscript has overlapping duties", Assignments: pair})
+				case b.StartTime.Sub(a.EndTime) < MinimumRest:
+					result = append(result, Conflict{Kind: KindRest, Message: fmt.Sprintf("The conscript rests less than %s between duties", MinimumRest), Assignments: pair})
+				}
+			}
+		}
+	}
+
+	for _, duty := range records.Duties {
+		if duty.Capacity == 0 {
+			continue
+		}
+		if list := byDuty[duty.ID]; peakOccupancy(list, from, to) > duty.Capacity {
+			result = append(result, Conflict{
+				Kind:        KindCapacity,
+				Message:     fmt.Sprintf("The duty is staffed beyond its capacity of %d", duty.Capacity),
+				Assignments: list,
+			})
+		}
+	}
+
+	for _, absence := range records.Absences {
+		if absence.Status != models.AbsenceApproved || !absence.StartTime.Before(to) || !absence.EndTime.After(from) {
+			continue
+		}
+		var during []models.ConscriptDuty
+		for _, a := range byConscript[absence.ConscriptID] {
+			if inRange(a) && a.StartTime.Before(absence.EndTime) && a.EndTime.After(absence.StartTime) {
+				during = append(during, a)
+			}
+		}
+		if len(during) > 0 {
+			result = append(result, Conflict{
+				Kind:        KindAbsence,
+				Message:     "The conscript has duties during an approved absence",
+				Assignments: during,
+				Absences:    []models.Absence{absence},
+			})
+		}
+	}
+
+	for _, conscript := range records.Conscripts {
+		if conscript.Status != models.StatusDischarged {
+			continue
+		}
+		var after []models.ConscriptDuty
+		for _, a := range byConscript[conscript.ID] {
+			if inRange(a) && dischargedBefore(conscript, a.EndTime) {
+				after = append(after, a)
+			}
+		}
+		if len(after) > 0 {
+			result = append(result, Conflict{
+				Kind:        KindDischarge,
+				Message:     "The conscript has duties after their discharge",
+				Assignments: after,
+			})
+		}
+	}
+
+	for _, duty := range records.Duties {
+		for _, a := range byDuty[duty.ID] {
+			if !inRange(a) {
+				continue
+			}
+			if missing := Missing(duty, a, records.Qualifications); len(missing) > 0 {
+				result = append(result, Conflict{
+					Kind:           KindQualification,
+					Message:        "The conscript lacks qualifications the duty requires",
+					Assignments:    []models.ConscriptDuty{a},
+					Qualifications: missing,
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Assignments[0].StartTime.Before(result[j].Assignments[0].StartTime)
+	})
+	return result
+}
@@ -0,0 +1,100 @@
+package conflicts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+func kinds(found []Conflict) []Kind {
+	result := make([]Kind, len(found))
+	for i, conflict := range found {
+		result[i] = conflict.Kind
+	}
+	return result
+}
+
+func TestCheck(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2025, 3, 12, hour, 0, 0, 0, time.UTC)
+	}
+	discharged := at(14)
+	licence := models.Qualification{ID: 1, Label: "Driving licence"}
+	records := Records{
+		Assignments: []models.ConscriptDuty{
+			{ID: 1, ConscriptID: 1, DutyID: 2, StartTime: at(6), EndTime: at(9)},
+			{ID: 2, ConscriptID: 1, DutyID: 2, StartTime: at(18), EndTime: at(20)},
+			{ID: 3, ConscriptID: 2, DutyID: 1, StartTime: at(10), EndTime: at(12)},
+		},
+		Absences: []models.Absence{
+			{ConscriptID: 1, Status: models.AbsenceApproved, StartTime: at(11), EndTime: at(13)},
+			{ConscriptID: 1, Status: models.AbsencePending, StartTime: at(8), EndTime: at(10)},
+		},
+		Conscripts: []models.Conscript{{ID: 1, Status: models.StatusDischarged, DischargeDate: &discharged}},
+		Duties:     []models.Duty{{ID: 1, Capacity: 1, Qualifications: []models.Qualification{licence}}},
+		Qualifications: []models.ConscriptQualification{
+			{ConscriptID: 1, QualificationID: licence.ID, ValidFrom: at(0), ValidUntil: &discharged},
+		},
+	}
+
+	found, err := Check(models.ConscriptDuty{ConscriptID: 1, DutyID: 1, StartTime: at(8), EndTime: at(16)}, records)
+	expected := []Kind{KindOverlap, KindRest, KindAbsence, KindDischarge, KindQualification, KindCapacity}
+	if err != nil || len(found) != len(expected) {
+		t.Fatalf("expected %v, got %v, %v", expected, kinds(found), err)
+	}
+	for i, kind := range expected {
+		if found[i].Kind != kind {
+			t.Errorf("expected %v, got %v", expected, kinds(found))
+			break
+		}
+	}
+	if len(found[2].Absences) != 1 || found[2].Absences[0].Status != models.AbsenceApproved {
+		t.Errorf("expected only the approved absence, got %+v", found[2].Absences)
+	}
+
+	found, err = Check(models.ConscriptDuty{ID: 3, ConscriptID: 2, DutyID: 2, StartTime: at(10), EndTime: at(12)}, records)
+	if err != nil || len(found) != 0 {
+		t.Errorf("expected an updated assignment not to conflict with itself, got %v, %v", kinds(found), err)
+	}
+	if _, err := Check(models.ConscriptDuty{StartTime: at(12), EndTime: at(12)}, records); err != ErrInvalidWindow {
+		t.Errorf("expected an empty assignment to be invalid, got %v", err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2025, 3, 12, hour, 0, 0, 0, time.UTC)
+	}
+	records := Records{
+		Assignments: []models.ConscriptDuty{
+			{ID: 1, ConscriptID: 1, DutyID: 1, StartTime: at(8), EndTime: at(12)},
+			{ID: 2, ConscriptID: 1, DutyID: 2, StartTime: at(10), EndTime: at(14)},
+			{ID: 3, ConscriptID: 2, DutyID: 1, StartTime: at(9), EndTime: at(11)},
+			{ID: 4, ConscriptID: 3, DutyID: 3, StartTime: at(16), EndTime: at(18)},
+		},
+		Duties: []models.Duty{
+			{ID: 1, Capacity: 1},
+			{ID: 3, Qualifications: []models.Qualification{{ID: 1, Label: "Radio"}}},
+		},
+	}
+	found := Report(at(0), at(23), records)
+	expected := []Kind{KindOverlap, KindCapacity, KindQualification}
+	if len(found) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, kinds(found))
+	}
+	for _, kind := range expected {
+		reported := 0
+		for _, conflict := range found {
+			if conflict.Kind == kind {
+				reported++
+			}
+		}
+		if reported != 1 {
+			t.Errorf("expected one %s conflict, got %v", kind, kinds(found))
+		}
+	}
+	if found := Report(at(20), at(23), records); len(found) != 0 {
+		t.Errorf("expected no conflicts outside the range, got %v", kinds(found))
+	}
+}
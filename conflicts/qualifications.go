@@ -0,0 +1,34 @@
+package conflicts
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// Missing returns the qualifications required by the duty of the
+// assignment, loaded with them, that the conscript does not hold for its
+// whole duration among the held qualifications, ordered by label.
+func Missing(duty models.Duty, a models.ConscriptDuty, held []models.ConscriptQualification) []models.Qualification {
+	var missing []models.Qualification
+	for _, required := range duty.Qualifications {
+		if !holdsThroughout(held, a.ConscriptID, required.ID, a.StartTime, a.EndTime) {
+			missing = append(missing, required)
+		}
+	}
+	sort.SliceStable(missing, func(i, j int) bool { return missing[i].Label < missing[j].Label })
+	return missing
+}
+
+// holdsThroughout reports whether the conscript holds the qualification for
+// the whole of [start, end).
+func holdsThroughout(held []models.ConscriptQualification, conscriptID, qualificationID uint, start, end time.Time) bool {
+	for _, q := range held {
+		if q.ConscriptID == conscriptID && q.QualificationID == qualificationID &&
+			!q.ValidFrom.After(start) && (q.ValidUntil == nil || !q.ValidUntil.Before(end)) {
+			return true
+		}
+	}
+	return false
+}
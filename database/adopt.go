@@ -0,0 +1,162 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// adopt brings a database created before versioned migrations, whose schema
+// AutoMigrate kept up to date on every boot, to the baseline and records the
+// baseline as applied. Only SQLite was supported then. Empty databases are
+// left for the baseline to create.
+func (m *Migrator) adopt() (map[int]AppliedMigration, error) {
+	applied := map[int]AppliedMigration{}
+	if m.db.Dialector.Name() != string(SQLite) || len(m.migrations) == 0 || m.migrations[0].Name != "baseline" || !m.db.Migrator().HasTable("conscripts") {
+		return applied, nil
+	}
+	baseline := m.migrations[0]
+	row := AppliedMigration{
+		Version:   baseline.Version,
+		Name:      baseline.Name,
+		Checksum:  baseline.Checksum,
+		AppliedAt: time.Now().UTC(),
+	}
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		legacy, err := renameLegacyAssignments(tx)
+		if err != nil {
+			return err
+		}
+		if err := upgradeToBaseline(tx, baseline.Up); err != nil {
+			return err
+		}
+		if legacy {
+			if err := tx.Exec(`INSERT INTO conscript_duties (conscript_id, duty_id, start_time, end_time, created_at, updated_at)
+				SELECT conscript_id, duty_id, start_time, end_time, created_at, updated_at
+				FROM conscript_duties_legacy ORDER BY start_time`).Error; err != nil {
+				return err
+			}
+			if err := tx.Migrator().DropTable("conscript_duties_legacy"); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&row).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("adopting the existing schema: %w", err)
+	}
+	applied[row.Version] = row
+	return applied, nil
+}
+
+// renameLegacyAssignments moves aside a conscript_duties table keyed by
+// (conscript_id, duty_id), from before every assignment had its own ID, so
+// that the baseline table is created in its place and the assignments are
+// copied over. SQLite cannot add a primary key to an existing table.
+func renameLegacyAssignments(tx *gorm.DB) (bool, error) {
+	if !tx.Migrator().HasTable("conscript_duties") {
+		return false, nil
+	}
+	columns, err := tableColumns(tx, "conscript_duties")
+	if err != nil {
+		return false, err
+	}
+	for _, column := range columns {
+		if column.Name == "id" {
+			return false, nil
+		}
+	}
+	return true, tx.Migrator().RenameTable("conscript_duties", "conscript_duties_legacy")
+}
+
+// tableColumn is a column as described by PRAGMA table_info.
+type tableColumn struct {
+	Name      string
+	Type      string
+	DfltValue *string
+}
+
+func tableColumns(db *gorm.DB, table string) ([]tableColumn, error) {
+	var columns []tableColumn
+	err := db.Raw(fmt.Sprintf("PRAGMA table_info(`%s`)", table)).Scan(&columns).Error
+	return columns, err
+}
+
+// upgradeToBaseline creates the tables, columns and indexes of the baseline
+// missing from the database. The baseline is created in a scratch in-memory
+// database to be compared with.
+func upgradeToBaseline(tx *gorm.DB, baseline string) error {
+	scratch, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	if sqlDB, err := scratch.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+		defer sqlDB.Close()
+	}
+	for _, statement := range statements(baseline) {
+		if err := scratch.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	var objects []struct {
+		Type, Name, TblName, SQL string
+	}
+	if err := scratch.Raw(`SELECT type, name, tbl_name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name <> 'sqlite_sequence'
+		ORDER BY CASE type WHEN 'table' THEN 0 ELSE 1 END, rowid`).Scan(&objects).Error; err != nil {
+		return err
+	}
+	for _, object := range objects {
+		var exists int64
+		if err := tx.Raw("SELECT count(*) FROM sqlite_master WHERE type = ? AND name = ?", object.Type, object.Name).Scan(&exists).Error; err != nil {
+			return err
+		}
+		if exists == 0 {
+			if err := tx.Exec(object.SQL).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if object.Type != "table" {
+			continue
+		}
+		if err := addMissingColumns(tx, scratch, object.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addMissingColumns adds to a table of the database the columns it lacks
+// compared to the same table of the baseline.
+func addMissingColumns(tx, baseline *gorm.DB, table string) error {
+	want, err := tableColumns(baseline, table)
+	if err != nil {
+		return err
+	}
+	have, err := tableColumns(tx, table)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]bool, len(have))
+	for _, column := range have {
+		existing[column.Name] = true
+	}
+	for _, column := range want {
+		if existing[column.Name] {
+			continue
+		}
+		statement := fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", table, column.Name, column.Type)
+		if column.DfltValue != nil {
+			statement += " DEFAULT " + *column.DfltValue
+		}
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,39 +1,54 @@
 package database
 
 import (
-	"log"
-	"os"
+	"fmt"
 
-	"github.com/alexandrosraikos/pixis/models"
-	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 )
 
-var DB *gorm.DB
-
-func ConnectDatabase(path string) {
-	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+// Open opens the database of a data source name, see ParseDSN, without
+// migrating it. Constraint violations are reported as gorm.ErrDuplicatedKey
+// and gorm.ErrForeignKeyViolated whatever the dialect.
+func Open(dsn string) (*gorm.DB, error) {
+	_, dialector, err := ParseDSN(dsn)
 	if err != nil {
-		log.Fatal("failed to connect database")
+		return nil, err
 	}
-
-	// Auto-migrate the Conscript model
-	db.AutoMigrate(
-		&models.Department{},
-		&models.Service{},
-		&models.Conscript{},
-		&models.Duty{},
-		&models.ConscriptDuty{},
-	)
-	DB = db
+	return gorm.Open(dialector, &gorm.Config{TranslateError: true})
 }
 
-// RecreateDatabase deletes the DB file (if exists) and creates a fresh one
-func RecreateDatabase(path string) {
-	_ = os.Remove(path) // ignore error if file does not exist
-	ConnectDatabase(path)
+// Ping reports whether the database of a data source name can be reached.
+func Ping(dsn string) error {
+	_, dialector, err := ParseDSN(dsn)
+	if err != nil {
+		return err
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	return sqlDB.Ping()
 }
 
-func GetDB() *gorm.DB {
-	return DB
+// Connect opens the database of a data source name, see ParseDSN, and
+// applies the pending migrations.
+func Connect(dsn string) (*gorm.DB, error) {
+	db, err := Open(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect database: %w", err)
+	}
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		return nil, fmt.Errorf("failed to migrate database: %w", err)
+	}
+	return db, nil
 }
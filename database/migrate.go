@@ -0,0 +1,416 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is a versioned change of the schema, read from a pair of
+// NNNN_name.up.sql and NNNN_name.down.sql files.
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// AppliedMigration records a migration applied to the database.
+type AppliedMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (AppliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// migrationLock is held by the runner applying migrations, so that two
+// instances starting together do not both migrate the database.
+type migrationLock struct {
+	ID       int `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string
+	LockedAt time.Time
+}
+
+func (migrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// MigrationStatus is a known or applied migration with whether and when it
+// was applied.
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+	// Modified is set when the migration changed since it was applied.
+	Modified bool
+	// Unknown is set when the migration was applied by a newer version.
+	Unknown bool
+}
+
+var (
+	// ErrLocked is returned when another runner holds the migration lock.
+	ErrLocked = errors.New("migrations are locked by another runner")
+	// ErrChecksum is returned when an applied migration has been modified.
+	ErrChecksum = errors.New("applied migration has been modified")
+	// ErrUnknownMigration is returned when the database has a migration this
+	// version does not know, applied by a newer version.
+	ErrUnknownMigration = errors.New("database has a migration unknown to this version")
+	// ErrPending is returned when a known migration is not applied yet.
+	ErrPending = errors.New("database has pending migrations")
+)
+
+var migrationFilename = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the migrations of a directory, ordered by version.
+// Every migration needs an up file; the down file is optional.
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFilename.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// statements splits a migration into its statements, which end with a
+// semicolon at the end of a line. Comment lines are left out.
+func statements(sql string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.Split(sql, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		result = append(result, rest)
+	}
+	return result
+}
+
+// Migrator applies and rolls back the migrations of a database.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+	// Owner identifies the runner in the migration lock.
+	Owner string
+	// LockTimeout is how long to wait for another runner to release the
+	// migration lock.
+	LockTimeout time.Duration
+	// StaleLockAge is how long a migration lock may be held before it is
+	// deemed left behind by an interrupted runner and taken over.
+	StaleLockAge time.Duration
+}
+
+// NewMigrator returns a migrator for the embedded migrations of the
+// dialect of the database.
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(migrationFiles, path.Join("migrations", db.Dialector.Name()))
+	if err != nil {
+		return nil, err
+	}
+	return NewMigratorFor(db, migrations), nil
+}
+
+// NewMigratorFor returns a migrator for the given migrations.
+func NewMigratorFor(db *gorm.DB, migrations []Migration) *Migrator {
+	hostname, _ := os.Hostname()
+	return &Migrator{
+		db:           db,
+		migrations:   migrations,
+		Owner:        fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		LockTimeout:  30 * time.Second,
+		StaleLockAge: 15 * time.Minute,
+	}
+}
+
+// WithContext returns a copy of the migrator running its queries in ctx.
+func (m *Migrator) WithContext(ctx context.Context) *Migrator {
+	copied := *m
+	copied.db = m.db.WithContext(ctx)
+	return &copied
+}
+
+// prepare creates the tables of the migrator.
+func (m *Migrator) prepare() error {
+	return m.db.AutoMigrate(&AppliedMigration{}, &migrationLock{})
+}
+
+// applied returns the applied migrations by version.
+func (m *Migrator) applied() (map[int]AppliedMigration, error) {
+	var rows []AppliedMigration
+	if err := m.db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int]AppliedMigration, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Status lists the known migrations and those applied by newer versions.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.prepare(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var result []MigrationStatus
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Name: migration.Name}
+		if row, ok := applied[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+			status.Modified = row.Checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		result = append(result, status)
+	}
+	for _, row := range applied {
+		appliedAt := row.AppliedAt
+		result = append(result, MigrationStatus{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+	return result, nil
+}
+
+// Verify checks that the migrations applied to the database are known to
+// this version and unchanged since, as they are before migrating it.
+func (m *Migrator) Verify() error {
+	if err := m.prepare(); err != nil {
+		return err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	return m.verify(applied)
+}
+
+// CheckApplied checks, without changing the database, that every known
+// migration is applied and unchanged and that none is unknown.
+func (m *Migrator) CheckApplied() error {
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			return fmt.Errorf("%w: %d (%s)", ErrPending, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// verify checks that the applied migrations are known and unchanged.
+func (m *Migrator) verify(applied map[int]AppliedMigration) error {
+	known := make(map[int]Migration, len(m.migrations))
+	for _, migration := range m.migrations {
+		known[migration.Version] = migration
+	}
+	for version, row := range applied {
+		migration, ok := known[version]
+		if !ok {
+			return fmt.Errorf("%w: %d (%s)", ErrUnknownMigration, version, row.Name)
+		}
+		if row.Checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d (%s)", ErrChecksum, version, migration.Name)
+		}
+	}
+	return nil
+}
+
+// lock takes the migration lock, waiting up to LockTimeout for another
+// runner to release it. A lock held for longer than StaleLockAge is taken
+// over from its holder.
+func (m *Migrator) lock() error {
+	deadline := time.Now().Add(m.LockTimeout)
+	for {
+		err := m.db.Create(&migrationLock{ID: 1, Owner: m.Owner, LockedAt: time.Now().UTC()}).Error
+		if err == nil {
+			return nil
+		}
+		var holder migrationLock
+		if found := m.db.Limit(1).Find(&holder).RowsAffected; found == 0 {
+			return err
+		}
+		if m.StaleLockAge > 0 && time.Since(holder.LockedAt) > m.StaleLockAge {
+			// Only the stale lock is removed, not one just taken by another
+			// runner taking it over too.
+			err := m.db.Where("id = 1 AND owner = ? AND locked_at <= ?", holder.Owner, holder.LockedAt).Delete(&migrationLock{}).Error
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%w: %s since %s", ErrLocked, holder.Owner, holder.LockedAt.Format(time.RFC3339))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (m *Migrator) unlock() error {
+	return m.db.Where("id = 1 AND owner = ?", m.Owner).Delete(&migrationLock{}).Error
+}
+
+// Unlock releases the migration lock whoever holds it, after a runner was
+// interrupted while migrating.
+func (m *Migrator) Unlock() error {
+	if err := m.prepare(); err != nil {
+		return err
+	}
+	return m.db.Where("id = 1").Delete(&migrationLock{}).Error
+}
+
+// locked runs fn holding the migration lock, with the applied migrations
+// verified against the known ones.
+func (m *Migrator) locked(fn func(applied map[int]AppliedMigration) error) error {
+	if err := m.prepare(); err != nil {
+		return err
+	}
+	if err := m.lock(); err != nil {
+		return err
+	}
+	defer m.unlock()
+	applied, err := m.applied()
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		if applied, err = m.adopt(); err != nil {
+			return err
+		}
+	}
+	if err := m.verify(applied); err != nil {
+		return err
+	}
+	return fn(applied)
+}
+
+// run executes the statements of a migration and records the change in a
+// transaction.
+func (m *Migrator) run(migration Migration, sql string, record func(tx *gorm.DB) error) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(sql) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+			}
+		}
+		return record(tx)
+	})
+}
+
+// Up applies the pending migrations in order and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	var done []Migration
+	err := m.locked(func(applied map[int]AppliedMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(migration, migration.Up, func(tx *gorm.DB) error {
+				return tx.Create(&AppliedMigration{
+					Version:   migration.Version,
+					Name:      migration.Name,
+					Checksum:  migration.Checksum,
+					AppliedAt: time.Now().UTC(),
+				}).Error
+			}); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns them.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(func(applied map[int]AppliedMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+			if err := m.run(migration, migration.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&AppliedMigration{}, migration.Version).Error
+			}); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
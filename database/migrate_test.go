@@ -0,0 +1,268 @@
+package database
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDatabase(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "migrate.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newTestMigrator(t *testing.T, db *gorm.DB) *Migrator {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator
+}
+
+// TestMigrationsMatchModels checks that the migrations create a column for
+// every field of the models, now that AutoMigrate no longer adds them.
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openTestDatabase(t)
+	if _, err := newTestMigrator(t, db).Up(); err != nil {
+		t.Fatal(err)
+	}
+	for _, model := range []any{
+		&models.Department{}, &models.Service{}, &models.Conscript{}, &models.Duty{},
+		&models.ConscriptDuty{}, &models.FeedToken{}, &models.ConflictOverride{}, &models.Holiday{},
+		&models.ShiftTemplate{}, &models.Absence{}, &models.SwapRequest{}, &models.Qualification{},
+		&models.ConscriptQualification{}, &models.DepartmentMembership{},
+	} {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		columns, err := tableColumns(db, stmt.Schema.Table)
+		if err != nil {
+			t.Fatal(err)
+		}
+		existing := map[string]bool{}
+		for _, column := range columns {
+			existing[column.Name] = true
+		}
+		for _, name := range stmt.Schema.DBNames {
+			if !existing[name] {
+				t.Errorf("table %s has no column %s", stmt.Schema.Table, name)
+			}
+		}
+	}
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	db := openTestDatabase(t)
+	migrator := newTestMigrator(t, db)
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrator.migrations) {
+		t.Errorf("expected every migration to be applied, got %d", len(applied))
+	}
+	if applied, _ := migrator.Up(); len(applied) != 0 {
+		t.Errorf("expected nothing left to apply, got %+v", applied)
+	}
+	if err := migrator.CheckApplied(); err != nil {
+		t.Errorf("expected every migration to be applied, got %v", err)
+	}
+
+	rolledBack, err := migrator.Down(len(migrator.migrations))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := migrator.CheckApplied(); !errors.Is(err, ErrPending) {
+		t.Errorf("expected pending migrations, got %v", err)
+	}
+	if len(rolledBack) != len(migrator.migrations) || db.Migrator().HasTable("conscripts") {
+		t.Errorf("expected every migration to be rolled back, got %d", len(rolledBack))
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range status {
+		if s.AppliedAt != nil {
+			t.Errorf("expected migration %d to be pending", s.Version)
+		}
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Errorf("expected the migrations to apply again, got %v", err)
+	}
+}
+
+func TestMigrateChecksum(t *testing.T) {
+	db := openTestDatabase(t)
+	migrator := newTestMigrator(t, db)
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	db.Model(&AppliedMigration{}).Where("version = 1").Update("checksum", "edited")
+	if _, err := migrator.Up(); !errors.Is(err, ErrChecksum) {
+		t.Errorf("expected a checksum error, got %v", err)
+	}
+	status, _ := migrator.Status()
+	if !status[0].Modified {
+		t.Errorf("expected the baseline to be reported as modified, got %+v", status[0])
+	}
+
+	db.Model(&AppliedMigration{}).Where("version = 1").Update("checksum", migrator.migrations[0].Checksum)
+	db.Create(&AppliedMigration{Version: 999, Name: "from_the_future", AppliedAt: time.Now()})
+	if _, err := migrator.Up(); !errors.Is(err, ErrUnknownMigration) {
+		t.Errorf("expected an unknown migration error, got %v", err)
+	}
+}
+
+func TestMigrateLock(t *testing.T) {
+	db := openTestDatabase(t)
+	migrator := newTestMigrator(t, db)
+	migrator.LockTimeout = 200 * time.Millisecond
+	if err := migrator.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&migrationLock{ID: 1, Owner: "other:1", LockedAt: time.Now().UTC()})
+	if _, err := migrator.Up(); !errors.Is(err, ErrLocked) {
+		t.Fatalf("expected the migrations to be locked, got %v", err)
+	}
+	if db.Migrator().HasTable("conscripts") {
+		t.Error("expected nothing to be applied while locked")
+	}
+	if err := migrator.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Errorf("expected the migrations to apply once unlocked, got %v", err)
+	}
+	var locks int64
+	db.Model(&migrationLock{}).Count(&locks)
+	if locks != 0 {
+		t.Errorf("expected the lock to be released, got %d", locks)
+	}
+}
+
+func TestMigrateStaleLock(t *testing.T) {
+	db := openTestDatabase(t)
+	migrator := newTestMigrator(t, db)
+	migrator.LockTimeout = 200 * time.Millisecond
+	migrator.StaleLockAge = time.Hour
+	if err := migrator.prepare(); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&migrationLock{ID: 1, Owner: "other:1", LockedAt: time.Now().Add(-2 * time.Hour).UTC()})
+	if _, err := migrator.Up(); err != nil {
+		t.Fatalf("expected the stale lock to be taken over, got %v", err)
+	}
+	if !db.Migrator().HasTable("conscripts") {
+		t.Error("expected the migrations to be applied")
+	}
+	var locks int64
+	db.Model(&migrationLock{}).Count(&locks)
+	if locks != 0 {
+		t.Errorf("expected the lock to be released, got %d", locks)
+	}
+}
+
+// TestAdoptLegacySchema migrates a database created by AutoMigrate by an
+// older version, with assignments keyed by conscript and duty and without
+// the lifecycle of conscripts.
+func TestAdoptLegacySchema(t *testing.T) {
+	db := openTestDatabase(t)
+	for _, statement := range []string{
+		"CREATE TABLE `departments` (`id` integer PRIMARY KEY AUTOINCREMENT,`label` text,`created_at` datetime,`updated_at` datetime)",
+		"CREATE TABLE `conscripts` (`id` integer PRIMARY KEY AUTOINCREMENT,`username` text,`department_id` integer,`created_at` datetime,`updated_at` datetime)",
+		"CREATE TABLE `conscript_duties` (`conscript_id` integer,`duty_id` integer,`start_time` datetime,`end_time` datetime,`created_at` datetime,`updated_at` datetime,PRIMARY KEY (`conscript_id`,`duty_id`))",
+		"INSERT INTO departments (label, created_at) VALUES ('Legacy company', '2024-01-01 00:00:00')",
+		"INSERT INTO conscripts (username, department_id, created_at) VALUES ('legacy', 1, '2024-02-01 00:00:00')",
+		"INSERT INTO conscript_duties (conscript_id, duty_id, start_time, end_time) VALUES (1, 1, '2024-03-01 08:00:00', '2024-03-01 16:00:00')",
+	} {
+		if err := db.Exec(statement).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	migrator := newTestMigrator(t, db)
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != len(migrator.migrations)-1 || applied[0].Name == "baseline" {
+		t.Errorf("expected the baseline to be adopted rather than applied, got %+v", applied)
+	}
+
+	var conscript models.Conscript
+	if err := db.First(&conscript).Error; err != nil {
+		t.Fatal(err)
+	}
+	if conscript.Status != models.StatusActive || conscript.Role != models.RoleConscript {
+		t.Errorf("expected the new columns with their defaults, got %+v", conscript)
+	}
+	var assignments []models.ConscriptDuty
+	db.Find(&assignments)
+	if len(assignments) != 1 || assignments[0].ID == 0 {
+		t.Errorf("expected the assignment to be kept with an ID, got %+v", assignments)
+	}
+	var memberships []models.DepartmentMembership
+	db.Find(&memberships)
+	if len(memberships) != 1 || memberships[0].DepartmentID != 1 || memberships[0].EndDate != nil {
+		t.Errorf("expected the department of the conscript to be backfilled, got %+v", memberships)
+	}
+	if !db.Migrator().HasIndex(&models.Conscript{}, "idx_conscripts_username") {
+		t.Error("expected the indexes of the baseline to be created")
+	}
+}
+
+// TestMigrateBackends applies and rolls back the migrations of every other
+// supported database that can be reached, see TestBackends.
+func TestMigrateBackends(t *testing.T) {
+	for _, backend := range TestBackends() {
+		t.Run(string(backend.Dialect), func(t *testing.T) {
+			if err := Ping(backend.DSN); err != nil {
+				t.Skipf("%s is unreachable: %v", backend.Dialect, err)
+			}
+			_, dialector, err := ParseDSN(backend.DSN)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := dropTables(db); err != nil {
+				t.Fatal(err)
+			}
+			migrator := newTestMigrator(t, db)
+			if _, err := migrator.Up(); err != nil {
+				t.Fatal(err)
+			}
+			for _, model := range []any{&models.Conscript{}, &models.ConscriptDuty{}, &models.DepartmentMembership{}} {
+				columns, err := db.Migrator().ColumnTypes(model)
+				if err != nil {
+					t.Fatal(err)
+				}
+				stmt := &gorm.Statement{DB: db}
+				if err := stmt.Parse(model); err != nil {
+					t.Fatal(err)
+				}
+				if len(columns) < len(stmt.Schema.DBNames) {
+					t.Errorf("table %s has %d columns, expected %d", stmt.Schema.Table, len(columns), len(stmt.Schema.DBNames))
+				}
+			}
+			if _, err := migrator.Down(len(migrator.migrations)); err != nil {
+				t.Fatal(err)
+			}
+			if db.Migrator().HasTable("conscripts") {
+				t.Error("expected every migration to be rolled back")
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+-- Baseline: the schema as created by AutoMigrate before versioned migrations,
+-- without the foreign key constraints SQLite did not enforce. Indexed text
+-- columns are limited to 191 characters to fit InnoDB index keys.
+
+CREATE TABLE `departments` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`label` varchar(191),
+	`parent_id` bigint,
+	`commander_id` bigint,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE INDEX `idx_departments_commander_id` ON `departments`(`commander_id`);
+CREATE INDEX `idx_departments_parent_id` ON `departments`(`parent_id`);
+CREATE UNIQUE INDEX `idx_departments_label` ON `departments`(`label`);
+
+CREATE TABLE `services` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`label` varchar(191),
+	`department_id` bigint,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE UNIQUE INDEX `idx_services_label` ON `services`(`label`);
+
+CREATE TABLE `conscripts` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`first_name` longtext,
+	`last_name` longtext,
+	`registry_number` varchar(191),
+	`username` varchar(191),
+	`password` longtext,
+	`role` varchar(191) DEFAULT 'conscript',
+	`rank` longtext,
+	`status` varchar(191) DEFAULT 'active',
+	`enlistment_date` datetime(3),
+	`expected_discharge_date` datetime(3),
+	`discharge_date` datetime(3),
+	`department_id` bigint,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE UNIQUE INDEX `idx_conscripts_registry_number` ON `conscripts`(`registry_number`);
+CREATE UNIQUE INDEX `idx_conscripts_username` ON `conscripts`(`username`);
+
+CREATE TABLE `duties` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`label` longtext,
+	`service_id` bigint,
+	`capacity` bigint,
+	`points` double DEFAULT 1,
+	`weekend_multiplier` double DEFAULT 1,
+	`night_multiplier` double DEFAULT 1,
+	`holiday_multiplier` double DEFAULT 1,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+
+CREATE TABLE `qualifications` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`label` varchar(191),
+	`description` longtext,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE UNIQUE INDEX `idx_qualifications_label` ON `qualifications`(`label`);
+
+CREATE TABLE `duty_qualifications` (
+	`duty_id` bigint,
+	`qualification_id` bigint,
+	PRIMARY KEY (`duty_id`,`qualification_id`)
+);
+
+CREATE TABLE `conscript_duties` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`conscript_id` bigint,
+	`duty_id` bigint,
+	`start_time` datetime(3),
+	`end_time` datetime(3),
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE INDEX `idx_conscript_duties_conscript_id` ON `conscript_duties`(`conscript_id`);
+CREATE INDEX `idx_conscript_duties_duty_id` ON `conscript_duties`(`duty_id`);
+CREATE INDEX `idx_conscript_duties_start_time` ON `conscript_duties`(`start_time`);
+
+CREATE TABLE `feed_tokens` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`conscript_id` bigint,
+	`token_hash` varchar(191),
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE UNIQUE INDEX `idx_feed_tokens_conscript_id` ON `feed_tokens`(`conscript_id`);
+CREATE UNIQUE INDEX `idx_feed_tokens_token_hash` ON `feed_tokens`(`token_hash`);
+
+CREATE TABLE `conflict_overrides` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`conscript_id` bigint,
+	`duty_id` bigint,
+	`start_time` datetime(3),
+	`end_time` datetime(3),
+	`admin_id` bigint,
+	`conflicts` longtext,
+	`created_at` datetime(3)
+);
+
+CREATE TABLE `holidays` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`date` varchar(191),
+	`label` longtext,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE UNIQUE INDEX `idx_holidays_date` ON `holidays`(`date`);
+
+CREATE TABLE `shift_templates` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`duty_id` bigint,
+	`label` longtext,
+	`dt_start` longtext,
+	`time_zone` longtext,
+	`duration_minutes` bigint,
+	`r_rule` longtext,
+	`ex_dates` longtext,
+	`count` bigint,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE INDEX `idx_shift_templates_duty_id` ON `shift_templates`(`duty_id`);
+
+CREATE TABLE `absences` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`conscript_id` bigint,
+	`type` longtext,
+	`start_time` datetime(3),
+	`end_time` datetime(3),
+	`reason` longtext,
+	`status` varchar(191) DEFAULT 'pending',
+	`approver_id` bigint,
+	`decided_at` datetime(3),
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE INDEX `idx_absences_conscript_id` ON `absences`(`conscript_id`);
+CREATE INDEX `idx_absences_start_time` ON `absences`(`start_time`);
+
+CREATE TABLE `swap_requests` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`requester_id` bigint,
+	`assignment_id` bigint,
+	`counterparty_id` bigint,
+	`counterpart_assignment_id` bigint,
+	`message` longtext,
+	`status` varchar(191) DEFAULT 'proposed',
+	`expires_at` datetime(3),
+	`responded_at` datetime(3),
+	`approver_id` bigint,
+	`decided_at` datetime(3),
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE INDEX `idx_swap_requests_assignment_id` ON `swap_requests`(`assignment_id`);
+CREATE INDEX `idx_swap_requests_counterpart_assignment_id` ON `swap_requests`(`counterpart_assignment_id`);
+CREATE INDEX `idx_swap_requests_counterparty_id` ON `swap_requests`(`counterparty_id`);
+CREATE INDEX `idx_swap_requests_requester_id` ON `swap_requests`(`requester_id`);
+CREATE INDEX `idx_swap_requests_status` ON `swap_requests`(`status`);
+
+CREATE TABLE `conscript_qualifications` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`conscript_id` bigint,
+	`qualification_id` bigint,
+	`valid_from` datetime(3),
+	`valid_until` datetime(3),
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE UNIQUE INDEX `idx_conscript_qualification` ON `conscript_qualifications`(`conscript_id`,`qualification_id`);
+
+CREATE TABLE `department_memberships` (
+	`id` bigint unsigned AUTO_INCREMENT PRIMARY KEY,
+	`conscript_id` bigint,
+	`department_id` bigint,
+	`start_date` datetime(3),
+	`end_date` datetime(3),
+	`reason` longtext,
+	`created_at` datetime(3),
+	`updated_at` datetime(3)
+);
+CREATE INDEX `idx_department_memberships_conscript_id` ON `department_memberships`(`conscript_id`);
+CREATE INDEX `idx_department_memberships_department_id` ON `department_memberships`(`department_id`);
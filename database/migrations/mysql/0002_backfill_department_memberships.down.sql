@@ -0,0 +1,2 @@
+-- The backfilled memberships cannot be told apart from recorded ones and are
+-- kept.
@@ -0,0 +1,11 @@
+-- Records the department of every conscript without memberships, from their
+-- enlistment or creation until their discharge, so that department history
+-- starts with the departments conscripts were in before memberships were
+-- recorded.
+INSERT INTO `department_memberships` (`conscript_id`, `department_id`, `start_date`, `end_date`, `reason`, `created_at`, `updated_at`)
+SELECT `id`, `department_id`, COALESCE(`enlistment_date`, `created_at`),
+	CASE WHEN `status` = 'discharged' THEN COALESCE(`discharge_date`, CURRENT_TIMESTAMP) END,
+	'', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP
+FROM `conscripts`
+WHERE `department_id` <> 0
+AND NOT EXISTS (SELECT 1 FROM `department_memberships` m WHERE m.`conscript_id` = `conscripts`.`id`);
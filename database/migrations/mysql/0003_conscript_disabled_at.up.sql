@@ -0,0 +1,2 @@
+-- Records when an operator disabled the account of a conscript.
+ALTER TABLE `conscripts` ADD COLUMN `disabled_at` datetime(3);
@@ -0,0 +1,16 @@
+-- Drops every table of the baseline.
+DROP TABLE "department_memberships";
+DROP TABLE "conscript_qualifications";
+DROP TABLE "swap_requests";
+DROP TABLE "absences";
+DROP TABLE "shift_templates";
+DROP TABLE "holidays";
+DROP TABLE "conflict_overrides";
+DROP TABLE "feed_tokens";
+DROP TABLE "conscript_duties";
+DROP TABLE "duty_qualifications";
+DROP TABLE "qualifications";
+DROP TABLE "duties";
+DROP TABLE "conscripts";
+DROP TABLE "services";
+DROP TABLE "departments";
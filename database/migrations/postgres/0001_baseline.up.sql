@@ -0,0 +1,190 @@
+-- Baseline: the schema as created by AutoMigrate before versioned migrations,
+-- without the foreign key constraints SQLite did not enforce.
+
+CREATE TABLE "departments" (
+	"id" bigserial PRIMARY KEY,
+	"label" text,
+	"parent_id" bigint,
+	"commander_id" bigint,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE INDEX "idx_departments_commander_id" ON "departments"("commander_id");
+CREATE INDEX "idx_departments_parent_id" ON "departments"("parent_id");
+CREATE UNIQUE INDEX "idx_departments_label" ON "departments"("label");
+
+CREATE TABLE "services" (
+	"id" bigserial PRIMARY KEY,
+	"label" text,
+	"department_id" bigint,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE UNIQUE INDEX "idx_services_label" ON "services"("label");
+
+CREATE TABLE "conscripts" (
+	"id" bigserial PRIMARY KEY,
+	"first_name" text,
+	"last_name" text,
+	"registry_number" text,
+	"username" text,
+	"password" text,
+	"role" text DEFAULT 'conscript',
+	"rank" text,
+	"status" text DEFAULT 'active',
+	"enlistment_date" timestamptz,
+	"expected_discharge_date" timestamptz,
+	"discharge_date" timestamptz,
+	"department_id" bigint,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE UNIQUE INDEX "idx_conscripts_registry_number" ON "conscripts"("registry_number");
+CREATE UNIQUE INDEX "idx_conscripts_username" ON "conscripts"("username");
+
+CREATE TABLE "duties" (
+	"id" bigserial PRIMARY KEY,
+	"label" text,
+	"service_id" bigint,
+	"capacity" bigint,
+	"points" double precision DEFAULT 1,
+	"weekend_multiplier" double precision DEFAULT 1,
+	"night_multiplier" double precision DEFAULT 1,
+	"holiday_multiplier" double precision DEFAULT 1,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+
+CREATE TABLE "qualifications" (
+	"id" bigserial PRIMARY KEY,
+	"label" text,
+	"description" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE UNIQUE INDEX "idx_qualifications_label" ON "qualifications"("label");
+
+CREATE TABLE "duty_qualifications" (
+	"duty_id" bigint,
+	"qualification_id" bigint,
+	PRIMARY KEY ("duty_id","qualification_id")
+);
+
+CREATE TABLE "conscript_duties" (
+	"id" bigserial PRIMARY KEY,
+	"conscript_id" bigint,
+	"duty_id" bigint,
+	"start_time" timestamptz,
+	"end_time" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE INDEX "idx_conscript_duties_conscript_id" ON "conscript_duties"("conscript_id");
+CREATE INDEX "idx_conscript_duties_duty_id" ON "conscript_duties"("duty_id");
+CREATE INDEX "idx_conscript_duties_start_time" ON "conscript_duties"("start_time");
+
+CREATE TABLE "feed_tokens" (
+	"id" bigserial PRIMARY KEY,
+	"conscript_id" bigint,
+	"token_hash" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE UNIQUE INDEX "idx_feed_tokens_conscript_id" ON "feed_tokens"("conscript_id");
+CREATE UNIQUE INDEX "idx_feed_tokens_token_hash" ON "feed_tokens"("token_hash");
+
+CREATE TABLE "conflict_overrides" (
+	"id" bigserial PRIMARY KEY,
+	"conscript_id" bigint,
+	"duty_id" bigint,
+	"start_time" timestamptz,
+	"end_time" timestamptz,
+	"admin_id" bigint,
+	"conflicts" text,
+	"created_at" timestamptz
+);
+
+CREATE TABLE "holidays" (
+	"id" bigserial PRIMARY KEY,
+	"date" text,
+	"label" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE UNIQUE INDEX "idx_holidays_date" ON "holidays"("date");
+
+CREATE TABLE "shift_templates" (
+	"id" bigserial PRIMARY KEY,
+	"duty_id" bigint,
+	"label" text,
+	"dt_start" text,
+	"time_zone" text,
+	"duration_minutes" bigint,
+	"r_rule" text,
+	"ex_dates" text,
+	"count" bigint,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE INDEX "idx_shift_templates_duty_id" ON "shift_templates"("duty_id");
+
+CREATE TABLE "absences" (
+	"id" bigserial PRIMARY KEY,
+	"conscript_id" bigint,
+	"type" text,
+	"start_time" timestamptz,
+	"end_time" timestamptz,
+	"reason" text,
+	"status" text DEFAULT 'pending',
+	"approver_id" bigint,
+	"decided_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE INDEX "idx_absences_conscript_id" ON "absences"("conscript_id");
+CREATE INDEX "idx_absences_start_time" ON "absences"("start_time");
+
+CREATE TABLE "swap_requests" (
+	"id" bigserial PRIMARY KEY,
+	"requester_id" bigint,
+	"assignment_id" bigint,
+	"counterparty_id" bigint,
+	"counterpart_assignment_id" bigint,
+	"message" text,
+	"status" text DEFAULT 'proposed',
+	"expires_at" timestamptz,
+	"responded_at" timestamptz,
+	"approver_id" bigint,
+	"decided_at" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE INDEX "idx_swap_requests_assignment_id" ON "swap_requests"("assignment_id");
+CREATE INDEX "idx_swap_requests_counterpart_assignment_id" ON "swap_requests"("counterpart_assignment_id");
+CREATE INDEX "idx_swap_requests_counterparty_id" ON "swap_requests"("counterparty_id");
+CREATE INDEX "idx_swap_requests_requester_id" ON "swap_requests"("requester_id");
+CREATE INDEX "idx_swap_requests_status" ON "swap_requests"("status");
+
+CREATE TABLE "conscript_qualifications" (
+	"id" bigserial PRIMARY KEY,
+	"conscript_id" bigint,
+	"qualification_id" bigint,
+	"valid_from" timestamptz,
+	"valid_until" timestamptz,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE UNIQUE INDEX "idx_conscript_qualification" ON "conscript_qualifications"("conscript_id","qualification_id");
+
+CREATE TABLE "department_memberships" (
+	"id" bigserial PRIMARY KEY,
+	"conscript_id" bigint,
+	"department_id" bigint,
+	"start_date" timestamptz,
+	"end_date" timestamptz,
+	"reason" text,
+	"created_at" timestamptz,
+	"updated_at" timestamptz
+);
+CREATE INDEX "idx_department_memberships_conscript_id" ON "department_memberships"("conscript_id");
+CREATE INDEX "idx_department_memberships_department_id" ON "department_memberships"("department_id");
@@ -0,0 +1 @@
+ALTER TABLE "conscripts" DROP COLUMN "disabled_at";
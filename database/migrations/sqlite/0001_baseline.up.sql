@@ -0,0 +1,199 @@
+-- Baseline: the schema as created by AutoMigrate before versioned migrations.
+
+CREATE TABLE `departments` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`label` text,
+	`parent_id` integer,
+	`commander_id` integer,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_departments_children` FOREIGN KEY (`parent_id`) REFERENCES `departments`(`id`)
+);
+CREATE INDEX `idx_departments_commander_id` ON `departments`(`commander_id`);
+CREATE INDEX `idx_departments_parent_id` ON `departments`(`parent_id`);
+CREATE UNIQUE INDEX `idx_departments_label` ON `departments`(`label`);
+
+CREATE TABLE `services` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`label` text,
+	`department_id` integer,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_departments_services` FOREIGN KEY (`department_id`) REFERENCES `departments`(`id`)
+);
+CREATE UNIQUE INDEX `idx_services_label` ON `services`(`label`);
+
+CREATE TABLE `conscripts` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`first_name` text,
+	`last_name` text,
+	`registry_number` text,
+	`username` text,
+	`password` text,
+	`role` text DEFAULT 'conscript',
+	`rank` text,
+	`status` text DEFAULT 'active',
+	`enlistment_date` datetime,
+	`expected_discharge_date` datetime,
+	`discharge_date` datetime,
+	`department_id` integer,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_departments_conscripts` FOREIGN KEY (`department_id`) REFERENCES `departments`(`id`)
+);
+CREATE UNIQUE INDEX `idx_conscripts_registry_number` ON `conscripts`(`registry_number`);
+CREATE UNIQUE INDEX `idx_conscripts_username` ON `conscripts`(`username`);
+
+CREATE TABLE `duties` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`label` text,
+	`service_id` integer,
+	`capacity` integer,
+	`points` real DEFAULT 1,
+	`weekend_multiplier` real DEFAULT 1,
+	`night_multiplier` real DEFAULT 1,
+	`holiday_multiplier` real DEFAULT 1,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_services_duties` FOREIGN KEY (`service_id`) REFERENCES `services`(`id`)
+);
+
+CREATE TABLE `qualifications` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`label` text,
+	`description` text,
+	`created_at` datetime,
+	`updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_qualifications_label` ON `qualifications`(`label`);
+
+CREATE TABLE `duty_qualifications` (
+	`duty_id` integer,
+	`qualification_id` integer,
+	PRIMARY KEY (`duty_id`,`qualification_id`),
+	CONSTRAINT `fk_duty_qualifications_duty` FOREIGN KEY (`duty_id`) REFERENCES `duties`(`id`),
+	CONSTRAINT `fk_duty_qualifications_qualification` FOREIGN KEY (`qualification_id`) REFERENCES `qualifications`(`id`)
+);
+
+CREATE TABLE `conscript_duties` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`conscript_id` integer,
+	`duty_id` integer,
+	`start_time` datetime,
+	`end_time` datetime,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_duties_conscript_duties` FOREIGN KEY (`duty_id`) REFERENCES `duties`(`id`)
+);
+CREATE INDEX `idx_conscript_duties_conscript_id` ON `conscript_duties`(`conscript_id`);
+CREATE INDEX `idx_conscript_duties_duty_id` ON `conscript_duties`(`duty_id`);
+CREATE INDEX `idx_conscript_duties_start_time` ON `conscript_duties`(`start_time`);
+
+CREATE TABLE `feed_tokens` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`conscript_id` integer,
+	`token_hash` text,
+	`created_at` datetime,
+	`updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_feed_tokens_conscript_id` ON `feed_tokens`(`conscript_id`);
+CREATE UNIQUE INDEX `idx_feed_tokens_token_hash` ON `feed_tokens`(`token_hash`);
+
+CREATE TABLE `conflict_overrides` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`conscript_id` integer,
+	`duty_id` integer,
+	`start_time` datetime,
+	`end_time` datetime,
+	`admin_id` integer,
+	`conflicts` text,
+	`created_at` datetime
+);
+
+CREATE TABLE `holidays` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`date` text,
+	`label` text,
+	`created_at` datetime,
+	`updated_at` datetime
+);
+CREATE UNIQUE INDEX `idx_holidays_date` ON `holidays`(`date`);
+
+CREATE TABLE `shift_templates` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`duty_id` integer,
+	`label` text,
+	`dt_start` text,
+	`time_zone` text,
+	`duration_minutes` integer,
+	`r_rule` text,
+	`ex_dates` text,
+	`count` integer,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_duties_shift_templates` FOREIGN KEY (`duty_id`) REFERENCES `duties`(`id`)
+);
+CREATE INDEX `idx_shift_templates_duty_id` ON `shift_templates`(`duty_id`);
+
+CREATE TABLE `absences` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`conscript_id` integer,
+	`type` text,
+	`start_time` datetime,
+	`end_time` datetime,
+	`reason` text,
+	`status` text DEFAULT 'pending',
+	`approver_id` integer,
+	`decided_at` datetime,
+	`created_at` datetime,
+	`updated_at` datetime
+);
+CREATE INDEX `idx_absences_conscript_id` ON `absences`(`conscript_id`);
+CREATE INDEX `idx_absences_start_time` ON `absences`(`start_time`);
+
+CREATE TABLE `swap_requests` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`requester_id` integer,
+	`assignment_id` integer,
+	`counterparty_id` integer,
+	`counterpart_assignment_id` integer,
+	`message` text,
+	`status` text DEFAULT 'proposed',
+	`expires_at` datetime,
+	`responded_at` datetime,
+	`approver_id` integer,
+	`decided_at` datetime,
+	`created_at` datetime,
+	`updated_at` datetime
+);
+CREATE INDEX `idx_swap_requests_assignment_id` ON `swap_requests`(`assignment_id`);
+CREATE INDEX `idx_swap_requests_counterpart_assignment_id` ON `swap_requests`(`counterpart_assignment_id`);
+CREATE INDEX `idx_swap_requests_counterparty_id` ON `swap_requests`(`counterparty_id`);
+CREATE INDEX `idx_swap_requests_requester_id` ON `swap_requests`(`requester_id`);
+CREATE INDEX `idx_swap_requests_status` ON `swap_requests`(`status`);
+
+CREATE TABLE `conscript_qualifications` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`conscript_id` integer,
+	`qualification_id` integer,
+	`valid_from` datetime,
+	`valid_until` datetime,
+	`created_at` datetime,
+	`updated_at` datetime,
+	CONSTRAINT `fk_conscript_qualifications_qualification` FOREIGN KEY (`qualification_id`) REFERENCES `qualifications`(`id`),
+	CONSTRAINT `fk_conscripts_qualifications` FOREIGN KEY (`conscript_id`) REFERENCES `conscripts`(`id`)
+);
+CREATE UNIQUE INDEX `idx_conscript_qualification` ON `conscript_qualifications`(`conscript_id`,`qualification_id`);
+
+CREATE TABLE `department_memberships` (
+	`id` integer PRIMARY KEY AUTOINCREMENT,
+	`conscript_id` integer,
+	`department_id` integer,
+	`start_date` datetime,
+	`end_date` datetime,
+	`reason` text,
+	`created_at` datetime,
+	`updated_at` datetime
+);
+CREATE INDEX `idx_department_memberships_conscript_id` ON `department_memberships`(`conscript_id`);
+CREATE INDEX `idx_department_memberships_department_id` ON `department_memberships`(`department_id`);
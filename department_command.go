@@ -0,0 +1,213 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/config"
+	"github.com/alexandrosraikos/pixis/importer"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+)
+
+const departmentUsage = "usage: pixis department import [-format csv|xlsx] [-commit] FILE"
+
+// errDryRun rolls back the departments imported without -commit.
+var errDryRun = errors.New("dry run")
+
+// departmentRow is a department to import, read from a line of the file.
+type departmentRow struct {
+	line                     int
+	label, parent, commander string
+}
+
+// runDepartment implements `pixis department import [flags] FILE`. The
+// file has label, parent and commander columns, named in its first row;
+// parents are given by label and may be imported in the same file, and
+// commanders by username.
+func runDepartment(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "import" {
+		fmt.Fprintln(stderr, departmentUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("department import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	formatName := flags.String("format", "", "file format (csv or xlsx), guessed from the file name by default")
+	commit := flags.Bool("commit", false, "create the departments instead of only validating them")
+	loader := config.Bind(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, departmentUsage)
+		return 2
+	}
+	path := flags.Arg(0)
+
+	var format importer.Format
+	var err error
+	if *formatName != "" {
+		format, err = importer.ParseFormat(*formatName)
+	} else {
+		format, err = importer.FormatFromFilename(path)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer file.Close()
+	rows, err := importer.ReadRows(file, format)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	departments, err := departmentRows(rows)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	db, err := connect(loader)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	var problems []string
+	err = repository.NewGormStore(db).Transaction(func(tx repository.Store) error {
+		problems, err = importDepartments(tx, departments)
+		if err != nil {
+			return err
+		}
+		if len(problems) > 0 || !*commit {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, problem)
+	}
+	fmt.Fprintf(stdout, "%d departments, %d invalid\n", len(departments), len(problems))
+	if len(problems) > 0 {
+		return 1
+	}
+	if !*commit {
+		fmt.Fprintln(stdout, "dry run, nothing imported (use -commit to import)")
+		return 0
+	}
+	fmt.Fprintf(stdout, "imported %d departments\n", len(departments))
+	return 0
+}
+
+// departmentRows reads the departments of the rows, whose first row names
+// the columns.
+func departmentRows(rows [][]string) ([]departmentRow, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+	columns := map[string]int{"label": -1, "parent": -1, "commander": -1}
+	for i, header := range rows[0] {
+		if _, ok := columns[strings.ToLower(strings.TrimSpace(header))]; ok {
+			columns[strings.ToLower(strings.TrimSpace(header))] = i
+		}
+	}
+	if columns["label"] < 0 {
+		return nil, errors.New("missing label column")
+	}
+	cell := func(row []string, column string) string {
+		if i := columns[column]; i >= 0 && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+	var departments []departmentRow
+	for i, row := range rows[1:] {
+		department := departmentRow{line: i + 2, label: cell(row, "label"), parent: cell(row, "parent"), commander: cell(row, "commander")}
+		if department != (departmentRow{line: i + 2}) {
+			departments = append(departments, department)
+		}
+	}
+	return departments, nil
+}
+
+// importDepartments creates the departments through the department
+// service, parents before their sub-departments, and reports the lines
+// that cannot be imported.
+func importDepartments(store repository.Store, rows []departmentRow) ([]string, error) {
+	departments := service.NewDepartments(store)
+	conscripts := service.NewConscripts(store)
+	existing, err := departments.List()
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]uint, len(existing))
+	for _, department := range existing {
+		ids[department.Label] = department.ID
+	}
+
+	var problems []string
+	var pending []departmentRow
+	seen := make(map[string]int)
+	for _, row := range rows {
+		switch {
+		case row.label == "":
+			problems = append(problems, fmt.Sprintf("line %d: missing label", row.line))
+		case ids[row.label] != 0:
+			problems = append(problems, fmt.Sprintf("line %d: department %q already exists", row.line, row.label))
+		case seen[row.label] != 0:
+			problems = append(problems, fmt.Sprintf("line %d: department %q already on line %d", row.line, row.label, seen[row.label]))
+		default:
+			seen[row.label] = row.line
+			pending = append(pending, row)
+		}
+	}
+
+	// Each pass creates the departments whose parent exists by then.
+	for progress := true; progress && len(pending) > 0; {
+		progress = false
+		var waiting []departmentRow
+		for _, row := range pending {
+			department := models.Department{Label: row.label}
+			if row.parent != "" {
+				parentID, ok := ids[row.parent]
+				if !ok {
+					waiting = append(waiting, row)
+					continue
+				}
+				department.ParentID = &parentID
+			}
+			if row.commander != "" {
+				commander, err := conscripts.GetByUsername(row.commander)
+				if err != nil {
+					problems = append(problems, fmt.Sprintf("line %d: commander %q not found", row.line, row.commander))
+					continue
+				}
+				department.CommanderID = &commander.ID
+			}
+			if err := departments.Create(&department, true); err != nil {
+				problems = append(problems, fmt.Sprintf("line %d: %v", row.line, err))
+				continue
+			}
+			ids[row.label] = department.ID
+			progress = true
+		}
+		pending = waiting
+	}
+	for _, row := range pending {
+		problems = append(problems, fmt.Sprintf("line %d: parent %q not found", row.line, row.parent))
+	}
+	return problems, nil
+}
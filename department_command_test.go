@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+func TestImportDepartments(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	battalion := models.Department{Label: "1st Battalion"}
+	if err := store.Departments().Create(&battalion); err != nil {
+		t.Fatal(err)
+	}
+	commander := models.Conscript{Username: "commander"}
+	if err := store.Conscripts().Create(&commander); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := departmentRows([][]string{
+		{"Parent", "Label", "Commander"},
+		{"Alpha Company", "1st Platoon", ""},
+		{"1st Battalion", "Alpha Company", "commander"},
+		{"", "", ""},
+		{"Nowhere", "Lost Platoon", ""},
+		{"", "1st Battalion", ""},
+		{"", "Bravo Company", "nobody"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems, err := importDepartments(store, rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		`line 6: department "1st Battalion" already exists`,
+		`line 7: commander "nobody" not found`,
+		`line 5: parent "Nowhere" not found`,
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("expected %d problems, got %q", len(expected), problems)
+	}
+	for i := range expected {
+		if problems[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], problems[i])
+		}
+	}
+
+	departments, _ := store.Departments().List()
+	labels := map[string]models.Department{}
+	for _, department := range departments {
+		labels[department.Label] = department
+	}
+	company, platoon := labels["Alpha Company"], labels["1st Platoon"]
+	if company.ParentID == nil || *company.ParentID != battalion.ID || company.CommanderID == nil || *company.CommanderID != commander.ID {
+		t.Errorf("expected the company under the battalion with its commander, got %+v", company)
+	}
+	if platoon.ParentID == nil || *platoon.ParentID != company.ID {
+		t.Errorf("expected the platoon listed before its company to be created under it, got %+v", platoon)
+	}
+}
@@ -15,67 +15,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "description": "Authenticate a conscript and get a JWT token",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "Login as a conscript",
-                "parameters": [
-                    {
-                        "description": "Login credentials",
-                        "name": "credentials",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized",
-                        "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/conscript_duties": {
+        "/absences": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "List conscript-duty assignments by conscript_id or duty_id",
+                "description": "List absences, optionally by conscript, status and date range. Conscripts only see their own absences and those of the conscripts under their command; administrators see everyone's.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "conscript_duties"
+                    "absences"
                 ],
-                "summary": "List conscript-duty assignments",
+                "summary": "List absences",
                 "parameters": [
                     {
                         "type": "integer",
@@ -84,9 +38,21 @@ const docTemplate = `{
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Duty ID",
-                        "name": "duty_id",
+                        "type": "string",
+                        "description": "Status (pending, approved or rejected)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only absences ending after this date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only absences starting before this date (inclusive when a date)",
+                        "name": "to",
                         "in": "query"
                     }
                 ],
@@ -96,10 +62,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.ConscriptDuty"
+                                "$ref": "#/definitions/models.Absence"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -108,13 +80,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update start and end time for a conscript-duty assignment",
+                "description": "Request a leave, sick day, training or other absence. Conscripts request absences for themselves, which is the default; commanders for the conscripts under their command and administrators for anyone. Absences start as pending until an administrator or commander approves or rejects them.",
                 "consumes": [
                     "application/json"
                 ],
@@ -122,25 +94,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "conscript_duties"
+                    "absences"
                 ],
-                "summary": "Update a conscript-duty assignment",
+                "summary": "Request an absence",
                 "parameters": [
                     {
-                        "description": "ConscriptDuty",
-                        "name": "conscript_duty",
+                        "description": "Absence",
+                        "name": "absence",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.ConscriptDuty"
+                            "$ref": "#/definitions/models.Absence"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.ConscriptDuty"
+                            "$ref": "#/definitions/models.Absence"
                         }
                     },
                     "400": {
@@ -149,47 +121,56 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/absences/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Assign a duty to a conscript with start and end time",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Get an absence by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "conscript_duties"
+                    "absences"
                 ],
-                "summary": "Assign a duty to a conscript",
+                "summary": "Get an absence by ID",
                 "parameters": [
                     {
-                        "description": "ConscriptDuty",
-                        "name": "conscript_duty",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.ConscriptDuty"
-                        }
+                        "type": "integer",
+                        "description": "Absence ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ConscriptDuty"
+                            "$ref": "#/definitions/models.Absence"
                         }
                     },
                     "400": {
@@ -198,21 +179,27 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             },
-            "delete": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Remove a duty from a conscript by conscript_id and duty_id",
+                "description": "Update the type, period or reason of an absence that is still pending",
                 "consumes": [
                     "application/json"
                 ],
@@ -220,25 +207,32 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "conscript_duties"
+                    "absences"
                 ],
-                "summary": "Remove a duty from a conscript",
+                "summary": "Update a pending absence",
                 "parameters": [
                     {
-                        "description": "ConscriptDuty IDs",
-                        "name": "conscript_duty",
+                        "type": "integer",
+                        "description": "Absence ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Absence",
+                        "name": "absence",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/models.Absence"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/models.Absence"
                         }
                     },
                     "400": {
@@ -247,81 +241,54 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/conscripts": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get a list of all conscripts",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "conscripts"
-                ],
-                "summary": "List all conscripts",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Conscript"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
             },
-            "post": {
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new conscript in the system",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Delete an absence. Conscripts can only cancel their pending absences; administrators can cancel any.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "conscripts"
+                    "absences"
                 ],
-                "summary": "Create a new conscript",
+                "summary": "Cancel an absence",
                 "parameters": [
                     {
-                        "description": "Conscript",
-                        "name": "conscript",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Conscript"
-                        }
+                        "type": "integer",
+                        "description": "Absence ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/models.Conscript"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -330,8 +297,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -339,25 +318,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/conscripts/{id}": {
-            "get": {
+        "/absences/{id}/approve": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a conscript by its ID",
+                "description": "Approve a pending absence, as an administrator or as a commander of the conscript. The conscript can no longer be assigned duties during it; existing assignments are reported by GET /assignments/conflicts.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "conscripts"
+                    "absences"
                 ],
-                "summary": "Get a conscript by ID",
+                "summary": "Approve an absence",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Conscript ID",
+                        "description": "Absence ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -367,7 +346,19 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Conscript"
+                            "$ref": "#/definitions/models.Absence"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "404": {
@@ -375,49 +366,45 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/absences/{id}/reject": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a conscript by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Reject a pending absence, as an administrator or as a commander of the conscript",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "conscripts"
+                    "absences"
                 ],
-                "summary": "Update a conscript",
+                "summary": "Reject an absence",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Conscript ID",
+                        "description": "Absence ID",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Conscript",
-                        "name": "conscript",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Conscript"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Conscript"
+                            "$ref": "#/definitions/models.Absence"
                         }
                     },
                     "400": {
@@ -426,46 +413,107 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/backups": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a conscript by its ID",
+                "description": "List the backups of the SQLite database, the most recent first. Only administrators can list backups.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "conscripts"
+                    "admin"
                 ],
-                "summary": "Delete a conscript",
-                "parameters": [
+                "summary": "List backups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/backup.Info"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Conscript ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "BearerAuth": []
                     }
                 ],
+                "description": "Take a backup of the SQLite database while serving requests. The backup is checked for integrity, compressed when configured, and the oldest backups are removed according to the retention settings. Only administrators can take backups.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Take a backup",
                 "responses": {
-                    "204": {
-                        "description": "No Content",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/backup.Info"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "501": {
+                        "description": "Not Implemented",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -473,31 +521,72 @@ const docTemplate = `{
                 }
             }
         },
-        "/departments": {
+        "/assignments": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a list of all departments",
+                "description": "List assignments, optionally by conscript_id or duty_id and within a date range. Dates are given as YYYY-MM-DD or RFC 3339; assignments overlapping the range are listed.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
                 ],
                 "tags": [
-                    "departments"
+                    "assignments"
+                ],
+                "summary": "List assignments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "conscript_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "duty_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assignments ending after this date",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only assignments starting before this date (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all departments",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Department"
+                                "$ref": "#/definitions/models.ConscriptDuty"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -512,7 +601,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new department in the system",
+                "description": "Assign a duty to a conscript from a start to an end time. Only administrators and commanders of the department of the service of the duty can assign it, to conscripts other than themselves. A conscript may hold the same duty many times in different periods. The assignment is rejected if it overlaps another duty of the conscript, leaves too little rest between duties, exceeds the capacity of the duty, falls within an approved absence of the conscript or the conscript lacks a qualification the duty requires, unless an administrator overrides the conflicts, which is recorded.",
                 "consumes": [
                     "application/json"
                 ],
@@ -520,25 +609,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "departments"
+                    "assignments"
                 ],
-                "summary": "Create a new department",
+                "summary": "Assign a duty to a conscript",
                 "parameters": [
                     {
-                        "description": "Department",
-                        "name": "department",
+                        "description": "Assignment",
+                        "name": "assignment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Department"
+                            "$ref": "#/definitions/models.ConscriptDuty"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Book despite conflicts (administrators only)",
+                        "name": "override",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Department"
+                            "$ref": "#/definitions/models.ConscriptDuty"
                         }
                     },
                     "400": {
@@ -547,6 +642,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConflictResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -556,25 +663,79 @@ const docTemplate = `{
                 }
             }
         },
-        "/departments/{id}": {
+        "/assignments/conflicts": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a department by its ID",
+                "description": "List overlapping duties, insufficient rest between duties, duties staffed beyond their capacity and duties during approved absences among the assignments in a date range. Dates are given as YYYY-MM-DD or RFC 3339 and default to the current day.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "departments"
+                    "assignments"
                 ],
-                "summary": "Get a department by ID",
+                "summary": "Report assignment conflicts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/conflicts.Conflict"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/assignments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a single assignment by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "assignments"
+                ],
+                "summary": "Get an assignment by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Department ID",
+                        "description": "Assignment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -584,7 +745,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Department"
+                            "$ref": "#/definitions/models.ConscriptDuty"
                         }
                     },
                     "400": {
@@ -607,7 +768,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a department by its ID",
+                "description": "Update the conscript, duty, start or end time of an assignment; omitted fields are kept. Only administrators and commanders of the duty can update an assignment, before and after the update, and conscripts exchange their own through swaps. The updated assignment is subject to the same conflict checks as new assignments.",
                 "consumes": [
                     "application/json"
                 ],
@@ -615,32 +776,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "departments"
+                    "assignments"
                 ],
-                "summary": "Update a department",
+                "summary": "Update an assignment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Department ID",
+                        "description": "Assignment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Department",
-                        "name": "department",
+                        "description": "Assignment",
+                        "name": "assignment",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Department"
+                            "$ref": "#/definitions/models.ConscriptDuty"
                         }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Update despite conflicts (administrators only)",
+                        "name": "override",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Department"
+                            "$ref": "#/definitions/models.ConscriptDuty"
                         }
                     },
                     "400": {
@@ -649,11 +816,23 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConflictResponse"
+                        }
                     }
                 }
             },
@@ -663,28 +842,28 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a department by its ID",
+                "description": "Remove an assignment by its ID. Only administrators and commanders of the duty can delete assignments.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "departments"
+                    "assignments"
                 ],
-                "summary": "Delete a department",
+                "summary": "Delete an assignment",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Department ID",
+                        "description": "Assignment ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -693,38 +872,16 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/duties": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Get a list of all duties",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "duties"
-                ],
-                "summary": "List all duties",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Duty"
-                            }
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
                     "500": {
@@ -734,14 +891,16 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/assignments:batch": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new duty in the system",
+                "description": "Apply many assignment operations in one transaction. Updates and deletes identify the assignment by id. Each operation is allowed to administrators and commanders of the duty only, as for single assignments. Created and updated assignments are checked for conflicts, including with earlier operations of the batch. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.",
                 "consumes": [
                     "application/json"
                 ],
@@ -749,25 +908,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "duties"
+                    "assignments"
                 ],
-                "summary": "Create a new duty",
+                "summary": "Create, update or delete many assignments at once",
                 "parameters": [
                     {
-                        "description": "Duty",
-                        "name": "duty",
+                        "description": "Batch operations",
+                        "name": "batch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Duty"
+                            "$ref": "#/definitions/handlers.BatchRequest-models_ConscriptDuty"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Duty"
+                            "$ref": "#/definitions/handlers.BatchResponse"
                         }
                     },
                     "400": {
@@ -776,6 +935,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -785,35 +950,35 @@ const docTemplate = `{
                 }
             }
         },
-        "/duties/{id}": {
-            "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
+        "/auth/login": {
+            "post": {
+                "description": "Authenticate a conscript and get a JWT token. Discharged conscripts and disabled accounts cannot log in.",
+                "consumes": [
+                    "application/json"
                 ],
-                "description": "Get a duty by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "duties"
+                    "auth"
                 ],
-                "summary": "Get a duty by ID",
+                "summary": "Login as a conscript",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Duty ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                        "description": "Login credentials",
+                        "name": "credentials",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.LoginRequest"
+                        }
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Duty"
+                            "$ref": "#/definitions/handlers.LoginResponse"
                         }
                     },
                     "400": {
@@ -822,54 +987,172 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
                 }
-            },
-            "put": {
-                "security": [
+            }
+        },
+        "/calendar/feeds/{token}/conscripts/{id}": {
+            "get": {
+                "description": "Get the duties of a conscript as an RFC 5545 iCalendar feed, authenticated by the feed token in the URL. The token opens the feeds of its own conscript and, for commanders, of the conscripts under their command; administrators open any feed.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Calendar feed of a conscript",
+                "parameters": [
                     {
-                        "BearerAuth": []
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Conscript ID, optionally followed by .ics",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
-                "description": "Update a duty by its ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/calendar/feeds/{token}/duties/{id}": {
+            "get": {
+                "description": "Get the assignments of a duty as an RFC 5545 iCalendar feed, authenticated by the feed token in the URL. Only the tokens of administrators and of commanders of the department of the service of the duty open it.",
                 "produces": [
-                    "application/json"
+                    "text/calendar"
                 ],
                 "tags": [
-                    "duties"
+                    "calendar"
                 ],
-                "summary": "Update a duty",
+                "summary": "Calendar feed of a duty",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Duty ID",
-                        "name": "id",
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Duty",
-                        "name": "duty",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "Duty ID, optionally followed by .ics",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar feed",
                         "schema": {
-                            "$ref": "#/definitions/models.Duty"
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
                         }
                     }
+                }
+            }
+        },
+        "/calendar/feeds/{token}/services/{id}": {
+            "get": {
+                "description": "Get the assignments of all duties of a service as an RFC 5545 iCalendar feed, authenticated by the feed token in the URL. Only the tokens of administrators and of commanders of the department of the service open it.",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Calendar feed of a service",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feed token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Service ID, optionally followed by .ics",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "iCalendar feed",
                         "schema": {
-                            "$ref": "#/definitions/models.Duty"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -878,6 +1161,18 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -885,39 +1180,67 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/calendar/token": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a duty by its ID",
+                "description": "Issue a new calendar feed token for the authenticated conscript, revoking any previous one. The token is embedded in feed URLs because calendar apps cannot send Bearer headers.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "duties"
+                    "calendar"
                 ],
-                "summary": "Delete a duty",
-                "parameters": [
+                "summary": "Issue a calendar feed token",
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.FeedTokenResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
                     {
-                        "type": "integer",
-                        "description": "Duty ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "BearerAuth": []
                     }
                 ],
+                "description": "Revoke the calendar feed token of the authenticated conscript, disabling all feed URLs that embed it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Revoke the calendar feed token",
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content",
                         "schema": {
-                            "$ref": "#/definitions/models.ErrorResponse"
+                            "type": "string"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
@@ -931,31 +1254,66 @@ const docTemplate = `{
                 }
             }
         },
-        "/services": {
+        "/conscripts": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a list of all services",
+                "description": "Get a list of all conscripts, optionally only those of a department and the departments under it. With as_of, list the conscripts as they were at that time, with the department they then belonged to, leaving out those not yet enlisted or already discharged.",
                 "produces": [
-                    "application/json"
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
                 ],
                 "tags": [
-                    "services"
+                    "conscripts"
+                ],
+                "summary": "List all conscripts",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only conscripts of this department",
+                        "name": "department_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also conscripts of the departments under department_id",
+                        "name": "include_subdepartments",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Point in time (RFC 3339 or YYYY-MM-DD for midday)",
+                        "name": "as_of",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default",
+                        "name": "format",
+                        "in": "query"
+                    }
                 ],
-                "summary": "List all services",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Service"
+                                "$ref": "#/definitions/models.Conscript"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -970,7 +1328,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Create a new service in the system",
+                "description": "Create a new conscript in the system",
                 "consumes": [
                     "application/json"
                 ],
@@ -978,17 +1336,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "services"
+                    "conscripts"
                 ],
-                "summary": "Create a new service",
+                "summary": "Create a new conscript",
                 "parameters": [
                     {
-                        "description": "Service",
-                        "name": "service",
+                        "description": "Conscript",
+                        "name": "conscript",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Service"
+                            "$ref": "#/definitions/models.Conscript"
                         }
                     }
                 ],
@@ -996,7 +1354,7 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.Service"
+                            "$ref": "#/definitions/models.Conscript"
                         }
                     },
                     "400": {
@@ -1014,25 +1372,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/services/{id}": {
+        "/conscripts/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Get a service by its ID",
+                "description": "Get a conscript by its ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "services"
+                    "conscripts"
                 ],
-                "summary": "Get a service by ID",
+                "summary": "Get a conscript by ID",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Service ID",
+                        "description": "Conscript ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1042,7 +1400,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Service"
+                            "$ref": "#/definitions/models.Conscript"
                         }
                     },
                     "400": {
@@ -1065,7 +1423,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Update a service by its ID",
+                "description": "Update a conscript by its ID. Administrators can update any conscript; other conscripts can only change their own first name, last name and password. Changing the department is recorded as a transfer effective now; use POST /conscripts/{id}/transfer to give a date or a reason.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1073,24 +1431,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "services"
+                    "conscripts"
                 ],
-                "summary": "Update a service",
+                "summary": "Update a conscript",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Service ID",
+                        "description": "Conscript ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Service",
-                        "name": "service",
+                        "description": "Conscript",
+                        "name": "conscript",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Service"
+                            "$ref": "#/definitions/models.Conscript"
                         }
                     }
                 ],
@@ -1098,7 +1456,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Service"
+                            "$ref": "#/definitions/models.Conscript"
                         }
                     },
                     "400": {
@@ -1107,6 +1465,12 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -1121,29 +1485,174 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "Delete a service by its ID",
+                "description": "Delete a conscript by its ID. Only administrators can delete conscripts.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "services"
+                    "conscripts"
                 ],
-                "summary": "Delete a service",
+                "summary": "Delete a conscript",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Service ID",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conscripts/{id}/discharge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Discharge a conscript from date, by default now, ending their department membership. Discharged conscripts can no longer log in or be assigned duties ending after their discharge; the assignments they already hold after it are returned and reported by GET /assignments/conflicts. Only administrators can discharge conscripts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conscripts"
+                ],
+                "summary": "Discharge a conscript",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Discharge",
+                        "name": "discharge",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DischargeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DischargeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    }
+                }
+            }
+        },
+        "/conscripts/{id}/ledger": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the assignments of a conscript starting in a date range, by default all of them, with the points each one earned, weighed in time_zone like the fairness report, and the running total",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Duty points ledger of a conscript",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of weekends and nights, UTC by default",
+                        "name": "time_zone",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fairness.Ledger"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
@@ -1156,91 +1665,4496 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conscripts/{id}/memberships": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the departments a conscript belonged to, oldest first, from their enlistment or creation.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conscripts"
+                ],
+                "summary": "Department history of a conscript",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.DepartmentMembership"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.LoginRequest": {
+        },
+        "/conscripts/{id}/qualifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the qualifications held by a conscript with their validity, optionally only those valid at a point in time",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "List the qualifications of a conscript",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only qualifications valid at this time (RFC 3339 or YYYY-MM-DD)",
+                        "name": "at",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ConscriptQualification"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conscripts/{id}/qualifications/{qualification_id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Grant a qualification to a conscript from valid_from, by default now, until valid_until, or indefinitely when it is omitted. Granting a qualification the conscript already holds renews it with the new validity. Only administrators can grant qualifications.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Grant or renew a qualification",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Qualification ID",
+                        "name": "qualification_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Validity",
+                        "name": "validity",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GrantQualificationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ConscriptQualification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove a qualification from a conscript. Only administrators can revoke qualifications.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Revoke a qualification",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Qualification ID",
+                        "name": "qualification_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conscripts/{id}/swaps": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pending swap requests a conscript takes part in, both those they proposed and those awaiting their answer",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Pending swaps of a conscript",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SwapRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conscripts/{id}/transfer": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move a conscript to another department from date, by default now. The membership of the previous department is kept in the history of the conscript. Only administrators can transfer conscripts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conscripts"
+                ],
+                "summary": "Transfer a conscript to another department",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Transfer",
+                        "name": "transfer",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.TransferRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Conscript"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/conscripts:batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply many conscript operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "conscripts"
+                ],
+                "summary": "Create, update or delete conscripts in bulk",
+                "parameters": [
+                    {
+                        "description": "Batch operations",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchRequest-models_Conscript"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of all departments",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "List all departments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Department"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new department in the system, optionally under a parent department. Only administrators can appoint a commander.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Create a new department",
+                "parameters": [
+                    {
+                        "description": "Department",
+                        "name": "department",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Department"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Department"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a department by its ID. With as_of, the department includes the conscripts that belonged to it at that time.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Get a department by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Point in time (RFC 3339 or YYYY-MM-DD for midday)",
+                        "name": "as_of",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Department"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a department by its ID. The department cannot be moved under itself or one of its sub-departments, and only administrators can appoint its commander.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Update a department",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Department",
+                        "name": "department",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Department"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Department"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a department by its ID. Departments with sub-departments cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Delete a department",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments/{id}/ancestors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the departments above a department, from the root of the hierarchy down to its parent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Departments above a department",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Department"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments/{id}/descendants": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every department under a department, at any depth, ordered by label",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Departments under a department",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Department"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments/{id}/tree": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a department with its sub-departments nested in Children, down to the lowest level, ordered by label",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Department tree",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Department"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/departments:batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply many department operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "departments"
+                ],
+                "summary": "Create, update or delete departments in bulk",
+                "parameters": [
+                    {
+                        "description": "Batch operations",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchRequest-models_Department"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/duties": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of all duties",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "duties"
+                ],
+                "summary": "List all duties",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Duty"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new duty in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duties"
+                ],
+                "summary": "Create a new duty",
+                "parameters": [
+                    {
+                        "description": "Duty",
+                        "name": "duty",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Duty"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Duty"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/duties/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a duty by its ID, with the qualifications it requires",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duties"
+                ],
+                "summary": "Get a duty by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Duty"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a duty by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duties"
+                ],
+                "summary": "Update a duty",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Duty",
+                        "name": "duty",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Duty"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Duty"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a duty by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duties"
+                ],
+                "summary": "Delete a duty",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/duties/{id}/eligible-conscripts": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the conscripts holding every qualification a duty requires throughout a period, by default now. Dates are given as YYYY-MM-DD or RFC 3339. With available, conscripts who could not be assigned the duty for the period because of other duties, rest or approved absences are left out too.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "List the conscripts eligible for a duty",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the period",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the period (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only conscripts of this department",
+                        "name": "department_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Leave out conscripts with conflicting duties or absences",
+                        "name": "available",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Conscript"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/duties/{id}/qualifications": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replace the qualifications required to be assigned a duty. An empty list lifts all requirements.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Set the qualifications a duty requires",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Required qualifications",
+                        "name": "qualifications",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.DutyQualificationsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Qualification"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/duties:batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply many duty operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "duties"
+                ],
+                "summary": "Create, update or delete duties in bulk",
+                "parameters": [
+                    {
+                        "description": "Batch operations",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchRequest-models_Duty"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/healthz": {
+            "get": {
+                "description": "Report that the server is running. It does not check the database, so that a database outage does not restart the server.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Liveness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holidays": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of all holidays by date",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holidays"
+                ],
+                "summary": "List all holidays",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Holiday"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new holiday, on which assignments earn the holiday multiplier of their duty. Only administrators can manage holidays.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holidays"
+                ],
+                "summary": "Create a new holiday",
+                "parameters": [
+                    {
+                        "description": "Holiday",
+                        "name": "holiday",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Holiday"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Holiday"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/holidays/{id}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a holiday by its ID. Only administrators can manage holidays.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holidays"
+                ],
+                "summary": "Update a holiday",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Holiday ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Holiday",
+                        "name": "holiday",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Holiday"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Holiday"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a holiday by its ID. Only administrators can manage holidays.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "holidays"
+                ],
+                "summary": "Delete a holiday",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Holiday ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/imports/conscripts": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload a CSV or XLSX intake and get a validation report. Nothing is stored unless commit is true and every row is valid, in which case all conscripts are created in one transaction. The mapping field maps conscript fields (first_name, last_name, registry_number, username, password, department) to column headers; departments are matched by label.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "imports"
+                ],
+                "summary": "Import a conscript intake",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Intake spreadsheet",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "File format (csv or xlsx), guessed from the file name by default",
+                        "name": "format",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Column mapping as JSON, e.g. {\\",
+                        "name": "mapping",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Create the conscripts instead of only validating them",
+                        "name": "commit",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/importer.Report"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/qualifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the qualifications catalogue",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "List qualifications",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Qualification"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Add a qualification to the catalogue",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Create a new qualification",
+                "parameters": [
+                    {
+                        "description": "Qualification",
+                        "name": "qualification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Qualification"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Qualification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/qualifications/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a qualification by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Get a qualification by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Qualification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Qualification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a qualification by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Update a qualification",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Qualification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Qualification",
+                        "name": "qualification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Qualification"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Qualification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a qualification by its ID, removing it from the conscripts holding it and the duties requiring it",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "qualifications"
+                ],
+                "summary": "Delete a qualification",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Qualification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Report whether the server can serve requests: the database answers and every migration is applied.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Readiness probe",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/fairness": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compare the duty points earned by the conscripts of a department with assignments starting in a date range, by default the last 90 days. Points are weighed by duty, with weekend, night and holiday multipliers that apply by the day and hours in time_zone (an IANA name, UTC by default). Conscripts further than threshold standard deviations (1.5 by default) from the mean are reported as outliers.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Duty fairness report of a department",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Department ID",
+                        "name": "department_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Outlier threshold in standard deviations",
+                        "name": "threshold",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone of weekends and nights, UTC by default",
+                        "name": "time_zone",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fairness.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/reports/strength": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Count the conscripts of each department present and absent, by type of approved absence, at a point in time, by default now",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Department strength",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Point in time (RFC 3339 or YYYY-MM-DD for midday)",
+                        "name": "at",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only this department",
+                        "name": "department_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.DepartmentStrength"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rosters/commit": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Store the assignments of a generated, and possibly edited, roster in one transaction. Only administrators and commanders of the duties can commit a roster, which must not assign them. Every assignment is checked for conflicts with the stored assignments and the earlier assignments of the roster; if any conflicts, nothing is stored, unless an administrator overrides the conflicts, which is recorded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rosters"
+                ],
+                "summary": "Commit a duty roster",
+                "parameters": [
+                    {
+                        "description": "Roster assignments",
+                        "name": "roster",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CommitRosterRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Commit despite conflicts (administrators only)",
+                        "name": "override",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ConscriptDuty"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rosters/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Export the duty assignments overlapping a date range as a printable roster, grouped by service and duty, with conscript names resolved. Dates are given as YYYY-MM-DD or RFC 3339 and default to the current day.",
+                "produces": [
+                    "application/pdf",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+                ],
+                "tags": [
+                    "rosters"
+                ],
+                "summary": "Export the duty roster",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Document format (pdf, csv or xlsx), PDF by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/rosters/generate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Preview a roster that staffs the given duty slots with eligible conscripts: the active conscripts of department_id, of the department of service_id, each with the departments under it, or among conscript_ids, combining the filters that are given. Conscripts are never given overlapping duties, always rest at least min_rest_hours (the conflict rest period by default) between duties, do not exceed max_per_week duties per ISO week, counted in time_zone (an IANA name, UTC by default), and are not assigned while unavailable, during approved absences or to duties whose qualifications they do not hold. Each seat goes to the conscript with the fewest duty points, with weekends and nights also told apart in time_zone, over the last history_days (90 by default) and the roster so far. Nothing is stored; commit the returned assignments with POST /rosters/commit.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "rosters"
+                ],
+                "summary": "Generate a duty roster",
+                "parameters": [
+                    {
+                        "description": "Roster request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.GenerateRosterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/roster.Plan"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a list of all services, optionally only those of a department and the departments under it",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+                    "application/pdf"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "List all services",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only services of this department",
+                        "name": "department_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Also services of the departments under department_id",
+                        "name": "include_subdepartments",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Service"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a new service in the system",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Create a new service",
+                "parameters": [
+                    {
+                        "description": "Service",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a service by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Get a service by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a service by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Update a service",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Service",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a service by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Delete a service",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{id}/swaps": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the pending swap requests of assignments to duties of a service, for administrators and commanders of the department of the service to review",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Pending swaps of a service",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SwapRequest"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services:batch": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apply many service operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "services"
+                ],
+                "summary": "Create, update or delete services in bulk",
+                "parameters": [
+                    {
+                        "description": "Batch operations",
+                        "name": "batch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchRequest-models_Service"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unprocessable Entity",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.BatchResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shift_templates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List all shift templates, optionally of a single duty",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shift_templates"
+                ],
+                "summary": "List shift templates",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Duty ID",
+                        "name": "duty_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ShiftTemplate"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a recurring shift of a duty, described by an RFC 5545 recurrence rule with a time zone and excluded dates",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shift_templates"
+                ],
+                "summary": "Create a new shift template",
+                "parameters": [
+                    {
+                        "description": "Shift template",
+                        "name": "shift_template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShiftTemplate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShiftTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/shift_templates/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a shift template by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shift_templates"
+                ],
+                "summary": "Get a shift template by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shift template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShiftTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Update a shift template by its ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shift_templates"
+                ],
+                "summary": "Update a shift template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shift template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Shift template",
+                        "name": "shift_template",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.ShiftTemplate"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ShiftTemplate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a shift template by its ID. Assignments made for its shifts are kept.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shift_templates"
+                ],
+                "summary": "Delete a shift template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Shift template ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/slots": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Expand the shift templates into the concrete shifts overlapping a date range, with how many conscripts each one needs and how many are already assigned. Only shifts that still need conscripts are listed unless all is true. Dates are given as YYYY-MM-DD or RFC 3339 and default to the current day.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "shift_templates"
+                ],
+                "summary": "Materialize the open duty slots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Start of the range",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the range (inclusive when a date)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only shifts of this duty",
+                        "name": "duty_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only shifts of the duties of this service",
+                        "name": "service_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include fully staffed shifts",
+                        "name": "all",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.OpenSlot"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List swap requests, optionally by status, conscript (as requester or counterparty) and service of the assignments. Conscripts only see the swaps they take part in; administrators see everyone's.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "List swaps",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Status (proposed, accepted, approved, declined, rejected, cancelled or expired)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Conscript ID",
+                        "name": "conscript_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "service_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SwapRequest"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Propose to exchange an assignment of the authenticated conscript with an assignment of another conscript. Both assignments must not have started and must not be part of another pending swap. The swap expires at expires_at, by default in 72 hours, and at the latest when the first of the assignments starts.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Propose a duty swap",
+                "parameters": [
+                    {
+                        "description": "Assignments to swap",
+                        "name": "swap",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateSwapRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a swap request by its ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Get a swap by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Swap ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps/{id}/accept": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Accept a swap proposed to the authenticated conscript. The swap then awaits the approval of an administrator or a commander.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Accept a swap",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Swap ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Approve a swap accepted by the counterparty, exchanging the conscripts of the two assignments atomically. Only administrators and commanders of the departments of both duties can approve swaps, other than their own. The swap is refused if the exchanged assignments conflict with other assignments or absences, unless an administrator overrides the conflicts, which is recorded, or if the assignments changed since the swap was proposed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Approve a swap",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Swap ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Exchange despite conflicts",
+                        "name": "override",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps/{id}/cancel": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Withdraw a pending swap proposed by the authenticated conscript",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Cancel a swap",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Swap ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps/{id}/decline": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Decline a swap proposed to the authenticated conscript",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Decline a swap",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Swap ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/swaps/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reject a pending swap, whether or not the counterparty accepted it. Only administrators and commanders of the departments of both duties can reject swaps, other than their own.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swaps"
+                ],
+                "summary": "Reject a swap",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Swap ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapRequest"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "backup.Info": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "conflicts.Conflict": {
+            "type": "object",
+            "properties": {
+                "absences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Absence"
+                    }
+                },
+                "assignments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConscriptDuty"
+                    }
+                },
+                "kind": {
+                    "$ref": "#/definitions/conflicts.Kind"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "qualifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Qualification"
+                    }
+                }
+            }
+        },
+        "conflicts.Kind": {
+            "type": "string",
+            "enum": [
+                "overlap",
+                "rest",
+                "capacity",
+                "absence",
+                "qualification",
+                "discharge"
+            ],
+            "x-enum-varnames": [
+                "KindOverlap",
+                "KindRest",
+                "KindCapacity",
+                "KindAbsence",
+                "KindQualification",
+                "KindDischarge"
+            ]
+        },
+        "fairness.Ledger": {
+            "type": "object",
+            "properties": {
+                "conscript_id": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fairness.LedgerEntry"
+                    }
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "fairness.LedgerEntry": {
+            "type": "object",
+            "properties": {
+                "assignment": {
+                    "$ref": "#/definitions/models.ConscriptDuty"
+                },
+                "balance": {
+                    "type": "number"
+                },
+                "points": {
+                    "type": "number"
+                }
+            }
+        },
+        "fairness.Report": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "mean": {
+                    "type": "number"
+                },
+                "outliers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fairness.Standing"
+                    }
+                },
+                "standings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/fairness.Standing"
+                    }
+                },
+                "std_dev": {
+                    "type": "number"
+                },
+                "threshold": {
+                    "type": "number"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "fairness.Standing": {
+            "type": "object",
+            "properties": {
+                "conscript_id": {
+                    "type": "integer"
+                },
+                "deviation": {
+                    "description": "Deviation is the distance from the mean in standard deviations.",
+                    "type": "number"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "outlier": {
+                    "type": "boolean"
+                },
+                "points": {
+                    "type": "number"
+                }
+            }
+        },
+        "handlers.BatchMode": {
+            "type": "string",
+            "enum": [
+                "atomic",
+                "partial"
+            ],
+            "x-enum-varnames": [
+                "BatchModeAtomic",
+                "BatchModePartial"
+            ]
+        },
+        "handlers.BatchOperation-models_Conscript": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Conscript"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchOperation-models_ConscriptDuty": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.ConscriptDuty"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchOperation-models_Department": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Department"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchOperation-models_Duty": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Duty"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchOperation-models_Service": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Service"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.BatchRequest-models_Conscript": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/handlers.BatchMode"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchOperation-models_Conscript"
+                    }
+                }
+            }
+        },
+        "handlers.BatchRequest-models_ConscriptDuty": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/handlers.BatchMode"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchOperation-models_ConscriptDuty"
+                    }
+                }
+            }
+        },
+        "handlers.BatchRequest-models_Department": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/handlers.BatchMode"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchOperation-models_Department"
+                    }
+                }
+            }
+        },
+        "handlers.BatchRequest-models_Duty": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/handlers.BatchMode"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchOperation-models_Duty"
+                    }
+                }
+            }
+        },
+        "handlers.BatchRequest-models_Service": {
+            "type": "object",
+            "required": [
+                "operations"
+            ],
+            "properties": {
+                "mode": {
+                    "$ref": "#/definitions/handlers.BatchMode"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchOperation-models_Service"
+                    }
+                }
+            }
+        },
+        "handlers.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "mode": {
+                    "$ref": "#/definitions/handlers.BatchMode"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.BatchResult"
+                    }
+                }
+            }
+        },
+        "handlers.BatchResult": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.CommitRosterRequest": {
+            "type": "object",
+            "required": [
+                "assignments"
+            ],
+            "properties": {
+                "assignments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConscriptDuty"
+                    }
+                }
+            }
+        },
+        "handlers.ConflictResponse": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/conflicts.Conflict"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateSwapRequest": {
+            "type": "object",
+            "required": [
+                "assignment_id",
+                "counterpart_assignment_id"
+            ],
+            "properties": {
+                "assignment_id": {
+                    "type": "integer"
+                },
+                "counterpart_assignment_id": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DischargeRequest": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.DischargeResponse": {
+            "type": "object",
+            "properties": {
+                "conscript": {
+                    "$ref": "#/definitions/models.Conscript"
+                },
+                "future_assignments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConscriptDuty"
+                    }
+                }
+            }
+        },
+        "handlers.DutyQualificationsRequest": {
+            "type": "object",
+            "properties": {
+                "qualification_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "handlers.FeedTokenResponse": {
+            "type": "object",
+            "properties": {
+                "token": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.GenerateRosterRequest": {
+            "type": "object",
+            "required": [
+                "slots"
+            ],
+            "properties": {
+                "conscript_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "department_id": {
+                    "type": "integer"
+                },
+                "history_days": {
+                    "type": "integer"
+                },
+                "max_per_week": {
+                    "type": "integer"
+                },
+                "min_rest_hours": {
+                    "type": "number"
+                },
+                "service_id": {
+                    "type": "integer"
+                },
+                "slots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roster.Slot"
+                    }
+                },
+                "time_zone": {
+                    "type": "string"
+                },
+                "unavailability": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roster.Unavailability"
+                    }
+                }
+            }
+        },
+        "handlers.GrantQualificationRequest": {
+            "type": "object",
+            "properties": {
+                "valid_from": {
+                    "type": "string"
+                },
+                "valid_until": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.HealthResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "conscript": {
+                    "$ref": "#/definitions/models.Conscript"
+                },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.TransferRequest": {
+            "type": "object",
+            "required": [
+                "department_id"
+            ],
+            "properties": {
+                "date": {
+                    "type": "string"
+                },
+                "department_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "importer.Report": {
+            "type": "object",
+            "properties": {
+                "committed": {
+                    "type": "boolean"
+                },
+                "invalid": {
+                    "type": "integer"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/importer.RowResult"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "importer.RowResult": {
+            "type": "object",
+            "properties": {
+                "conscript": {
+                    "$ref": "#/definitions/models.Conscript"
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "line": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Absence": {
+            "description": "Absence is a leave, sick day, training or other absence of a conscript from StartTime to EndTime. Absences are requested as pending and approved or rejected by an administrator, the approver, at DecidedAt. Conscripts cannot be assigned duties during approved absences. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "approverID": {
+                    "type": "integer"
+                },
+                "conscriptID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "decidedAt": {
+                    "type": "string"
+                },
+                "endTime": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "startTime": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.AbsenceStatus"
+                },
+                "type": {
+                    "$ref": "#/definitions/models.AbsenceType"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.AbsenceStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "approved",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "AbsencePending",
+                "AbsenceApproved",
+                "AbsenceRejected"
+            ]
+        },
+        "models.AbsenceType": {
+            "type": "string",
+            "enum": [
+                "leave",
+                "sick",
+                "training",
+                "other"
+            ],
+            "x-enum-varnames": [
+                "AbsenceLeave",
+                "AbsenceSick",
+                "AbsenceTraining",
+                "AbsenceOther"
+            ]
+        },
+        "models.Conscript": {
+            "description": "Conscript is a user entity used for authentication and as a foreign key in other models. It includes unique registry and username fields, a password, stored as a bcrypt hash and never returned, a role (conscript or admin), belongs to a department, and holds qualifications. Status follows the service of the conscript from enlistment: active, on leave, transferred out of the unit or discharged. DepartmentID is the department of the current membership; changes of department are recorded as memberships, and discharged conscripts can neither log in nor be assigned duties. Operators can disable an account with the pixis command, which blocks login until it is enabled again; this is not exposed by the API. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "department": {
+                    "$ref": "#/definitions/models.Department"
+                },
+                "departmentID": {
+                    "type": "integer"
+                },
+                "dischargeDate": {
+                    "type": "string"
+                },
+                "enlistmentDate": {
+                    "type": "string"
+                },
+                "expectedDischargeDate": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "qualifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConscriptQualification"
+                    }
+                },
+                "rank": {
+                    "type": "string"
+                },
+                "registryNumber": {
+                    "type": "string"
+                },
+                "role": {
+                    "$ref": "#/definitions/models.Role"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.ConscriptStatus"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConscriptDuty": {
+            "description": "ConscriptDuty is an assignment of a duty to a conscript from StartTime to EndTime. Assignments have their own ID, so a conscript may hold the same duty many times in different periods.",
+            "type": "object",
+            "properties": {
+                "conscriptID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "dutyID": {
+                    "type": "integer"
+                },
+                "endTime": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "startTime": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConscriptQualification": {
+            "description": "ConscriptQualification grants a qualification to a conscript from ValidFrom until ValidUntil, or indefinitely when ValidUntil is null. A conscript holds each qualification at most once; renewals update the validity. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "conscriptID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "qualification": {
+                    "$ref": "#/definitions/models.Qualification"
+                },
+                "qualificationID": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "validFrom": {
+                    "type": "string"
+                },
+                "validUntil": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ConscriptStatus": {
+            "type": "string",
+            "enum": [
+                "active",
+                "on_leave",
+                "transferred",
+                "discharged"
+            ],
+            "x-enum-varnames": [
+                "StatusActive",
+                "StatusOnLeave",
+                "StatusTransferred",
+                "StatusDischarged"
+            ]
+        },
+        "models.Department": {
+            "description": "Department is a unique grouping for conscripts and services. It is referenced by conscripts and services, and includes a unique label. Departments form a hierarchy, such as battalion, company and platoon, through their optional parent, and may have a commander who oversees the department and every department under it. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "children": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Department"
+                    }
+                },
+                "commanderID": {
+                    "type": "integer"
+                },
+                "conscripts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Conscript"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "parentID": {
+                    "type": "integer"
+                },
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Service"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DepartmentMembership": {
+            "description": "DepartmentMembership records that a conscript belonged to a department from StartDate until EndDate, or to this day when EndDate is null. Transfers close the current membership and open a new one. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "conscriptID": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "departmentID": {
+                    "type": "integer"
+                },
+                "endDate": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "startDate": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Duty": {
+            "description": "Duty is a task or responsibility assigned to conscripts, linked to a service, and can be assigned to many conscripts through assignments. Capacity limits how many conscripts may hold the duty at the same time (0 means unlimited). Points weigh each assignment of the duty for fairness, multiplied by the weekend, night and holiday multipliers when they apply; all default to 1. Shift templates describe when the duty recurs. Only conscripts holding all of its qualifications may be assigned the duty. Only the label and service_id are required for creation; timestamps and IDs are managed by Gorm.",
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
+                "capacity": {
+                    "type": "integer"
+                },
+                "conscriptDuties": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ConscriptDuty"
+                    }
+                },
+                "createdAt": {
                     "type": "string"
                 },
-                "username": {
+                "holidayMultiplier": {
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "nightMultiplier": {
+                    "type": "number"
+                },
+                "points": {
+                    "type": "number"
+                },
+                "qualifications": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Qualification"
+                    }
+                },
+                "service": {
+                    "$ref": "#/definitions/models.Service"
+                },
+                "serviceID": {
+                    "type": "integer"
+                },
+                "shiftTemplates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ShiftTemplate"
+                    }
+                },
+                "updatedAt": {
                     "type": "string"
+                },
+                "weekendMultiplier": {
+                    "type": "number"
                 }
             }
         },
-        "handlers.LoginResponse": {
+        "models.ErrorResponse": {
             "type": "object",
             "properties": {
-                "conscript": {
-                    "$ref": "#/definitions/models.Conscript"
+                "error": {
+                    "type": "string"
                 },
-                "token": {
+                "request_id": {
                     "type": "string"
                 }
             }
         },
-        "models.Conscript": {
-            "description": "Conscript is a user entity used for authentication and as a foreign key in other models. It includes unique registry and username fields, a password (should be hashed in production), and belongs to a department. Timestamps are managed by Gorm.",
+        "models.Holiday": {
+            "description": "Holiday is a day, given as YYYY-MM-DD, on which assignments earn the holiday multiplier of their duty. Date is unique. Timestamps are managed by Gorm.",
             "type": "object",
             "properties": {
                 "createdAt": {
                     "type": "string"
                 },
-                "department": {
-                    "$ref": "#/definitions/models.Department"
+                "date": {
+                    "type": "string"
                 },
-                "departmentID": {
+                "id": {
                     "type": "integer"
                 },
-                "firstName": {
+                "label": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Qualification": {
+            "description": "Qualification is an entry of the qualifications catalogue, such as a driving licence or a weapons qualification. Duties require qualifications, and conscripts hold them for a period of validity. Label is unique. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "description": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "lastName": {
+                "label": {
                     "type": "string"
                 },
-                "password": {
+                "updatedAt": {
                     "type": "string"
-                },
-                "registryNumber": {
+                }
+            }
+        },
+        "models.Role": {
+            "type": "string",
+            "enum": [
+                "conscript",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "RoleConscript",
+                "RoleAdmin"
+            ]
+        },
+        "models.Service": {
+            "description": "Service is a grouping of duties within a department. Label is unique. Timestamps are managed by Gorm.",
+            "type": "object",
+            "properties": {
+                "createdAt": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "department": {
+                    "$ref": "#/definitions/models.Department"
+                },
+                "departmentID": {
+                    "type": "integer"
+                },
+                "duties": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Duty"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
                     "type": "string"
                 },
-                "username": {
+                "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "models.ConscriptDuty": {
-            "description": "ConscriptDuty is the join table for conscripts and duties, with assignment period and timestamps. Composite primary key: conscript_id, duty_id.",
+        "models.ShiftTemplate": {
+            "description": "ShiftTemplate describes when a duty recurs. DTStart is the local start of the first shift (YYYY-MM-DDTHH:MM[:SS]) in TimeZone, an IANA name defaulting to the server's time zone. RRule is an RFC 5545 recurrence rule such as FREQ=DAILY or FREQ=WEEKLY;BYDAY=MO,WE,FR. ExDates is a comma-separated list of excluded shift starts, or of dates (YYYY-MM-DD) on which no shift takes place. Count is the number of conscripts each shift needs; 0 staffs the duty up to its capacity, or with one conscript when unlimited.",
             "type": "object",
             "properties": {
-                "conscriptID": {
+                "count": {
                     "type": "integer"
                 },
                 "createdAt": {
                     "type": "string"
                 },
+                "dtstart": {
+                    "type": "string"
+                },
+                "durationMinutes": {
+                    "type": "integer"
+                },
                 "dutyID": {
                     "type": "integer"
                 },
-                "endTime": {
+                "exDates": {
                     "type": "string"
                 },
-                "startTime": {
+                "id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "rrule": {
+                    "type": "string"
+                },
+                "timeZone": {
                     "type": "string"
                 },
                 "updatedAt": {
@@ -1248,101 +6162,212 @@ const docTemplate = `{
                 }
             }
         },
-        "models.Department": {
-            "description": "Department is a unique grouping for conscripts and services. It is referenced by conscripts and services, and includes a unique label. Timestamps are managed by Gorm.",
+        "models.SwapRequest": {
+            "description": "SwapRequest is a proposal of the requester to exchange their assignment, AssignmentID, with the assignment of the counterparty, CounterpartAssignmentID. The counterparty accepts or declines it at RespondedAt, then an administrator approves or rejects it at DecidedAt, the approver. Approved swaps exchange the conscripts of the two assignments. Requests still proposed or accepted at ExpiresAt expire. Timestamps are managed by Gorm.",
             "type": "object",
             "properties": {
-                "conscripts": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Conscript"
-                    }
+                "approverID": {
+                    "type": "integer"
+                },
+                "assignmentID": {
+                    "type": "integer"
+                },
+                "counterpartAssignmentID": {
+                    "type": "integer"
+                },
+                "counterpartyID": {
+                    "type": "integer"
                 },
                 "createdAt": {
                     "type": "string"
                 },
+                "decidedAt": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "label": {
+                "message": {
                     "type": "string"
                 },
-                "services": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Service"
-                    }
+                "requesterID": {
+                    "type": "integer"
+                },
+                "respondedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/models.SwapStatus"
                 },
                 "updatedAt": {
                     "type": "string"
                 }
             }
         },
-        "models.Duty": {
-            "description": "Duty is a task or responsibility assigned to conscripts, linked to a service, and can be assigned to many conscripts. Timestamps are managed by Gorm.",
+        "models.SwapStatus": {
+            "type": "string",
+            "enum": [
+                "proposed",
+                "accepted",
+                "approved",
+                "declined",
+                "rejected",
+                "cancelled",
+                "expired"
+            ],
+            "x-enum-varnames": [
+                "SwapProposed",
+                "SwapAccepted",
+                "SwapApproved",
+                "SwapDeclined",
+                "SwapRejected",
+                "SwapCancelled",
+                "SwapExpired"
+            ]
+        },
+        "roster.Load": {
             "type": "object",
             "properties": {
-                "conscriptDuties": {
+                "conscript_id": {
+                    "type": "integer"
+                },
+                "history_points": {
+                    "type": "number"
+                },
+                "planned": {
+                    "type": "integer"
+                },
+                "planned_points": {
+                    "type": "number"
+                }
+            }
+        },
+        "roster.Plan": {
+            "type": "object",
+            "properties": {
+                "assignments": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/models.ConscriptDuty"
                     }
                 },
-                "createdAt": {
-                    "type": "string"
+                "load": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roster.Load"
+                    }
                 },
-                "id": {
+                "unfilled": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/roster.UnfilledSlot"
+                    }
+                }
+            }
+        },
+        "roster.Slot": {
+            "type": "object",
+            "properties": {
+                "count": {
                     "type": "integer"
                 },
-                "label": {
-                    "type": "string"
-                },
-                "service": {
-                    "$ref": "#/definitions/models.Service"
-                },
-                "serviceID": {
+                "duty_id": {
                     "type": "integer"
                 },
-                "updatedAt": {
+                "end_time": {
+                    "type": "string"
+                },
+                "start_time": {
                     "type": "string"
                 }
             }
         },
-        "models.ErrorResponse": {
+        "roster.Unavailability": {
             "type": "object",
             "properties": {
-                "error": {
+                "conscript_id": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "start_time": {
                     "type": "string"
                 }
             }
         },
-        "models.Service": {
-            "description": "Service is a grouping of duties within a department. Label is unique. Timestamps are managed by Gorm.",
+        "roster.UnfilledSlot": {
             "type": "object",
             "properties": {
-                "createdAt": {
+                "count": {
+                    "type": "integer"
+                },
+                "duty_id": {
+                    "type": "integer"
+                },
+                "end_time": {
                     "type": "string"
                 },
-                "department": {
-                    "$ref": "#/definitions/models.Department"
+                "missing": {
+                    "type": "integer"
                 },
-                "departmentID": {
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.DepartmentStrength": {
+            "type": "object",
+            "properties": {
+                "absent": {
                     "type": "integer"
                 },
-                "duties": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Duty"
+                "absent_by_type": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
                     }
                 },
-                "id": {
+                "department_id": {
                     "type": "integer"
                 },
                 "label": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "present": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.OpenSlot": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "duty_id": {
+                    "type": "integer"
+                },
+                "end_time": {
                     "type": "string"
+                },
+                "open": {
+                    "type": "integer"
+                },
+                "staffed": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "template_id": {
+                    "type": "integer"
                 }
             }
         }
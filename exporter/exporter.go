@@ -0,0 +1,275 @@
+// Package exporter renders tabular data as CSV, XLSX or printable PDF documents.
+package exporter
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is a document format supported by the exporter.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+	FormatPDF  Format = "pdf"
+)
+
+// MIME types of the supported formats.
+const (
+	MIMEJSON = "application/json"
+	MIMECSV  = "text/csv"
+	MIMEXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	MIMEPDF  = "application/pdf"
+)
+
+var contentTypes = map[Format]string{
+	FormatJSON: MIMEJSON,
+	FormatCSV:  MIMECSV,
+	FormatXLSX: MIMEXLSX,
+	FormatPDF:  MIMEPDF,
+}
+
+// ParseFormat validates a format name such as "csv" or "pdf".
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(name))
+	if _, ok := contentTypes[format]; !ok {
+		return "", fmt.Errorf("unsupported export format %q", name)
+	}
+	return format, nil
+}
+
+// FormatFromContentType returns the format with the given MIME type.
+func FormatFromContentType(contentType string) (Format, bool) {
+	for format, mime := range contentTypes {
+		if mime == contentType {
+			return format, true
+		}
+	}
+	return "", false
+}
+
+// ContentType returns the MIME type of the format.
+func (f Format) ContentType() string {
+	if f == FormatCSV {
+		return MIMECSV + "; charset=utf-8"
+	}
+	return contentTypes[f]
+}
+
+// Column describes how a single column of a table is filled from an item.
+type Column[T any] struct {
+	Header string
+	Value  func(T) string
+}
+
+// Table is a titled grid of cells.
+type Table struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+}
+
+// NewTable builds a table with one row per item.
+func NewTable[T any](title string, columns []Column[T], items []T) Table {
+	table := Table{Title: title, Headers: make([]string, len(columns))}
+	for i, column := range columns {
+		table.Headers[i] = column.Header
+	}
+	for _, item := range items {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = column.Value(item)
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	return table
+}
+
+// Document is a set of tables rendered under a common title.
+type Document struct {
+	Title  string
+	Tables []Table
+}
+
+// Write renders the document in the given format. JSON is not rendered by
+// the exporter and is rejected.
+func Write(w io.Writer, format Format, doc Document) error {
+	switch format {
+	case FormatCSV:
+		return writeCSV(w, doc)
+	case FormatXLSX:
+		return writeXLSX(w, doc)
+	case FormatPDF:
+		return writePDF(w, doc)
+	}
+	return fmt.Errorf("unsupported export format %q", format)
+}
+
+// writeCSV writes the tables one after the other. When the document holds
+// more than one table, each one is preceded by its title and followed by an
+// empty line.
+func writeCSV(w io.Writer, doc Document) error {
+	writer := csv.NewWriter(w)
+	multiple := len(doc.Tables) > 1
+	for i, table := range doc.Tables {
+		if multiple {
+			if i > 0 {
+				writer.Write(nil)
+			}
+			writer.Write([]string{table.Title})
+		}
+		writer.Write(table.Headers)
+		writer.WriteAll(table.Rows)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeXLSX writes each table to its own worksheet.
+func writeXLSX(w io.Writer, doc Document) error {
+	workbook := excelize.NewFile()
+	defer workbook.Close()
+	headerStyle, err := workbook.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	used := map[string]bool{}
+	for i, table := range doc.Tables {
+		name := sheetName(table.Title, i, used)
+		if i == 0 {
+			if err := workbook.SetSheetName("Sheet1", name); err != nil {
+				return err
+			}
+		} else if _, err := workbook.NewSheet(name); err != nil {
+			return err
+		}
+		rows := append([][]string{table.Headers}, table.Rows...)
+		for r, row := range rows {
+			cell, _ := excelize.CoordinatesToCellName(1, r+1)
+			values := make([]any, len(row))
+			for c, value := range row {
+				values[c] = value
+			}
+			if err := workbook.SetSheetRow(name, cell, &values); err != nil {
+				return err
+			}
+		}
+		if len(table.Headers) > 0 {
+			last, _ := excelize.CoordinatesToCellName(len(table.Headers), 1)
+			workbook.SetCellStyle(name, "A1", last, headerStyle)
+		}
+	}
+	return workbook.Write(w)
+}
+
+// sheetName derives a unique worksheet name, which Excel limits to 31
+// characters without any of []:*?/\.
+func sheetName(title string, index int, used map[string]bool) string {
+	name := strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '-'
+		}
+		return r
+	}, title)
+	if runes := []rune(name); len(runes) > 31 {
+		name = string(runes[:31])
+	}
+	if name == "" || used[name] {
+		name = fmt.Sprintf("Sheet%d", index+1)
+	}
+	used[name] = true
+	return name
+}
+
+//go:embed fonts/DejaVuSansCondensed.ttf
+var pdfFont []byte
+
+const (
+	pdfFontFamily = "dejavu"
+	pdfLineHeight = 6.0
+)
+
+// writePDF renders the tables as an A4 printable document, repeating the
+// header row of a table whenever it continues on a new page.
+func writePDF(w io.Writer, doc Document) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFontFamily, "", pdfFont)
+	pdf.SetTitle(doc.Title, true)
+	pdf.SetAutoPageBreak(false, 15)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont(pdfFontFamily, "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("%d / {nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	left, _, right, bottom := pdf.GetMargins()
+	usable := pageWidth - left - right
+	fits := func(height float64) bool {
+		return pdf.GetY()+height <= pageHeight-bottom
+	}
+
+	pdf.SetFont(pdfFontFamily, "", 16)
+	pdf.MultiCell(usable, 8, doc.Title, "", "L", false)
+	pdf.Ln(2)
+
+	for _, table := range doc.Tables {
+		if len(table.Headers) == 0 {
+			continue
+		}
+		width := usable / float64(len(table.Headers))
+		header := func() {
+			pdf.SetFont(pdfFontFamily, "", 9)
+			pdf.SetFillColor(220, 220, 220)
+			for _, h := range table.Headers {
+				pdf.CellFormat(width, pdfLineHeight, h, "1", 0, "L", true, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		// Keep the title together with the header and the first row.
+		if !fits(4 * pdfLineHeight) {
+			pdf.AddPage()
+		}
+		if table.Title != "" {
+			pdf.SetFont(pdfFontFamily, "", 12)
+			pdf.CellFormat(usable, 8, table.Title, "", 1, "L", false, 0, "")
+		}
+		header()
+		pdf.SetFont(pdfFontFamily, "", 9)
+		for _, row := range table.Rows {
+			if !fits(pdfLineHeight) {
+				pdf.AddPage()
+				header()
+				pdf.SetFont(pdfFontFamily, "", 9)
+			}
+			for _, value := range row {
+				pdf.CellFormat(width, pdfLineHeight, fitText(pdf, value, width-2), "1", 0, "L", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.Ln(4)
+	}
+	return pdf.Output(w)
+}
+
+// fitText truncates text with an ellipsis so that it fits in the given width.
+func fitText(pdf *fpdf.Fpdf, text string, width float64) string {
+	if pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
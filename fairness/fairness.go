@@ -0,0 +1,220 @@
+// Package fairness weighs duty assignments in points and compares the points
+// earned by conscripts, so that unequal duties can be shared out fairly.
+package fairness
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// Night hours, in the time zone of the unit. Assignments overlapping the
+// night earn the night multiplier of their duty.
+var (
+	NightStart = 22
+	NightEnd   = 6
+)
+
+// Holidays is a set of holiday dates formatted as 2006-01-02.
+type Holidays map[string]bool
+
+// NewHolidays returns the set of the dates of the holidays.
+func NewHolidays(holidays []models.Holiday) Holidays {
+	result := make(Holidays, len(holidays))
+	for _, h := range holidays {
+		result[h.Date] = true
+	}
+	return result
+}
+
+// multiplier treats unset multipliers as neutral.
+func multiplier(m float64) float64 {
+	if m == 0 {
+		return 1
+	}
+	return m
+}
+
+// orUTC returns the location, or UTC when nil.
+func orUTC(location *time.Location) *time.Location {
+	if location == nil {
+		return time.UTC
+	}
+	return location
+}
+
+// IsNight reports whether the period overlaps the night hours in the
+// location, UTC when nil.
+func IsNight(start, end time.Time, location *time.Location) bool {
+	location = orUTC(location)
+	start, end = start.In(location), end.In(location)
+	day := time.Date(start.Year(), start.Month(), start.Day()-1, 0, 0, 0, 0, location)
+	for !day.After(end) {
+		nightStart := time.Date(day.Year(), day.Month(), day.Day(), NightStart, 0, 0, 0, location)
+		nightEnd := time.Date(day.Year(), day.Month(), day.Day()+1, NightEnd, 0, 0, 0, location)
+		if nightStart.Before(end) && nightEnd.After(start) {
+			return true
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return false
+}
+
+// Points returns the points an assignment of the duty from start to end
+// earns. The points of the duty are multiplied by each multiplier that
+// applies: weekend and holiday by the day the assignment starts on, night if
+// the assignment overlaps the night hours, both in the location, UTC when
+// nil.
+func Points(duty models.Duty, start, end time.Time, holidays Holidays, location *time.Location) float64 {
+	points := duty.Points
+	day := start.In(orUTC(location))
+	if weekday := day.Weekday(); weekday == time.Saturday || weekday == time.Sunday {
+		points *= multiplier(duty.WeekendMultiplier)
+	}
+	if holidays[day.Format(time.DateOnly)] {
+		points *= multiplier(duty.HolidayMultiplier)
+	}
+	if IsNight(start, end, location) {
+		points *= multiplier(duty.NightMultiplier)
+	}
+	return points
+}
+
+// Scorer computes the points of assignments of the duties on the holidays,
+// telling days and nights apart in the time zone of the unit.
+type Scorer struct {
+	duties   map[uint]models.Duty
+	holidays Holidays
+	location *time.Location
+}
+
+// NewScorer returns a scorer of assignments of the duties in the location,
+// UTC when nil.
+func NewScorer(duties []models.Duty, holidays Holidays, location *time.Location) *Scorer {
+	byID := make(map[uint]models.Duty, len(duties))
+	for _, duty := range duties {
+		byID[duty.ID] = duty
+	}
+	return &Scorer{duties: byID, holidays: holidays, location: location}
+}
+
+// Points returns the points of an assignment of the duty from start to end.
+// Assignments of deleted duties earn no points.
+func (s *Scorer) Points(dutyID uint, start, end time.Time) float64 {
+	return Points(s.duties[dutyID], start, end, s.holidays, s.location)
+}
+
+// starting returns the assignments starting within [from, to) in
+// chronological order.
+func starting(assignments []models.ConscriptDuty, from, to time.Time) []models.ConscriptDuty {
+	result := []models.ConscriptDuty{}
+	for _, a := range assignments {
+		if !a.StartTime.Before(from) && a.StartTime.Before(to) {
+			result = append(result, a)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].StartTime.Before(result[j].StartTime) })
+	return result
+}
+
+// LedgerEntry is an assignment with the points it earned and the running
+// total of the conscript up to and including it.
+type LedgerEntry struct {
+	Assignment models.ConscriptDuty `json:"assignment"`
+	Points     float64              `json:"points"`
+	Balance    float64              `json:"balance"`
+}
+
+// Ledger is the points history of a conscript.
+type Ledger struct {
+	ConscriptID uint          `json:"conscript_id"`
+	Total       float64       `json:"total"`
+	Entries     []LedgerEntry `json:"entries"`
+}
+
+// ConscriptLedger computes the ledger of a conscript from those of the
+// assignments starting within [from, to), in chronological order.
+func ConscriptLedger(scorer *Scorer, conscriptID uint, assignments []models.ConscriptDuty, from, to time.Time) *Ledger {
+	ledger := &Ledger{ConscriptID: conscriptID, Entries: []LedgerEntry{}}
+	for _, a := range starting(assignments, from, to) {
+		if a.ConscriptID != conscriptID {
+			continue
+		}
+		points := scorer.Points(a.DutyID, a.StartTime, a.EndTime)
+		ledger.Total += points
+		ledger.Entries = append(ledger.Entries, LedgerEntry{Assignment: a, Points: points, Balance: ledger.Total})
+	}
+	return ledger
+}
+
+// Totals returns the points earned by each conscript with the assignments
+// starting within [from, to).
+func Totals(scorer *Scorer, assignments []models.ConscriptDuty, from, to time.Time) map[uint]float64 {
+	totals := map[uint]float64{}
+	for _, a := range starting(assignments, from, to) {
+		totals[a.ConscriptID] += scorer.Points(a.DutyID, a.StartTime, a.EndTime)
+	}
+	return totals
+}
+
+// Standing is the points of a conscript compared to the group.
+type Standing struct {
+	ConscriptID uint    `json:"conscript_id"`
+	FirstName   string  `json:"first_name"`
+	LastName    string  `json:"last_name"`
+	Points      float64 `json:"points"`
+	// Deviation is the distance from the mean in standard deviations.
+	Deviation float64 `json:"deviation"`
+	Outlier   bool    `json:"outlier"`
+}
+
+// Report compares the points of a group of conscripts.
+type Report struct {
+	From      time.Time  `json:"from"`
+	To        time.Time  `json:"to"`
+	Mean      float64    `json:"mean"`
+	StdDev    float64    `json:"std_dev"`
+	Threshold float64    `json:"threshold"`
+	Standings []Standing `json:"standings"`
+	Outliers  []Standing `json:"outliers"`
+}
+
+// Compare reports the points the conscripts earned with those of the
+// assignments starting within [from, to), with their population standard
+// deviation. Conscripts further than threshold standard deviations from the
+// mean are outliers. Standings are sorted by descending points.
+func Compare(scorer *Scorer, conscripts []models.Conscript, assignments []models.ConscriptDuty, from, to time.Time, threshold float64) *Report {
+	totals := Totals(scorer, assignments, from, to)
+	report := &Report{From: from, To: to, Threshold: threshold, Standings: []Standing{}, Outliers: []Standing{}}
+	if len(conscripts) == 0 {
+		return report
+	}
+	for _, c := range conscripts {
+		report.Mean += totals[c.ID]
+	}
+	report.Mean /= float64(len(conscripts))
+	for _, c := range conscripts {
+		report.StdDev += math.Pow(totals[c.ID]-report.Mean, 2)
+	}
+	report.StdDev = math.Sqrt(report.StdDev / float64(len(conscripts)))
+
+	for _, c := range conscripts {
+		standing := Standing{ConscriptID: c.ID, FirstName: c.FirstName, LastName: c.LastName, Points: totals[c.ID]}
+		if report.StdDev > 0 {
+			standing.Deviation = (standing.Points - report.Mean) / report.StdDev
+			standing.Outlier = math.Abs(standing.Deviation) > threshold
+		}
+		report.Standings = append(report.Standings, standing)
+	}
+	sort.SliceStable(report.Standings, func(i, j int) bool {
+		return report.Standings[i].Points > report.Standings[j].Points
+	})
+	for _, standing := range report.Standings {
+		if standing.Outlier {
+			report.Outliers = append(report.Outliers, standing)
+		}
+	}
+	return report
+}
@@ -0,0 +1,71 @@
+package fairness
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+func TestPoints(t *testing.T) {
+	duty := models.Duty{Points: 2, WeekendMultiplier: 2, NightMultiplier: 1.5, HolidayMultiplier: 3}
+	holidays := Holidays{"2025-03-25": true}
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name       string
+		start, end time.Time
+		points     float64
+	}{
+		{"weekday", at(12, 8), at(12, 16), 2},
+		{"weekend", at(15, 8), at(15, 16), 4},
+		{"night", at(17, 22), at(18, 6), 3},
+		{"early morning", at(18, 4), at(18, 8), 3},
+		{"evening", at(18, 14), at(18, 22), 2},
+		{"holiday", at(25, 8), at(25, 16), 6},
+		{"weekend night", at(15, 20), at(16, 4), 6},
+	}
+	for _, tc := range cases {
+		if points := Points(duty, tc.start, tc.end, holidays, nil); points != tc.points {
+			t.Errorf("%s: expected %g points, got %g", tc.name, tc.points, points)
+		}
+	}
+	if points := Points(models.Duty{Points: 1}, at(15, 22), at(16, 6), nil, nil); points != 1 {
+		t.Errorf("expected unset multipliers to be neutral, got %g points", points)
+	}
+}
+
+func TestPointsInTimeZone(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+	duty := models.Duty{Points: 1, WeekendMultiplier: 2, NightMultiplier: 3, HolidayMultiplier: 5}
+	holidays := Holidays{"2025-03-25": true}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 3, day, hour, minute, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		name        string
+		start, end  time.Time
+		utc, athens float64
+	}{
+		// Athens is two hours ahead of UTC until the last Sunday of March.
+		{"evening", at(14, 21, 0), at(14, 21, 30), 1, 3},
+		{"past midnight on Friday", at(14, 23, 0), at(15, 0, 0), 3, 6},
+		{"past midnight before a holiday", at(24, 22, 30), at(24, 23, 0), 3, 15},
+		// The clocks go forward from 03:00 to 04:00 on March 30, so the
+		// same hour in UTC is still night the day before, but not after.
+		{"early morning before the switch", at(29, 3, 30), at(29, 4, 30), 6, 6},
+		{"early morning after the switch", at(30, 3, 30), at(30, 4, 30), 6, 2},
+	}
+	for _, tc := range cases {
+		if points := NewScorer([]models.Duty{duty}, holidays, nil).Points(duty.ID, tc.start, tc.end); points != tc.utc {
+			t.Errorf("%s: expected %g points in UTC, got %g", tc.name, tc.utc, points)
+		}
+		if points := NewScorer([]models.Duty{duty}, holidays, athens).Points(duty.ID, tc.start, tc.end); points != tc.athens {
+			t.Errorf("%s: expected %g points in Athens, got %g", tc.name, tc.athens, points)
+		}
+	}
+}
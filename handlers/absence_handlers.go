@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// AbsenceHandler serves the /absences routes and the strength report.
+type AbsenceHandler struct {
+	store    repository.Store
+	absences *service.Absences
+}
+
+// NewAbsenceHandler returns the absence handler of the store.
+func NewAbsenceHandler(store repository.Store) *AbsenceHandler {
+	return &AbsenceHandler{store: store, absences: service.NewAbsences(store)}
+}
+
+// Create handles POST /absences
+// @Summary Request an absence
+// @Description Request a leave, sick day, training or other absence. Conscripts request absences for themselves, which is the default; commanders for the conscripts under their command and administrators for anyone. Absences start as pending until an administrator or commander approves or rejects them.
+// @Tags absences
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param absence body models.Absence true "Absence"
+// @Success 201 {object} models.Absence
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /absences [post]
+func (h *AbsenceHandler) Create(c *gin.Context) {
+	var absence models.Absence
+	if err := c.ShouldBindJSON(&absence); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if _, ok := currentConscriptID(c); !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Unknown conscript"})
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	if err := h.absences.Create(&absence, actorID, admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, absence)
+}
+
+// List handles GET /absences
+// @Summary List absences
+// @Description List absences, optionally by conscript, status and date range. Conscripts only see their own absences and those of the conscripts under their command; administrators see everyone's.
+// @Tags absences
+// @Produce json
+// @Security BearerAuth
+// @Param conscript_id query int false "Conscript ID"
+// @Param status query string false "Status (pending, approved or rejected)"
+// @Param from query string false "Only absences ending after this date"
+// @Param to query string false "Only absences starting before this date (inclusive when a date)"
+// @Success 200 {array} models.Absence
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /absences [get]
+func (h *AbsenceHandler) List(c *gin.Context) {
+	filter := repository.AbsenceFilter{Status: models.AbsenceStatus(c.Query("status"))}
+	if id, err := strconv.ParseUint(c.Query("conscript_id"), 10, 0); err == nil {
+		filter.ConscriptID = uint(id)
+	}
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseDateOrTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid from: " + err.Error()})
+			return
+		}
+		filter.From = &from
+	}
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseDateOrTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid to: " + err.Error()})
+			return
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		filter.To = &to
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	absences, err := h.absences.List(filter, actorID, admin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, absences)
+}
+
+// Get handles GET /absences/:id
+// @Summary Get an absence by ID
+// @Description Get an absence by its ID
+// @Tags absences
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Absence ID"
+// @Success 200 {object} models.Absence
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /absences/{id} [get]
+func (h *AbsenceHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid absence ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	absence, err := h.absences.Get(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, absence)
+}
+
+// Update handles PUT /absences/:id
+// @Summary Update a pending absence
+// @Description Update the type, period or reason of an absence that is still pending
+// @Tags absences
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Absence ID"
+// @Param absence body models.Absence true "Absence"
+// @Success 200 {object} models.Absence
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /absences/{id} [put]
+func (h *AbsenceHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid absence ID")
+	if !ok {
+		return
+	}
+	var input models.Absence
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	absence, err := h.absences.Update(id, input, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, absence)
+}
+
+// Delete handles DELETE /absences/:id
+// @Summary Cancel an absence
+// @Description Delete an absence. Conscripts can only cancel their pending absences; administrators can cancel any.
+// @Tags absences
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Absence ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /absences/{id} [delete]
+func (h *AbsenceHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid absence ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	if err := h.absences.Delete(id, actorID, admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// decide approves or rejects a pending absence, see service.Absences.Decide.
+func (h *AbsenceHandler) decide(c *gin.Context, status models.AbsenceStatus) {
+	id, ok := parseID(c, "Invalid absence ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	absence, err := h.absences.Decide(id, status, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, absence)
+}
+
+// Approve handles POST /absences/:id/approve
+// @Summary Approve an absence
+// @Description Approve a pending absence, as an administrator or as a commander of the conscript. The conscript can no longer be assigned duties during it; existing assignments are reported by GET /assignments/conflicts.
+// @Tags absences
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Absence ID"
+// @Success 200 {object} models.Absence
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /absences/{id}/approve [post]
+func (h *AbsenceHandler) Approve(c *gin.Context) {
+	h.decide(c, models.AbsenceApproved)
+}
+
+// Reject handles POST /absences/:id/reject
+// @Summary Reject an absence
+// @Description Reject a pending absence, as an administrator or as a commander of the conscript
+// @Tags absences
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Absence ID"
+// @Success 200 {object} models.Absence
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /absences/{id}/reject [post]
+func (h *AbsenceHandler) Reject(c *gin.Context) {
+	h.decide(c, models.AbsenceRejected)
+}
+
+// Strength handles GET /reports/strength
+// @Summary Department strength
+// @Description Count the conscripts of each department present and absent, by type of approved absence, at a point in time, by default now
+// @Tags reports
+// @Produce json
+// @Security BearerAuth
+// @Param at query string false "Point in time (RFC 3339 or YYYY-MM-DD for midday)"
+// @Param department_id query int false "Only this department"
+// @Success 200 {array} service.DepartmentStrength
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /reports/strength [get]
+func (h *AbsenceHandler) Strength(c *gin.Context) {
+	at := time.Now()
+	if value := c.Query("at"); value != "" {
+		t, err := parsePointInTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid at: " + err.Error()})
+			return
+		}
+		at = t
+	}
+	var departmentID uint
+	if id, err := strconv.ParseUint(c.Query("department_id"), 10, 0); err == nil {
+		departmentID = uint(id)
+	}
+	strengths, err := h.absences.Strength(at, departmentID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, strengths)
+}
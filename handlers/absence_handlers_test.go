@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupAbsenceRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	auth := r.Group("", h.Auth.Middleware())
+	auth.POST("/absences", h.Absences.Create)
+	auth.GET("/absences", h.Absences.List)
+	auth.GET("/absences/:id", h.Absences.Get)
+	auth.PUT("/absences/:id", h.Absences.Update)
+	auth.DELETE("/absences/:id", h.Absences.Delete)
+	auth.POST("/absences/:id/approve", h.Absences.Approve)
+	auth.POST("/absences/:id/reject", h.Absences.Reject)
+	auth.GET("/reports/strength", h.Absences.Strength)
+	auth.POST("/assignments", h.Assignments.Create)
+	return r
+}
+
+func beforeEachAbsence(t *testing.T, db *gorm.DB) (*gin.Engine, models.Department, []models.Conscript, models.Conscript) {
+	r := setupAbsenceRouter(db)
+	department := models.Department{Label: "Absence company"}
+	db.Create(&department)
+	var conscripts []models.Conscript
+	for i := 0; i < 2; i++ {
+		conscript := models.Conscript{RegistryNumber: fmt.Sprintf("absence-%d", i), Username: fmt.Sprintf("absence-%d", i), DepartmentID: department.ID}
+		db.Create(&conscript)
+		conscripts = append(conscripts, conscript)
+	}
+	admin := models.Conscript{RegistryNumber: "absence-admin", Username: "absence-admin", Role: models.RoleAdmin}
+	db.Create(&admin)
+	return r, department, conscripts, admin
+}
+
+func sendAuthRequest(r *gin.Engine, method, path string, body any, conscriptID uint) *httptest.ResponseRecorder {
+	var reader *bytes.Buffer
+	if body != nil {
+		jsonValue, _ := json.Marshal(body)
+		reader = bytes.NewBuffer(jsonValue)
+	} else {
+		reader = bytes.NewBuffer(nil)
+	}
+	req, _ := http.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", bearerToken(conscriptID))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+var absenceStart = time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func TestAbsenceApproval(t *testing.T) {
+	r, _, conscripts, admin := beforeEachAbsence(t, testDatabase(t))
+	absence := models.Absence{Type: models.AbsenceLeave, StartTime: absenceStart, EndTime: absenceStart.AddDate(0, 0, 3)}
+
+	w := sendAuthRequest(r, "POST", "/absences", absence, conscripts[0].ID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var created models.Absence
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ConscriptID != conscripts[0].ID || created.Status != models.AbsencePending {
+		t.Errorf("expected a pending absence of the requester, got %+v", created)
+	}
+
+	other := absence
+	other.ConscriptID = conscripts[1].ID
+	if w := sendAuthRequest(r, "POST", "/absences", other, conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for someone else's absence, got %d", http.StatusForbidden, w.Code)
+	}
+	invalid := absence
+	invalid.Type = "vacation"
+	if w := sendAuthRequest(r, "POST", "/absences", invalid, conscripts[0].ID); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid type, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	path := fmt.Sprintf("/absences/%d", created.ID)
+	if w := sendAuthRequest(r, "GET", path, nil, conscripts[1].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for another conscript, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := sendAuthRequest(r, "POST", path+"/approve", nil, conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for self-approval, got %d", http.StatusForbidden, w.Code)
+	}
+	w = sendAuthRequest(r, "POST", path+"/approve", nil, admin.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var approved models.Absence
+	json.Unmarshal(w.Body.Bytes(), &approved)
+	if approved.Status != models.AbsenceApproved || approved.ApproverID != admin.ID || approved.DecidedAt == nil {
+		t.Errorf("expected the absence to be approved by the admin, got %+v", approved)
+	}
+	if w := sendAuthRequest(r, "POST", path+"/reject", nil, admin.ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a decided absence, got %d", http.StatusConflict, w.Code)
+	}
+	if w := sendAuthRequest(r, "PUT", path, models.Absence{Reason: "Wedding"}, conscripts[0].ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for changing an approved absence, got %d", http.StatusConflict, w.Code)
+	}
+	if w := sendAuthRequest(r, "DELETE", path, nil, conscripts[0].ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for cancelling an approved absence, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = sendAuthRequest(r, "GET", "/absences", nil, conscripts[1].ID)
+	var listed []models.Absence
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed) != 0 {
+		t.Errorf("expected conscripts to only see their own absences, got %+v", listed)
+	}
+	w = sendAuthRequest(r, "GET", "/absences?status=approved&from=2025-03-12", nil, admin.ID)
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed) != 1 {
+		t.Errorf("expected administrators to see the approved absence, got %+v", listed)
+	}
+}
+
+func TestAssignmentDuringAbsence(t *testing.T) {
+	db := testDatabase(t)
+	r, _, conscripts, admin := beforeEachAbsence(t, db)
+	duty := models.Duty{Label: "Absence duty"}
+	db.Create(&duty)
+	db.Create(&models.Absence{ConscriptID: conscripts[0].ID, Type: models.AbsenceSick, StartTime: absenceStart, EndTime: absenceStart.AddDate(0, 0, 2), Status: models.AbsenceApproved, ApproverID: admin.ID})
+	db.Create(&models.Absence{ConscriptID: conscripts[1].ID, Type: models.AbsenceLeave, StartTime: absenceStart, EndTime: absenceStart.AddDate(0, 0, 2)})
+
+	assignment := models.ConscriptDuty{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: absenceStart.Add(32 * time.Hour), EndTime: absenceStart.Add(40 * time.Hour)}
+	w := sendAuthRequest(r, "POST", "/assignments", assignment, admin.ID)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var resp ConflictResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Conflicts) != 1 || resp.Conflicts[0].Kind != conflicts.KindAbsence || len(resp.Conflicts[0].Absences) != 1 {
+		t.Errorf("expected an absence conflict, got %+v", resp.Conflicts)
+	}
+
+	// Pending absences do not block assignments.
+	assignment.ConscriptID = conscripts[1].ID
+	if w := sendAuthRequest(r, "POST", "/assignments", assignment, admin.ID); w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+}
+
+func TestStrength(t *testing.T) {
+	db := testDatabase(t)
+	r, department, conscripts, admin := beforeEachAbsence(t, db)
+	db.Create(&models.Absence{ConscriptID: conscripts[0].ID, Type: models.AbsenceSick, StartTime: absenceStart, EndTime: absenceStart.AddDate(0, 0, 2), Status: models.AbsenceApproved})
+
+	strength := func(at string) service.DepartmentStrength {
+		w := sendAuthRequest(r, "GET", fmt.Sprintf("/reports/strength?department_id=%d&at=%s", department.ID, at), nil, admin.ID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var strengths []service.DepartmentStrength
+		json.Unmarshal(w.Body.Bytes(), &strengths)
+		if len(strengths) != 1 {
+			t.Fatalf("expected the strength of one department, got %+v", strengths)
+		}
+		return strengths[0]
+	}
+	s := strength("2025-03-11T10:00:00Z")
+	if s.Total != 2 || s.Present != 1 || s.Absent != 1 || s.AbsentByType[models.AbsenceSick] != 1 {
+		t.Errorf("expected one present and one sick conscript, got %+v", s)
+	}
+	s = strength("2025-03-13T10:00:00Z")
+	if s.Present != 2 || s.Absent != 0 {
+		t.Errorf("expected everyone present after the absence, got %+v", s)
+	}
+}
@@ -1,17 +1,46 @@
 package handlers
 
 import (
+	"errors"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
-	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/logging"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/passwords"
+	"github.com/alexandrosraikos/pixis/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte("your-secret-key") // Use env var in production
+// jwtSecret signs the tokens issued by Login, which stay valid for
+// tokenLifetime. Both are set by ConfigureAuth.
+var (
+	jwtSecret     []byte
+	tokenLifetime = 24 * time.Hour
+)
+
+// ConfigureAuth sets the secret signing the tokens and how long they stay
+// valid.
+func ConfigureAuth(secret string, lifetime time.Duration) {
+	jwtSecret = []byte(secret)
+	tokenLifetime = lifetime
+}
+
+// conscriptIDKey is the context key holding the ID of the authenticated conscript.
+const conscriptIDKey = "conscript_id"
+
+// AuthHandler logs conscripts in and authenticates their requests.
+type AuthHandler struct {
+	store repository.Store
+}
+
+// NewAuthHandler returns the authentication handler of the store.
+func NewAuthHandler(store repository.Store) *AuthHandler {
+	return &AuthHandler{store: store}
+}
 
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -23,8 +52,9 @@ type LoginResponse struct {
 	Conscript models.Conscript `json:"conscript"`
 }
 
+// Login handles POST /auth/login
 // @Summary Login as a conscript
-// @Description Authenticate a conscript and get a JWT token
+// @Description Authenticate a conscript and get a JWT token. Discharged conscripts and disabled accounts cannot log in.
 // @Tags auth
 // @Accept json
 // @Produce json
@@ -32,31 +62,45 @@ type LoginResponse struct {
 // @Success 200 {object} LoginResponse
 // @Failure 401 {object} models.ErrorResponse
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Router /auth/login [post]
-func Login(c *gin.Context) {
+func (h *AuthHandler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid request"})
 		return
 	}
 
-	var conscript models.Conscript
-	db := database.GetDB()
-	if err := db.Where("username = ?", req.Username).First(&conscript).Error; err != nil {
+	// The log tells apart the refusals that the response hides.
+	logger := logging.FromContext(c.Request.Context()).With(slog.String("username", req.Username))
+	conscript, err := h.store.Conscripts().FindByUsername(req.Username)
+	if err != nil {
+		logger.Info("login refused", slog.String("reason", "unknown username"))
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid username or password"})
 		return
 	}
 
-	// In production, use hashed passwords!
-	if conscript.Password != req.Password {
+	if !passwords.Check(conscript.Password, req.Password) {
+		logger.Info("login refused", slog.String("reason", "wrong password"))
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid username or password"})
 		return
 	}
 
+	if conscript.Status == models.StatusDischarged {
+		logger.Info("login refused", slog.String("reason", "discharged"))
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Discharged conscripts cannot log in"})
+		return
+	}
+	if conscript.DisabledAt != nil {
+		logger.Info("login refused", slog.String("reason", "disabled"))
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "This account is disabled"})
+		return
+	}
+
 	// Create JWT token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub": conscript.ID,
-		"exp": time.Now().Add(24 * time.Hour).Unix(),
+		"exp": time.Now().Add(tokenLifetime).Unix(),
 	})
 	tokenString, err := token.SignedString(jwtSecret)
 	if err != nil {
@@ -64,14 +108,15 @@ func Login(c *gin.Context) {
 		return
 	}
 
+	logger.Info("login", slog.Uint64("conscript_id", uint64(conscript.ID)))
 	c.JSON(http.StatusOK, LoginResponse{
 		Token:     tokenString,
 		Conscript: conscript,
 	})
 }
 
-// AuthMiddleware checks for a valid JWT token in the Authorization header
-func AuthMiddleware() gin.HandlerFunc {
+// Middleware checks for a valid JWT token in the Authorization header
+func (h *AuthHandler) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.GetHeader("Authorization")
 		if header == "" || !strings.HasPrefix(header, "Bearer ") {
@@ -89,6 +134,57 @@ func AuthMiddleware() gin.HandlerFunc {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired token"})
 			return
 		}
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if sub, ok := claims["sub"].(float64); ok {
+				c.Set(conscriptIDKey, uint(sub))
+			}
+		}
+		// Tokens issued before a discharge, or before the account was
+		// disabled, stop working with it.
+		if id, ok := currentConscriptID(c); ok && !activeConscript(c, h.store.Conscripts(), id) {
+			return
+		}
 		c.Next()
 	}
 }
+
+// activeConscript aborts the request unless the conscript still exists, is
+// not discharged and their account is not disabled.
+func activeConscript(c *gin.Context, conscripts repository.Conscripts, id uint) bool {
+	conscript, err := conscripts.Find(id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or expired token"})
+		return false
+	}
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Could not authenticate the request"})
+		return false
+	}
+	if conscript.Status == models.StatusDischarged {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: "Discharged conscripts cannot log in"})
+		return false
+	}
+	if conscript.DisabledAt != nil {
+		c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{Error: "This account is disabled"})
+		return false
+	}
+	return true
+}
+
+// Principal returns the ID of the conscript authenticated by
+// AuthHandler.Middleware or CalendarHandler.Authenticate, for the request
+// logs.
+func Principal(c *gin.Context) (uint, bool) {
+	return currentConscriptID(c)
+}
+
+// currentConscriptID returns the ID of the conscript authenticated by
+// AuthHandler.Middleware.
+func currentConscriptID(c *gin.Context) (uint, bool) {
+	id, ok := c.Get(conscriptIDKey)
+	if !ok {
+		return 0, false
+	}
+	conscriptID, ok := id.(uint)
+	return conscriptID, ok && conscriptID != 0
+}
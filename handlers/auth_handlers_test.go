@@ -8,23 +8,24 @@ import (
 	"testing"
 	"time"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/passwords"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
 )
 
-func setupAuthRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	database.RecreateDatabase("auth_test.db")
+func setupAuthRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
 	r := gin.Default()
-	r.POST("/auth/login", Login)
+	r.POST("/auth/login", h.Auth.Login)
 	return r
 }
 
-func beforeEachAuth(t *testing.T) *gin.Engine {
-	r := setupAuthRouter()
-	db := database.GetDB()
+func beforeEachAuth(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := setupAuthRouter(db)
 	// Create a conscript for login
 	conscript := models.Conscript{
 		FirstName:      "Auth",
@@ -33,13 +34,56 @@ func beforeEachAuth(t *testing.T) *gin.Engine {
 		Username:       "authuser",
 		Password:       "testpass",
 	}
-	db.Create(&conscript)
+	if err := service.NewConscripts(repository.NewGormStore(db)).Create(&conscript, false); err != nil {
+		t.Fatal(err)
+	}
 	t.Cleanup(func() {})
 	return r
 }
 
+// login posts the credentials and returns the status of the response.
+func login(r *gin.Engine, username, password string) int {
+	jsonValue, _ := json.Marshal(LoginRequest{Username: username, Password: password})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestLoginHashedPasswords(t *testing.T) {
+	db := testDatabase(t)
+	r := beforeEachAuth(t, db)
+	var stored models.Conscript
+	db.Where("username = ?", "authuser").First(&stored)
+	if stored.Password == "testpass" || !passwords.IsHash(stored.Password) {
+		t.Errorf("expected the password to be stored as a hash, got %q", stored.Password)
+	}
+	if code := login(r, "authuser", stored.Password); code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for logging in with the hash, got %d", http.StatusUnauthorized, code)
+	}
+
+	// Passwords stored in plaintext by earlier versions are refused until
+	// they are hashed on start.
+	legacy := models.Conscript{RegistryNumber: "legacy", Username: "legacy", Password: "plaintext"}
+	db.Create(&legacy)
+	if code := login(r, "legacy", "plaintext"); code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a plaintext password, got %d", http.StatusUnauthorized, code)
+	}
+	hashed, err := service.NewConscripts(repository.NewGormStore(db)).HashPasswords()
+	if err != nil || hashed != 1 {
+		t.Fatalf("expected the plaintext password to be hashed, got %d, %v", hashed, err)
+	}
+	if code := login(r, "legacy", "plaintext"); code != http.StatusOK {
+		t.Errorf("expected status %d once the password is hashed, got %d", http.StatusOK, code)
+	}
+	if hashed, err := service.NewConscripts(repository.NewGormStore(db)).HashPasswords(); err != nil || hashed != 0 {
+		t.Errorf("expected the hashed passwords to be kept, got %d, %v", hashed, err)
+	}
+}
+
 func TestLoginSuccess(t *testing.T) {
-	r := beforeEachAuth(t)
+	r := beforeEachAuth(t, testDatabase(t))
 	login := map[string]string{
 		"username": "authuser",
 		"password": "testpass",
@@ -60,10 +104,13 @@ func TestLoginSuccess(t *testing.T) {
 	if resp["token"] == nil {
 		t.Errorf("expected token in response")
 	}
+	if conscript, _ := resp["conscript"].(map[string]interface{}); conscript == nil || conscript["Password"] != nil {
+		t.Errorf("expected the conscript without its password, got %v", resp["conscript"])
+	}
 }
 
 func TestLoginWrongPassword(t *testing.T) {
-	r := beforeEachAuth(t)
+	r := beforeEachAuth(t, testDatabase(t))
 	login := map[string]string{
 		"username": "authuser",
 		"password": "wrongpass",
@@ -79,7 +126,7 @@ func TestLoginWrongPassword(t *testing.T) {
 }
 
 func TestLoginNonexistentUser(t *testing.T) {
-	r := beforeEachAuth(t)
+	r := beforeEachAuth(t, testDatabase(t))
 	login := map[string]string{
 		"username": "nope",
 		"password": "testpass",
@@ -95,7 +142,7 @@ func TestLoginNonexistentUser(t *testing.T) {
 }
 
 func TestLoginInvalidRequest(t *testing.T) {
-	r := beforeEachAuth(t)
+	r := beforeEachAuth(t, testDatabase(t))
 	login := map[string]string{
 		"username": "authuser",
 	}
@@ -111,7 +158,7 @@ func TestLoginInvalidRequest(t *testing.T) {
 
 func TestAuthMiddleware_NoToken(t *testing.T) {
 	r := gin.New()
-	r.GET("/protected", AuthMiddleware(), func(c *gin.Context) {
+	r.GET("/protected", testHandlers(testDatabase(t)).Auth.Middleware(), func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
 	w := httptest.NewRecorder()
@@ -124,7 +171,7 @@ func TestAuthMiddleware_NoToken(t *testing.T) {
 
 func TestAuthMiddleware_InvalidToken(t *testing.T) {
 	r := gin.New()
-	r.GET("/protected", AuthMiddleware(), func(c *gin.Context) {
+	r.GET("/protected", testHandlers(testDatabase(t)).Auth.Middleware(), func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
 	w := httptest.NewRecorder()
@@ -137,13 +184,16 @@ func TestAuthMiddleware_InvalidToken(t *testing.T) {
 }
 
 func TestAuthMiddleware_ValidToken(t *testing.T) {
-	r := gin.New()
-	r.GET("/protected", AuthMiddleware(), func(c *gin.Context) {
+	db := testDatabase(t)
+	r := beforeEachAuth(t, db)
+	r.GET("/protected", testHandlers(db).Auth.Middleware(), func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
 	})
+	var conscript models.Conscript
+	db.Where("username = ?", "authuser").First(&conscript)
 	// Generate a valid token
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub": 1,
+		"sub": conscript.ID,
 		"exp": time.Now().Add(time.Hour).Unix(),
 	})
 	tokenString, _ := token.SignedString(jwtSecret)
@@ -155,3 +205,63 @@ func TestAuthMiddleware_ValidToken(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 }
+
+func TestAuthMiddleware_DeletedConscript(t *testing.T) {
+	db := testDatabase(t)
+	r := beforeEachAuth(t, db)
+	r.GET("/protected", testHandlers(db).Auth.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	var conscript models.Conscript
+	db.Where("username = ?", "authuser").First(&conscript)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": conscript.ID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString(jwtSecret)
+	if err := service.NewConscripts(repository.NewGormStore(db)).Delete(conscript.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for a token of a deleted conscript, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestLoginDisabled(t *testing.T) {
+	db := testDatabase(t)
+	r := beforeEachAuth(t, db)
+	r.GET("/protected", testHandlers(db).Auth.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+	var conscript models.Conscript
+	db.Where("username = ?", "authuser").First(&conscript)
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": conscript.ID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString(jwtSecret)
+	if _, err := service.NewConscripts(repository.NewGormStore(db)).SetDisabled(conscript.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	jsonValue, _ := json.Marshal(map[string]string{"username": "authuser", "password": "testpass"})
+	req, _ := http.NewRequest("POST", "/auth/login", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a disabled account, got %d", http.StatusForbidden, w.Code)
+	}
+	req, _ = http.NewRequest("GET", "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+tokenString)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a token of a disabled account, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alexandrosraikos/pixis/backup"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// BackupHandler serves the /admin/backups routes.
+type BackupHandler struct {
+	store   repository.Store
+	manager *backup.Manager
+}
+
+// NewBackupHandler returns the backup handler of the store, whose backups
+// are taken by the manager. The manager is nil when the database cannot be
+// backed up.
+func NewBackupHandler(store repository.Store, manager *backup.Manager) *BackupHandler {
+	return &BackupHandler{store: store, manager: manager}
+}
+
+// allowed checks that backups are supported and requested by an
+// administrator.
+func (h *BackupHandler) allowed(c *gin.Context) bool {
+	if !isAdmin(c, h.store.Conscripts()) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only administrators can manage backups"})
+		return false
+	}
+	if h.manager == nil {
+		c.JSON(http.StatusNotImplemented, models.ErrorResponse{Error: backup.ErrUnsupported.Error()})
+		return false
+	}
+	return true
+}
+
+// Create handles POST /admin/backups
+// @Summary Take a backup
+// @Description Take a backup of the SQLite database while serving requests. The backup is checked for integrity, compressed when configured, and the oldest backups are removed according to the retention settings. Only administrators can take backups.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} backup.Info
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse
+// @Router /admin/backups [post]
+func (h *BackupHandler) Create(c *gin.Context) {
+	if !h.allowed(c) {
+		return
+	}
+	info, err := h.manager.Backup()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, info)
+}
+
+// List handles GET /admin/backups
+// @Summary List backups
+// @Description List the backups of the SQLite database, the most recent first. Only administrators can list backups.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} backup.Info
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Failure 501 {object} models.ErrorResponse
+// @Router /admin/backups [get]
+func (h *BackupHandler) List(c *gin.Context) {
+	if !h.allowed(c) {
+		return
+	}
+	backups, err := h.manager.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, backups)
+}
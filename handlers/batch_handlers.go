@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// BatchMode controls how a batch request reacts to failing operations.
+type BatchMode string
+
+const (
+	// BatchModeAtomic commits the batch only if every operation succeeds.
+	BatchModeAtomic BatchMode = "atomic"
+	// BatchModePartial commits every successful operation and reports the failing ones.
+	BatchModePartial BatchMode = "partial"
+)
+
+// Batch operation names.
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// BatchOperation is a single create, update or delete within a batch request.
+// ID is required for updates and deletes of resources identified by ID.
+type BatchOperation[T any] struct {
+	Op   string `json:"op"`
+	ID   uint   `json:"id,omitempty"`
+	Data T      `json:"data"`
+}
+
+// BatchRequest is the body accepted by the :batch endpoints.
+type BatchRequest[T any] struct {
+	Mode       BatchMode           `json:"mode"`
+	Operations []BatchOperation[T] `json:"operations" binding:"required"`
+}
+
+// BatchResult reports the outcome of one operation of a batch request.
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Data   any    `json:"data,omitempty"`
+}
+
+// BatchResponse is the response of the :batch endpoints.
+type BatchResponse struct {
+	Mode      BatchMode     `json:"mode"`
+	Committed bool          `json:"committed"`
+	Results   []BatchResult `json:"results"`
+}
+
+// batchError carries the HTTP status to report for a failed operation.
+type batchError struct {
+	status  int
+	message string
+}
+
+func (e *batchError) Error() string {
+	return e.message
+}
+
+// errBatchRolledBack aborts the outer transaction of a failed atomic batch.
+var errBatchRolledBack = errors.New("batch rolled back")
+
+// batchOps holds the per-resource implementation of each batch operation.
+// Operations run against the store of the batch transaction and may replace
+// op.Data with the stored record so that it is echoed back in the result.
+type batchOps[T any] struct {
+	create func(store repository.Store, op *BatchOperation[T]) error
+	update func(store repository.Store, op *BatchOperation[T]) error
+	delete func(store repository.Store, op *BatchOperation[T]) error
+}
+
+// runBatch applies every operation of the request in a single transaction.
+// Each operation runs in its own savepoint so that a failure only undoes that
+// operation; in atomic mode the whole transaction is rolled back afterwards if
+// any operation failed.
+func runBatch[T any](c *gin.Context, store repository.Store, ops batchOps[T]) {
+	var req BatchRequest[T]
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = BatchModeAtomic
+	}
+	if req.Mode != BatchModeAtomic && req.Mode != BatchModePartial {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("Invalid batch mode %q", req.Mode)})
+		return
+	}
+
+	results := make([]BatchResult, len(req.Operations))
+	failed := false
+	err := store.Transaction(func(tx repository.Store) error {
+		for i := range req.Operations {
+			op := &req.Operations[i]
+			results[i] = BatchResult{Index: i, Op: op.Op}
+			status, err := applyBatchOperation(tx, ops, op)
+			results[i].Status = status
+			if err != nil {
+				failed = true
+				results[i].Error = err.Error()
+				continue
+			}
+			if op.Op != BatchOpDelete {
+				results[i].Data = op.Data
+			}
+		}
+		if failed && req.Mode == BatchModeAtomic {
+			return errBatchRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBatchRolledBack) {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	committed := err == nil
+	if !committed {
+		for i := range results {
+			results[i].Data = nil
+		}
+	}
+	status := http.StatusOK
+	if !committed {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, BatchResponse{Mode: req.Mode, Committed: committed, Results: results})
+}
+
+// applyBatchOperation runs a single operation in a nested transaction and
+// returns the HTTP status describing its outcome.
+func applyBatchOperation[T any](store repository.Store, ops batchOps[T], op *BatchOperation[T]) (int, error) {
+	var apply func(store repository.Store, op *BatchOperation[T]) error
+	status := http.StatusOK
+	switch op.Op {
+	case BatchOpCreate:
+		apply, status = ops.create, http.StatusCreated
+	case BatchOpUpdate:
+		apply = ops.update
+	case BatchOpDelete:
+		apply, status = ops.delete, http.StatusNoContent
+	default:
+		return http.StatusBadRequest, fmt.Errorf("Invalid operation %q", op.Op)
+	}
+	if op.Op != BatchOpCreate && op.ID == 0 {
+		return http.StatusBadRequest, &batchError{http.StatusBadRequest, "Missing id"}
+	}
+	if err := store.Transaction(func(tx repository.Store) error {
+		return apply(tx, op)
+	}); err != nil {
+		return statusOf(err), err
+	}
+	return status, nil
+}
+
+// CustomMethods dispatches custom methods registered on a collection route,
+// e.g. POST /conscripts:batch, which is routed as "/conscripts:method". The
+// route also matches paths without the colon, such as /conscriptsbatch,
+// which are not found.
+func CustomMethods(methods map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name, ok := strings.CutPrefix(c.Param("method"), ":")
+		handler, found := methods[name]
+		if !ok || !found {
+			c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Unknown method"})
+			return
+		}
+		handler(c)
+	}
+}
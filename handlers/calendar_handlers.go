@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/calendar"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// FeedTokenResponse is returned when a calendar feed token is issued.
+// The token is only shown once.
+type FeedTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// CalendarHandler serves the /calendar routes.
+type CalendarHandler struct {
+	store    repository.Store
+	calendar *service.Calendar
+}
+
+// NewCalendarHandler returns the calendar handler of the store.
+func NewCalendarHandler(store repository.Store) *CalendarHandler {
+	return &CalendarHandler{store: store, calendar: service.NewCalendar(store)}
+}
+
+// CreateToken handles POST /calendar/token
+// @Summary Issue a calendar feed token
+// @Description Issue a new calendar feed token for the authenticated conscript, revoking any previous one. The token is embedded in feed URLs because calendar apps cannot send Bearer headers.
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} FeedTokenResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /calendar/token [post]
+func (h *CalendarHandler) CreateToken(c *gin.Context) {
+	conscriptID, ok := currentConscriptID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Unknown conscript"})
+		return
+	}
+	token, err := h.calendar.IssueToken(conscriptID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, FeedTokenResponse{
+		Token: token,
+		URL:   fmt.Sprintf("/calendar/feeds/%s/conscripts/%d.ics", token, conscriptID),
+	})
+}
+
+// RevokeToken handles DELETE /calendar/token
+// @Summary Revoke the calendar feed token
+// @Description Revoke the calendar feed token of the authenticated conscript, disabling all feed URLs that embed it
+// @Tags calendar
+// @Produce json
+// @Security BearerAuth
+// @Success 204 {string} string "No Content"
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /calendar/token [delete]
+func (h *CalendarHandler) RevokeToken(c *gin.Context) {
+	conscriptID, ok := currentConscriptID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Unknown conscript"})
+		return
+	}
+	if err := h.calendar.RevokeToken(conscriptID); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Authenticate authenticates calendar feed requests by the token path parameter.
+func (h *CalendarHandler) Authenticate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		conscriptID, err := h.calendar.Authenticate(c.Param("token"))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid or revoked feed token"})
+			return
+		}
+		// Feeds of discharged or disabled conscripts stop with their tokens.
+		if !activeConscript(c, h.store.Conscripts(), conscriptID) {
+			return
+		}
+		c.Set(conscriptIDKey, conscriptID)
+		c.Next()
+	}
+}
+
+// feedID parses the id path parameter of a feed, which may carry an .ics extension.
+func feedID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(strings.TrimSuffix(c.Param("id"), ".ics"), 10, 0)
+	return uint(id), err == nil
+}
+
+// respondFeed renders the assignments of the feed as an iCalendar feed.
+func (h *CalendarHandler) respondFeed(c *gin.Context, feed service.Feed, summary func(rosterEntry) string) {
+	entries, err := resolveAssignments(h.store, feed.Assignments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	cal := calendar.Calendar{Name: feed.Name}
+	for _, entry := range entries {
+		a := entry.Assignment
+		cal.Events = append(cal.Events, calendar.Event{
+			UID:          fmt.Sprintf("assignment-%d@pixis", a.ID),
+			Summary:      summary(entry),
+			Description:  fmt.Sprintf("Service: %s\nDuty: %s\nConscript: %s %s", entry.Service, entry.Duty, entry.Conscript.LastName, entry.Conscript.FirstName),
+			Start:        a.StartTime,
+			End:          a.EndTime,
+			LastModified: a.UpdatedAt,
+		})
+	}
+	var buf bytes.Buffer
+	if err := cal.Write(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", buf.Bytes())
+}
+
+// ConscriptFeed handles GET /calendar/feeds/:token/conscripts/:id
+// @Summary Calendar feed of a conscript
+// @Description Get the duties of a conscript as an RFC 5545 iCalendar feed, authenticated by the feed token in the URL. The token opens the feeds of its own conscript and, for commanders, of the conscripts under their command; administrators open any feed.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Param id path string true "Conscript ID, optionally followed by .ics"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /calendar/feeds/{token}/conscripts/{id} [get]
+func (h *CalendarHandler) ConscriptFeed(c *gin.Context) {
+	id, ok := feedID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid conscript ID"})
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	feed, err := h.calendar.ConscriptFeed(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	h.respondFeed(c, feed, func(e rosterEntry) string {
+		return fmt.Sprintf("%s (%s)", e.Duty, e.Service)
+	})
+}
+
+// DutyFeed handles GET /calendar/feeds/:token/duties/:id
+// @Summary Calendar feed of a duty
+// @Description Get the assignments of a duty as an RFC 5545 iCalendar feed, authenticated by the feed token in the URL. Only the tokens of administrators and of commanders of the department of the service of the duty open it.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Param id path string true "Duty ID, optionally followed by .ics"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /calendar/feeds/{token}/duties/{id} [get]
+func (h *CalendarHandler) DutyFeed(c *gin.Context) {
+	id, ok := feedID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid duty ID"})
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	feed, err := h.calendar.DutyFeed(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	h.respondFeed(c, feed, func(e rosterEntry) string {
+		return fmt.Sprintf("%s: %s %s", e.Duty, e.Conscript.LastName, e.Conscript.FirstName)
+	})
+}
+
+// ServiceFeed handles GET /calendar/feeds/:token/services/:id
+// @Summary Calendar feed of a service
+// @Description Get the assignments of all duties of a service as an RFC 5545 iCalendar feed, authenticated by the feed token in the URL. Only the tokens of administrators and of commanders of the department of the service open it.
+// @Tags calendar
+// @Produce text/calendar
+// @Param token path string true "Feed token"
+// @Param id path string true "Service ID, optionally followed by .ics"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 401 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /calendar/feeds/{token}/services/{id} [get]
+func (h *CalendarHandler) ServiceFeed(c *gin.Context) {
+	id, ok := feedID(c)
+	if !ok {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid service ID"})
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	feed, err := h.calendar.ServiceFeed(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	h.respondFeed(c, feed, func(e rosterEntry) string {
+		return fmt.Sprintf("%s: %s %s", e.Duty, e.Conscript.LastName, e.Conscript.FirstName)
+	})
+}
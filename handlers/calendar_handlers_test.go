@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+func setupCalendarRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	auth := r.Group("", h.Auth.Middleware())
+	auth.POST("/calendar/token", h.Calendar.CreateToken)
+	auth.DELETE("/calendar/token", h.Calendar.RevokeToken)
+	feeds := r.Group("/calendar/feeds/:token", h.Calendar.Authenticate())
+	feeds.GET("/conscripts/:id", h.Calendar.ConscriptFeed)
+	feeds.GET("/duties/:id", h.Calendar.DutyFeed)
+	feeds.GET("/services/:id", h.Calendar.ServiceFeed)
+	return r
+}
+
+// bearerToken signs a JWT for the given conscript.
+func bearerToken(conscriptID uint) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": conscriptID,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString(jwtSecret)
+	return "Bearer " + tokenString
+}
+
+func beforeEachCalendar(t *testing.T, db *gorm.DB) (*gin.Engine, models.Conscript, models.Duty) {
+	r := setupCalendarRouter(db)
+	service := models.Service{Label: "Guard"}
+	db.Create(&service)
+	duty := models.Duty{Label: "Gate, north", ServiceID: service.ID}
+	db.Create(&duty)
+	conscript := models.Conscript{FirstName: "Nikos", LastName: "Papadopoulos", RegistryNumber: "4001", Username: "nikos"}
+	db.Create(&conscript)
+	start := time.Now().Add(24 * time.Hour)
+	db.Create(&models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	return r, conscript, duty
+}
+
+func issueFeedToken(t *testing.T, r *gin.Engine, conscriptID uint) FeedTokenResponse {
+	req, _ := http.NewRequest("POST", "/calendar/token", nil)
+	req.Header.Set("Authorization", bearerToken(conscriptID))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var resp FeedTokenResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to unmarshal response: %v", err)
+	}
+	return resp
+}
+
+func TestConscriptFeed(t *testing.T) {
+	db := testDatabase(t)
+	r, conscript, duty := beforeEachCalendar(t, db)
+	feed := issueFeedToken(t, r, conscript.ID)
+
+	req, _ := http.NewRequest("GET", feed.URL, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/calendar") {
+		t.Errorf("expected text/calendar content type, got %q", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	var assignment models.ConscriptDuty
+	db.First(&assignment, "conscript_id = ? AND duty_id = ?", conscript.ID, duty.ID)
+	uid := fmt.Sprintf("UID:assignment-%d@pixis\r\n", assignment.ID)
+	if !strings.HasPrefix(body, "BEGIN:VCALENDAR\r\n") || !strings.Contains(body, uid) {
+		t.Errorf("expected a calendar with a stable UID, got %q", body)
+	}
+	if !strings.Contains(body, `SUMMARY:Gate\, north (Guard)`) {
+		t.Errorf("expected escaped summary, got %q", body)
+	}
+}
+
+func TestServiceAndDutyFeeds(t *testing.T) {
+	db := testDatabase(t)
+	r, conscript, duty := beforeEachCalendar(t, db)
+	commander := models.Conscript{RegistryNumber: "4002", Username: "commander"}
+	db.Create(&commander)
+	department := models.Department{Label: "Guard company", CommanderID: &commander.ID}
+	db.Create(&department)
+	db.Model(&models.Service{}).Where("id = ?", duty.ServiceID).Update("department_id", department.ID)
+
+	own := issueFeedToken(t, r, conscript.ID)
+	commanded := issueFeedToken(t, r, commander.ID)
+	for _, path := range []string{
+		fmt.Sprintf("/duties/%d.ics", duty.ID),
+		fmt.Sprintf("/services/%d", duty.ServiceID),
+	} {
+		req, _ := http.NewRequest("GET", "/calendar/feeds/"+commanded.Token+path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusOK, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "Papadopoulos Nikos") {
+			t.Errorf("%s: expected the assigned conscript in the feed", path)
+		}
+
+		// Conscripts who do not command the department are refused.
+		req, _ = http.NewRequest("GET", "/calendar/feeds/"+own.Token+path, nil)
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s: expected status %d, got %d", path, http.StatusForbidden, w.Code)
+		}
+	}
+}
+
+func TestFeedOfAnotherConscript(t *testing.T) {
+	db := testDatabase(t)
+	r, conscript, _ := beforeEachCalendar(t, db)
+	other := models.Conscript{RegistryNumber: "4003", Username: "other"}
+	db.Create(&other)
+	feed := issueFeedToken(t, r, other.ID)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/calendar/feeds/%s/conscripts/%d.ics", feed.Token, conscript.ID), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestRevokedFeedToken(t *testing.T) {
+	r, conscript, _ := beforeEachCalendar(t, testDatabase(t))
+	feed := issueFeedToken(t, r, conscript.ID)
+
+	// Issuing a new token revokes the previous one.
+	rotated := issueFeedToken(t, r, conscript.ID)
+	req, _ := http.NewRequest("GET", feed.URL, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	req, _ = http.NewRequest("DELETE", "/calendar/token", nil)
+	req.Header.Set("Authorization", bearerToken(conscript.ID))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+	req, _ = http.NewRequest("GET", rotated.URL, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestFeedOfDischargedConscript(t *testing.T) {
+	db := testDatabase(t)
+	r, conscript, _ := beforeEachCalendar(t, db)
+	feed := issueFeedToken(t, r, conscript.ID)
+
+	conscripts := service.NewConscripts(repository.NewGormStore(db))
+	if _, _, err := conscripts.Discharge(conscript.ID, time.Now(), "End of service", true); err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", feed.URL, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for the feed of a discharged conscript, got %d", http.StatusForbidden, w.Code)
+	}
+
+	if err := conscripts.Delete(conscript.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d for the feed of a deleted conscript, got %d", http.StatusUnauthorized, w.Code)
+	}
+}
@@ -4,135 +4,268 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/conflicts"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateConscriptDuty assigns a duty to a conscript with metadata
+// ConflictResponse is returned when an assignment conflicts with existing ones.
+type ConflictResponse struct {
+	Error     string               `json:"error"`
+	Conflicts []conflicts.Conflict `json:"conflicts"`
+}
+
+// overridingAdmin returns the ID of the administrator overriding conflicts
+// with the override query parameter, or 0 if conflicts are not overridden.
+func overridingAdmin(c *gin.Context, conscripts repository.Conscripts) (uint, bool) {
+	if c.Query("override") != "true" {
+		return 0, true
+	}
+	conscript, ok := currentConscript(c, conscripts)
+	if !ok || conscript.Role != models.RoleAdmin {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{Error: "Only administrators can override conflicts"})
+		return 0, false
+	}
+	return conscript.ID, true
+}
+
+// AssignmentHandler serves the /assignments routes.
+type AssignmentHandler struct {
+	store       repository.Store
+	assignments *service.Assignments
+}
+
+// NewAssignmentHandler returns the assignment handler of the store.
+func NewAssignmentHandler(store repository.Store) *AssignmentHandler {
+	return &AssignmentHandler{store: store, assignments: service.NewAssignments(store)}
+}
+
+// Create handles POST /assignments
 // @Summary Assign a duty to a conscript
-// @Description Assign a duty to a conscript with start and end time
-// @Tags conscript_duties
+// @Description Assign a duty to a conscript from a start to an end time. Only administrators and commanders of the department of the service of the duty can assign it, to conscripts other than themselves. A conscript may hold the same duty many times in different periods. The assignment is rejected if it overlaps another duty of the conscript, leaves too little rest between duties, exceeds the capacity of the duty, falls within an approved absence of the conscript or the conscript lacks a qualification the duty requires, unless an administrator overrides the conflicts, which is recorded.
+// @Tags assignments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param conscript_duty body models.ConscriptDuty true "ConscriptDuty"
+// @Param assignment body models.ConscriptDuty true "Assignment"
+// @Param override query bool false "Book despite conflicts (administrators only)"
 // @Success 201 {object} models.ConscriptDuty
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} ConflictResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /conscript_duties [post]
-func CreateConscriptDuty(c *gin.Context) {
+// @Router /assignments [post]
+func (h *AssignmentHandler) Create(c *gin.Context) {
 	var cd models.ConscriptDuty
 	if err := c.ShouldBindJSON(&cd); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	if err := database.GetDB().Create(&cd).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	adminID, ok := overridingAdmin(c, h.store.Conscripts())
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	if err := h.assignments.Create(&cd, actorID, admin, adminID); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, cd)
 }
 
-// GetConscriptDuties lists conscript-duty assignments by conscript_id or duty_id
-// @Summary List conscript-duty assignments
-// @Description List conscript-duty assignments by conscript_id or duty_id
-// @Tags conscript_duties
-// @Produce json
+// List handles GET /assignments
+// @Summary List assignments
+// @Description List assignments, optionally by conscript_id or duty_id and within a date range. Dates are given as YYYY-MM-DD or RFC 3339; assignments overlapping the range are listed.
+// @Tags assignments
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Security BearerAuth
+// @Param format query string false "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default"
 // @Param conscript_id query int false "Conscript ID"
 // @Param duty_id query int false "Duty ID"
+// @Param from query string false "Only assignments ending after this date"
+// @Param to query string false "Only assignments starting before this date (inclusive when a date)"
 // @Success 200 {array} models.ConscriptDuty
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /conscript_duties [get]
-func GetConscriptDuties(c *gin.Context) {
-	var cds []models.ConscriptDuty
-	db := database.GetDB()
-	conscriptID := c.Query("conscript_id")
-	dutyID := c.Query("duty_id")
-
-	query := db
-	if conscriptID != "" {
-		id, err := strconv.Atoi(conscriptID)
-		if err == nil {
-			query = query.Where("conscript_id = ?", id)
+// @Router /assignments [get]
+func (h *AssignmentHandler) List(c *gin.Context) {
+	var filter repository.AssignmentFilter
+	if id, err := strconv.ParseUint(c.Query("conscript_id"), 10, 0); err == nil {
+		filter.ConscriptID = uint(id)
+	}
+	if id, err := strconv.ParseUint(c.Query("duty_id"), 10, 0); err == nil {
+		filter.DutyID = uint(id)
+	}
+	if value := c.Query("from"); value != "" {
+		from, _, err := parseDateOrTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid from: " + err.Error()})
+			return
 		}
+		filter.From = &from
 	}
-	if dutyID != "" {
-		id, err := strconv.Atoi(dutyID)
-		if err == nil {
-			query = query.Where("duty_id = ?", id)
+	if value := c.Query("to"); value != "" {
+		to, dateOnly, err := parseDateOrTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid to: " + err.Error()})
+			return
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
 		}
+		filter.To = &to
 	}
-	if err := query.Find(&cds).Error; err != nil {
+	cds, err := h.assignments.List(filter)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, cds)
+	respondList(c, "Assignments", cds, conscriptDutyColumns)
+}
+
+// Get handles GET /assignments/:id
+// @Summary Get an assignment by ID
+// @Description Get a single assignment by its ID
+// @Tags assignments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Assignment ID"
+// @Success 200 {object} models.ConscriptDuty
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /assignments/{id} [get]
+func (h *AssignmentHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid assignment ID")
+	if !ok {
+		return
+	}
+	cd, err := h.assignments.Get(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, cd)
 }
 
-// UpdateConscriptDuty updates metadata for a conscript-duty assignment
-// @Summary Update a conscript-duty assignment
-// @Description Update start and end time for a conscript-duty assignment
-// @Tags conscript_duties
+// Update handles PUT /assignments/:id
+// @Summary Update an assignment
+// @Description Update the conscript, duty, start or end time of an assignment; omitted fields are kept. Only administrators and commanders of the duty can update an assignment, before and after the update, and conscripts exchange their own through swaps. The updated assignment is subject to the same conflict checks as new assignments.
+// @Tags assignments
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param conscript_duty body models.ConscriptDuty true "ConscriptDuty"
+// @Param id path int true "Assignment ID"
+// @Param assignment body models.ConscriptDuty true "Assignment"
+// @Param override query bool false "Update despite conflicts (administrators only)"
 // @Success 200 {object} models.ConscriptDuty
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
-// @Router /conscript_duties [put]
-func UpdateConscriptDuty(c *gin.Context) {
+// @Failure 409 {object} ConflictResponse
+// @Router /assignments/{id} [put]
+func (h *AssignmentHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid assignment ID")
+	if !ok {
+		return
+	}
 	var input models.ConscriptDuty
 	if err := c.ShouldBindJSON(&input); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	db := database.GetDB()
-	var cd models.ConscriptDuty
-	if err := db.First(&cd, "conscript_id = ? AND duty_id = ?", input.ConscriptID, input.DutyID).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Assignment not found"})
+	adminID, ok := overridingAdmin(c, h.store.Conscripts())
+	if !ok {
 		return
 	}
-	if !input.StartTime.IsZero() {
-		cd.StartTime = input.StartTime
-	}
-	if !input.EndTime.IsZero() {
-		cd.EndTime = input.EndTime
-	}
-	if err := db.Save(&cd).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	actorID, admin := actor(c, h.store.Conscripts())
+	cd, err := h.assignments.Update(id, input, actorID, admin, adminID)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, cd)
 }
 
-// DeleteConscriptDuty removes a duty from a conscript
-// @Summary Remove a duty from a conscript
-// @Description Remove a duty from a conscript by conscript_id and duty_id
-// @Tags conscript_duties
-// @Accept json
+// Delete handles DELETE /assignments/:id
+// @Summary Delete an assignment
+// @Description Remove an assignment by its ID. Only administrators and commanders of the duty can delete assignments.
+// @Tags assignments
 // @Produce json
 // @Security BearerAuth
-// @Param conscript_duty body object true "ConscriptDuty IDs" {"conscript_id":0,"duty_id":0}
+// @Param id path int true "Assignment ID"
 // @Success 204 {string} string "No Content"
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
-// @Router /conscript_duties [delete]
-func DeleteConscriptDuty(c *gin.Context) {
-	var input struct {
-		ConscriptID uint `json:"conscript_id"`
-		DutyID      uint `json:"duty_id"`
+// @Router /assignments/{id} [delete]
+func (h *AssignmentHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid assignment ID")
+	if !ok {
+		return
 	}
-	if err := c.ShouldBindJSON(&input); err != nil {
+	actorID, admin := actor(c, h.store.Conscripts())
+	if err := h.assignments.Delete(id, actorID, admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Batch handles POST /assignments:batch
+// @Summary Create, update or delete many assignments at once
+// @Description Apply many assignment operations in one transaction. Updates and deletes identify the assignment by id. Each operation is allowed to administrators and commanders of the duty only, as for single assignments. Created and updated assignments are checked for conflicts, including with earlier operations of the batch. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.
+// @Tags assignments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param batch body BatchRequest[models.ConscriptDuty] true "Batch operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /assignments:batch [post]
+func (h *AssignmentHandler) Batch(c *gin.Context) {
+	actorID, admin := actor(c, h.store.Conscripts())
+	runBatch(c, h.store, batchOps[models.ConscriptDuty]{
+		create: func(store repository.Store, op *BatchOperation[models.ConscriptDuty]) error {
+			return service.NewAssignments(store).Create(&op.Data, actorID, admin, 0)
+		},
+		update: func(store repository.Store, op *BatchOperation[models.ConscriptDuty]) error {
+			updated, err := service.NewAssignments(store).Update(op.ID, op.Data, actorID, admin, 0)
+			op.Data = updated
+			return err
+		},
+		delete: func(store repository.Store, op *BatchOperation[models.ConscriptDuty]) error {
+			return service.NewAssignments(store).Delete(op.ID, actorID, admin)
+		},
+	})
+}
+
+// Conflicts handles GET /assignments/conflicts
+// @Summary Report assignment conflicts
+// @Description List overlapping duties, insufficient rest between duties, duties staffed beyond their capacity and duties during approved absences among the assignments in a date range. Dates are given as YYYY-MM-DD or RFC 3339 and default to the current day.
+// @Tags assignments
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start of the range"
+// @Param to query string false "End of the range (inclusive when a date)"
+// @Success 200 {array} conflicts.Conflict
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /assignments/conflicts [get]
+func (h *AssignmentHandler) Conflicts(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	db := database.GetDB()
-	if err := db.Delete(&models.ConscriptDuty{}, "conscript_id = ? AND duty_id = ?", input.ConscriptID, input.DutyID).Error; err != nil {
+	report, err := h.assignments.Report(from, to)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	c.Status(http.StatusNoContent)
+	c.JSON(http.StatusOK, report)
 }
@@ -10,9 +10,10 @@ import (
 	"testing"
 	"time"
 
-	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/conflicts"
 	"github.com/alexandrosraikos/pixis/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 var (
@@ -22,21 +23,27 @@ var (
 	}
 )
 
-func setupConscriptDutyRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	database.RecreateDatabase("conscript_duties_test.db")
+// setupConscriptDutyRouter serves the assignment routes to an administrator.
+func setupConscriptDutyRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	admin := models.Conscript{RegistryNumber: "cd-router-admin", Username: "cd-router-admin", Role: models.RoleAdmin}
+	db.Create(&admin)
 	r := gin.Default()
-	r.POST("/conscript_duties", CreateConscriptDuty)
-	r.GET("/conscript_duties", GetConscriptDuties)
-	r.PUT("/conscript_duties", UpdateConscriptDuty)
-	r.DELETE("/conscript_duties", DeleteConscriptDuty)
+	r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, admin.ID) })
+	r.POST("/assignments", h.Assignments.Create)
+	r.GET("/assignments", h.Assignments.List)
+	r.GET("/assignments/:id", h.Assignments.Get)
+	r.PUT("/assignments/:id", h.Assignments.Update)
+	r.DELETE("/assignments/:id", h.Assignments.Delete)
+	r.POST("/assignments:method", CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Assignments.Batch,
+	}))
 	return r
 }
 
-func beforeEachConscriptDuty(t *testing.T) (*gin.Engine, uint, uint) {
-	r := setupConscriptDutyRouter()
+func beforeEachConscriptDuty(t *testing.T, db *gorm.DB) (*gin.Engine, uint, uint) {
+	r := setupConscriptDutyRouter(db)
 	// Create a conscript and a duty for foreign keys
-	db := database.GetDB()
 	conscript := models.Conscript{
 		FirstName:      "DutyTest",
 		LastName:       "User",
@@ -52,12 +59,12 @@ func beforeEachConscriptDuty(t *testing.T) (*gin.Engine, uint, uint) {
 }
 
 func TestCreateConscriptDuty(t *testing.T) {
-	r, conscriptID, dutyID := beforeEachConscriptDuty(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, testDatabase(t))
 	cd := MockConscriptDuty
 	cd.ConscriptID = conscriptID
 	cd.DutyID = dutyID
 	jsonValue, _ := json.Marshal(cd)
-	req, _ := http.NewRequest("POST", "/conscript_duties", bytes.NewBuffer(jsonValue))
+	req, _ := http.NewRequest("POST", "/assignments", bytes.NewBuffer(jsonValue))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -67,18 +74,18 @@ func TestCreateConscriptDuty(t *testing.T) {
 }
 
 func TestGetConscriptDutiesByConscript(t *testing.T) {
-	r, conscriptID, dutyID := beforeEachConscriptDuty(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, testDatabase(t))
 	cd := MockConscriptDuty
 	cd.ConscriptID = conscriptID
 	cd.DutyID = dutyID
 	jsonValue, _ := json.Marshal(cd)
-	req, _ := http.NewRequest("POST", "/conscript_duties", bytes.NewBuffer(jsonValue))
+	req, _ := http.NewRequest("POST", "/assignments", bytes.NewBuffer(jsonValue))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
 	// Use strconv.FormatUint for uint to string conversion
-	req, _ = http.NewRequest("GET", "/conscript_duties?conscript_id="+strconv.FormatUint(uint64(conscriptID), 10), nil)
+	req, _ = http.NewRequest("GET", "/assignments?conscript_id="+strconv.FormatUint(uint64(conscriptID), 10), nil)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	if w.Code != http.StatusOK {
@@ -95,17 +102,17 @@ func TestGetConscriptDutiesByConscript(t *testing.T) {
 }
 
 func TestGetConscriptDutiesByDuty(t *testing.T) {
-	r, conscriptID, dutyID := beforeEachConscriptDuty(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, testDatabase(t))
 	cd := MockConscriptDuty
 	cd.ConscriptID = conscriptID
 	cd.DutyID = dutyID
 	jsonValue, _ := json.Marshal(cd)
-	req, _ := http.NewRequest("POST", "/conscript_duties", bytes.NewBuffer(jsonValue))
+	req, _ := http.NewRequest("POST", "/assignments", bytes.NewBuffer(jsonValue))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	req, _ = http.NewRequest("GET", "/conscript_duties?duty_id="+strconv.FormatUint(uint64(dutyID), 10), nil)
+	req, _ = http.NewRequest("GET", "/assignments?duty_id="+strconv.FormatUint(uint64(dutyID), 10), nil)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	if w.Code != http.StatusOK {
@@ -122,24 +129,24 @@ func TestGetConscriptDutiesByDuty(t *testing.T) {
 }
 
 func TestUpdateConscriptDuty(t *testing.T) {
-	r, conscriptID, dutyID := beforeEachConscriptDuty(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, testDatabase(t))
 	cd := MockConscriptDuty
 	cd.ConscriptID = conscriptID
 	cd.DutyID = dutyID
 	jsonValue, _ := json.Marshal(cd)
-	req, _ := http.NewRequest("POST", "/conscript_duties", bytes.NewBuffer(jsonValue))
+	req, _ := http.NewRequest("POST", "/assignments", bytes.NewBuffer(jsonValue))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
+	var created models.ConscriptDuty
+	json.Unmarshal(w.Body.Bytes(), &created)
 
 	// Update EndTime
 	update := models.ConscriptDuty{
-		ConscriptID: conscriptID,
-		DutyID:      dutyID,
-		EndTime:     time.Now().Add(2 * time.Hour),
+		EndTime: time.Now().Add(2 * time.Hour),
 	}
 	jsonValue, _ = json.Marshal(update)
-	req, _ = http.NewRequest("PUT", "/conscript_duties", bytes.NewBuffer(jsonValue))
+	req, _ = http.NewRequest("PUT", "/assignments/"+strconv.FormatUint(uint64(created.ID), 10), bytes.NewBuffer(jsonValue))
 	req.Header.Set("Content-Type", "application/json")
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
@@ -154,30 +161,305 @@ func TestUpdateConscriptDuty(t *testing.T) {
 	if !updated.EndTime.After(cd.EndTime) {
 		t.Errorf("expected EndTime to be updated")
 	}
+	if updated.ID != created.ID || updated.DutyID != dutyID {
+		t.Errorf("expected the other fields to be kept, got %+v", updated)
+	}
 }
 
 func TestDeleteConscriptDuty(t *testing.T) {
-	r, conscriptID, dutyID := beforeEachConscriptDuty(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, testDatabase(t))
 	cd := MockConscriptDuty
 	cd.ConscriptID = conscriptID
 	cd.DutyID = dutyID
 	jsonValue, _ := json.Marshal(cd)
-	req, _ := http.NewRequest("POST", "/conscript_duties", bytes.NewBuffer(jsonValue))
+	req, _ := http.NewRequest("POST", "/assignments", bytes.NewBuffer(jsonValue))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
+	var created models.ConscriptDuty
+	json.Unmarshal(w.Body.Bytes(), &created)
 
 	// Now delete
-	deleteBody := struct {
-		ConscriptID uint `json:"conscript_id"`
-		DutyID      uint `json:"duty_id"`
-	}{ConscriptID: conscriptID, DutyID: dutyID}
-	jsonValue, _ = json.Marshal(deleteBody)
-	req, _ = http.NewRequest("DELETE", "/conscript_duties", bytes.NewBuffer(jsonValue))
-	req.Header.Set("Content-Type", "application/json")
+	path := "/assignments/" + strconv.FormatUint(uint64(created.ID), 10)
+	req, _ = http.NewRequest("DELETE", path, nil)
 	w = httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	if w.Code != http.StatusNoContent {
 		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
 	}
+	req, _ = http.NewRequest("GET", path, nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+func TestRepeatedConscriptDuty(t *testing.T) {
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, testDatabase(t))
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	var ids []uint
+	for day := 0; day < 3; day++ {
+		cd := models.ConscriptDuty{
+			ConscriptID: conscriptID,
+			DutyID:      dutyID,
+			StartTime:   start.AddDate(0, 0, day),
+			EndTime:     start.AddDate(0, 0, day).Add(8 * time.Hour),
+		}
+		w := postConscriptDuty(r, "/assignments", cd, "")
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+		var created models.ConscriptDuty
+		json.Unmarshal(w.Body.Bytes(), &created)
+		ids = append(ids, created.ID)
+	}
+	if ids[0] == ids[1] || ids[1] == ids[2] {
+		t.Errorf("expected distinct assignment IDs, got %v", ids)
+	}
+
+	req, _ := http.NewRequest("GET", "/assignments?duty_id="+strconv.FormatUint(uint64(dutyID), 10)+"&from=2025-03-11&to=2025-03-11", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var cds []models.ConscriptDuty
+	json.Unmarshal(w.Body.Bytes(), &cds)
+	if len(cds) != 1 || cds[0].ID != ids[1] {
+		t.Errorf("expected only assignment %d in range, got %+v", ids[1], cds)
+	}
+
+	req, _ = http.NewRequest("GET", "/assignments?from=yesterday", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestBatchConscriptDuties(t *testing.T) {
+	db := testDatabase(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, db)
+	duty := models.Duty{Label: fmt.Sprintf("SecondDutyForCD%d", time.Now().UnixNano())}
+	db.Create(&duty)
+	first := MockConscriptDuty
+	first.ConscriptID = conscriptID
+	first.DutyID = dutyID
+	second := MockConscriptDuty
+	second.ConscriptID = conscriptID
+	second.DutyID = duty.ID
+	second.StartTime = first.StartTime.Add(24 * time.Hour)
+	second.EndTime = first.EndTime.Add(24 * time.Hour)
+	batch := BatchRequest[models.ConscriptDuty]{
+		Operations: []BatchOperation[models.ConscriptDuty]{
+			{Op: BatchOpCreate, Data: first},
+			{Op: BatchOpCreate, Data: second},
+		},
+	}
+	jsonValue, _ := json.Marshal(batch)
+	req, _ := http.NewRequest("POST", "/assignments:batch", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var count int64
+	db.Model(&models.ConscriptDuty{}).Where("conscript_id = ?", conscriptID).Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 assignments, got %d", count)
+	}
+	var resp BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	created, _ := resp.Results[0].Data.(map[string]any)
+	firstID, _ := created["ID"].(float64)
+
+	// Removing an assignment that does not exist fails the whole atomic batch.
+	batch = BatchRequest[models.ConscriptDuty]{
+		Operations: []BatchOperation[models.ConscriptDuty]{
+			{Op: BatchOpDelete, ID: uint(firstID)},
+			{Op: BatchOpDelete, ID: 9999},
+		},
+	}
+	jsonValue, _ = json.Marshal(batch)
+	req, _ = http.NewRequest("POST", "/assignments:batch", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	db.Model(&models.ConscriptDuty{}).Where("conscript_id = ?", conscriptID).Count(&count)
+	if count != 2 {
+		t.Errorf("expected rollback to keep 2 assignments, got %d", count)
+	}
+}
+
+func postConscriptDuty(r *gin.Engine, path string, cd models.ConscriptDuty, authorization string) *httptest.ResponseRecorder {
+	jsonValue, _ := json.Marshal(cd)
+	req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateConscriptDutyConflicts(t *testing.T) {
+	db := testDatabase(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, db)
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	db.Create(&models.ConscriptDuty{ConscriptID: conscriptID, DutyID: dutyID, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	other := models.Duty{Label: fmt.Sprintf("OtherDuty%d", time.Now().UnixNano()), Capacity: 1}
+	db.Create(&other)
+	colleague := models.Conscript{RegistryNumber: "cd-colleague", Username: "cd-colleague"}
+	db.Create(&colleague)
+	db.Create(&models.ConscriptDuty{ConscriptID: colleague.ID, DutyID: other.ID, StartTime: start.AddDate(0, 0, 2), EndTime: start.AddDate(0, 0, 2).Add(8 * time.Hour)})
+
+	cases := []struct {
+		name   string
+		cd     models.ConscriptDuty
+		status int
+		kind   conflicts.Kind
+	}{
+		{"ends before start", models.ConscriptDuty{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start.AddDate(0, 0, 5), EndTime: start.AddDate(0, 0, 4)}, http.StatusBadRequest, ""},
+		{"overlap", models.ConscriptDuty{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start.Add(4 * time.Hour), EndTime: start.Add(12 * time.Hour)}, http.StatusConflict, conflicts.KindOverlap},
+		{"rest", models.ConscriptDuty{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start.Add(10 * time.Hour), EndTime: start.Add(14 * time.Hour)}, http.StatusConflict, conflicts.KindRest},
+		{"capacity", models.ConscriptDuty{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start.AddDate(0, 0, 2), EndTime: start.AddDate(0, 0, 2).Add(time.Hour)}, http.StatusConflict, conflicts.KindCapacity},
+		{"no conflict", models.ConscriptDuty{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start.AddDate(0, 0, 1), EndTime: start.AddDate(0, 0, 1).Add(8 * time.Hour)}, http.StatusCreated, ""},
+	}
+	for _, tc := range cases {
+		w := postConscriptDuty(r, "/assignments", tc.cd, "")
+		if w.Code != tc.status {
+			t.Errorf("%s: expected status %d, got %d", tc.name, tc.status, w.Code)
+			continue
+		}
+		if tc.kind != "" {
+			var resp ConflictResponse
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.Conflicts) != 1 || resp.Conflicts[0].Kind != tc.kind || len(resp.Conflicts[0].Assignments) == 0 {
+				t.Errorf("%s: expected a %s conflict with the conflicting assignments, got %+v", tc.name, tc.kind, resp.Conflicts)
+			}
+		}
+	}
+}
+
+func TestCreateConscriptDutyOverride(t *testing.T) {
+	db := testDatabase(t)
+	_, conscriptID, dutyID := beforeEachConscriptDuty(t, db)
+	h := testHandlers(db)
+	r := gin.New()
+	r.POST("/assignments", h.Auth.Middleware(), h.Assignments.Create)
+	admin := models.Conscript{RegistryNumber: "cd-admin", Username: "cd-admin", Role: models.RoleAdmin}
+	db.Create(&admin)
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	db.Create(&models.ConscriptDuty{ConscriptID: conscriptID, DutyID: dutyID, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	other := models.Duty{Label: fmt.Sprintf("OverrideDuty%d", time.Now().UnixNano())}
+	db.Create(&other)
+	cd := models.ConscriptDuty{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start, EndTime: start.Add(2 * time.Hour)}
+
+	w := postConscriptDuty(r, "/assignments?override=true", cd, bearerToken(conscriptID))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+	w = postConscriptDuty(r, "/assignments?override=true", cd, bearerToken(admin.ID))
+	if w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var override models.ConflictOverride
+	if err := db.First(&override, "conscript_id = ? AND duty_id = ?", conscriptID, other.ID).Error; err != nil {
+		t.Fatalf("expected the override to be recorded: %v", err)
+	}
+	if override.AdminID != admin.ID || override.Conflicts != string(conflicts.KindOverlap) {
+		t.Errorf("expected overlap override by admin %d, got %+v", admin.ID, override)
+	}
+}
+
+func TestGetConscriptDutyConflicts(t *testing.T) {
+	db := testDatabase(t)
+	r, conscriptID, dutyID := beforeEachConscriptDuty(t, db)
+	h := testHandlers(db)
+	r.GET("/assignments/conflicts", h.Assignments.Conflicts)
+	other := models.Duty{Label: fmt.Sprintf("ReportDuty%d", time.Now().UnixNano())}
+	db.Create(&other)
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	db.Create(&[]models.ConscriptDuty{
+		{ConscriptID: conscriptID, DutyID: dutyID, StartTime: start, EndTime: start.Add(8 * time.Hour)},
+		{ConscriptID: conscriptID, DutyID: other.ID, StartTime: start.Add(6 * time.Hour), EndTime: start.Add(10 * time.Hour)},
+	})
+
+	req, _ := http.NewRequest("GET", "/assignments/conflicts?from=2025-03-10&to=2025-03-10", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var report []conflicts.Conflict
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if len(report) != 1 || report[0].Kind != conflicts.KindOverlap || len(report[0].Assignments) != 2 {
+		t.Errorf("expected one overlap between 2 assignments, got %+v", report)
+	}
+}
+
+func TestAssignmentAuthority(t *testing.T) {
+	db := testDatabase(t)
+	h := testHandlers(db)
+	r := gin.New()
+	auth := r.Group("", h.Auth.Middleware())
+	auth.PUT("/assignments/:id", h.Assignments.Update)
+	auth.DELETE("/assignments/:id", h.Assignments.Delete)
+	auth.POST("/assignments:method", CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Assignments.Batch,
+	}))
+	commander := models.Conscript{RegistryNumber: "cd-commander", Username: "cd-commander"}
+	db.Create(&commander)
+	department := models.Department{Label: "Guard company", CommanderID: &commander.ID}
+	db.Create(&department)
+	service := models.Service{Label: "Guard", DepartmentID: department.ID}
+	db.Create(&service)
+	duty := models.Duty{Label: "Gate", ServiceID: service.ID}
+	db.Create(&duty)
+	conscripts := []models.Conscript{
+		{RegistryNumber: "cd-first", Username: "cd-first", DepartmentID: department.ID},
+		{RegistryNumber: "cd-second", Username: "cd-second", DepartmentID: department.ID},
+	}
+	db.Create(&conscripts)
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	assignment := models.ConscriptDuty{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)}
+	db.Create(&assignment)
+	path := "/assignments/" + strconv.FormatUint(uint64(assignment.ID), 10)
+
+	// Conscripts exchange their assignments through swaps only.
+	handOver := models.ConscriptDuty{ConscriptID: conscripts[1].ID}
+	if w := sendAuthRequest(r, "PUT", path, handOver, conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for handing an assignment over, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := sendAuthRequest(r, "DELETE", path, nil, conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for deleting an assignment, got %d", http.StatusForbidden, w.Code)
+	}
+	batch := BatchRequest[models.ConscriptDuty]{
+		Mode:       BatchModeAtomic,
+		Operations: []BatchOperation[models.ConscriptDuty]{{Op: BatchOpUpdate, ID: assignment.ID, Data: handOver}},
+	}
+	w := sendAuthRequest(r, "POST", "/assignments:batch", batch, conscripts[1].ID)
+	var response BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusUnprocessableEntity || len(response.Results) != 1 || response.Results[0].Status != http.StatusForbidden {
+		t.Errorf("expected the batch to be forbidden, got %d: %s", w.Code, w.Body.String())
+	}
+	var stored models.ConscriptDuty
+	db.First(&stored, assignment.ID)
+	if stored.ConscriptID != conscripts[0].ID {
+		t.Errorf("expected the assignment to be kept, got %+v", stored)
+	}
+
+	if w := sendAuthRequest(r, "PUT", path, handOver, commander.ID); w.Code != http.StatusOK {
+		t.Errorf("expected the commander to reassign the duty, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := sendAuthRequest(r, "DELETE", path, nil, commander.ID); w.Code != http.StatusNoContent {
+		t.Errorf("expected the commander to delete the assignment, got %d", w.Code)
+	}
 }
@@ -2,13 +2,43 @@ package handlers
 
 import (
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateConscript handles POST /conscripts
+// ConscriptHandler serves the /conscripts routes.
+type ConscriptHandler struct {
+	store       repository.Store
+	conscripts  *service.Conscripts
+	departments *service.Departments
+}
+
+// NewConscriptHandler returns the conscript handler of the store.
+func NewConscriptHandler(store repository.Store) *ConscriptHandler {
+	return &ConscriptHandler{
+		store:       store,
+		conscripts:  service.NewConscripts(store),
+		departments: service.NewDepartments(store),
+	}
+}
+
+// parseID reads the id path parameter, writing the error response
+// otherwise.
+func parseID(c *gin.Context, invalid string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: invalid})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// Create handles POST /conscripts
 // @Summary Create a new conscript
 // @Description Create a new conscript in the system
 // @Tags conscripts
@@ -20,37 +50,57 @@ import (
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /conscripts [post]
-func CreateConscript(c *gin.Context) {
+func (h *ConscriptHandler) Create(c *gin.Context) {
 	var conscript models.Conscript
 	if err := c.ShouldBindJSON(&conscript); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	db := database.GetDB()
-	if err := db.Create(&conscript).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	if err := h.conscripts.Create(&conscript, isAdmin(c, h.store.Conscripts())); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, conscript)
 }
 
-// GetConscripts handles GET /conscripts
+// List handles GET /conscripts
 // @Summary List all conscripts
-// @Description Get a list of all conscripts
+// @Description Get a list of all conscripts, optionally only those of a department and the departments under it. With as_of, list the conscripts as they were at that time, with the department they then belonged to, leaving out those not yet enlisted or already discharged.
 // @Tags conscripts
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Security BearerAuth
+// @Param department_id query int false "Only conscripts of this department"
+// @Param include_subdepartments query bool false "Also conscripts of the departments under department_id"
+// @Param as_of query string false "Point in time (RFC 3339 or YYYY-MM-DD for midday)"
+// @Param format query string false "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default"
 // @Success 200 {array} models.Conscript
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /conscripts [get]
-func GetConscripts(c *gin.Context) {
-	db := database.GetDB()
-	var conscripts []models.Conscript
-	db.Find(&conscripts)
-	c.JSON(http.StatusOK, conscripts)
+func (h *ConscriptHandler) List(c *gin.Context) {
+	departmentIDs, err := departmentScope(c, h.departments)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	var asOf *time.Time
+	if value := c.Query("as_of"); value != "" {
+		at, err := parsePointInTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid as_of: " + err.Error()})
+			return
+		}
+		asOf = &at
+	}
+	conscripts, err := h.conscripts.List(departmentIDs, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	respondList(c, "Conscripts", conscripts, conscriptColumns)
 }
 
-// GetConscript handles GET /conscripts/:id
+// Get handles GET /conscripts/:id
 // @Summary Get a conscript by ID
 // @Description Get a conscript by its ID
 // @Tags conscripts
@@ -58,22 +108,25 @@ func GetConscripts(c *gin.Context) {
 // @Security BearerAuth
 // @Param id path int true "Conscript ID"
 // @Success 200 {object} models.Conscript
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /conscripts/{id} [get]
-func GetConscript(c *gin.Context) {
-	id := c.Param("id")
-	db := database.GetDB()
-	var conscript models.Conscript
-	if err := db.First(&conscript, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Conscript not found"})
+func (h *ConscriptHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	conscript, err := h.conscripts.Get(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, conscript)
 }
 
-// UpdateConscript handles PUT /conscripts/:id
+// Update handles PUT /conscripts/:id
 // @Summary Update a conscript
-// @Description Update a conscript by its ID
+// @Description Update a conscript by its ID. Administrators can update any conscript; other conscripts can only change their own first name, last name and password. Changing the department is recorded as a transfer effective now; use POST /conscripts/{id}/transfer to give a date or a reason.
 // @Tags conscripts
 // @Accept json
 // @Produce json
@@ -82,14 +135,12 @@ func GetConscript(c *gin.Context) {
 // @Param conscript body models.Conscript true "Conscript"
 // @Success 200 {object} models.Conscript
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /conscripts/{id} [put]
-func UpdateConscript(c *gin.Context) {
-	id := c.Param("id")
-	db := database.GetDB()
-	var conscript models.Conscript
-	if err := db.First(&conscript, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Conscript not found"})
+func (h *ConscriptHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
 		return
 	}
 	var input models.Conscript
@@ -97,28 +148,66 @@ func UpdateConscript(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	db.Model(&conscript).Updates(input)
+	actorID, _ := currentConscriptID(c)
+	conscript, err := h.conscripts.Update(id, input, actorID, isAdmin(c, h.store.Conscripts()))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
 	c.JSON(http.StatusOK, conscript)
 }
 
-// DeleteConscript handles DELETE /conscripts/:id
+// Delete handles DELETE /conscripts/:id
 // @Summary Delete a conscript
-// @Description Delete a conscript by its ID
+// @Description Delete a conscript by its ID. Only administrators can delete conscripts.
 // @Tags conscripts
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Conscript ID"
 // @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /conscripts/{id} [delete]
-func DeleteConscript(c *gin.Context) {
-	id := c.Param("id")
-	db := database.GetDB()
-	var conscript models.Conscript
-	if err := db.First(&conscript, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Conscript not found"})
+func (h *ConscriptHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	if err := h.conscripts.Delete(id, isAdmin(c, h.store.Conscripts())); err != nil {
+		respondError(c, err)
 		return
 	}
-	db.Delete(&conscript)
 	c.Status(http.StatusNoContent)
 }
+
+// Batch handles POST /conscripts:batch
+// @Summary Create, update or delete conscripts in bulk
+// @Description Apply many conscript operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.
+// @Tags conscripts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param batch body BatchRequest[models.Conscript] true "Batch operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts:batch [post]
+func (h *ConscriptHandler) Batch(c *gin.Context) {
+	actorID, _ := currentConscriptID(c)
+	admin := isAdmin(c, h.store.Conscripts())
+	runBatch(c, h.store, batchOps[models.Conscript]{
+		create: func(store repository.Store, op *BatchOperation[models.Conscript]) error {
+			return service.NewConscripts(store).Create(&op.Data, admin)
+		},
+		update: func(store repository.Store, op *BatchOperation[models.Conscript]) error {
+			updated, err := service.NewConscripts(store).Update(op.ID, op.Data, actorID, admin)
+			op.Data = updated
+			return err
+		},
+		delete: func(store repository.Store, op *BatchOperation[models.Conscript]) error {
+			return service.NewConscripts(store).Delete(op.ID, admin)
+		},
+	})
+}
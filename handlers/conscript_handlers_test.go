@@ -8,9 +8,9 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 var MockConscript = models.Conscript{
@@ -21,22 +21,28 @@ var MockConscript = models.Conscript{
 	Password:       "testpass",
 }
 
-func setupRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	database.RecreateDatabase("conscript_test.db")
+// principalID is the conscript the routes of setupRouter are served to,
+// an administrator created by beforeEach unless a test changes it.
+var principalID uint
+
+func setupRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
 	r := gin.Default()
-	r.POST("/conscripts", CreateConscript)
-	r.GET("/conscripts", GetConscripts)
-	r.GET("/conscripts/:id", GetConscript)
-	r.PUT("/conscripts/:id", UpdateConscript)
-	r.DELETE("/conscripts/:id", DeleteConscript)
+	r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, principalID) })
+	r.POST("/conscripts", h.Conscripts.Create)
+	r.GET("/conscripts", h.Conscripts.List)
+	r.GET("/conscripts/:id", h.Conscripts.Get)
+	r.PUT("/conscripts/:id", h.Conscripts.Update)
+	r.DELETE("/conscripts/:id", h.Conscripts.Delete)
+	r.POST("/conscripts:method", CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Conscripts.Batch,
+	}))
 	return r
 }
 
-func beforeEach(t *testing.T) (*gin.Engine, uint) {
-	r := setupRouter()
+func beforeEach(t *testing.T, db *gorm.DB) (*gin.Engine, uint) {
+	r := setupRouter(db)
 	// Create a department for foreign key
-	db := database.GetDB()
 	dept := MockDepartment
 
 	// Ensure department with same label does not exist
@@ -45,6 +51,11 @@ func beforeEach(t *testing.T) (*gin.Engine, uint) {
 	if err := db.Create(&dept).Error; err != nil {
 		t.Fatalf("failed to create department: %v", err)
 	}
+	admin := models.Conscript{Username: "admin", RegistryNumber: "admin", Role: models.RoleAdmin, DepartmentID: dept.ID}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatalf("failed to create administrator: %v", err)
+	}
+	principalID = admin.ID
 	t.Cleanup(func() {
 		// Add DB cleanup code here if needed
 	})
@@ -52,7 +63,7 @@ func beforeEach(t *testing.T) (*gin.Engine, uint) {
 }
 
 func TestCreateConscript(t *testing.T) {
-	r, deptID := beforeEach(t)
+	r, deptID := beforeEach(t, testDatabase(t))
 	conscript := MockConscript
 	conscript.DepartmentID = deptID
 	jsonValue, _ := json.Marshal(conscript)
@@ -74,7 +85,7 @@ func TestCreateConscript(t *testing.T) {
 }
 
 func TestGetConscripts(t *testing.T) {
-	r, deptID := beforeEach(t)
+	r, deptID := beforeEach(t, testDatabase(t))
 	conscript := MockConscript
 	conscript.RegistryNumber = "54321"
 	conscript.Username = "janesmith"
@@ -107,7 +118,7 @@ func TestGetConscripts(t *testing.T) {
 }
 
 func TestGetConscript(t *testing.T) {
-	r, deptID := beforeEach(t)
+	r, deptID := beforeEach(t, testDatabase(t))
 	conscript := MockConscript
 	conscript.RegistryNumber = "11111"
 	conscript.Username = "alice"
@@ -141,7 +152,7 @@ func TestGetConscript(t *testing.T) {
 }
 
 func TestUpdateConscript(t *testing.T) {
-	r, deptID := beforeEach(t)
+	r, deptID := beforeEach(t, testDatabase(t))
 	conscript := MockConscript
 	conscript.RegistryNumber = "22222"
 	conscript.Username = "bob"
@@ -175,7 +186,7 @@ func TestUpdateConscript(t *testing.T) {
 }
 
 func TestDeleteConscript(t *testing.T) {
-	r, deptID := beforeEach(t)
+	r, deptID := beforeEach(t, testDatabase(t))
 	conscript := MockConscript
 	conscript.RegistryNumber = "33333"
 	conscript.Username = "carl"
@@ -196,3 +207,167 @@ func TestDeleteConscript(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
 	}
 }
+
+func TestBatchConscriptsAtomicRollsBack(t *testing.T) {
+	db := testDatabase(t)
+	r, deptID := beforeEach(t, db)
+	first := MockConscript
+	first.RegistryNumber = "44441"
+	first.Username = "batch1"
+	first.DepartmentID = deptID
+	duplicate := first
+	batch := BatchRequest[models.Conscript]{
+		Mode: BatchModeAtomic,
+		Operations: []BatchOperation[models.Conscript]{
+			{Op: BatchOpCreate, Data: first},
+			{Op: BatchOpCreate, Data: duplicate},
+		},
+	}
+	jsonValue, _ := json.Marshal(batch)
+	req, _ := http.NewRequest("POST", "/conscripts:batch", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+	var resp BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Errorf("failed to unmarshal response: %v", err)
+	}
+	if resp.Committed || len(resp.Results) != 2 {
+		t.Fatalf("expected 2 uncommitted results, got %+v", resp)
+	}
+	if resp.Results[0].Status != http.StatusCreated || resp.Results[1].Error == "" {
+		t.Errorf("expected first operation to succeed and second to fail, got %+v", resp.Results)
+	}
+	var count int64
+	db.Model(&models.Conscript{}).Where("username = ?", "batch1").Count(&count)
+	if count != 0 {
+		t.Errorf("expected no conscripts after rollback, got %d", count)
+	}
+}
+
+func TestBatchConscriptsPartial(t *testing.T) {
+	db := testDatabase(t)
+	r, deptID := beforeEach(t, db)
+	created := MockConscript
+	created.RegistryNumber = "44442"
+	created.Username = "batch2"
+	created.DepartmentID = deptID
+	batch := BatchRequest[models.Conscript]{
+		Mode: BatchModePartial,
+		Operations: []BatchOperation[models.Conscript]{
+			{Op: BatchOpCreate, Data: created},
+			{Op: BatchOpUpdate, ID: 9999, Data: models.Conscript{FirstName: "Ghost"}},
+			{Op: BatchOpDelete},
+		},
+	}
+	jsonValue, _ := json.Marshal(batch)
+	req, _ := http.NewRequest("POST", "/conscripts:batch", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Errorf("failed to unmarshal response: %v", err)
+	}
+	if !resp.Committed || len(resp.Results) != 3 {
+		t.Fatalf("expected 3 committed results, got %+v", resp)
+	}
+	expected := []int{http.StatusCreated, http.StatusNotFound, http.StatusBadRequest}
+	for i, status := range expected {
+		if resp.Results[i].Status != status {
+			t.Errorf("expected result %d to have status %d, got %d", i, status, resp.Results[i].Status)
+		}
+	}
+	var count int64
+	db.Model(&models.Conscript{}).Where("username = ?", "batch2").Count(&count)
+	if count != 1 {
+		t.Errorf("expected the created conscript to be committed, got %d", count)
+	}
+}
+
+func TestBatchConscriptsDuplicateKey(t *testing.T) {
+	db := testDatabase(t)
+	r, deptID := beforeEach(t, db)
+	first := MockConscript
+	first.RegistryNumber = "44443"
+	first.Username = "batch3"
+	first.DepartmentID = deptID
+	duplicate := first
+	duplicate.RegistryNumber = "44444"
+	batch := BatchRequest[models.Conscript]{
+		Mode: BatchModePartial,
+		Operations: []BatchOperation[models.Conscript]{
+			{Op: BatchOpCreate, Data: first},
+			{Op: BatchOpCreate, Data: duplicate},
+		},
+	}
+	jsonValue, _ := json.Marshal(batch)
+	req, _ := http.NewRequest("POST", "/conscripts:batch", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Errorf("failed to unmarshal response: %v", err)
+	}
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 results, got %+v", resp)
+	}
+	if resp.Results[0].Status != http.StatusCreated {
+		t.Errorf("expected the first conscript to be created, got %+v", resp.Results[0])
+	}
+	if resp.Results[1].Status != http.StatusConflict {
+		t.Errorf("expected status %d for the duplicate username, got %+v", http.StatusConflict, resp.Results[1])
+	}
+}
+
+func TestCreateConscriptAdminRoleForbidden(t *testing.T) {
+	db := testDatabase(t)
+	r, deptID := beforeEach(t, db)
+	conscript := MockConscript
+	conscript.RegistryNumber = "55555"
+	conscript.Username = "wannabe"
+	conscript.DepartmentID = deptID
+	conscript.Role = models.RoleAdmin
+	principal := models.Conscript{Username: "principal", RegistryNumber: "principal", DepartmentID: deptID}
+	db.Create(&principal)
+	principalID = principal.ID
+	jsonValue, _ := json.Marshal(conscript)
+	req, _ := http.NewRequest("POST", "/conscripts", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestCustomMethods(t *testing.T) {
+	t.Parallel()
+	r := gin.New()
+	r.POST("/conscripts:method", CustomMethods(map[string]gin.HandlerFunc{
+		"batch": func(c *gin.Context) { c.Status(http.StatusOK) },
+	}))
+	cases := map[string]int{
+		"/conscripts:batch":   http.StatusOK,
+		"/conscripts:unknown": http.StatusNotFound,
+		"/conscriptsbatch":    http.StatusNotFound,
+	}
+	for path, status := range cases {
+		req, _ := http.NewRequest("POST", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != status {
+			t.Errorf("%s: expected status %d, got %d", path, status, w.Code)
+		}
+	}
+}
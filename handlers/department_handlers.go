@@ -4,14 +4,46 @@ import (
 	"net/http"
 	"strconv"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateDepartment handles POST /departments
+// DepartmentHandler serves the /departments routes.
+type DepartmentHandler struct {
+	store       repository.Store
+	departments *service.Departments
+	conscripts  *service.Conscripts
+}
+
+// NewDepartmentHandler returns the department handler of the store.
+func NewDepartmentHandler(store repository.Store) *DepartmentHandler {
+	return &DepartmentHandler{
+		store:       store,
+		departments: service.NewDepartments(store),
+		conscripts:  service.NewConscripts(store),
+	}
+}
+
+// departmentScope reads the department_id and include_subdepartments query
+// parameters into the IDs of the departments a list is restricted to, or nil
+// when it is not restricted.
+func departmentScope(c *gin.Context, departments *service.Departments) ([]uint, error) {
+	value := c.Query("department_id")
+	if value == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 0)
+	if err != nil {
+		return nil, &batchError{http.StatusBadRequest, "Invalid department ID"}
+	}
+	return departments.Scope(uint(id), c.Query("include_subdepartments") == "true")
+}
+
+// Create handles POST /departments
 // @Summary Create a new department
-// @Description Create a new department in the system
+// @Description Create a new department in the system, optionally under a parent department. Only administrators can appoint a commander.
 // @Tags departments
 // @Accept json
 // @Produce json
@@ -19,67 +51,82 @@ import (
 // @Param department body models.Department true "Department"
 // @Success 201 {object} models.Department
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /departments [post]
-func CreateDepartment(c *gin.Context) {
+func (h *DepartmentHandler) Create(c *gin.Context) {
 	var department models.Department
 	if err := c.ShouldBindJSON(&department); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	if err := database.GetDB().Create(&department).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	if err := h.departments.Create(&department, isAdmin(c, h.store.Conscripts())); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusCreated, department)
 }
 
-// GetDepartments handles GET /departments
+// List handles GET /departments
 // @Summary List all departments
 // @Description Get a list of all departments
 // @Tags departments
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Security BearerAuth
+// @Param format query string false "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default"
 // @Success 200 {array} models.Department
 // @Failure 500 {object} models.ErrorResponse
 // @Router /departments [get]
-func GetDepartments(c *gin.Context) {
-	var departments []models.Department
-	if err := database.GetDB().Find(&departments).Error; err != nil {
+func (h *DepartmentHandler) List(c *gin.Context) {
+	departments, err := h.departments.List()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, departments)
+	respondList(c, "Departments", departments, departmentColumns)
 }
 
-// GetDepartment handles GET /departments/:id
+// Get handles GET /departments/:id
 // @Summary Get a department by ID
-// @Description Get a department by its ID
+// @Description Get a department by its ID. With as_of, the department includes the conscripts that belonged to it at that time.
 // @Tags departments
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Department ID"
+// @Param as_of query string false "Point in time (RFC 3339 or YYYY-MM-DD for midday)"
 // @Success 200 {object} models.Department
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /departments/{id} [get]
-func GetDepartment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid department ID"})
+func (h *DepartmentHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid department ID")
+	if !ok {
 		return
 	}
-	var department models.Department
-	if err := database.GetDB().First(&department, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Department not found"})
+	department, err := h.departments.Get(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	if value := c.Query("as_of"); value != "" {
+		at, err := parsePointInTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid as_of: " + err.Error()})
+			return
+		}
+		if department.Conscripts, err = h.conscripts.List([]uint{department.ID}, &at); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
 	c.JSON(http.StatusOK, department)
 }
 
-// UpdateDepartment handles PUT /departments/:id
+// Update handles PUT /departments/:id
 // @Summary Update a department
-// @Description Update a department by its ID
+// @Description Update a department by its ID. The department cannot be moved under itself or one of its sub-departments, and only administrators can appoint its commander.
 // @Tags departments
 // @Accept json
 // @Produce json
@@ -88,35 +135,37 @@ func GetDepartment(c *gin.Context) {
 // @Param department body models.Department true "Department"
 // @Success 200 {object} models.Department
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
 // @Router /departments/{id} [put]
-func UpdateDepartment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid department ID"})
+func (h *DepartmentHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid department ID")
+	if !ok {
 		return
 	}
-	var department models.Department
-	db := database.GetDB()
-	if err := db.First(&department, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Department not found"})
+	department, err := h.departments.Get(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
+	previousCommander := department.CommanderID
 	if err := c.ShouldBindJSON(&department); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	department.ID = uint(id)
-	if err := db.Save(&department).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	department.ID = id
+	if err := h.departments.Replace(&department, previousCommander, isAdmin(c, h.store.Conscripts())); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, department)
 }
 
-// DeleteDepartment handles DELETE /departments/:id
+// Delete handles DELETE /departments/:id
 // @Summary Delete a department
-// @Description Delete a department by its ID
+// @Description Delete a department by its ID. Departments with sub-departments cannot be deleted.
 // @Tags departments
 // @Produce json
 // @Security BearerAuth
@@ -124,22 +173,122 @@ func UpdateDepartment(c *gin.Context) {
 // @Success 200 {object} models.ErrorResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
 // @Router /departments/{id} [delete]
-func DeleteDepartment(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
+func (h *DepartmentHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid department ID")
+	if !ok {
+		return
+	}
+	if err := h.departments.Delete(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, models.ErrorResponse{Error: "Department deleted"})
+}
+
+// Batch handles POST /departments:batch
+// @Summary Create, update or delete departments in bulk
+// @Description Apply many department operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.
+// @Tags departments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param batch body BatchRequest[models.Department] true "Batch operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /departments:batch [post]
+func (h *DepartmentHandler) Batch(c *gin.Context) {
+	admin := isAdmin(c, h.store.Conscripts())
+	runBatch(c, h.store, batchOps[models.Department]{
+		create: func(store repository.Store, op *BatchOperation[models.Department]) error {
+			return service.NewDepartments(store).Create(&op.Data, admin)
+		},
+		update: func(store repository.Store, op *BatchOperation[models.Department]) error {
+			updated, err := service.NewDepartments(store).Update(op.ID, op.Data, admin)
+			op.Data = updated
+			return err
+		},
+		delete: func(store repository.Store, op *BatchOperation[models.Department]) error {
+			return service.NewDepartments(store).Delete(op.ID)
+		},
+	})
+}
+
+// Tree handles GET /departments/:id/tree
+// @Summary Department tree
+// @Description Get a department with its sub-departments nested in Children, down to the lowest level, ordered by label
+// @Tags departments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Department ID"
+// @Success 200 {object} models.Department
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /departments/{id}/tree [get]
+func (h *DepartmentHandler) Tree(c *gin.Context) {
+	id, ok := parseID(c, "Invalid department ID")
+	if !ok {
+		return
+	}
+	tree, err := h.departments.Tree(id)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid department ID"})
+		respondError(c, err)
 		return
 	}
-	var department models.Department
-	db := database.GetDB()
-	if err := db.First(&department, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Department not found"})
+	c.JSON(http.StatusOK, tree)
+}
+
+// Ancestors handles GET /departments/:id/ancestors
+// @Summary Departments above a department
+// @Description List the departments above a department, from the root of the hierarchy down to its parent
+// @Tags departments
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Department ID"
+// @Success 200 {array} models.Department
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /departments/{id}/ancestors [get]
+func (h *DepartmentHandler) Ancestors(c *gin.Context) {
+	id, ok := parseID(c, "Invalid department ID")
+	if !ok {
 		return
 	}
-	if err := db.Delete(&department).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	ancestors, err := h.departments.Ancestors(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, models.ErrorResponse{Error: "Department deleted"})
+	c.JSON(http.StatusOK, ancestors)
+}
+
+// Descendants handles GET /departments/:id/descendants
+// @Summary Departments under a department
+// @Description List every department under a department, at any depth, ordered by label
+// @Tags departments
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
+// @Security BearerAuth
+// @Param id path int true "Department ID"
+// @Param format query string false "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default"
+// @Success 200 {array} models.Department
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /departments/{id}/descendants [get]
+func (h *DepartmentHandler) Descendants(c *gin.Context) {
+	id, ok := parseID(c, "Invalid department ID")
+	if !ok {
+		return
+	}
+	departments, err := h.departments.Descendants(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	respondList(c, "Departments", departments, departmentColumns)
 }
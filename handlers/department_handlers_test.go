@@ -7,10 +7,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
-	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/hierarchy"
 	"github.com/alexandrosraikos/pixis/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // MockDepartment is a reusable mock for tests
@@ -18,20 +20,19 @@ var MockDepartment = models.Department{
 	Label: "Test Department",
 }
 
-func setupDepartmentRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	database.RecreateDatabase("department_test.db")
+func setupDepartmentRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
 	r := gin.Default()
-	r.POST("/departments", CreateDepartment)
-	r.GET("/departments", GetDepartments)
-	r.GET("/departments/:id", GetDepartment)
-	r.PUT("/departments/:id", UpdateDepartment)
-	r.DELETE("/departments/:id", DeleteDepartment)
+	r.POST("/departments", h.Departments.Create)
+	r.GET("/departments", h.Departments.List)
+	r.GET("/departments/:id", h.Departments.Get)
+	r.PUT("/departments/:id", h.Departments.Update)
+	r.DELETE("/departments/:id", h.Departments.Delete)
 	return r
 }
 
-func beforeEachDepartment(t *testing.T) *gin.Engine {
-	r := setupDepartmentRouter()
+func beforeEachDepartment(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := setupDepartmentRouter(db)
 	t.Cleanup(func() {
 		// Add DB cleanup code here if needed
 	})
@@ -39,7 +40,7 @@ func beforeEachDepartment(t *testing.T) *gin.Engine {
 }
 
 func TestCreateDepartment(t *testing.T) {
-	r := beforeEachDepartment(t)
+	r := beforeEachDepartment(t, testDatabase(t))
 	department := MockDepartment
 	jsonValue, _ := json.Marshal(department)
 	req, _ := http.NewRequest("POST", "/departments", bytes.NewBuffer(jsonValue))
@@ -52,7 +53,7 @@ func TestCreateDepartment(t *testing.T) {
 }
 
 func TestGetDepartments(t *testing.T) {
-	r := beforeEachDepartment(t)
+	r := beforeEachDepartment(t, testDatabase(t))
 	department := MockDepartment
 	department.Label = "Another Department"
 	jsonValue, _ := json.Marshal(department)
@@ -78,7 +79,7 @@ func TestGetDepartments(t *testing.T) {
 }
 
 func TestGetDepartment(t *testing.T) {
-	r := beforeEachDepartment(t)
+	r := beforeEachDepartment(t, testDatabase(t))
 	department := MockDepartment
 	department.Label = "Unique Department"
 	jsonValue, _ := json.Marshal(department)
@@ -107,7 +108,7 @@ func TestGetDepartment(t *testing.T) {
 }
 
 func TestUpdateDepartment(t *testing.T) {
-	r := beforeEachDepartment(t)
+	r := beforeEachDepartment(t, testDatabase(t))
 	department := MockDepartment
 	department.Label = "Department To Update"
 	jsonValue, _ := json.Marshal(department)
@@ -136,7 +137,7 @@ func TestUpdateDepartment(t *testing.T) {
 }
 
 func TestDeleteDepartment(t *testing.T) {
-	r := beforeEachDepartment(t)
+	r := beforeEachDepartment(t, testDatabase(t))
 	department := MockDepartment
 	department.Label = "Department To Delete"
 	jsonValue, _ := json.Marshal(department)
@@ -155,3 +156,163 @@ func TestDeleteDepartment(t *testing.T) {
 		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
 	}
 }
+
+func setupHierarchyRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	auth := r.Group("", h.Auth.Middleware())
+	auth.POST("/departments", h.Departments.Create)
+	auth.PUT("/departments/:id", h.Departments.Update)
+	auth.DELETE("/departments/:id", h.Departments.Delete)
+	auth.GET("/departments/:id/tree", h.Departments.Tree)
+	auth.GET("/departments/:id/ancestors", h.Departments.Ancestors)
+	auth.GET("/departments/:id/descendants", h.Departments.Descendants)
+	auth.GET("/conscripts", h.Conscripts.List)
+	auth.POST("/absences", h.Absences.Create)
+	auth.GET("/absences", h.Absences.List)
+	auth.POST("/absences/:id/approve", h.Absences.Approve)
+	return r
+}
+
+// beforeEachHierarchy creates a battalion of two companies, the first with a
+// platoon, with a soldier in the platoon and one in the second company. The
+// battalion is commanded by a commander of the first company.
+func beforeEachHierarchy(t *testing.T, db *gorm.DB) (*gin.Engine, map[string]models.Department, map[string]models.Conscript) {
+	r := setupHierarchyRouter(db)
+	people := map[string]models.Conscript{}
+	for _, name := range []string{"admin", "commander", "platoon", "second"} {
+		conscript := models.Conscript{RegistryNumber: "hierarchy-" + name, Username: "hierarchy-" + name}
+		if name == "admin" {
+			conscript.Role = models.RoleAdmin
+		}
+		db.Create(&conscript)
+		people[name] = conscript
+	}
+	departments := map[string]models.Department{}
+	create := func(name, parent string) {
+		department := models.Department{Label: name}
+		if parent != "" {
+			id := departments[parent].ID
+			department.ParentID = &id
+		}
+		if name == "Battalion" {
+			id := people["commander"].ID
+			department.CommanderID = &id
+		}
+		w := sendAuthRequest(r, "POST", "/departments", department, people["admin"].ID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+		}
+		json.Unmarshal(w.Body.Bytes(), &department)
+		departments[name] = department
+	}
+	create("Battalion", "")
+	create("Alpha company", "Battalion")
+	create("Bravo company", "Battalion")
+	create("First platoon", "Alpha company")
+	for name, department := range map[string]string{"commander": "Alpha company", "platoon": "First platoon", "second": "Bravo company"} {
+		db.Model(&models.Conscript{}).Where("id = ?", people[name].ID).Update("department_id", departments[department].ID)
+	}
+	return r, departments, people
+}
+
+func TestDepartmentTree(t *testing.T) {
+	r, departments, people := beforeEachHierarchy(t, testDatabase(t))
+	admin := people["admin"].ID
+
+	w := sendAuthRequest(r, "GET", fmt.Sprintf("/departments/%d/tree", departments["Battalion"].ID), nil, admin)
+	var tree models.Department
+	json.Unmarshal(w.Body.Bytes(), &tree)
+	if len(tree.Children) != 2 || tree.Children[0].Label != "Alpha company" || len(tree.Children[0].Children) != 1 || tree.Children[0].Children[0].Label != "First platoon" {
+		t.Errorf("expected the battalion with its companies and platoon, got %+v", tree)
+	}
+
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/departments/%d/ancestors", departments["First platoon"].ID), nil, admin)
+	var ancestors []models.Department
+	json.Unmarshal(w.Body.Bytes(), &ancestors)
+	if len(ancestors) != 2 || ancestors[0].Label != "Battalion" || ancestors[1].Label != "Alpha company" {
+		t.Errorf("expected the battalion and the company above the platoon, got %+v", ancestors)
+	}
+
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/departments/%d/descendants", departments["Battalion"].ID), nil, admin)
+	var descendants []models.Department
+	json.Unmarshal(w.Body.Bytes(), &descendants)
+	if len(descendants) != 3 {
+		t.Errorf("expected every department under the battalion, got %+v", descendants)
+	}
+
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts?department_id=%d&include_subdepartments=true", departments["Alpha company"].ID), nil, admin)
+	var conscripts []models.Conscript
+	json.Unmarshal(w.Body.Bytes(), &conscripts)
+	if len(conscripts) != 2 {
+		t.Errorf("expected the conscripts of the company and its platoon, got %+v", conscripts)
+	}
+
+	if w := sendAuthRequest(r, "DELETE", fmt.Sprintf("/departments/%d", departments["Alpha company"].ID), nil, admin); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a department with sub-departments, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestDepartmentCycles(t *testing.T) {
+	db := testDatabase(t)
+	r, departments, people := beforeEachHierarchy(t, db)
+	battalion := departments["Battalion"]
+	platoon := departments["First platoon"].ID
+	battalion.ParentID = &platoon
+	w := sendAuthRequest(r, "PUT", fmt.Sprintf("/departments/%d", battalion.ID), battalion, people["admin"].ID)
+	if w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a cycle, got %d", http.StatusConflict, w.Code)
+	}
+	if err := hierarchy.CheckParent(db, battalion.ID, battalion.ID); err != hierarchy.ErrCycle {
+		t.Errorf("expected a department under itself to be a cycle, got %v", err)
+	}
+
+	bravo := departments["Bravo company"].ID
+	company := departments["First platoon"]
+	company.ParentID = &bravo
+	if w := sendAuthRequest(r, "PUT", fmt.Sprintf("/departments/%d", company.ID), company, people["admin"].ID); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for moving the platoon, got %d", http.StatusOK, w.Code)
+	}
+	commander := people["second"].ID
+	company.CommanderID = &commander
+	if w := sendAuthRequest(r, "PUT", fmt.Sprintf("/departments/%d", company.ID), company, people["commander"].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a conscript appointing a commander, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+func TestCommanderPermissions(t *testing.T) {
+	r, _, people := beforeEachHierarchy(t, testDatabase(t))
+	start := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	request := func(name string) models.Absence {
+		absence := models.Absence{Type: models.AbsenceLeave, StartTime: start, EndTime: start.Add(48 * time.Hour)}
+		w := sendAuthRequest(r, "POST", "/absences", absence, people[name].ID)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+		}
+		json.Unmarshal(w.Body.Bytes(), &absence)
+		return absence
+	}
+	own, platoon, second := request("commander"), request("platoon"), request("second")
+
+	w := sendAuthRequest(r, "GET", "/absences", nil, people["commander"].ID)
+	var absences []models.Absence
+	json.Unmarshal(w.Body.Bytes(), &absences)
+	if len(absences) != 3 {
+		t.Errorf("expected the battalion commander to see every absence under the battalion, got %+v", absences)
+	}
+	w = sendAuthRequest(r, "GET", "/absences", nil, people["platoon"].ID)
+	json.Unmarshal(w.Body.Bytes(), &absences)
+	if len(absences) != 1 || absences[0].ID != platoon.ID {
+		t.Errorf("expected a soldier to only see their own absence, got %+v", absences)
+	}
+
+	if w := sendAuthRequest(r, "POST", fmt.Sprintf("/absences/%d/approve", second.ID), nil, people["commander"].ID); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for the commander approving, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendAuthRequest(r, "POST", fmt.Sprintf("/absences/%d/approve", own.ID), nil, people["commander"].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for the commander approving their own absence, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := sendAuthRequest(r, "POST", fmt.Sprintf("/absences/%d/approve", platoon.ID), nil, people["second"].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a soldier approving, got %d", http.StatusForbidden, w.Code)
+	}
+}
@@ -2,14 +2,25 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateDuty handles POST /duties
+// DutyHandler serves the /duties routes.
+type DutyHandler struct {
+	store  repository.Store
+	duties *service.Duties
+}
+
+// NewDutyHandler returns the duty handler of the store.
+func NewDutyHandler(store repository.Store) *DutyHandler {
+	return &DutyHandler{store: store, duties: service.NewDuties(store)}
+}
+
+// Create handles POST /duties
 // @Summary Create a new duty
 // @Description Create a new duty in the system
 // @Tags duties
@@ -21,40 +32,41 @@ import (
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /duties [post]
-func CreateDuty(c *gin.Context) {
+func (h *DutyHandler) Create(c *gin.Context) {
 	var duty models.Duty
 	if err := c.ShouldBindJSON(&duty); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	if err := database.GetDB().Create(&duty).Error; err != nil {
+	if err := h.duties.Create(&duty); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, duty)
 }
 
-// GetDuties handles GET /duties
+// List handles GET /duties
 // @Summary List all duties
 // @Description Get a list of all duties
 // @Tags duties
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Security BearerAuth
+// @Param format query string false "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default"
 // @Success 200 {array} models.Duty
 // @Failure 500 {object} models.ErrorResponse
 // @Router /duties [get]
-func GetDuties(c *gin.Context) {
-	var duties []models.Duty
-	if err := database.GetDB().Find(&duties).Error; err != nil {
+func (h *DutyHandler) List(c *gin.Context) {
+	duties, err := h.duties.List()
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, duties)
+	respondList(c, "Duties", duties, dutyColumns)
 }
 
-// GetDuty handles GET /duties/:id
+// Get handles GET /duties/:id
 // @Summary Get a duty by ID
-// @Description Get a duty by its ID
+// @Description Get a duty by its ID, with the qualifications it requires
 // @Tags duties
 // @Produce json
 // @Security BearerAuth
@@ -63,21 +75,20 @@ func GetDuties(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /duties/{id} [get]
-func GetDuty(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid duty ID"})
+func (h *DutyHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid duty ID")
+	if !ok {
 		return
 	}
-	var duty models.Duty
-	if err := database.GetDB().First(&duty, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Duty not found"})
+	duty, err := h.duties.GetWithQualifications(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, duty)
 }
 
-// UpdateDuty handles PUT /duties/:id
+// Update handles PUT /duties/:id
 // @Summary Update a duty
 // @Description Update a duty by its ID
 // @Tags duties
@@ -90,31 +101,29 @@ func GetDuty(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /duties/{id} [put]
-func UpdateDuty(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid duty ID"})
+func (h *DutyHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid duty ID")
+	if !ok {
 		return
 	}
-	var duty models.Duty
-	db := database.GetDB()
-	if err := db.First(&duty, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Duty not found"})
+	duty, err := h.duties.Get(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	if err := c.ShouldBindJSON(&duty); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	duty.ID = uint(id)
-	if err := db.Save(&duty).Error; err != nil {
+	duty.ID = id
+	if err := h.duties.Replace(&duty); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, duty)
 }
 
-// DeleteDuty handles DELETE /duties/:id
+// Delete handles DELETE /duties/:id
 // @Summary Delete a duty
 // @Description Delete a duty by its ID
 // @Tags duties
@@ -125,21 +134,43 @@ func UpdateDuty(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /duties/{id} [delete]
-func DeleteDuty(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid duty ID"})
+func (h *DutyHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid duty ID")
+	if !ok {
 		return
 	}
-	var duty models.Duty
-	db := database.GetDB()
-	if err := db.First(&duty, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Duty not found"})
-		return
-	}
-	if err := db.Delete(&duty).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	if err := h.duties.Delete(id); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, models.ErrorResponse{Error: "Duty deleted"})
 }
+
+// Batch handles POST /duties:batch
+// @Summary Create, update or delete duties in bulk
+// @Description Apply many duty operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.
+// @Tags duties
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param batch body BatchRequest[models.Duty] true "Batch operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /duties:batch [post]
+func (h *DutyHandler) Batch(c *gin.Context) {
+	runBatch(c, h.store, batchOps[models.Duty]{
+		create: func(store repository.Store, op *BatchOperation[models.Duty]) error {
+			return service.NewDuties(store).Create(&op.Data)
+		},
+		update: func(store repository.Store, op *BatchOperation[models.Duty]) error {
+			updated, err := service.NewDuties(store).Update(op.ID, op.Data)
+			op.Data = updated
+			return err
+		},
+		delete: func(store repository.Store, op *BatchOperation[models.Duty]) error {
+			return service.NewDuties(store).Delete(op.ID)
+		},
+	})
+}
@@ -8,35 +8,34 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 var MockDuty = models.Duty{
 	Label: "TestDuty",
 }
 
-func setupDutyRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	database.RecreateDatabase("duty_test.db")
+func setupDutyRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
 	r := gin.Default()
-	r.POST("/duties", CreateDuty)
-	r.GET("/duties", GetDuties)
-	r.GET("/duties/:id", GetDuty)
-	r.PUT("/duties/:id", UpdateDuty)
-	r.DELETE("/duties/:id", DeleteDuty)
+	r.POST("/duties", h.Duties.Create)
+	r.GET("/duties", h.Duties.List)
+	r.GET("/duties/:id", h.Duties.Get)
+	r.PUT("/duties/:id", h.Duties.Update)
+	r.DELETE("/duties/:id", h.Duties.Delete)
 	return r
 }
 
-func beforeEachDuty(t *testing.T) *gin.Engine {
-	r := setupDutyRouter()
+func beforeEachDuty(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := setupDutyRouter(db)
 	t.Cleanup(func() {})
 	return r
 }
 
 func TestCreateDuty(t *testing.T) {
-	r := beforeEachDuty(t)
+	r := beforeEachDuty(t, testDatabase(t))
 	duty := MockDuty
 	jsonValue, _ := json.Marshal(duty)
 	req, _ := http.NewRequest("POST", "/duties", bytes.NewBuffer(jsonValue))
@@ -49,7 +48,7 @@ func TestCreateDuty(t *testing.T) {
 }
 
 func TestGetDuties(t *testing.T) {
-	r := beforeEachDuty(t)
+	r := beforeEachDuty(t, testDatabase(t))
 	duty := MockDuty
 	duty.Label = "AnotherDuty"
 	jsonValue, _ := json.Marshal(duty)
@@ -75,7 +74,7 @@ func TestGetDuties(t *testing.T) {
 }
 
 func TestGetDuty(t *testing.T) {
-	r := beforeEachDuty(t)
+	r := beforeEachDuty(t, testDatabase(t))
 	duty := MockDuty
 	duty.Label = "UniqueDuty"
 	jsonValue, _ := json.Marshal(duty)
@@ -104,7 +103,7 @@ func TestGetDuty(t *testing.T) {
 }
 
 func TestUpdateDuty(t *testing.T) {
-	r := beforeEachDuty(t)
+	r := beforeEachDuty(t, testDatabase(t))
 	duty := MockDuty
 	duty.Label = "DutyToUpdate"
 	jsonValue, _ := json.Marshal(duty)
@@ -133,7 +132,7 @@ func TestUpdateDuty(t *testing.T) {
 }
 
 func TestDeleteDuty(t *testing.T) {
-	r := beforeEachDuty(t)
+	r := beforeEachDuty(t, testDatabase(t))
 	duty := MockDuty
 	duty.Label = "DutyToDelete"
 	jsonValue, _ := json.Marshal(duty)
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/exporter"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// exportTimeLayout is how times are printed in exported documents.
+const exportTimeLayout = "2006-01-02 15:04"
+
+// negotiateExportFormat picks the response format from the format query
+// parameter or, failing that, from the Accept header. JSON is the default.
+func negotiateExportFormat(c *gin.Context) (exporter.Format, error) {
+	if name := c.Query("format"); name != "" {
+		return exporter.ParseFormat(name)
+	}
+	offered := c.NegotiateFormat(exporter.MIMEJSON, exporter.MIMECSV, exporter.MIMEXLSX, exporter.MIMEPDF)
+	if format, ok := exporter.FormatFromContentType(offered); ok {
+		return format, nil
+	}
+	return exporter.FormatJSON, nil
+}
+
+// respondList writes a list of items as JSON or as an exported document,
+// depending on the negotiated format.
+func respondList[T any](c *gin.Context, title string, items []T, columns []exporter.Column[T]) {
+	format, err := negotiateExportFormat(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if format == exporter.FormatJSON {
+		c.JSON(http.StatusOK, items)
+		return
+	}
+	respondDocument(c, format, exporter.Document{
+		Title:  title,
+		Tables: []exporter.Table{exporter.NewTable(title, columns, items)},
+	})
+}
+
+// respondDocument renders the document and sends it as an attachment.
+func respondDocument(c *gin.Context, format exporter.Format, doc exporter.Document) {
+	var buf bytes.Buffer
+	if err := exporter.Write(&buf, format, doc); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	filename := fmt.Sprintf("%s.%s", doc.Title, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Data(http.StatusOK, format.ContentType(), buf.Bytes())
+}
+
+func formatID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+var conscriptColumns = []exporter.Column[models.Conscript]{
+	{Header: "ID", Value: func(c models.Conscript) string { return formatID(c.ID) }},
+	{Header: "Registry Number", Value: func(c models.Conscript) string { return c.RegistryNumber }},
+	{Header: "First Name", Value: func(c models.Conscript) string { return c.FirstName }},
+	{Header: "Last Name", Value: func(c models.Conscript) string { return c.LastName }},
+	{Header: "Username", Value: func(c models.Conscript) string { return c.Username }},
+	{Header: "Rank", Value: func(c models.Conscript) string { return c.Rank }},
+	{Header: "Status", Value: func(c models.Conscript) string { return string(c.Status) }},
+	{Header: "Department ID", Value: func(c models.Conscript) string { return formatID(c.DepartmentID) }},
+}
+
+var departmentColumns = []exporter.Column[models.Department]{
+	{Header: "ID", Value: func(d models.Department) string { return formatID(d.ID) }},
+	{Header: "Label", Value: func(d models.Department) string { return d.Label }},
+}
+
+var serviceColumns = []exporter.Column[models.Service]{
+	{Header: "ID", Value: func(s models.Service) string { return formatID(s.ID) }},
+	{Header: "Label", Value: func(s models.Service) string { return s.Label }},
+	{Header: "Department ID", Value: func(s models.Service) string { return formatID(s.DepartmentID) }},
+}
+
+var dutyColumns = []exporter.Column[models.Duty]{
+	{Header: "ID", Value: func(d models.Duty) string { return formatID(d.ID) }},
+	{Header: "Label", Value: func(d models.Duty) string { return d.Label }},
+	{Header: "Service ID", Value: func(d models.Duty) string { return formatID(d.ServiceID) }},
+	{Header: "Points", Value: func(d models.Duty) string { return strconv.FormatFloat(d.Points, 'g', -1, 64) }},
+}
+
+var conscriptDutyColumns = []exporter.Column[models.ConscriptDuty]{
+	{Header: "ID", Value: func(cd models.ConscriptDuty) string { return formatID(cd.ID) }},
+	{Header: "Conscript ID", Value: func(cd models.ConscriptDuty) string { return formatID(cd.ConscriptID) }},
+	{Header: "Duty ID", Value: func(cd models.ConscriptDuty) string { return formatID(cd.DutyID) }},
+	{Header: "Start", Value: func(cd models.ConscriptDuty) string { return cd.StartTime.Format(exportTimeLayout) }},
+	{Header: "End", Value: func(cd models.ConscriptDuty) string { return cd.EndTime.Format(exportTimeLayout) }},
+}
+
+// parseDateRange reads the from and to query parameters, given either as
+// dates (2006-01-02) or as RFC 3339 times. A date-only to parameter includes
+// the whole day. The range defaults to the current day.
+func parseDateRange(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if value := c.Query("from"); value != "" {
+		t, _, err := parseDateOrTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid from: %w", err)
+		}
+		from = t
+	}
+	to := from.AddDate(0, 0, 1)
+	if value := c.Query("to"); value != "" {
+		t, dateOnly, err := parseDateOrTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid to: %w", err)
+		}
+		to = t
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be after from")
+	}
+	return from, to, nil
+}
+
+func parseDateOrTime(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(time.DateOnly, value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// parsePointInTime parses a point in time given as an RFC 3339 time or as a
+// date, which stands for its midday.
+func parsePointInTime(value string) (time.Time, error) {
+	t, dateOnly, err := parseDateOrTime(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if dateOnly {
+		t = t.Add(12 * time.Hour)
+	}
+	return t, nil
+}
+
+// rosterEntry is a single assignment with its duty, service and conscript resolved.
+type rosterEntry struct {
+	Service    string
+	Duty       string
+	Assignment models.ConscriptDuty
+	Conscript  models.Conscript
+}
+
+// resolveAssignments loads the conscripts, duties and services referenced by
+// the assignments, keeping the order of the assignments.
+func resolveAssignments(store repository.Store, assignments []models.ConscriptDuty) ([]rosterEntry, error) {
+	conscripts, err := store.Conscripts().List(nil)
+	if err != nil {
+		return nil, err
+	}
+	duties, err := store.Duties().List()
+	if err != nil {
+		return nil, err
+	}
+	services, err := store.Services().List(nil)
+	if err != nil {
+		return nil, err
+	}
+	conscriptsByID := make(map[uint]models.Conscript, len(conscripts))
+	for _, conscript := range conscripts {
+		conscriptsByID[conscript.ID] = conscript
+	}
+	dutiesByID := make(map[uint]models.Duty, len(duties))
+	for _, duty := range duties {
+		dutiesByID[duty.ID] = duty
+	}
+	servicesByID := make(map[uint]models.Service, len(services))
+	for _, service := range services {
+		servicesByID[service.ID] = service
+	}
+
+	entries := make([]rosterEntry, 0, len(assignments))
+	for _, a := range assignments {
+		duty := dutiesByID[a.DutyID]
+		entries = append(entries, rosterEntry{
+			Service:    servicesByID[duty.ServiceID].Label,
+			Duty:       duty.Label,
+			Assignment: a,
+			Conscript:  conscriptsByID[a.ConscriptID],
+		})
+	}
+	return entries, nil
+}
+
+var rosterColumns = []exporter.Column[rosterEntry]{
+	{Header: "Registry Number", Value: func(e rosterEntry) string { return e.Conscript.RegistryNumber }},
+	{Header: "Name", Value: func(e rosterEntry) string { return e.Conscript.LastName + " " + e.Conscript.FirstName }},
+	{Header: "Start", Value: func(e rosterEntry) string { return e.Assignment.StartTime.Format(exportTimeLayout) }},
+	{Header: "End", Value: func(e rosterEntry) string { return e.Assignment.EndTime.Format(exportTimeLayout) }},
+}
+
+// Export handles GET /rosters/export
+// @Summary Export the duty roster
+// @Description Export the duty assignments overlapping a date range as a printable roster, grouped by service and duty, with conscript names resolved. Dates are given as YYYY-MM-DD or RFC 3339 and default to the current day.
+// @Tags rosters
+// @Produce application/pdf,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Security BearerAuth
+// @Param from query string false "Start of the range"
+// @Param to query string false "End of the range (inclusive when a date)"
+// @Param format query string false "Document format (pdf, csv or xlsx), PDF by default"
+// @Success 200 {file} file
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /rosters/export [get]
+func (h *RosterHandler) Export(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	format := exporter.FormatPDF
+	if name := c.Query("format"); name != "" {
+		if format, err = exporter.ParseFormat(name); err != nil || format == exporter.FormatJSON {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: fmt.Sprintf("unsupported roster format %q", name)})
+			return
+		}
+	}
+
+	assignments, err := h.store.Assignments().List(repository.AssignmentFilter{From: &from, To: &to})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	entries, err := resolveAssignments(h.store, assignments)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Service != entries[j].Service {
+			return entries[i].Service < entries[j].Service
+		}
+		return entries[i].Duty < entries[j].Duty
+	})
+
+	title := fmt.Sprintf("Roster %s - %s", from.Format(time.DateOnly), to.Add(-time.Second).Format(time.DateOnly))
+	doc := exporter.Document{Title: title}
+	for start := 0; start < len(entries); {
+		end := start
+		for end < len(entries) && entries[end].Service == entries[start].Service && entries[end].Duty == entries[start].Duty {
+			end++
+		}
+		group := fmt.Sprintf("%s / %s", entries[start].Service, entries[start].Duty)
+		doc.Tables = append(doc.Tables, exporter.NewTable(group, rosterColumns, entries[start:end]))
+		start = end
+	}
+	if len(doc.Tables) == 0 {
+		doc.Tables = []exporter.Table{exporter.NewTable("No assignments", rosterColumns, nil)}
+	}
+	respondDocument(c, format, doc)
+}
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+func setupExportRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	r.GET("/conscripts", h.Conscripts.List)
+	r.GET("/rosters/export", h.Rosters.Export)
+	return r
+}
+
+func beforeEachExport(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := setupExportRouter(db)
+	service := models.Service{Label: "Guard"}
+	db.Create(&service)
+	gate := models.Duty{Label: "Gate", ServiceID: service.ID}
+	db.Create(&gate)
+	conscripts := []models.Conscript{
+		{FirstName: "Nikos", LastName: "Papadopoulos", RegistryNumber: "3001", Username: "nikos", Password: "secret"},
+		{FirstName: "Giorgos", LastName: "Ioannou", RegistryNumber: "3002", Username: "giorgos", Password: "secret"},
+	}
+	db.Create(&conscripts)
+	day := time.Date(2025, 3, 10, 8, 0, 0, 0, time.Local)
+	db.Create(&[]models.ConscriptDuty{
+		{ConscriptID: conscripts[0].ID, DutyID: gate.ID, StartTime: day, EndTime: day.Add(8 * time.Hour)},
+		{ConscriptID: conscripts[1].ID, DutyID: gate.ID, StartTime: day.AddDate(0, 0, 5), EndTime: day.AddDate(0, 0, 5).Add(8 * time.Hour)},
+	})
+	return r
+}
+
+func TestGetConscriptsAsCSV(t *testing.T) {
+	r := beforeEachExport(t, testDatabase(t))
+	req, _ := http.NewRequest("GET", "/conscripts", nil)
+	req.Header.Set("Accept", "text/csv")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("expected CSV content type, got %q", w.Header().Get("Content-Type"))
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Registry Number") || !strings.Contains(body, "Papadopoulos") {
+		t.Errorf("expected header and conscripts in CSV, got %q", body)
+	}
+	if strings.Contains(body, "secret") {
+		t.Errorf("expected passwords not to be exported")
+	}
+}
+
+func TestGetConscriptsAsXLSX(t *testing.T) {
+	r := beforeEachExport(t, testDatabase(t))
+	req, _ := http.NewRequest("GET", "/conscripts?format=xlsx", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	workbook, err := excelize.OpenReader(bytes.NewReader(w.Body.Bytes()))
+	if err != nil {
+		t.Fatalf("failed to open workbook: %v", err)
+	}
+	rows, _ := workbook.GetRows(workbook.GetSheetList()[0])
+	if len(rows) != 3 {
+		t.Errorf("expected header and 2 rows, got %d rows", len(rows))
+	}
+}
+
+func TestGetConscriptsInvalidFormat(t *testing.T) {
+	r := beforeEachExport(t, testDatabase(t))
+	req, _ := http.NewRequest("GET", "/conscripts?format=doc", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestExportRosterPDF(t *testing.T) {
+	r := beforeEachExport(t, testDatabase(t))
+	req, _ := http.NewRequest("GET", "/rosters/export?from=2025-03-10&to=2025-03-16", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if w.Header().Get("Content-Type") != "application/pdf" {
+		t.Errorf("expected PDF content type, got %q", w.Header().Get("Content-Type"))
+	}
+	if !bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF")) {
+		t.Errorf("expected a PDF document")
+	}
+}
+
+func TestExportRosterCSVDateRange(t *testing.T) {
+	r := beforeEachExport(t, testDatabase(t))
+	req, _ := http.NewRequest("GET", "/rosters/export?from=2025-03-10&to=2025-03-10&format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "Papadopoulos Nikos") {
+		t.Errorf("expected roster to resolve conscript names, got %q", body)
+	}
+	if strings.Contains(body, "Ioannou") {
+		t.Errorf("expected assignments outside the range to be excluded, got %q", body)
+	}
+}
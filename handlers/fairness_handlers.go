@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// Defaults of the fairness report.
+const (
+	defaultFairnessDays      = 90
+	defaultFairnessThreshold = 1.5
+)
+
+// FairnessHandler serves the fairness report and the points ledgers.
+type FairnessHandler struct {
+	store    repository.Store
+	fairness *service.Fairness
+}
+
+// NewFairnessHandler returns the fairness handler of the store.
+func NewFairnessHandler(store repository.Store) *FairnessHandler {
+	return &FairnessHandler{store: store, fairness: service.NewFairness(store)}
+}
+
+// parseOptionalRange reads the from and to query parameters like
+// parseDateRange does, falling back to the given defaults when missing.
+func parseOptionalRange(c *gin.Context, from, to time.Time) (time.Time, time.Time, error) {
+	if value := c.Query("from"); value != "" {
+		t, _, err := parseDateOrTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid from: %w", err)
+		}
+		from = t
+	}
+	if value := c.Query("to"); value != "" {
+		t, dateOnly, err := parseDateOrTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid to: %w", err)
+		}
+		to = t
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to must be after from")
+	}
+	return from, to, nil
+}
+
+// parseTimeZone loads the location of an IANA time zone name, or nil for
+// UTC when the name is empty.
+func parseTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid time_zone %q", name)
+	}
+	return location, nil
+}
+
+// Report handles GET /reports/fairness
+// @Summary Duty fairness report of a department
+// @Description Compare the duty points earned by the conscripts of a department with assignments starting in a date range, by default the last 90 days. Points are weighed by duty, with weekend, night and holiday multipliers that apply by the day and hours in time_zone (an IANA name, UTC by default). Conscripts further than threshold standard deviations (1.5 by default) from the mean are reported as outliers.
+// @Tags reports
+// @Produce json
+// @Security BearerAuth
+// @Param department_id query int true "Department ID"
+// @Param from query string false "Start of the range"
+// @Param to query string false "End of the range (inclusive when a date)"
+// @Param threshold query number false "Outlier threshold in standard deviations"
+// @Param time_zone query string false "IANA time zone of weekends and nights, UTC by default"
+// @Success 200 {object} fairness.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /reports/fairness [get]
+func (h *FairnessHandler) Report(c *gin.Context) {
+	departmentID, err := strconv.Atoi(c.Query("department_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid department ID"})
+		return
+	}
+	now := time.Now()
+	from, to, err := parseOptionalRange(c, now.AddDate(0, 0, -defaultFairnessDays), now)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	threshold := defaultFairnessThreshold
+	if value := c.Query("threshold"); value != "" {
+		if threshold, err = strconv.ParseFloat(value, 64); err != nil || threshold <= 0 {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid threshold"})
+			return
+		}
+	}
+
+	location, err := parseTimeZone(c.Query("time_zone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	report, err := h.fairness.Report(uint(departmentID), from, to, threshold, location)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// Ledger handles GET /conscripts/:id/ledger
+// @Summary Duty points ledger of a conscript
+// @Description List the assignments of a conscript starting in a date range, by default all of them, with the points each one earned, weighed in time_zone like the fairness report, and the running total
+// @Tags reports
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Param from query string false "Start of the range"
+// @Param to query string false "End of the range (inclusive when a date)"
+// @Param time_zone query string false "IANA time zone of weekends and nights, UTC by default"
+// @Success 200 {object} fairness.Ledger
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/ledger [get]
+func (h *FairnessHandler) Ledger(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	from, to, err := parseOptionalRange(c, time.Time{}, time.Date(9999, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	location, err := parseTimeZone(c.Query("time_zone"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	ledger, err := h.fairness.Ledger(id, from, to, location)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, ledger)
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/fairness"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupFairnessRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	r.GET("/reports/fairness", h.Fairness.Report)
+	r.GET("/conscripts/:id/ledger", h.Fairness.Ledger)
+	return r
+}
+
+func beforeEachFairness(t *testing.T, db *gorm.DB) (*gin.Engine, models.Department, []models.Conscript, models.Duty) {
+	r := setupFairnessRouter(db)
+	department := models.Department{Label: "Fairness company"}
+	db.Create(&department)
+	duty := models.Duty{Label: "Night guard", Points: 2, WeekendMultiplier: 2, NightMultiplier: 1.5, HolidayMultiplier: 3}
+	db.Create(&duty)
+	var conscripts []models.Conscript
+	for i := 0; i < 5; i++ {
+		conscript := models.Conscript{
+			FirstName:      fmt.Sprintf("Fair%d", i),
+			RegistryNumber: fmt.Sprintf("fair-%d", i),
+			Username:       fmt.Sprintf("fair-%d", i),
+			DepartmentID:   department.ID,
+		}
+		db.Create(&conscript)
+		conscripts = append(conscripts, conscript)
+	}
+	return r, department, conscripts, duty
+}
+
+func TestHolidays(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	admin := models.Conscript{Username: "admin", Role: models.RoleAdmin}
+	conscript := models.Conscript{Username: "conscript"}
+	for _, c := range []*models.Conscript{&admin, &conscript} {
+		if err := store.Conscripts().Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := New(store)
+	router := func(conscriptID uint) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, conscriptID) })
+		r.POST("/holidays", h.Holidays.Create)
+		r.GET("/holidays", h.Holidays.List)
+		r.PUT("/holidays/:id", h.Holidays.Update)
+		r.DELETE("/holidays/:id", h.Holidays.Delete)
+		return r
+	}
+	r := router(admin.ID)
+	post := func(holiday models.Holiday) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(holiday)
+		req, _ := http.NewRequest("POST", "/holidays", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	if w := post(models.Holiday{Date: "25/03/2025", Label: "Independence Day"}); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+	w := post(models.Holiday{Date: "2025-03-25", Label: "Independence Day"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var holiday models.Holiday
+	json.Unmarshal(w.Body.Bytes(), &holiday)
+
+	req, _ := http.NewRequest("GET", "/holidays", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var holidays []models.Holiday
+	json.Unmarshal(w.Body.Bytes(), &holidays)
+	if len(holidays) != 1 || holidays[0].Date != "2025-03-25" {
+		t.Errorf("expected the holiday to be listed, got %+v", holidays)
+	}
+
+	// Conscripts who are not administrators only list the holidays.
+	denied := router(conscript.ID)
+	jsonValue, _ := json.Marshal(models.Holiday{Date: "2025-03-26", Label: "Not a holiday"})
+	for _, route := range []struct{ method, path string }{
+		{"POST", "/holidays"},
+		{"PUT", fmt.Sprintf("/holidays/%d", holiday.ID)},
+		{"DELETE", fmt.Sprintf("/holidays/%d", holiday.ID)},
+	} {
+		req, _ := http.NewRequest(route.method, route.path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		denied.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("%s %s: expected status %d, got %d", route.method, route.path, http.StatusForbidden, w.Code)
+		}
+	}
+	if holidays, _ := store.Holidays().List(); len(holidays) != 1 || holidays[0].Date != "2025-03-25" {
+		t.Errorf("expected the holidays to be unchanged, got %+v", holidays)
+	}
+
+	req, _ = http.NewRequest("DELETE", fmt.Sprintf("/holidays/%d", holiday.ID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent {
+		t.Errorf("expected status %d, got %d", http.StatusNoContent, w.Code)
+	}
+}
+
+func TestConscriptLedger(t *testing.T) {
+	db := testDatabase(t)
+	r, _, conscripts, duty := beforeEachFairness(t, db)
+	db.Create(&models.Holiday{Date: "2025-03-25", Label: "Independence Day"})
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+	}
+	db.Create(&[]models.ConscriptDuty{
+		{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: at(12, 8), EndTime: at(12, 16)},
+		{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: at(15, 8), EndTime: at(15, 16)},
+		{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: at(17, 22), EndTime: at(18, 6)},
+		{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: at(25, 8), EndTime: at(25, 16)},
+	})
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/conscripts/%d/ledger", conscripts[0].ID), nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var ledger fairness.Ledger
+	json.Unmarshal(w.Body.Bytes(), &ledger)
+	balances := []float64{2, 6, 9, 15}
+	if len(ledger.Entries) != len(balances) || ledger.Total != 15 {
+		t.Fatalf("expected 4 entries totalling 15 points, got %+v", ledger)
+	}
+	for i, entry := range ledger.Entries {
+		if entry.Balance != balances[i] {
+			t.Errorf("entry %d: expected balance %g, got %g", i, balances[i], entry.Balance)
+		}
+	}
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/conscripts/%d/ledger?from=2025-03-15&to=2025-03-17", conscripts[0].ID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &ledger)
+	if len(ledger.Entries) != 2 || ledger.Total != 7 {
+		t.Errorf("expected 2 entries totalling 7 points in range, got %+v", ledger)
+	}
+
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/conscripts/%d/ledger?time_zone=Nowhere/Else", conscripts[0].ID), nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid time zone, got %d", http.StatusBadRequest, w.Code)
+	}
+}
+
+func TestFairnessReport(t *testing.T) {
+	db := testDatabase(t)
+	r, department, conscripts, duty := beforeEachFairness(t, db)
+	// The first conscript takes five weekday duties, nobody else any.
+	for day := 10; day < 15; day++ {
+		start := time.Date(2025, 3, day, 8, 0, 0, 0, time.UTC)
+		db.Create(&models.ConscriptDuty{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	}
+
+	path := fmt.Sprintf("/reports/fairness?department_id=%d&from=2025-03-01&to=2025-03-31", department.ID)
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var report fairness.Report
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if len(report.Standings) != 5 || report.Mean != 2 || report.StdDev != 4 {
+		t.Fatalf("expected 5 standings with mean 2 and standard deviation 4, got %+v", report)
+	}
+	if len(report.Outliers) != 1 || report.Outliers[0].ConscriptID != conscripts[0].ID || report.Outliers[0].Points != 10 {
+		t.Errorf("expected the first conscript to be the only outlier, got %+v", report.Outliers)
+	}
+
+	req, _ = http.NewRequest("GET", path+"&threshold=3", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	json.Unmarshal(w.Body.Bytes(), &report)
+	if len(report.Outliers) != 0 {
+		t.Errorf("expected no outliers beyond 3 standard deviations, got %+v", report.Outliers)
+	}
+
+	req, _ = http.NewRequest("GET", "/reports/fairness", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+}
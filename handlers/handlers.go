@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Handlers serves the routes of the API, whose rules live in the service
+// package and whose records live in a repository.Store.
+type Handlers struct {
+	Conscripts     *ConscriptHandler
+	Departments    *DepartmentHandler
+	Services       *ServiceHandler
+	Duties         *DutyHandler
+	Assignments    *AssignmentHandler
+	Absences       *AbsenceHandler
+	Holidays       *HolidayHandler
+	ShiftTemplates *ShiftTemplateHandler
+	Qualifications *QualificationHandler
+	Swaps          *SwapHandler
+	Calendar       *CalendarHandler
+	Fairness       *FairnessHandler
+	Rosters        *RosterHandler
+	Imports        *ImportHandler
+	Auth           *AuthHandler
+}
+
+// New returns the handlers of the store.
+func New(store repository.Store) *Handlers {
+	return &Handlers{
+		Conscripts:     NewConscriptHandler(store),
+		Departments:    NewDepartmentHandler(store),
+		Services:       NewServiceHandler(store),
+		Duties:         NewDutyHandler(store),
+		Assignments:    NewAssignmentHandler(store),
+		Absences:       NewAbsenceHandler(store),
+		Holidays:       NewHolidayHandler(store),
+		ShiftTemplates: NewShiftTemplateHandler(store),
+		Qualifications: NewQualificationHandler(store),
+		Swaps:          NewSwapHandler(store),
+		Calendar:       NewCalendarHandler(store),
+		Fairness:       NewFairnessHandler(store),
+		Rosters:        NewRosterHandler(store),
+		Imports:        NewImportHandler(store),
+		Auth:           NewAuthHandler(store),
+	}
+}
+
+// statusOf returns the HTTP status reporting an error of the service layer
+// or of a batch operation.
+func statusOf(err error) int {
+	var be *batchError
+	var se *service.Error
+	var ce *service.ConflictError
+	switch {
+	case errors.As(err, &be):
+		return be.status
+	case errors.As(err, &ce):
+		return http.StatusConflict
+	case errors.Is(err, gorm.ErrDuplicatedKey), errors.Is(err, gorm.ErrForeignKeyViolated):
+		// A unique or foreign key constraint rejected the record.
+		return http.StatusConflict
+	case errors.As(err, &se):
+		switch se.Kind {
+		case service.KindInvalid:
+			return http.StatusBadRequest
+		case service.KindNotFound:
+			return http.StatusNotFound
+		case service.KindForbidden:
+			return http.StatusForbidden
+		case service.KindConflict:
+			return http.StatusConflict
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// respondError writes the response of a request rejected by the service
+// layer, listing the conflicts of rejected assignments.
+func respondError(c *gin.Context, err error) {
+	var ce *service.ConflictError
+	if errors.As(err, &ce) {
+		c.JSON(http.StatusConflict, ConflictResponse{Error: err.Error(), Conflicts: ce.Conflicts})
+		return
+	}
+	c.JSON(statusOf(err), models.ErrorResponse{Error: err.Error()})
+}
+
+// currentConscript loads the conscript authenticated by
+// AuthHandler.Middleware from the repository.
+func currentConscript(c *gin.Context, conscripts repository.Conscripts) (models.Conscript, bool) {
+	id, ok := currentConscriptID(c)
+	if !ok {
+		return models.Conscript{}, false
+	}
+	conscript, err := conscripts.Find(id)
+	return conscript, err == nil
+}
+
+// isAdmin reports whether the authenticated conscript is an administrator.
+func isAdmin(c *gin.Context, conscripts repository.Conscripts) bool {
+	conscript, ok := currentConscript(c, conscripts)
+	return ok && conscript.Role == models.RoleAdmin
+}
+
+// actor returns the ID of the authenticated conscript and whether they
+// are an administrator, for the rules of the service layer.
+func actor(c *gin.Context, conscripts repository.Conscripts) (uint, bool) {
+	id, _ := currentConscriptID(c)
+	return id, isAdmin(c, conscripts)
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// memoryRouter serves the department routes over an in-memory store, as the
+// conscript with the given ID.
+func memoryRouter(store repository.Store, conscriptID uint) *gin.Engine {
+	h := New(store)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, conscriptID) })
+	r.POST("/departments", h.Departments.Create)
+	r.DELETE("/departments/:id", h.Departments.Delete)
+	r.POST("/departments:batch", h.Departments.Batch)
+	return r
+}
+
+func TestHandlersOverMemoryStore(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	commander := models.Conscript{Username: "commander"}
+	if err := store.Conscripts().Create(&commander); err != nil {
+		t.Fatal(err)
+	}
+	root := models.Department{Label: "Battalion"}
+	if err := store.Departments().Create(&root); err != nil {
+		t.Fatal(err)
+	}
+	child := models.Department{Label: "Company", ParentID: &root.ID}
+	if err := store.Departments().Create(&child); err != nil {
+		t.Fatal(err)
+	}
+	r := memoryRouter(store, commander.ID)
+	send := func(method, path string, body any) *httptest.ResponseRecorder {
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := send("POST", "/departments", models.Department{Label: "Platoon", CommanderID: &commander.ID}); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a commander appointed by a conscript, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := send("DELETE", "/departments/abc", nil); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid ID, got %d", http.StatusBadRequest, w.Code)
+	}
+	if w := send("DELETE", "/departments/99", nil); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for a missing department, got %d", http.StatusNotFound, w.Code)
+	}
+	if w := send("DELETE", fmt.Sprintf("/departments/%d", root.ID), nil); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a department with sub-departments, got %d", http.StatusConflict, w.Code)
+	}
+
+	w := send("POST", "/departments:batch", BatchRequest[models.Department]{
+		Mode: BatchModeAtomic,
+		Operations: []BatchOperation[models.Department]{
+			{Op: BatchOpCreate, Data: models.Department{Label: "Platoon", ParentID: &child.ID}},
+			{Op: BatchOpDelete, ID: root.ID},
+		},
+	})
+	var response BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusUnprocessableEntity || response.Committed {
+		t.Fatalf("expected the atomic batch to fail, got %d: %s", w.Code, w.Body.String())
+	}
+	if departments, _ := store.Departments().List(); len(departments) != 2 {
+		t.Errorf("expected the atomic batch to be rolled back, got %d departments", len(departments))
+	}
+}
+
+func TestBackupsRequireAdminAndSQLite(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	conscript := models.Conscript{Username: "conscript"}
+	admin := models.Conscript{Username: "admin", Role: models.RoleAdmin}
+	for _, c := range []*models.Conscript{&conscript, &admin} {
+		if err := store.Conscripts().Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups := NewBackupHandler(store, nil)
+	for id, expected := range map[uint]int{conscript.ID: http.StatusForbidden, admin.ID: http.StatusNotImplemented} {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, id) })
+		r.POST("/admin/backups", backups.Create)
+		req, _ := http.NewRequest("POST", "/admin/backups", nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("expected status %d for conscript %d, got %d", expected, id, w.Code)
+		}
+	}
+}
+
+func TestConscriptPermissions(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	conscript := models.Conscript{Username: "conscript", FirstName: "Nikos"}
+	other := models.Conscript{Username: "other"}
+	admin := models.Conscript{Username: "admin", Role: models.RoleAdmin}
+	for _, c := range []*models.Conscript{&conscript, &other, &admin} {
+		if err := store.Conscripts().Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := New(store)
+	send := func(actorID uint, method, path string, body any) *httptest.ResponseRecorder {
+		r := gin.New()
+		r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, actorID) })
+		r.PUT("/conscripts/:id", h.Conscripts.Update)
+		r.DELETE("/conscripts/:id", h.Conscripts.Delete)
+		r.POST("/conscripts:batch", h.Conscripts.Batch)
+		jsonValue, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	self := fmt.Sprintf("/conscripts/%d", conscript.ID)
+	department := uint(1)
+
+	refused := map[string]struct {
+		method, path string
+		body         any
+	}{
+		"updating another conscript":     {"PUT", fmt.Sprintf("/conscripts/%d", other.ID), map[string]any{"FirstName": "Giorgos"}},
+		"promoting oneself":              {"PUT", self, map[string]any{"Role": models.RoleAdmin}},
+		"changing one's own rank":        {"PUT", self, map[string]any{"Rank": "Sergeant"}},
+		"changing one's own status":      {"PUT", self, map[string]any{"Status": "discharged"}},
+		"moving oneself to a department": {"PUT", self, map[string]any{"DepartmentID": department}},
+		"deleting another conscript":     {"DELETE", fmt.Sprintf("/conscripts/%d", other.ID), nil},
+		"deleting oneself":               {"DELETE", self, nil},
+	}
+	for name, request := range refused {
+		if w := send(conscript.ID, request.method, request.path, request.body); w.Code != http.StatusForbidden {
+			t.Errorf("expected status %d for %s, got %d: %s", http.StatusForbidden, name, w.Code, w.Body.String())
+		}
+	}
+
+	w := send(conscript.ID, "POST", "/conscripts:batch", BatchRequest[models.Conscript]{
+		Operations: []BatchOperation[models.Conscript]{
+			{Op: BatchOpUpdate, ID: other.ID, Data: models.Conscript{FirstName: "Giorgos"}},
+			{Op: BatchOpDelete, ID: other.ID},
+		},
+	})
+	var response BatchResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Code != http.StatusUnprocessableEntity || len(response.Results) != 2 || response.Results[0].Status != http.StatusForbidden || response.Results[1].Status != http.StatusForbidden {
+		t.Errorf("expected the batch operations on another conscript to be refused, got %d: %s", w.Code, w.Body.String())
+	}
+	if unchanged, _ := store.Conscripts().Find(other.ID); unchanged.FirstName != "" {
+		t.Errorf("expected the other conscript to be unchanged, got %q", unchanged.FirstName)
+	}
+
+	if w := send(conscript.ID, "PUT", self, map[string]any{"FirstName": "Kostas", "DisabledAt": "2025-03-01T00:00:00Z"}); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for changing one's own name, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	if updated, _ := store.Conscripts().Find(conscript.ID); updated.FirstName != "Kostas" || updated.DisabledAt != nil {
+		t.Errorf("expected only the name to change, got %q disabled at %v", updated.FirstName, updated.DisabledAt)
+	}
+	if w := send(admin.ID, "PUT", fmt.Sprintf("/conscripts/%d", other.ID), map[string]any{"Rank": "Corporal"}); w.Code != http.StatusOK {
+		t.Errorf("expected status %d for an administrator updating a conscript, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+}
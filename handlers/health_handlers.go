@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// readinessTimeout limits the database checks of a readiness probe.
+const readinessTimeout = 2 * time.Second
+
+// HealthResponse is the status of a probe, with the failed check of an
+// unready server.
+type HealthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HealthHandler serves the /healthz and /readyz probes.
+type HealthHandler struct {
+	db       *gorm.DB
+	migrator *database.Migrator
+}
+
+// NewHealthHandler returns the health handler of a database.
+func NewHealthHandler(db *gorm.DB) (*HealthHandler, error) {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthHandler{db: db, migrator: migrator}, nil
+}
+
+// Live handles GET /healthz
+// @Summary Liveness probe
+// @Description Report that the server is running. It does not check the database, so that a database outage does not restart the server.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Router /healthz [get]
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Ready handles GET /readyz
+// @Summary Readiness probe
+// @Description Report whether the server can serve requests: the database answers and every migration is applied.
+// @Tags health
+// @Produce json
+// @Success 200 {object} HealthResponse
+// @Failure 503 {object} HealthResponse
+// @Router /readyz [get]
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if err := h.check(c.Request.Context()); err != nil {
+		c.JSON(http.StatusServiceUnavailable, HealthResponse{Status: "unavailable", Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// check returns why the server cannot serve requests, if it cannot.
+func (h *HealthHandler) check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+	return h.migrator.WithContext(ctx).CheckApplied()
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHealthProbes(t *testing.T) {
+	db := testDatabase(t)
+	health, err := NewHealthHandler(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	r.GET("/healthz", health.Live)
+	r.GET("/readyz", health.Ready)
+	probe := func(path string) int {
+		req, _ := http.NewRequest("GET", path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := probe("/readyz"); code != http.StatusOK {
+		t.Errorf("expected a migrated database to be ready, got %d", code)
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Down(1); err != nil {
+		t.Fatal(err)
+	}
+	if code := probe("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d with a pending migration, got %d", http.StatusServiceUnavailable, code)
+	}
+	if code := probe("/healthz"); code != http.StatusOK {
+		t.Errorf("expected the server to stay live, got %d", code)
+	}
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// HolidayHandler serves the /holidays routes.
+type HolidayHandler struct {
+	store    repository.Store
+	holidays *service.Holidays
+}
+
+// NewHolidayHandler returns the holiday handler of the store.
+func NewHolidayHandler(store repository.Store) *HolidayHandler {
+	return &HolidayHandler{store: store, holidays: service.NewHolidays(store)}
+}
+
+// Create handles POST /holidays
+// @Summary Create a new holiday
+// @Description Create a new holiday, on which assignments earn the holiday multiplier of their duty. Only administrators can manage holidays.
+// @Tags holidays
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param holiday body models.Holiday true "Holiday"
+// @Success 201 {object} models.Holiday
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /holidays [post]
+func (h *HolidayHandler) Create(c *gin.Context) {
+	var holiday models.Holiday
+	if err := c.ShouldBindJSON(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	_, admin := actor(c, h.store.Conscripts())
+	if err := h.holidays.Create(&holiday, admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, holiday)
+}
+
+// List handles GET /holidays
+// @Summary List all holidays
+// @Description Get a list of all holidays by date
+// @Tags holidays
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Holiday
+// @Failure 500 {object} models.ErrorResponse
+// @Router /holidays [get]
+func (h *HolidayHandler) List(c *gin.Context) {
+	holidays, err := h.holidays.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, holidays)
+}
+
+// Update handles PUT /holidays/:id
+// @Summary Update a holiday
+// @Description Update a holiday by its ID. Only administrators can manage holidays.
+// @Tags holidays
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Holiday ID"
+// @Param holiday body models.Holiday true "Holiday"
+// @Success 200 {object} models.Holiday
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /holidays/{id} [put]
+func (h *HolidayHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid holiday ID")
+	if !ok {
+		return
+	}
+	holiday, err := h.holidays.Get(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := c.ShouldBindJSON(&holiday); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	holiday.ID = id
+	_, admin := actor(c, h.store.Conscripts())
+	if err := h.holidays.Replace(&holiday, admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, holiday)
+}
+
+// Delete handles DELETE /holidays/:id
+// @Summary Delete a holiday
+// @Description Delete a holiday by its ID. Only administrators can manage holidays.
+// @Tags holidays
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Holiday ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /holidays/{id} [delete]
+func (h *HolidayHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid holiday ID")
+	if !ok {
+		return
+	}
+	_, admin := actor(c, h.store.Conscripts())
+	if err := h.holidays.Delete(id, admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/alexandrosraikos/pixis/importer"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// ImportHandler serves the /imports routes.
+type ImportHandler struct {
+	store repository.Store
+}
+
+// NewImportHandler returns the import handler of the store.
+func NewImportHandler(store repository.Store) *ImportHandler {
+	return &ImportHandler{store: store}
+}
+
+// Conscripts handles POST /imports/conscripts
+// @Summary Import a conscript intake
+// @Description Upload a CSV or XLSX intake and get a validation report. Nothing is stored unless commit is true and every row is valid, in which case all conscripts are created in one transaction. The mapping field maps conscript fields (first_name, last_name, registry_number, username, password, department) to column headers; departments are matched by label.
+// @Tags imports
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Intake spreadsheet"
+// @Param format formData string false "File format (csv or xlsx), guessed from the file name by default"
+// @Param mapping formData string false "Column mapping as JSON, e.g. {\"registry_number\":\"AM\"}"
+// @Param commit formData bool false "Create the conscripts instead of only validating them"
+// @Success 200 {object} importer.Report
+// @Success 201 {object} importer.Report
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} importer.Report
+// @Failure 500 {object} models.ErrorResponse
+// @Router /imports/conscripts [post]
+func (h *ImportHandler) Conscripts(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Missing file"})
+		return
+	}
+	var format importer.Format
+	if name := c.PostForm("format"); name != "" {
+		format, err = importer.ParseFormat(name)
+	} else {
+		format, err = importer.FormatFromFilename(header.Filename)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	var mapping importer.ColumnMapping
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid mapping: " + err.Error()})
+			return
+		}
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	defer file.Close()
+	rows, err := importer.ReadRows(file, format)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+
+	report, err := importer.Validate(h.store, rows, mapping)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if c.PostForm("commit") != "true" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+	if !report.Valid() {
+		c.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+	if err := importer.Commit(h.store, report); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
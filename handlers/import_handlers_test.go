@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexandrosraikos/pixis/importer"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+func setupImportRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	r.POST("/imports/conscripts", h.Imports.Conscripts)
+	return r
+}
+
+func beforeEachImport(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := setupImportRouter(db)
+	dept := MockDepartment
+	if err := db.Create(&dept).Error; err != nil {
+		t.Fatalf("failed to create department: %v", err)
+	}
+	existing := models.Conscript{
+		FirstName:      "Existing",
+		LastName:       "Conscript",
+		RegistryNumber: "1000",
+		Username:       "existing",
+		DepartmentID:   dept.ID,
+	}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("failed to create conscript: %v", err)
+	}
+	return r
+}
+
+func newImportRequest(t *testing.T, filename string, content []byte, fields map[string]string) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	part.Write(content)
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+	req, _ := http.NewRequest("POST", "/imports/conscripts", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestImportConscriptsDryRunReport(t *testing.T) {
+	db := testDatabase(t)
+	r := beforeEachImport(t, db)
+	csv := "Name,Surname,AM,Unit\n" +
+		"Nikos,Papadopoulos,2001,Test Department\n" +
+		"Giorgos,Ioannou,2001,Test Department\n" +
+		"Kostas,Georgiou,1000,Test Department\n" +
+		"Petros,Dimitriou,2002,Unknown Unit\n"
+	mapping := `{"first_name":"Name","last_name":"Surname","registry_number":"AM","department":"Unit"}`
+	req := newImportRequest(t, "intake.csv", []byte(csv), map[string]string{"mapping": mapping})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	var report importer.Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Errorf("failed to unmarshal response: %v", err)
+	}
+	if report.Total != 4 || report.Invalid != 3 {
+		t.Errorf("expected 4 rows with 3 invalid, got %d with %d invalid", report.Total, report.Invalid)
+	}
+	if len(report.Rows[0].Errors) != 0 {
+		t.Errorf("expected first row to be valid, got %v", report.Rows[0].Errors)
+	}
+	var count int64
+	db.Model(&models.Conscript{}).Count(&count)
+	if count != 1 {
+		t.Errorf("expected dry run to create nothing, got %d conscripts", count)
+	}
+
+	req = newImportRequest(t, "intake.csv", []byte(csv), map[string]string{"mapping": mapping, "commit": "true"})
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status %d, got %d", http.StatusUnprocessableEntity, w.Code)
+	}
+}
+
+func TestImportConscriptsCommitXLSX(t *testing.T) {
+	db := testDatabase(t)
+	r := beforeEachImport(t, db)
+	workbook := excelize.NewFile()
+	rows := [][]any{
+		{"first_name", "last_name", "registry_number", "department"},
+		{"Nikos", "Papadopoulos", "2001", "test department"},
+		{"Giorgos", "Ioannou", "2002", "Test Department"},
+	}
+	for i, row := range rows {
+		cell, _ := excelize.CoordinatesToCellName(1, i+1)
+		workbook.SetSheetRow("Sheet1", cell, &row)
+	}
+	var content bytes.Buffer
+	if err := workbook.Write(&content); err != nil {
+		t.Fatalf("failed to write workbook: %v", err)
+	}
+
+	req := newImportRequest(t, "intake.xlsx", content.Bytes(), map[string]string{"commit": "true"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var created models.Conscript
+	if err := db.Where("registry_number = ?", "2002").First(&created).Error; err != nil {
+		t.Fatalf("expected conscript to be imported: %v", err)
+	}
+	if created.Username != "2002" || created.DepartmentID == 0 {
+		t.Errorf("expected username to default to registry number and department to be set, got %+v", created)
+	}
+}
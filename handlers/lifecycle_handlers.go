@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+)
+
+// TransferRequest is the request body of POST /conscripts/:id/transfer.
+type TransferRequest struct {
+	DepartmentID uint       `json:"department_id" binding:"required"`
+	Date         *time.Time `json:"date"`
+	Reason       string     `json:"reason"`
+}
+
+// DischargeRequest is the request body of POST /conscripts/:id/discharge.
+type DischargeRequest struct {
+	Date   *time.Time `json:"date"`
+	Reason string     `json:"reason"`
+}
+
+// DischargeResponse is the discharged conscript with the assignments they
+// hold after their discharge, which need to be reassigned.
+type DischargeResponse struct {
+	Conscript         models.Conscript       `json:"conscript"`
+	FutureAssignments []models.ConscriptDuty `json:"future_assignments"`
+}
+
+// Transfer handles POST /conscripts/:id/transfer
+// @Summary Transfer a conscript to another department
+// @Description Move a conscript to another department from date, by default now. The membership of the previous department is kept in the history of the conscript. Only administrators can transfer conscripts.
+// @Tags conscripts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Param transfer body TransferRequest true "Transfer"
+// @Success 200 {object} models.Conscript
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/transfer [post]
+func (h *ConscriptHandler) Transfer(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	var req TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+	_, admin := actor(c, h.store.Conscripts())
+	conscript, err := h.conscripts.Transfer(id, req.DepartmentID, date, req.Reason, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conscript)
+}
+
+// Discharge handles POST /conscripts/:id/discharge
+// @Summary Discharge a conscript
+// @Description Discharge a conscript from date, by default now, ending their department membership. Discharged conscripts can no longer log in or be assigned duties ending after their discharge; the assignments they already hold after it are returned and reported by GET /assignments/conflicts. Only administrators can discharge conscripts.
+// @Tags conscripts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Param discharge body DischargeRequest false "Discharge"
+// @Success 200 {object} DischargeResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/discharge [post]
+func (h *ConscriptHandler) Discharge(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	var req DischargeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+			return
+		}
+	}
+	date := time.Now()
+	if req.Date != nil {
+		date = *req.Date
+	}
+	_, admin := actor(c, h.store.Conscripts())
+	conscript, future, err := h.conscripts.Discharge(id, date, req.Reason, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, DischargeResponse{Conscript: conscript, FutureAssignments: future})
+}
+
+// Memberships handles GET /conscripts/:id/memberships
+// @Summary Department history of a conscript
+// @Description List the departments a conscript belonged to, oldest first, from their enlistment or creation.
+// @Tags conscripts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Success 200 {array} models.DepartmentMembership
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/memberships [get]
+func (h *ConscriptHandler) Memberships(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	memberships, err := h.conscripts.Memberships(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, memberships)
+}
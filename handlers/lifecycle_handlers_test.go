@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/passwords"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupLifecycleRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	r.POST("/auth/login", h.Auth.Login)
+	auth := r.Group("", h.Auth.Middleware())
+	auth.POST("/conscripts", h.Conscripts.Create)
+	auth.GET("/conscripts", h.Conscripts.List)
+	auth.PUT("/conscripts/:id", h.Conscripts.Update)
+	auth.GET("/departments/:id", h.Departments.Get)
+	auth.POST("/conscripts/:id/transfer", h.Conscripts.Transfer)
+	auth.POST("/conscripts/:id/discharge", h.Conscripts.Discharge)
+	auth.GET("/conscripts/:id/memberships", h.Conscripts.Memberships)
+	auth.POST("/assignments", h.Assignments.Create)
+	return r
+}
+
+func beforeEachLifecycle(t *testing.T, db *gorm.DB) (*gin.Engine, []models.Department, models.Conscript, models.Conscript) {
+	r := setupLifecycleRouter(db)
+	var departments []models.Department
+	for i := 0; i < 2; i++ {
+		department := models.Department{Label: fmt.Sprintf("Lifecycle company %d", i)}
+		db.Create(&department)
+		departments = append(departments, department)
+	}
+	enlisted := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	hash, _ := passwords.Hash("secret")
+	conscript := models.Conscript{RegistryNumber: "lifecycle", Username: "lifecycle", Password: hash, Rank: "Private", EnlistmentDate: &enlisted, DepartmentID: departments[0].ID}
+	db.Create(&conscript)
+	admin := models.Conscript{RegistryNumber: "lifecycle-admin", Username: "lifecycle-admin", Role: models.RoleAdmin}
+	db.Create(&admin)
+	return r, departments, conscript, admin
+}
+
+func TestTransferConscript(t *testing.T) {
+	r, departments, conscript, admin := beforeEachLifecycle(t, testDatabase(t))
+	path := fmt.Sprintf("/conscripts/%d/transfer", conscript.ID)
+	date := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	transfer := TransferRequest{DepartmentID: departments[1].ID, Date: &date, Reason: "Reinforcement"}
+
+	if w := sendAuthRequest(r, "POST", path, transfer, conscript.ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a conscript, got %d", http.StatusForbidden, w.Code)
+	}
+	w := sendAuthRequest(r, "POST", path, transfer, admin.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var moved models.Conscript
+	json.Unmarshal(w.Body.Bytes(), &moved)
+	if moved.DepartmentID != departments[1].ID || moved.Status != models.StatusActive {
+		t.Errorf("expected an active conscript in the new department, got %+v", moved)
+	}
+	if w := sendAuthRequest(r, "POST", path, transfer, admin.ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for the same department, got %d", http.StatusConflict, w.Code)
+	}
+
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts/%d/memberships", conscript.ID), nil, admin.ID)
+	var memberships []models.DepartmentMembership
+	json.Unmarshal(w.Body.Bytes(), &memberships)
+	if len(memberships) != 2 {
+		t.Fatalf("expected the previous and current memberships, got %+v", memberships)
+	}
+	if memberships[0].DepartmentID != departments[0].ID || !memberships[0].StartDate.Equal(*conscript.EnlistmentDate) || memberships[0].EndDate == nil || !memberships[0].EndDate.Equal(date) {
+		t.Errorf("expected the first department from enlistment until the transfer, got %+v", memberships[0])
+	}
+	if memberships[1].DepartmentID != departments[1].ID || memberships[1].EndDate != nil || memberships[1].Reason != "Reinforcement" {
+		t.Errorf("expected an open membership of the new department, got %+v", memberships[1])
+	}
+}
+
+func TestMembershipAsOf(t *testing.T) {
+	db := testDatabase(t)
+	r, departments, conscript, admin := beforeEachLifecycle(t, db)
+	date := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	transfer := TransferRequest{DepartmentID: departments[1].ID, Date: &date}
+	if w := sendAuthRequest(r, "POST", fmt.Sprintf("/conscripts/%d/transfer", conscript.ID), transfer, admin.ID); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	list := func(query string) []models.Conscript {
+		w := sendAuthRequest(r, "GET", "/conscripts?"+query, nil, admin.ID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var conscripts []models.Conscript
+		json.Unmarshal(w.Body.Bytes(), &conscripts)
+		return conscripts
+	}
+	if result := list("as_of=2025-03-01"); len(result) != 1 || result[0].DepartmentID != departments[0].ID {
+		t.Errorf("expected the conscript in the first department before the transfer, got %+v", result)
+	}
+	if result := list(fmt.Sprintf("as_of=2025-05-01&department_id=%d", departments[0].ID)); len(result) != 0 {
+		t.Errorf("expected nobody in the first department after the transfer, got %+v", result)
+	}
+	if result := list("as_of=2025-01-01"); len(result) != 0 {
+		t.Errorf("expected nobody before the enlistment, got %+v", result)
+	}
+	if result := list(fmt.Sprintf("department_id=%d", departments[1].ID)); len(result) != 1 || result[0].ID != conscript.ID {
+		t.Errorf("expected the conscript in the second department now, got %+v", result)
+	}
+	if w := sendAuthRequest(r, "GET", "/conscripts?as_of=March", nil, admin.ID); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d for an invalid time, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	var department models.Department
+	w := sendAuthRequest(r, "GET", fmt.Sprintf("/departments/%d?as_of=2025-03-01", departments[0].ID), nil, admin.ID)
+	json.Unmarshal(w.Body.Bytes(), &department)
+	if len(department.Conscripts) != 1 || department.Conscripts[0].ID != conscript.ID {
+		t.Errorf("expected the conscript in the first department before the transfer, got %+v", department.Conscripts)
+	}
+
+	// Changing the department directly is recorded as a transfer.
+	update := models.Conscript{DepartmentID: departments[0].ID}
+	if w := sendAuthRequest(r, "PUT", fmt.Sprintf("/conscripts/%d", conscript.ID), update, admin.ID); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var memberships []models.DepartmentMembership
+	db.Where("conscript_id = ?", conscript.ID).Order("start_date, id").Find(&memberships)
+	if len(memberships) != 3 || memberships[1].EndDate == nil || memberships[2].DepartmentID != departments[0].ID || memberships[2].EndDate != nil {
+		t.Errorf("expected the update to end the second membership and start a third, got %+v", memberships)
+	}
+}
+
+func TestDischargeConscript(t *testing.T) {
+	db := testDatabase(t)
+	r, _, conscript, admin := beforeEachLifecycle(t, db)
+	duty := models.Duty{Label: "Lifecycle duty"}
+	db.Create(&duty)
+	date := time.Now().UTC().Truncate(time.Hour).Add(24 * time.Hour)
+	before := models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: duty.ID, StartTime: date.Add(-12 * time.Hour), EndTime: date.Add(-4 * time.Hour)}
+	after := models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: duty.ID, StartTime: date.Add(48 * time.Hour), EndTime: date.Add(56 * time.Hour)}
+	db.Create(&before)
+	db.Create(&after)
+
+	w := sendAuthRequest(r, "POST", fmt.Sprintf("/conscripts/%d/discharge", conscript.ID), DischargeRequest{Date: &date}, admin.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	var resp DischargeResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Conscript.Status != models.StatusDischarged || len(resp.FutureAssignments) != 1 || resp.FutureAssignments[0].ID != after.ID {
+		t.Errorf("expected a discharged conscript with one assignment to reassign, got %+v", resp)
+	}
+	if w := sendAuthRequest(r, "POST", fmt.Sprintf("/conscripts/%d/discharge", conscript.ID), nil, admin.ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a second discharge, got %d", http.StatusConflict, w.Code)
+	}
+
+	if w := sendAuthRequest(r, "POST", "/auth/login", LoginRequest{Username: "lifecycle", Password: "secret"}, 0); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for logging in, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts/%d/memberships", conscript.ID), nil, conscript.ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for an existing token, got %d", http.StatusForbidden, w.Code)
+	}
+
+	assignment := models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: duty.ID, StartTime: date.Add(24 * time.Hour), EndTime: date.Add(30 * time.Hour)}
+	w = sendAuthRequest(r, "POST", "/assignments", assignment, admin.ID)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var conflict ConflictResponse
+	json.Unmarshal(w.Body.Bytes(), &conflict)
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0].Kind != conflicts.KindDischarge {
+		t.Errorf("expected a discharge conflict, got %+v", conflict.Conflicts)
+	}
+
+	found, _ := service.NewAssignments(repository.NewGormStore(db)).Report(date.Add(-24*time.Hour), date.Add(72*time.Hour))
+	flagged := 0
+	for _, c := range found {
+		if c.Kind == conflicts.KindDischarge {
+			flagged++
+			if len(c.Assignments) != 1 || c.Assignments[0].ID != after.ID {
+				t.Errorf("expected only the assignment after the discharge to be flagged, got %+v", c.Assignments)
+			}
+		}
+	}
+	if flagged != 1 {
+		t.Errorf("expected the assignments after the discharge to be flagged, got %+v", found)
+	}
+}
+
+func TestConscriptStatus(t *testing.T) {
+	r, _, _, admin := beforeEachLifecycle(t, testDatabase(t))
+	for status, code := range map[models.ConscriptStatus]int{
+		models.StatusOnLeave:    http.StatusCreated,
+		models.StatusDischarged: http.StatusBadRequest,
+		"deserted":              http.StatusBadRequest,
+	} {
+		conscript := models.Conscript{RegistryNumber: "status-" + string(status), Username: "status-" + string(status), Status: status}
+		if w := sendAuthRequest(r, "POST", "/conscripts", conscript, admin.ID); w.Code != code {
+			t.Errorf("status %s: expected %d, got %d", status, code, w.Code)
+		}
+	}
+}
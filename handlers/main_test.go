@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TestMain runs the tests against SQLite and then again against every other
+// supported database that can be reached, see database.TestBackends.
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	ConfigureAuth("test-secret-of-at-least-thirty-two-bytes", 24*time.Hour)
+	code := m.Run()
+	for _, backend := range database.TestBackends() {
+		if err := database.Ping(backend.DSN); err != nil {
+			fmt.Printf("skipping the %s tests: %v\n", backend.Dialect, err)
+			continue
+		}
+		fmt.Printf("running the tests against %s\n", backend.Dialect)
+		os.Setenv(database.TestDatabaseEnv, backend.DSN)
+		if result := m.Run(); result != 0 {
+			code = result
+		}
+	}
+	os.Unsetenv(database.TestDatabaseEnv)
+	os.Exit(code)
+}
+
+// testDatabase returns a fresh database for the test, see
+// database.OpenTestDatabase, closed when the test ends. Tests with a
+// database of their own run in parallel.
+func testDatabase(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, shared, err := database.OpenTestDatabase(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if !shared {
+		t.Parallel()
+	}
+	return db
+}
+
+// testHandlers returns the handlers over the test database.
+func testHandlers(db *gorm.DB) *Handlers {
+	return New(repository.NewGormStore(db))
+}
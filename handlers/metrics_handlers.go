@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/metrics"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CollectDomain returns the collector of the state of the units for the
+// metrics: the active conscripts of each department and the places left to
+// staff in the shifts of the next metrics.SlotWindow.
+func CollectDomain(db *gorm.DB) func() (metrics.Domain, error) {
+	return func() (metrics.Domain, error) {
+		var domain metrics.Domain
+		err := db.Model(&models.Conscript{}).
+			Select("departments.id AS department_id, departments.label AS department, COUNT(*) AS count").
+			Joins("JOIN departments ON departments.id = conscripts.department_id").
+			Where("conscripts.status = ? AND conscripts.disabled_at IS NULL", models.StatusActive).
+			Group("departments.id, departments.label").
+			Order("departments.id").
+			Scan(&domain.ActiveConscripts).Error
+		if err != nil {
+			return metrics.Domain{}, err
+		}
+		from := time.Now()
+		slots, err := service.NewShiftTemplates(repository.NewGormStore(db)).Slots(repository.ShiftTemplateFilter{}, from, from.Add(metrics.SlotWindow))
+		if err != nil {
+			return metrics.Domain{}, err
+		}
+		for _, slot := range slots {
+			domain.OpenSlots += slot.Open
+		}
+		return domain, nil
+	}
+}
+
+// MetricsTokenMiddleware requires the bearer token of the metrics endpoint,
+// unless it is empty.
+func MetricsTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			return
+		}
+		given, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.ErrorResponse{Error: "Invalid metrics token"})
+			return
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCollectDomain(t *testing.T) {
+	db := testDatabase(t)
+	platoon := models.Department{Label: "1st Platoon"}
+	db.Create(&platoon)
+	now := time.Now()
+	db.Create(&[]models.Conscript{
+		{Username: "active", RegistryNumber: "1", DepartmentID: platoon.ID},
+		{Username: "on_leave", RegistryNumber: "2", DepartmentID: platoon.ID, Status: models.StatusOnLeave},
+		{Username: "disabled", RegistryNumber: "3", DepartmentID: platoon.ID, DisabledAt: &now},
+	})
+	duty := models.Duty{Label: "Gate guard", Capacity: 2}
+	db.Create(&duty)
+	tomorrow := now.UTC().AddDate(0, 0, 1)
+	db.Create(&models.ShiftTemplate{DutyID: duty.ID, DTStart: tomorrow.Format("2006-01-02") + "T08:00", TimeZone: "UTC", DurationMinutes: 480, RRule: "FREQ=DAILY;COUNT=3"})
+	start := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 8, 0, 0, 0, time.UTC)
+	db.Create(&models.ConscriptDuty{ConscriptID: 1, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+
+	domain, err := CollectDomain(db)()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domain.ActiveConscripts) != 1 || domain.ActiveConscripts[0].Department != "1st Platoon" || domain.ActiveConscripts[0].Count != 1 {
+		t.Errorf("expected the active conscript of the platoon, got %+v", domain.ActiveConscripts)
+	}
+	if domain.OpenSlots != 5 {
+		t.Errorf("expected 5 open places in 3 shifts of 2 with 1 assignment, got %d", domain.OpenSlots)
+	}
+}
+
+func TestMetricsToken(t *testing.T) {
+	for token, expected := range map[string]int{"": http.StatusOK, "secret": http.StatusUnauthorized} {
+		r := gin.New()
+		r.GET("/metrics", MetricsTokenMiddleware(token), func(c *gin.Context) { c.Status(http.StatusOK) })
+		req, _ := http.NewRequest("GET", "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != expected {
+			t.Errorf("expected status %d with the token %q, got %d", expected, token, w.Code)
+		}
+	}
+}
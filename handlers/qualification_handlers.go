@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// GrantQualificationRequest is the request body of PUT /conscripts/:id/qualifications/:qualification_id.
+type GrantQualificationRequest struct {
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// DutyQualificationsRequest is the request body of PUT /duties/:id/qualifications.
+type DutyQualificationsRequest struct {
+	QualificationIDs []uint `json:"qualification_ids"`
+}
+
+// QualificationHandler serves the /qualifications routes and those of the
+// qualifications of conscripts and duties.
+type QualificationHandler struct {
+	store          repository.Store
+	qualifications *service.Qualifications
+}
+
+// NewQualificationHandler returns the qualification handler of the store.
+func NewQualificationHandler(store repository.Store) *QualificationHandler {
+	return &QualificationHandler{store: store, qualifications: service.NewQualifications(store)}
+}
+
+// Create handles POST /qualifications
+// @Summary Create a new qualification
+// @Description Add a qualification to the catalogue
+// @Tags qualifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param qualification body models.Qualification true "Qualification"
+// @Success 201 {object} models.Qualification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /qualifications [post]
+func (h *QualificationHandler) Create(c *gin.Context) {
+	var qualification models.Qualification
+	if err := c.ShouldBindJSON(&qualification); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := h.qualifications.Create(&qualification); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, qualification)
+}
+
+// List handles GET /qualifications
+// @Summary List qualifications
+// @Description List the qualifications catalogue
+// @Tags qualifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.Qualification
+// @Failure 500 {object} models.ErrorResponse
+// @Router /qualifications [get]
+func (h *QualificationHandler) List(c *gin.Context) {
+	qualifications, err := h.qualifications.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, qualifications)
+}
+
+// Get handles GET /qualifications/:id
+// @Summary Get a qualification by ID
+// @Description Get a qualification by its ID
+// @Tags qualifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Qualification ID"
+// @Success 200 {object} models.Qualification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /qualifications/{id} [get]
+func (h *QualificationHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid qualification ID")
+	if !ok {
+		return
+	}
+	qualification, err := h.qualifications.Get(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, qualification)
+}
+
+// Update handles PUT /qualifications/:id
+// @Summary Update a qualification
+// @Description Update a qualification by its ID
+// @Tags qualifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Qualification ID"
+// @Param qualification body models.Qualification true "Qualification"
+// @Success 200 {object} models.Qualification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /qualifications/{id} [put]
+func (h *QualificationHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid qualification ID")
+	if !ok {
+		return
+	}
+	qualification, err := h.qualifications.Get(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := c.ShouldBindJSON(&qualification); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	qualification.ID = id
+	if err := h.qualifications.Replace(&qualification); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, qualification)
+}
+
+// Delete handles DELETE /qualifications/:id
+// @Summary Delete a qualification
+// @Description Delete a qualification by its ID, removing it from the conscripts holding it and the duties requiring it
+// @Tags qualifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Qualification ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /qualifications/{id} [delete]
+func (h *QualificationHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid qualification ID")
+	if !ok {
+		return
+	}
+	if err := h.qualifications.Delete(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Held handles GET /conscripts/:id/qualifications
+// @Summary List the qualifications of a conscript
+// @Description List the qualifications held by a conscript with their validity, optionally only those valid at a point in time
+// @Tags qualifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Param at query string false "Only qualifications valid at this time (RFC 3339 or YYYY-MM-DD)"
+// @Success 200 {array} models.ConscriptQualification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/qualifications [get]
+func (h *QualificationHandler) Held(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	var at *time.Time
+	if value := c.Query("at"); value != "" {
+		parsed, _, err := parseDateOrTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid at: " + err.Error()})
+			return
+		}
+		at = &parsed
+	}
+	held, err := h.qualifications.Held(id, at)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, held)
+}
+
+// Grant handles PUT /conscripts/:id/qualifications/:qualification_id
+// @Summary Grant or renew a qualification
+// @Description Grant a qualification to a conscript from valid_from, by default now, until valid_until, or indefinitely when it is omitted. Granting a qualification the conscript already holds renews it with the new validity. Only administrators can grant qualifications.
+// @Tags qualifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Param qualification_id path int true "Qualification ID"
+// @Param validity body GrantQualificationRequest true "Validity"
+// @Success 200 {object} models.ConscriptQualification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/qualifications/{qualification_id} [put]
+func (h *QualificationHandler) Grant(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	qualificationID, err := strconv.ParseUint(c.Param("qualification_id"), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid qualification ID"})
+		return
+	}
+	var req GrantQualificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	validFrom := time.Now()
+	if req.ValidFrom != nil {
+		validFrom = *req.ValidFrom
+	}
+	_, admin := actor(c, h.store.Conscripts())
+	held, err := h.qualifications.Grant(id, uint(qualificationID), validFrom, req.ValidUntil, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, held)
+}
+
+// Revoke handles DELETE /conscripts/:id/qualifications/:qualification_id
+// @Summary Revoke a qualification
+// @Description Remove a qualification from a conscript. Only administrators can revoke qualifications.
+// @Tags qualifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Param qualification_id path int true "Qualification ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/qualifications/{qualification_id} [delete]
+func (h *QualificationHandler) Revoke(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	qualificationID, err := strconv.ParseUint(c.Param("qualification_id"), 10, 0)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid qualification ID"})
+		return
+	}
+	_, admin := actor(c, h.store.Conscripts())
+	if err := h.qualifications.Revoke(id, uint(qualificationID), admin); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// SetRequired handles PUT /duties/:id/qualifications
+// @Summary Set the qualifications a duty requires
+// @Description Replace the qualifications required to be assigned a duty. An empty list lifts all requirements.
+// @Tags qualifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Duty ID"
+// @Param qualifications body DutyQualificationsRequest true "Required qualifications"
+// @Success 200 {array} models.Qualification
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /duties/{id}/qualifications [put]
+func (h *QualificationHandler) SetRequired(c *gin.Context) {
+	id, ok := parseID(c, "Invalid duty ID")
+	if !ok {
+		return
+	}
+	var req DutyQualificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	qualifications, err := h.qualifications.SetRequired(id, req.QualificationIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, qualifications)
+}
+
+// Eligible handles GET /duties/:id/eligible-conscripts
+// @Summary List the conscripts eligible for a duty
+// @Description List the conscripts holding every qualification a duty requires throughout a period, by default now. Dates are given as YYYY-MM-DD or RFC 3339. With available, conscripts who could not be assigned the duty for the period because of other duties, rest or approved absences are left out too.
+// @Tags qualifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Duty ID"
+// @Param from query string false "Start of the period"
+// @Param to query string false "End of the period (inclusive when a date)"
+// @Param department_id query int false "Only conscripts of this department"
+// @Param available query bool false "Leave out conscripts with conflicting duties or absences"
+// @Success 200 {array} models.Conscript
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /duties/{id}/eligible-conscripts [get]
+func (h *QualificationHandler) Eligible(c *gin.Context) {
+	id, ok := parseID(c, "Invalid duty ID")
+	if !ok {
+		return
+	}
+	now := time.Now()
+	from, to, err := parseOptionalRange(c, now, now.Add(time.Second))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	var departmentID uint
+	if value, err := strconv.ParseUint(c.Query("department_id"), 10, 0); err == nil {
+		departmentID = uint(value)
+	}
+	conscripts, err := h.qualifications.Eligible(id, from, to, departmentID, c.Query("available") == "true")
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, conscripts)
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupQualificationRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	auth := r.Group("", h.Auth.Middleware())
+	auth.POST("/qualifications", h.Qualifications.Create)
+	auth.GET("/qualifications", h.Qualifications.List)
+	auth.DELETE("/qualifications/:id", h.Qualifications.Delete)
+	auth.GET("/conscripts/:id/qualifications", h.Qualifications.Held)
+	auth.PUT("/conscripts/:id/qualifications/:qualification_id", h.Qualifications.Grant)
+	auth.DELETE("/conscripts/:id/qualifications/:qualification_id", h.Qualifications.Revoke)
+	auth.PUT("/duties/:id/qualifications", h.Qualifications.SetRequired)
+	auth.GET("/duties/:id", h.Duties.Get)
+	auth.GET("/duties/:id/eligible-conscripts", h.Qualifications.Eligible)
+	auth.POST("/assignments", h.Assignments.Create)
+	return r
+}
+
+// beforeEachQualification creates a driver duty requiring a driving licence,
+// held by the first of two conscripts until the end of March 2025.
+func beforeEachQualification(t *testing.T, db *gorm.DB) (*gin.Engine, models.Duty, []models.Conscript, models.Conscript) {
+	r := setupQualificationRouter(db)
+	admin := models.Conscript{RegistryNumber: "qualification-admin", Username: "qualification-admin", Role: models.RoleAdmin}
+	db.Create(&admin)
+	var conscripts []models.Conscript
+	for i := 0; i < 2; i++ {
+		conscript := models.Conscript{RegistryNumber: fmt.Sprintf("qualification-%d", i), Username: fmt.Sprintf("qualification-%d", i)}
+		db.Create(&conscript)
+		conscripts = append(conscripts, conscript)
+	}
+	duty := models.Duty{Label: "Driver"}
+	db.Create(&duty)
+
+	w := sendAuthRequest(r, "POST", "/qualifications", models.Qualification{Label: "Driving licence"}, admin.ID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	var licence models.Qualification
+	json.Unmarshal(w.Body.Bytes(), &licence)
+	if w := sendAuthRequest(r, "PUT", fmt.Sprintf("/duties/%d/qualifications", duty.ID), DutyQualificationsRequest{QualificationIDs: []uint{licence.ID}}, admin.ID); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	from, until := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	grant := GrantQualificationRequest{ValidFrom: &from, ValidUntil: &until}
+	path := fmt.Sprintf("/conscripts/%d/qualifications/%d", conscripts[0].ID, licence.ID)
+	if w := sendAuthRequest(r, "PUT", path, grant, conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a conscript granting themselves, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := sendAuthRequest(r, "PUT", path, grant, admin.ID); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	return r, duty, conscripts, admin
+}
+
+func TestDutyQualifications(t *testing.T) {
+	r, duty, conscripts, admin := beforeEachQualification(t, testDatabase(t))
+
+	w := sendAuthRequest(r, "GET", fmt.Sprintf("/duties/%d", duty.ID), nil, admin.ID)
+	var loaded models.Duty
+	json.Unmarshal(w.Body.Bytes(), &loaded)
+	if len(loaded.Qualifications) != 1 || loaded.Qualifications[0].Label != "Driving licence" {
+		t.Errorf("expected the duty to require a driving licence, got %+v", loaded.Qualifications)
+	}
+	if w := sendAuthRequest(r, "PUT", fmt.Sprintf("/duties/%d/qualifications", duty.ID), DutyQualificationsRequest{QualificationIDs: []uint{99}}, admin.ID); w.Code != http.StatusNotFound {
+		t.Errorf("expected status %d for an unknown qualification, got %d", http.StatusNotFound, w.Code)
+	}
+
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts/%d/qualifications?at=2025-05-01", conscripts[0].ID), nil, admin.ID)
+	var held []models.ConscriptQualification
+	json.Unmarshal(w.Body.Bytes(), &held)
+	if len(held) != 0 {
+		t.Errorf("expected no valid qualifications after expiry, got %+v", held)
+	}
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts/%d/qualifications?at=2025-03-01", conscripts[0].ID), nil, admin.ID)
+	json.Unmarshal(w.Body.Bytes(), &held)
+	if len(held) != 1 || held[0].Qualification.Label != "Driving licence" {
+		t.Errorf("expected the driving licence, got %+v", held)
+	}
+}
+
+func TestAssignmentRequiresQualifications(t *testing.T) {
+	r, duty, conscripts, admin := beforeEachQualification(t, testDatabase(t))
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	assignment := models.ConscriptDuty{ConscriptID: conscripts[1].ID, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)}
+
+	w := sendAuthRequest(r, "POST", "/assignments", assignment, admin.ID)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var resp ConflictResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Conflicts) != 1 || resp.Conflicts[0].Kind != conflicts.KindQualification || len(resp.Conflicts[0].Qualifications) != 1 {
+		t.Errorf("expected a qualification conflict, got %+v", resp.Conflicts)
+	}
+
+	assignment.ConscriptID = conscripts[0].ID
+	if w := sendAuthRequest(r, "POST", "/assignments", assignment, admin.ID); w.Code != http.StatusCreated {
+		t.Errorf("expected status %d for the licensed driver, got %d", http.StatusCreated, w.Code)
+	}
+	// The licence expires before the end of the duty.
+	assignment.StartTime = time.Date(2025, 3, 31, 20, 0, 0, 0, time.UTC)
+	assignment.EndTime = assignment.StartTime.Add(8 * time.Hour)
+	if w := sendAuthRequest(r, "POST", "/assignments", assignment, admin.ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for an expiring licence, got %d", http.StatusConflict, w.Code)
+	}
+}
+
+func TestEligibleConscripts(t *testing.T) {
+	db := testDatabase(t)
+	r, duty, conscripts, admin := beforeEachQualification(t, db)
+	eligible := func(query string) []models.Conscript {
+		w := sendAuthRequest(r, "GET", fmt.Sprintf("/duties/%d/eligible-conscripts?%s", duty.ID, query), nil, admin.ID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var result []models.Conscript
+		json.Unmarshal(w.Body.Bytes(), &result)
+		return result
+	}
+	if result := eligible("from=2025-03-10&to=2025-03-10"); len(result) != 1 || result[0].ID != conscripts[0].ID {
+		t.Errorf("expected only the licensed driver, got %+v", result)
+	}
+	if result := eligible("from=2025-03-10&to=2025-04-10"); len(result) != 0 {
+		t.Errorf("expected nobody past the expiry of the licence, got %+v", result)
+	}
+
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	db.Create(&models.ConscriptDuty{ConscriptID: conscripts[0].ID, DutyID: 99, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	if result := eligible("from=2025-03-10T10:00:00Z&to=2025-03-10T12:00:00Z&available=true"); len(result) != 0 {
+		t.Errorf("expected the busy driver not to be available, got %+v", result)
+	}
+
+	if w := sendAuthRequest(r, "PUT", fmt.Sprintf("/duties/%d/qualifications", duty.ID), DutyQualificationsRequest{}, admin.ID); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if result := eligible("from=2025-03-10&to=2025-03-10"); len(result) != 3 {
+		t.Errorf("expected everyone once the requirements are lifted, got %+v", result)
+	}
+}
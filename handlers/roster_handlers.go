@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/roster"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRosterHistoryDays is how many days of past assignments count towards
+// the points of each conscript when none is requested.
+const defaultRosterHistoryDays = 90
+
+// GenerateRosterRequest describes the roster to generate. Eligible conscripts
+// are the active ones of the department, of the department of the service,
+// each with the departments under it, or with the given IDs; filters that are
+// given are combined.
+type GenerateRosterRequest struct {
+	DepartmentID   uint                    `json:"department_id"`
+	ServiceID      uint                    `json:"service_id"`
+	ConscriptIDs   []uint                  `json:"conscript_ids"`
+	Slots          []roster.Slot           `json:"slots" binding:"required"`
+	MaxPerWeek     int                     `json:"max_per_week"`
+	MinRestHours   *float64                `json:"min_rest_hours"`
+	Unavailability []roster.Unavailability `json:"unavailability"`
+	HistoryDays    int                     `json:"history_days"`
+	TimeZone       string                  `json:"time_zone"`
+}
+
+// RosterHandler serves the /rosters routes.
+type RosterHandler struct {
+	store   repository.Store
+	rosters *service.Rosters
+}
+
+// NewRosterHandler returns the roster handler of the store.
+func NewRosterHandler(store repository.Store) *RosterHandler {
+	return &RosterHandler{store: store, rosters: service.NewRosters(store)}
+}
+
+// CommitRosterRequest carries the assignments of a roster to store.
+type CommitRosterRequest struct {
+	Assignments []models.ConscriptDuty `json:"assignments" binding:"required"`
+}
+
+// Generate handles POST /rosters/generate
+// @Summary Generate a duty roster
+// @Description Preview a roster that staffs the given duty slots with eligible conscripts: the active conscripts of department_id, of the department of service_id, each with the departments under it, or among conscript_ids, combining the filters that are given. Conscripts are never given overlapping duties, always rest at least min_rest_hours (the conflict rest period by default) between duties, do not exceed max_per_week duties per ISO week, counted in time_zone (an IANA name, UTC by default), and are not assigned while unavailable, during approved absences or to duties whose qualifications they do not hold. Each seat goes to the conscript with the fewest duty points, with weekends and nights also told apart in time_zone, over the last history_days (90 by default) and the roster so far. Nothing is stored; commit the returned assignments with POST /rosters/commit.
+// @Tags rosters
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body GenerateRosterRequest true "Roster request"
+// @Success 200 {object} roster.Plan
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /rosters/generate [post]
+func (h *RosterHandler) Generate(c *gin.Context) {
+	var input GenerateRosterRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if input.DepartmentID == 0 && input.ServiceID == 0 && len(input.ConscriptIDs) == 0 {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "One of department_id, service_id or conscript_ids is required"})
+		return
+	}
+	if input.MaxPerWeek < 0 || input.HistoryDays < 0 || (input.MinRestHours != nil && *input.MinRestHours < 0) {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "max_per_week, min_rest_hours and history_days cannot be negative"})
+		return
+	}
+
+	candidates, err := h.rosters.Candidates(input.DepartmentID, input.ServiceID, input.ConscriptIDs)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	req := roster.Request{
+		Slots:       input.Slots,
+		Candidates:  candidates,
+		MinimumRest: conflicts.MinimumRest,
+		MaxPerWeek:  input.MaxPerWeek,
+		Unavailable: input.Unavailability,
+	}
+	location, err := parseTimeZone(input.TimeZone)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	req.Location = location
+	if input.MinRestHours != nil {
+		req.MinimumRest = time.Duration(*input.MinRestHours * float64(time.Hour))
+	}
+	historyDays := input.HistoryDays
+	if historyDays == 0 {
+		historyDays = defaultRosterHistoryDays
+	}
+	req.HistorySince = time.Now().AddDate(0, 0, -historyDays)
+
+	plan, err := h.rosters.Generate(req)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, plan)
+}
+
+// Commit handles POST /rosters/commit
+// @Summary Commit a duty roster
+// @Description Store the assignments of a generated, and possibly edited, roster in one transaction. Only administrators and commanders of the duties can commit a roster, which must not assign them. Every assignment is checked for conflicts with the stored assignments and the earlier assignments of the roster; if any conflicts, nothing is stored, unless an administrator overrides the conflicts, which is recorded.
+// @Tags rosters
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param roster body CommitRosterRequest true "Roster assignments"
+// @Param override query bool false "Commit despite conflicts (administrators only)"
+// @Success 201 {array} models.ConscriptDuty
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 409 {object} ConflictResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /rosters/commit [post]
+func (h *RosterHandler) Commit(c *gin.Context) {
+	var input CommitRosterRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	adminID, ok := overridingAdmin(c, h.store.Conscripts())
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	if err := h.rosters.Commit(input.Assignments, actorID, admin, adminID); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, input.Assignments)
+}
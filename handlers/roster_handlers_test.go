@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/roster"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// setupRosterRouter serves the roster routes to an administrator.
+func setupRosterRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	admin := models.Conscript{RegistryNumber: "roster-admin", Username: "roster-admin", Role: models.RoleAdmin}
+	db.Create(&admin)
+	r := gin.Default()
+	r.Use(func(c *gin.Context) { c.Set(conscriptIDKey, admin.ID) })
+	r.POST("/rosters/generate", h.Rosters.Generate)
+	r.POST("/rosters/commit", h.Rosters.Commit)
+	return r
+}
+
+func beforeEachRoster(t *testing.T, db *gorm.DB) (*gin.Engine, models.Department, []models.Conscript, models.Duty) {
+	r := setupRosterRouter(db)
+	department := models.Department{Label: "Guard company"}
+	db.Create(&department)
+	service := models.Service{Label: "Guard", DepartmentID: department.ID}
+	db.Create(&service)
+	duty := models.Duty{Label: "Gate", ServiceID: service.ID}
+	db.Create(&duty)
+	var conscripts []models.Conscript
+	for i := 0; i < 3; i++ {
+		conscript := models.Conscript{
+			FirstName:      fmt.Sprintf("Roster%d", i),
+			RegistryNumber: fmt.Sprintf("roster-%d", i),
+			Username:       fmt.Sprintf("roster-%d", i),
+			DepartmentID:   department.ID,
+		}
+		db.Create(&conscript)
+		conscripts = append(conscripts, conscript)
+	}
+	// Outside the department, never eligible.
+	db.Create(&models.Conscript{RegistryNumber: "roster-other", Username: "roster-other"})
+	return r, department, conscripts, duty
+}
+
+// rosterDay returns midnight of a day after the current one, so that the
+// roster lies in the future and past assignments count as history.
+func rosterDay(days int) time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day()+days, 0, 0, 0, 0, time.Local)
+}
+
+func generateRoster(t *testing.T, r *gin.Engine, input GenerateRosterRequest) (int, roster.Plan) {
+	jsonValue, _ := json.Marshal(input)
+	req, _ := http.NewRequest("POST", "/rosters/generate", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var plan roster.Plan
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &plan); err != nil {
+			t.Fatalf("failed to unmarshal response: %v", err)
+		}
+	}
+	return w.Code, plan
+}
+
+func TestGenerateRosterBalancesLoad(t *testing.T) {
+	db := testDatabase(t)
+	r, department, conscripts, duty := beforeEachRoster(t, db)
+	// The first conscript has served recently and should be spared.
+	past := rosterDay(-10).Add(8 * time.Hour)
+	db.Create(&models.ConscriptDuty{ConscriptID: conscripts[0].ID, DutyID: duty.ID, StartTime: past, EndTime: past.Add(8 * time.Hour)})
+
+	var slots []roster.Slot
+	for day := 7; day < 11; day++ {
+		start := rosterDay(day).Add(8 * time.Hour)
+		slots = append(slots, roster.Slot{DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)})
+	}
+	status, plan := generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots})
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if len(plan.Assignments) != 4 || len(plan.Unfilled) != 0 {
+		t.Fatalf("expected 4 assignments and no unfilled slots, got %+v", plan)
+	}
+	counts := map[uint]int{}
+	for _, a := range plan.Assignments {
+		counts[a.ConscriptID]++
+	}
+	if counts[conscripts[0].ID] != 1 || counts[conscripts[1].ID] != 2 || counts[conscripts[2].ID] != 1 {
+		t.Errorf("expected the load to be balanced against the history, got %v", counts)
+	}
+	if plan.Assignments[0].ConscriptID == conscripts[0].ID {
+		t.Errorf("expected the first slot to go to a conscript without history")
+	}
+}
+
+func TestGenerateRosterConstraints(t *testing.T) {
+	r, department, conscripts, duty := beforeEachRoster(t, testDatabase(t))
+	start := rosterDay(7).Add(8 * time.Hour)
+	slots := []roster.Slot{
+		{DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour), Count: 2},
+		// Too little rest after the first slot for anyone who took it.
+		{DutyID: duty.ID, StartTime: start.Add(10 * time.Hour), EndTime: start.Add(14 * time.Hour), Count: 2},
+	}
+	unavailable := []roster.Unavailability{{ConscriptID: conscripts[2].ID, StartTime: start, EndTime: start.Add(24 * time.Hour)}}
+	status, plan := generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots, Unavailability: unavailable})
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if len(plan.Assignments) != 2 {
+		t.Fatalf("expected only the first slot to be staffed, got %+v", plan.Assignments)
+	}
+	for _, a := range plan.Assignments {
+		if a.ConscriptID == conscripts[2].ID || !a.StartTime.Equal(start) {
+			t.Errorf("unexpected assignment %+v", a)
+		}
+	}
+	if len(plan.Unfilled) != 1 || plan.Unfilled[0].Missing != 2 {
+		t.Errorf("expected the second slot to be reported unfilled, got %+v", plan.Unfilled)
+	}
+
+	status, _ = generateRoster(t, r, GenerateRosterRequest{Slots: slots})
+	if status != http.StatusBadRequest {
+		t.Errorf("expected status %d without eligible conscripts, got %d", http.StatusBadRequest, status)
+	}
+	status, _ = generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots, TimeZone: "Mars/Olympus"})
+	if status != http.StatusBadRequest {
+		t.Errorf("expected status %d for an unknown time zone, got %d", http.StatusBadRequest, status)
+	}
+}
+
+func TestGenerateRosterSubdepartments(t *testing.T) {
+	db := testDatabase(t)
+	r, department, conscripts, duty := beforeEachRoster(t, db)
+	platoon := models.Department{Label: "Guard platoon", ParentID: &department.ID}
+	db.Create(&platoon)
+	member := models.Conscript{RegistryNumber: "roster-platoon", Username: "roster-platoon", DepartmentID: platoon.ID}
+	db.Create(&member)
+
+	start := rosterDay(7).Add(8 * time.Hour)
+	slots := []roster.Slot{{DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour), Count: len(conscripts) + 2}}
+	status, plan := generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots})
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if len(plan.Assignments) != len(conscripts)+1 || plan.Assignments[len(conscripts)].ConscriptID != member.ID {
+		t.Errorf("expected the conscripts of the department and of the platoon under it, got %+v", plan.Assignments)
+	}
+}
+
+func TestGenerateRosterQualifications(t *testing.T) {
+	db := testDatabase(t)
+	r, department, conscripts, duty := beforeEachRoster(t, db)
+	armed := models.Qualification{Label: "Armed guard"}
+	db.Create(&armed)
+	db.Model(&duty).Association("Qualifications").Append(&armed)
+	db.Create(&models.ConscriptQualification{ConscriptID: conscripts[1].ID, QualificationID: armed.ID})
+
+	start := rosterDay(7).Add(8 * time.Hour)
+	slots := []roster.Slot{{DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour), Count: 2}}
+	status, plan := generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots})
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if len(plan.Assignments) != 1 || plan.Assignments[0].ConscriptID != conscripts[1].ID {
+		t.Errorf("expected only the qualified conscript to be assigned, got %+v", plan.Assignments)
+	}
+	if len(plan.Unfilled) != 1 || plan.Unfilled[0].Missing != 1 {
+		t.Errorf("expected the second seat to be reported unfilled, got %+v", plan.Unfilled)
+	}
+}
+
+func TestGenerateRosterMaxPerWeek(t *testing.T) {
+	r, _, conscripts, duty := beforeEachRoster(t, testDatabase(t))
+	var slots []roster.Slot
+	for day := 0; day < 3; day++ {
+		start := rosterDay(14 + day).Add(8 * time.Hour)
+		slots = append(slots, roster.Slot{DutyID: duty.ID, StartTime: start, EndTime: start.Add(2 * time.Hour)})
+	}
+	zero := 0.0
+	status, plan := generateRoster(t, r, GenerateRosterRequest{
+		ConscriptIDs: []uint{conscripts[0].ID},
+		Slots:        slots,
+		MaxPerWeek:   1,
+		MinRestHours: &zero,
+		TimeZone:     "Europe/Athens",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, status)
+	}
+	athens, _ := time.LoadLocation("Europe/Athens")
+	weeks := map[int]int{}
+	for _, a := range plan.Assignments {
+		year, week := a.StartTime.In(athens).ISOWeek()
+		weeks[year*100+week]++
+	}
+	for week, count := range weeks {
+		if count > 1 {
+			t.Errorf("expected at most one duty in week %d, got %d", week, count)
+		}
+	}
+	if len(plan.Assignments)+len(plan.Unfilled) != 3 {
+		t.Errorf("expected every slot to be staffed or reported, got %+v", plan)
+	}
+}
+
+func TestCommitRoster(t *testing.T) {
+	db := testDatabase(t)
+	r, department, _, duty := beforeEachRoster(t, db)
+	start := rosterDay(7).Add(8 * time.Hour)
+	slots := []roster.Slot{{DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour), Count: 2}}
+	_, plan := generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots})
+
+	commit := func() int {
+		jsonValue, _ := json.Marshal(CommitRosterRequest{Assignments: plan.Assignments})
+		req, _ := http.NewRequest("POST", "/rosters/commit", bytes.NewBuffer(jsonValue))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if status := commit(); status != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d", http.StatusCreated, status)
+	}
+	var count int64
+	db.Model(&models.ConscriptDuty{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected 2 stored assignments, got %d", count)
+	}
+
+	// Committing the same roster again overlaps the stored one.
+	if status := commit(); status != http.StatusConflict {
+		t.Errorf("expected status %d, got %d", http.StatusConflict, status)
+	}
+	db.Model(&models.ConscriptDuty{}).Count(&count)
+	if count != 2 {
+		t.Errorf("expected nothing to be stored, got %d assignments", count)
+	}
+
+	// A fully staffed slot needs nobody else.
+	_, plan = generateRoster(t, r, GenerateRosterRequest{DepartmentID: department.ID, Slots: slots})
+	if len(plan.Assignments) != 0 || len(plan.Unfilled) != 0 {
+		t.Errorf("expected no further assignments, got %+v", plan)
+	}
+}
@@ -2,14 +2,30 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
 	"github.com/gin-gonic/gin"
 )
 
-// CreateService handles POST /services
+// ServiceHandler serves the /services routes.
+type ServiceHandler struct {
+	store       repository.Store
+	services    *service.Services
+	departments *service.Departments
+}
+
+// NewServiceHandler returns the service handler of the store.
+func NewServiceHandler(store repository.Store) *ServiceHandler {
+	return &ServiceHandler{
+		store:       store,
+		services:    service.NewServices(store),
+		departments: service.NewDepartments(store),
+	}
+}
+
+// Create handles POST /services
 // @Summary Create a new service
 // @Description Create a new service in the system
 // @Tags services
@@ -21,38 +37,47 @@ import (
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /services [post]
-func CreateService(c *gin.Context) {
+func (h *ServiceHandler) Create(c *gin.Context) {
 	var service models.Service
 	if err := c.ShouldBindJSON(&service); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	if err := database.GetDB().Create(&service).Error; err != nil {
+	if err := h.services.Create(&service); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 	c.JSON(http.StatusCreated, service)
 }
 
-// GetServices handles GET /services
+// List handles GET /services
 // @Summary List all services
-// @Description Get a list of all services
+// @Description Get a list of all services, optionally only those of a department and the departments under it
 // @Tags services
-// @Produce json
+// @Produce json,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet,application/pdf
 // @Security BearerAuth
+// @Param department_id query int false "Only services of this department"
+// @Param include_subdepartments query bool false "Also services of the departments under department_id"
+// @Param format query string false "Response format (json, csv, xlsx or pdf), negotiated from the Accept header by default"
 // @Success 200 {array} models.Service
+// @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
 // @Router /services [get]
-func GetServices(c *gin.Context) {
-	var services []models.Service
-	if err := database.GetDB().Find(&services).Error; err != nil {
+func (h *ServiceHandler) List(c *gin.Context) {
+	departmentIDs, err := departmentScope(c, h.departments)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	services, err := h.services.List(departmentIDs)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, services)
+	respondList(c, "Services", services, serviceColumns)
 }
 
-// GetService handles GET /services/:id
+// Get handles GET /services/:id
 // @Summary Get a service by ID
 // @Description Get a service by its ID
 // @Tags services
@@ -63,21 +88,20 @@ func GetServices(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /services/{id} [get]
-func GetService(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid service ID"})
+func (h *ServiceHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid service ID")
+	if !ok {
 		return
 	}
-	var service models.Service
-	if err := database.GetDB().First(&service, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Service not found"})
+	service, err := h.services.Get(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, service)
 }
 
-// UpdateService handles PUT /services/:id
+// Update handles PUT /services/:id
 // @Summary Update a service
 // @Description Update a service by its ID
 // @Tags services
@@ -90,31 +114,29 @@ func GetService(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /services/{id} [put]
-func UpdateService(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid service ID"})
+func (h *ServiceHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid service ID")
+	if !ok {
 		return
 	}
-	var service models.Service
-	db := database.GetDB()
-	if err := db.First(&service, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Service not found"})
+	service, err := h.services.Get(id)
+	if err != nil {
+		respondError(c, err)
 		return
 	}
 	if err := c.ShouldBindJSON(&service); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
 		return
 	}
-	service.ID = uint(id)
-	if err := db.Save(&service).Error; err != nil {
+	service.ID = id
+	if err := h.services.Replace(&service); err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
 		return
 	}
 	c.JSON(http.StatusOK, service)
 }
 
-// DeleteService handles DELETE /services/:id
+// Delete handles DELETE /services/:id
 // @Summary Delete a service
 // @Description Delete a service by its ID
 // @Tags services
@@ -125,21 +147,43 @@ func UpdateService(c *gin.Context) {
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
 // @Router /services/{id} [delete]
-func DeleteService(c *gin.Context) {
-	id, err := strconv.Atoi(c.Param("id"))
-	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: "Invalid service ID"})
-		return
-	}
-	var service models.Service
-	db := database.GetDB()
-	if err := db.First(&service, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Service not found"})
+func (h *ServiceHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid service ID")
+	if !ok {
 		return
 	}
-	if err := db.Delete(&service).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+	if err := h.services.Delete(id); err != nil {
+		respondError(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, models.ErrorResponse{Error: "Service deleted"})
 }
+
+// Batch handles POST /services:batch
+// @Summary Create, update or delete services in bulk
+// @Description Apply many service operations in one transaction. In atomic mode nothing is committed if any operation fails; in partial mode successful operations are committed and failures are reported per item.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param batch body BatchRequest[models.Service] true "Batch operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 422 {object} BatchResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services:batch [post]
+func (h *ServiceHandler) Batch(c *gin.Context) {
+	runBatch(c, h.store, batchOps[models.Service]{
+		create: func(store repository.Store, op *BatchOperation[models.Service]) error {
+			return service.NewServices(store).Create(&op.Data)
+		},
+		update: func(store repository.Store, op *BatchOperation[models.Service]) error {
+			updated, err := service.NewServices(store).Update(op.ID, op.Data)
+			op.Data = updated
+			return err
+		},
+		delete: func(store repository.Store, op *BatchOperation[models.Service]) error {
+			return service.NewServices(store).Delete(op.ID)
+		},
+	})
+}
@@ -8,35 +8,34 @@ import (
 	"net/http/httptest"
 	"testing"
 
-	"github.com/alexandrosraikos/pixis/database"
 	"github.com/alexandrosraikos/pixis/models"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 var MockService = models.Service{
 	Label: "TestService",
 }
 
-func setupServiceRouter() *gin.Engine {
-	gin.SetMode(gin.TestMode)
-	database.RecreateDatabase("service_test.db")
+func setupServiceRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
 	r := gin.Default()
-	r.POST("/services", CreateService)
-	r.GET("/services", GetServices)
-	r.GET("/services/:id", GetService)
-	r.PUT("/services/:id", UpdateService)
-	r.DELETE("/services/:id", DeleteService)
+	r.POST("/services", h.Services.Create)
+	r.GET("/services", h.Services.List)
+	r.GET("/services/:id", h.Services.Get)
+	r.PUT("/services/:id", h.Services.Update)
+	r.DELETE("/services/:id", h.Services.Delete)
 	return r
 }
 
-func beforeEachService(t *testing.T) *gin.Engine {
-	r := setupServiceRouter()
+func beforeEachService(t *testing.T, db *gorm.DB) *gin.Engine {
+	r := setupServiceRouter(db)
 	t.Cleanup(func() {})
 	return r
 }
 
 func TestCreateService(t *testing.T) {
-	r := beforeEachService(t)
+	r := beforeEachService(t, testDatabase(t))
 	service := MockService
 	jsonValue, _ := json.Marshal(service)
 	req, _ := http.NewRequest("POST", "/services", bytes.NewBuffer(jsonValue))
@@ -49,7 +48,7 @@ func TestCreateService(t *testing.T) {
 }
 
 func TestGetServices(t *testing.T) {
-	r := beforeEachService(t)
+	r := beforeEachService(t, testDatabase(t))
 	service := MockService
 	service.Label = "AnotherService"
 	jsonValue, _ := json.Marshal(service)
@@ -75,7 +74,7 @@ func TestGetServices(t *testing.T) {
 }
 
 func TestGetService(t *testing.T) {
-	r := beforeEachService(t)
+	r := beforeEachService(t, testDatabase(t))
 	service := MockService
 	service.Label = "UniqueService"
 	jsonValue, _ := json.Marshal(service)
@@ -104,7 +103,7 @@ func TestGetService(t *testing.T) {
 }
 
 func TestUpdateService(t *testing.T) {
-	r := beforeEachService(t)
+	r := beforeEachService(t, testDatabase(t))
 	service := MockService
 	service.Label = "ServiceToUpdate"
 	jsonValue, _ := json.Marshal(service)
@@ -133,7 +132,7 @@ func TestUpdateService(t *testing.T) {
 }
 
 func TestDeleteService(t *testing.T) {
-	r := beforeEachService(t)
+	r := beforeEachService(t, testDatabase(t))
 	service := MockService
 	service.Label = "ServiceToDelete"
 	jsonValue, _ := json.Marshal(service)
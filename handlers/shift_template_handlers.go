@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ShiftTemplateHandler serves the /shift_templates and /slots routes.
+type ShiftTemplateHandler struct {
+	store     repository.Store
+	templates *service.ShiftTemplates
+}
+
+// NewShiftTemplateHandler returns the shift template handler of the store.
+func NewShiftTemplateHandler(store repository.Store) *ShiftTemplateHandler {
+	return &ShiftTemplateHandler{store: store, templates: service.NewShiftTemplates(store)}
+}
+
+// shiftTemplateFilter reads the duty_id and service_id query parameters.
+func shiftTemplateFilter(c *gin.Context) repository.ShiftTemplateFilter {
+	var filter repository.ShiftTemplateFilter
+	if id, err := strconv.ParseUint(c.Query("duty_id"), 10, 0); err == nil {
+		filter.DutyID = uint(id)
+	}
+	if id, err := strconv.ParseUint(c.Query("service_id"), 10, 0); err == nil {
+		filter.ServiceID = uint(id)
+	}
+	return filter
+}
+
+// Create handles POST /shift_templates
+// @Summary Create a new shift template
+// @Description Create a recurring shift of a duty, described by an RFC 5545 recurrence rule with a time zone and excluded dates
+// @Tags shift_templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param shift_template body models.ShiftTemplate true "Shift template"
+// @Success 201 {object} models.ShiftTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shift_templates [post]
+func (h *ShiftTemplateHandler) Create(c *gin.Context) {
+	var template models.ShiftTemplate
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := h.templates.Create(&template); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+// List handles GET /shift_templates
+// @Summary List shift templates
+// @Description List all shift templates, optionally of a single duty
+// @Tags shift_templates
+// @Produce json
+// @Security BearerAuth
+// @Param duty_id query int false "Duty ID"
+// @Success 200 {array} models.ShiftTemplate
+// @Failure 500 {object} models.ErrorResponse
+// @Router /shift_templates [get]
+func (h *ShiftTemplateHandler) List(c *gin.Context) {
+	templates, err := h.templates.List(repository.ShiftTemplateFilter{DutyID: shiftTemplateFilter(c).DutyID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+// Get handles GET /shift_templates/:id
+// @Summary Get a shift template by ID
+// @Description Get a shift template by its ID
+// @Tags shift_templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shift template ID"
+// @Success 200 {object} models.ShiftTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /shift_templates/{id} [get]
+func (h *ShiftTemplateHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid shift template ID")
+	if !ok {
+		return
+	}
+	template, err := h.templates.Get(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// Update handles PUT /shift_templates/:id
+// @Summary Update a shift template
+// @Description Update a shift template by its ID
+// @Tags shift_templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shift template ID"
+// @Param shift_template body models.ShiftTemplate true "Shift template"
+// @Success 200 {object} models.ShiftTemplate
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /shift_templates/{id} [put]
+func (h *ShiftTemplateHandler) Update(c *gin.Context) {
+	id, ok := parseID(c, "Invalid shift template ID")
+	if !ok {
+		return
+	}
+	template, err := h.templates.Get(id)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if err := c.ShouldBindJSON(&template); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	template.ID = id
+	if err := h.templates.Replace(&template); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+// Delete handles DELETE /shift_templates/:id
+// @Summary Delete a shift template
+// @Description Delete a shift template by its ID. Assignments made for its shifts are kept.
+// @Tags shift_templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Shift template ID"
+// @Success 204 {string} string "No Content"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /shift_templates/{id} [delete]
+func (h *ShiftTemplateHandler) Delete(c *gin.Context) {
+	id, ok := parseID(c, "Invalid shift template ID")
+	if !ok {
+		return
+	}
+	if err := h.templates.Delete(id); err != nil {
+		respondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Slots handles GET /slots
+// @Summary Materialize the open duty slots
+// @Description Expand the shift templates into the concrete shifts overlapping a date range, with how many conscripts each one needs and how many are already assigned. Only shifts that still need conscripts are listed unless all is true. Dates are given as YYYY-MM-DD or RFC 3339 and default to the current day.
+// @Tags shift_templates
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start of the range"
+// @Param to query string false "End of the range (inclusive when a date)"
+// @Param duty_id query int false "Only shifts of this duty"
+// @Param service_id query int false "Only shifts of the duties of this service"
+// @Param all query bool false "Include fully staffed shifts"
+// @Success 200 {array} service.OpenSlot
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /slots [get]
+func (h *ShiftTemplateHandler) Slots(c *gin.Context) {
+	from, to, err := parseDateRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	slots, err := h.templates.Slots(shiftTemplateFilter(c), from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	if c.Query("all") != "true" {
+		open := []service.OpenSlot{}
+		for _, slot := range slots {
+			if slot.Open > 0 {
+				open = append(open, slot)
+			}
+		}
+		slots = open
+	}
+	c.JSON(http.StatusOK, slots)
+}
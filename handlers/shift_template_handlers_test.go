@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// beforeEachShiftTemplate serves the shift template routes over a memory
+// store holding a duty.
+func beforeEachShiftTemplate(t *testing.T) (*gin.Engine, *repository.MemoryStore, models.Duty) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	duty := models.Duty{Label: "Gate guard", Capacity: 2}
+	if err := store.Duties().Create(&duty); err != nil {
+		t.Fatal(err)
+	}
+	h := New(store)
+	r := gin.New()
+	r.POST("/shift_templates", h.ShiftTemplates.Create)
+	r.GET("/shift_templates", h.ShiftTemplates.List)
+	r.GET("/shift_templates/:id", h.ShiftTemplates.Get)
+	r.PUT("/shift_templates/:id", h.ShiftTemplates.Update)
+	r.DELETE("/shift_templates/:id", h.ShiftTemplates.Delete)
+	r.GET("/slots", h.ShiftTemplates.Slots)
+	return r, store, duty
+}
+
+func postShiftTemplate(r *gin.Engine, template models.ShiftTemplate) *httptest.ResponseRecorder {
+	jsonValue, _ := json.Marshal(template)
+	req, _ := http.NewRequest("POST", "/shift_templates", bytes.NewBuffer(jsonValue))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestCreateShiftTemplate(t *testing.T) {
+	r, _, duty := beforeEachShiftTemplate(t)
+	template := models.ShiftTemplate{DutyID: duty.ID, DTStart: "2025-03-10T08:00", TimeZone: "Europe/Athens", DurationMinutes: 480, RRule: "FREQ=DAILY"}
+	if w := postShiftTemplate(r, template); w.Code != http.StatusCreated {
+		t.Errorf("expected status %d, got %d", http.StatusCreated, w.Code)
+	}
+	template.RRule = "FREQ=FORTNIGHTLY"
+	if w := postShiftTemplate(r, template); w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	req, _ := http.NewRequest("GET", "/shift_templates?duty_id=1", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var templates []models.ShiftTemplate
+	json.Unmarshal(w.Body.Bytes(), &templates)
+	if len(templates) != 1 {
+		t.Errorf("expected 1 shift template, got %d", len(templates))
+	}
+}
+
+func TestGetSlots(t *testing.T) {
+	r, store, duty := beforeEachShiftTemplate(t)
+	postShiftTemplate(r, models.ShiftTemplate{DutyID: duty.ID, DTStart: "2025-03-10T08:00", TimeZone: "UTC", DurationMinutes: 480, RRule: "FREQ=DAILY", ExDates: "2025-03-12"})
+	postShiftTemplate(r, models.ShiftTemplate{DutyID: duty.ID, DTStart: "2025-03-10T20:00", TimeZone: "UTC", DurationMinutes: 600, RRule: "FREQ=DAILY", Count: 1})
+	start := time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC)
+	for _, a := range []models.ConscriptDuty{
+		{ConscriptID: 1, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)},
+		{ConscriptID: 2, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)},
+	} {
+		if err := store.Assignments().Create(&a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	get := func(query string) []service.OpenSlot {
+		req, _ := http.NewRequest("GET", "/slots?from=2025-03-11T00:00:00Z&to=2025-03-13T00:00:00Z"+query, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+		}
+		var slots []service.OpenSlot
+		json.Unmarshal(w.Body.Bytes(), &slots)
+		return slots
+	}
+	// Night shifts of the 10th, 11th and 12th; the day shift of the 11th is
+	// fully staffed and the 12th is excluded.
+	slots := get("")
+	if len(slots) != 3 {
+		t.Fatalf("expected 3 open slots, got %+v", slots)
+	}
+	for i, slot := range slots {
+		if slot.StartTime.Hour() != 20 || slot.Count != 1 || slot.Open != 1 {
+			t.Errorf("slot %d: expected an open night shift, got %+v", i, slot)
+		}
+		if i > 0 && !slot.StartTime.After(slots[i-1].StartTime) {
+			t.Errorf("expected slots in chronological order")
+		}
+	}
+
+	slots = get("&all=true")
+	if len(slots) != 4 || slots[1].Staffed != 2 || slots[1].Open != 0 || slots[1].Count != 2 {
+		t.Errorf("expected the staffed day shift to be listed with all=true, got %+v", slots)
+	}
+}
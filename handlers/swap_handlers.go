@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateSwapRequest is the request body of POST /swaps.
+type CreateSwapRequest struct {
+	AssignmentID            uint       `json:"assignment_id" binding:"required"`
+	CounterpartAssignmentID uint       `json:"counterpart_assignment_id" binding:"required"`
+	Message                 string     `json:"message"`
+	ExpiresAt               *time.Time `json:"expires_at"`
+}
+
+// SwapHandler serves the /swaps routes and the swaps of conscripts and
+// services.
+type SwapHandler struct {
+	store repository.Store
+	swaps *service.Swaps
+}
+
+// NewSwapHandler returns the swap handler of the store.
+func NewSwapHandler(store repository.Store) *SwapHandler {
+	return &SwapHandler{store: store, swaps: service.NewSwaps(store)}
+}
+
+// Create handles POST /swaps
+// @Summary Propose a duty swap
+// @Description Propose to exchange an assignment of the authenticated conscript with an assignment of another conscript. Both assignments must not have started and must not be part of another pending swap. The swap expires at expires_at, by default in 72 hours, and at the latest when the first of the assignments starts.
+// @Tags swaps
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param swap body CreateSwapRequest true "Assignments to swap"
+// @Success 201 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /swaps [post]
+func (h *SwapHandler) Create(c *gin.Context) {
+	var req CreateSwapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	actorID, _ := currentConscriptID(c)
+	swap, err := h.swaps.Create(req.AssignmentID, req.CounterpartAssignmentID, req.Message, req.ExpiresAt, actorID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, swap)
+}
+
+// List handles GET /swaps
+// @Summary List swaps
+// @Description List swap requests, optionally by status, conscript (as requester or counterparty) and service of the assignments. Conscripts only see the swaps they take part in; administrators see everyone's.
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "Status (proposed, accepted, approved, declined, rejected, cancelled or expired)"
+// @Param conscript_id query int false "Conscript ID"
+// @Param service_id query int false "Service ID"
+// @Success 200 {array} models.SwapRequest
+// @Failure 500 {object} models.ErrorResponse
+// @Router /swaps [get]
+func (h *SwapHandler) List(c *gin.Context) {
+	filter := repository.SwapFilter{Status: models.SwapStatus(c.Query("status"))}
+	if conscriptID, err := strconv.ParseUint(c.Query("conscript_id"), 10, 0); err == nil {
+		filter.Involving = []uint{uint(conscriptID)}
+	}
+	if serviceID, err := strconv.ParseUint(c.Query("service_id"), 10, 0); err == nil {
+		filter.ServiceID = uint(serviceID)
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swaps, err := h.swaps.List(filter, actorID, admin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, swaps)
+}
+
+// Pending handles GET /conscripts/:id/swaps
+// @Summary Pending swaps of a conscript
+// @Description List the pending swap requests a conscript takes part in, both those they proposed and those awaiting their answer
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conscript ID"
+// @Success 200 {array} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /conscripts/{id}/swaps [get]
+func (h *SwapHandler) Pending(c *gin.Context) {
+	id, ok := parseID(c, "Invalid conscript ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swaps, err := h.swaps.Pending(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swaps)
+}
+
+// PendingOfService handles GET /services/:id/swaps
+// @Summary Pending swaps of a service
+// @Description List the pending swap requests of assignments to duties of a service, for administrators and commanders of the department of the service to review
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Service ID"
+// @Success 200 {array} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /services/{id}/swaps [get]
+func (h *SwapHandler) PendingOfService(c *gin.Context) {
+	id, ok := parseID(c, "Invalid service ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swaps, err := h.swaps.PendingOfService(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swaps)
+}
+
+// Get handles GET /swaps/:id
+// @Summary Get a swap by ID
+// @Description Get a swap request by its ID
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Swap ID"
+// @Success 200 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /swaps/{id} [get]
+func (h *SwapHandler) Get(c *gin.Context) {
+	id, ok := parseID(c, "Invalid swap ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swap, err := h.swaps.Get(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swap)
+}
+
+// respond lets the counterparty accept or decline a proposed swap.
+func (h *SwapHandler) respond(c *gin.Context, status models.SwapStatus) {
+	id, ok := parseID(c, "Invalid swap ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swap, err := h.swaps.Respond(id, status, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swap)
+}
+
+// Accept handles POST /swaps/:id/accept
+// @Summary Accept a swap
+// @Description Accept a swap proposed to the authenticated conscript. The swap then awaits the approval of an administrator or a commander.
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Swap ID"
+// @Success 200 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /swaps/{id}/accept [post]
+func (h *SwapHandler) Accept(c *gin.Context) {
+	h.respond(c, models.SwapAccepted)
+}
+
+// Decline handles POST /swaps/:id/decline
+// @Summary Decline a swap
+// @Description Decline a swap proposed to the authenticated conscript
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Swap ID"
+// @Success 200 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /swaps/{id}/decline [post]
+func (h *SwapHandler) Decline(c *gin.Context) {
+	h.respond(c, models.SwapDeclined)
+}
+
+// Cancel handles POST /swaps/:id/cancel
+// @Summary Cancel a swap
+// @Description Withdraw a pending swap proposed by the authenticated conscript
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Swap ID"
+// @Success 200 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /swaps/{id}/cancel [post]
+func (h *SwapHandler) Cancel(c *gin.Context) {
+	id, ok := parseID(c, "Invalid swap ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swap, err := h.swaps.Cancel(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swap)
+}
+
+// Approve handles POST /swaps/:id/approve
+// @Summary Approve a swap
+// @Description Approve a swap accepted by the counterparty, exchanging the conscripts of the two assignments atomically. Only administrators and commanders of the departments of both duties can approve swaps, other than their own. The swap is refused if the exchanged assignments conflict with other assignments or absences, unless an administrator overrides the conflicts, which is recorded, or if the assignments changed since the swap was proposed.
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Swap ID"
+// @Param override query bool false "Exchange despite conflicts"
+// @Success 200 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} ConflictResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /swaps/{id}/approve [post]
+func (h *SwapHandler) Approve(c *gin.Context) {
+	adminID, ok := overridingAdmin(c, h.store.Conscripts())
+	if !ok {
+		return
+	}
+	id, ok := parseID(c, "Invalid swap ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swap, err := h.swaps.Approve(id, actorID, admin, adminID)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swap)
+}
+
+// Reject handles POST /swaps/:id/reject
+// @Summary Reject a swap
+// @Description Reject a pending swap, whether or not the counterparty accepted it. Only administrators and commanders of the departments of both duties can reject swaps, other than their own.
+// @Tags swaps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Swap ID"
+// @Success 200 {object} models.SwapRequest
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse
+// @Router /swaps/{id}/reject [post]
+func (h *SwapHandler) Reject(c *gin.Context) {
+	id, ok := parseID(c, "Invalid swap ID")
+	if !ok {
+		return
+	}
+	actorID, admin := actor(c, h.store.Conscripts())
+	swap, err := h.swaps.Reject(id, actorID, admin)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, swap)
+}
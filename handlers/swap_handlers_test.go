@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func setupSwapRouter(db *gorm.DB) *gin.Engine {
+	h := testHandlers(db)
+	r := gin.Default()
+	auth := r.Group("", h.Auth.Middleware())
+	auth.POST("/swaps", h.Swaps.Create)
+	auth.GET("/swaps", h.Swaps.List)
+	auth.GET("/swaps/:id", h.Swaps.Get)
+	auth.POST("/swaps/:id/accept", h.Swaps.Accept)
+	auth.POST("/swaps/:id/decline", h.Swaps.Decline)
+	auth.POST("/swaps/:id/cancel", h.Swaps.Cancel)
+	auth.POST("/swaps/:id/approve", h.Swaps.Approve)
+	auth.POST("/swaps/:id/reject", h.Swaps.Reject)
+	auth.GET("/conscripts/:id/swaps", h.Swaps.Pending)
+	auth.GET("/services/:id/swaps", h.Swaps.PendingOfService)
+	return r
+}
+
+type swapFixture struct {
+	router      *gin.Engine
+	service     models.Service
+	conscripts  []models.Conscript
+	admin       models.Conscript
+	assignments []models.ConscriptDuty
+}
+
+// beforeEachSwap creates two conscripts with a duty each on consecutive
+// upcoming days.
+func beforeEachSwap(t *testing.T, db *gorm.DB) swapFixture {
+	f := swapFixture{router: setupSwapRouter(db)}
+	f.service = models.Service{Label: "Swap service"}
+	db.Create(&f.service)
+	duty := models.Duty{Label: "Swap duty", ServiceID: f.service.ID}
+	db.Create(&duty)
+	start := time.Now().UTC().Truncate(time.Hour).Add(48 * time.Hour)
+	for i := 0; i < 2; i++ {
+		conscript := models.Conscript{RegistryNumber: fmt.Sprintf("swap-%d", i), Username: fmt.Sprintf("swap-%d", i)}
+		db.Create(&conscript)
+		f.conscripts = append(f.conscripts, conscript)
+		assignment := models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: duty.ID, StartTime: start.AddDate(0, 0, i), EndTime: start.AddDate(0, 0, i).Add(8 * time.Hour)}
+		db.Create(&assignment)
+		f.assignments = append(f.assignments, assignment)
+	}
+	f.admin = models.Conscript{RegistryNumber: "swap-admin", Username: "swap-admin", Role: models.RoleAdmin}
+	db.Create(&f.admin)
+	return f
+}
+
+func (f swapFixture) propose(t *testing.T) models.SwapRequest {
+	req := CreateSwapRequest{AssignmentID: f.assignments[0].ID, CounterpartAssignmentID: f.assignments[1].ID, Message: "Family visit"}
+	w := sendAuthRequest(f.router, "POST", "/swaps", req, f.conscripts[0].ID)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var swap models.SwapRequest
+	json.Unmarshal(w.Body.Bytes(), &swap)
+	return swap
+}
+
+func TestSwapWorkflow(t *testing.T) {
+	db := testDatabase(t)
+	f := beforeEachSwap(t, db)
+	r := f.router
+
+	req := CreateSwapRequest{AssignmentID: f.assignments[1].ID, CounterpartAssignmentID: f.assignments[0].ID}
+	if w := sendAuthRequest(r, "POST", "/swaps", req, f.conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for someone else's assignment, got %d", http.StatusForbidden, w.Code)
+	}
+	swap := f.propose(t)
+	if swap.Status != models.SwapProposed || swap.CounterpartyID != f.conscripts[1].ID {
+		t.Errorf("expected a swap proposed to the counterparty, got %+v", swap)
+	}
+	if !swap.ExpiresAt.Equal(f.assignments[0].StartTime) {
+		t.Errorf("expected the swap to expire when the first assignment starts, got %s", swap.ExpiresAt)
+	}
+	req = CreateSwapRequest{AssignmentID: f.assignments[0].ID, CounterpartAssignmentID: f.assignments[1].ID}
+	if w := sendAuthRequest(r, "POST", "/swaps", req, f.conscripts[0].ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for a second pending swap, got %d", http.StatusConflict, w.Code)
+	}
+
+	var pending []models.SwapRequest
+	w := sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts/%d/swaps", f.conscripts[1].ID), nil, f.conscripts[1].ID)
+	json.Unmarshal(w.Body.Bytes(), &pending)
+	if len(pending) != 1 || pending[0].ID != swap.ID {
+		t.Errorf("expected the swap awaiting the counterparty, got %+v", pending)
+	}
+	if w := sendAuthRequest(r, "GET", fmt.Sprintf("/services/%d/swaps", f.service.ID), nil, f.conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a conscript reviewing a service, got %d", http.StatusForbidden, w.Code)
+	}
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/services/%d/swaps", f.service.ID), nil, f.admin.ID)
+	json.Unmarshal(w.Body.Bytes(), &pending)
+	if len(pending) != 1 {
+		t.Errorf("expected the pending swap of the service, got %+v", pending)
+	}
+
+	path := fmt.Sprintf("/swaps/%d", swap.ID)
+	if w := sendAuthRequest(r, "POST", path+"/approve", nil, f.admin.ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d before the counterparty accepts, got %d", http.StatusConflict, w.Code)
+	}
+	if w := sendAuthRequest(r, "POST", path+"/accept", nil, f.conscripts[0].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for the requester accepting, got %d", http.StatusForbidden, w.Code)
+	}
+	if w := sendAuthRequest(r, "POST", path+"/accept", nil, f.conscripts[1].ID); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, w.Code)
+	}
+	if w := sendAuthRequest(r, "POST", path+"/approve", nil, f.conscripts[1].ID); w.Code != http.StatusForbidden {
+		t.Errorf("expected status %d for a conscript approving, got %d", http.StatusForbidden, w.Code)
+	}
+	w = sendAuthRequest(r, "POST", path+"/approve", nil, f.admin.ID)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	json.Unmarshal(w.Body.Bytes(), &swap)
+	if swap.Status != models.SwapApproved || swap.ApproverID != f.admin.ID || swap.DecidedAt == nil {
+		t.Errorf("expected the swap to be approved by the admin, got %+v", swap)
+	}
+
+	var first, second models.ConscriptDuty
+	db.First(&first, f.assignments[0].ID)
+	db.First(&second, f.assignments[1].ID)
+	if first.ConscriptID != f.conscripts[1].ID || second.ConscriptID != f.conscripts[0].ID {
+		t.Errorf("expected the assignments to be exchanged, got %+v and %+v", first, second)
+	}
+	w = sendAuthRequest(r, "GET", fmt.Sprintf("/conscripts/%d/swaps", f.conscripts[1].ID), nil, f.conscripts[1].ID)
+	json.Unmarshal(w.Body.Bytes(), &pending)
+	if len(pending) != 0 {
+		t.Errorf("expected no pending swaps after approval, got %+v", pending)
+	}
+}
+
+func TestSwapConflicts(t *testing.T) {
+	db := testDatabase(t)
+	f := beforeEachSwap(t, db)
+	swap := f.propose(t)
+	path := fmt.Sprintf("/swaps/%d", swap.ID)
+	sendAuthRequest(f.router, "POST", path+"/accept", nil, f.conscripts[1].ID)
+
+	// The counterparty takes another duty at the time of the requester's.
+	other := models.ConscriptDuty{ConscriptID: f.conscripts[1].ID, DutyID: 99, StartTime: f.assignments[0].StartTime, EndTime: f.assignments[0].EndTime}
+	db.Create(&other)
+
+	w := sendAuthRequest(f.router, "POST", path+"/approve", nil, f.admin.ID)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected status %d, got %d", http.StatusConflict, w.Code)
+	}
+	var resp ConflictResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if len(resp.Conflicts) == 0 || resp.Conflicts[0].Kind != conflicts.KindOverlap {
+		t.Errorf("expected an overlap conflict, got %+v", resp.Conflicts)
+	}
+	var first models.ConscriptDuty
+	db.First(&first, f.assignments[0].ID)
+	if first.ConscriptID != f.conscripts[0].ID {
+		t.Errorf("expected the assignments to be left untouched, got %+v", first)
+	}
+	db.First(&swap, swap.ID)
+	if swap.Status != models.SwapAccepted {
+		t.Errorf("expected the swap to stay accepted, got %s", swap.Status)
+	}
+
+	if w := sendAuthRequest(f.router, "POST", path+"/approve?override=true", nil, f.admin.ID); w.Code != http.StatusOK {
+		t.Errorf("expected status %d when overriding, got %d", http.StatusOK, w.Code)
+	}
+}
+
+func TestSwapExpiry(t *testing.T) {
+	db := testDatabase(t)
+	f := beforeEachSwap(t, db)
+	swap := f.propose(t)
+	db.Model(&swap).Update("expires_at", time.Now().UTC().Add(-time.Minute))
+
+	path := fmt.Sprintf("/swaps/%d", swap.ID)
+	if w := sendAuthRequest(f.router, "POST", path+"/accept", nil, f.conscripts[1].ID); w.Code != http.StatusConflict {
+		t.Errorf("expected status %d for an expired swap, got %d", http.StatusConflict, w.Code)
+	}
+	w := sendAuthRequest(f.router, "GET", "/swaps?status=expired", nil, f.conscripts[0].ID)
+	var swaps []models.SwapRequest
+	json.Unmarshal(w.Body.Bytes(), &swaps)
+	if len(swaps) != 1 || swaps[0].Status != models.SwapExpired {
+		t.Errorf("expected the swap to have expired, got %+v", swaps)
+	}
+	// Expired swaps no longer hold the assignments.
+	f.propose(t)
+}
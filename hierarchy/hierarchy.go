@@ -0,0 +1,77 @@
+// Package hierarchy queries the tree of departments, such as battalions
+// made of companies made of platoons, with recursive common table
+// expressions, and prevents departments from becoming their own ancestors.
+package hierarchy
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// ErrCycle is returned when a department would become its own ancestor.
+var ErrCycle = errors.New("a department cannot be placed under itself or its sub-departments")
+
+// subtree selects the departments given by the seed query, on the id column,
+// and every department under them.
+const subtree = `WITH RECURSIVE subtree(id) AS (
+	%s
+	UNION
+	SELECT departments.id FROM departments JOIN subtree ON departments.parent_id = subtree.id
+) SELECT id FROM subtree`
+
+// Subtree selects the IDs of a department and of every department under it,
+// for use as a subquery.
+func Subtree(db *gorm.DB, departmentID uint) *gorm.DB {
+	return db.Raw(fmt.Sprintf(subtree, "SELECT id FROM departments WHERE id = ?"), departmentID)
+}
+
+// Commanded selects the IDs of the departments a conscript commands and of
+// every department under them, for use as a subquery.
+func Commanded(db *gorm.DB, conscriptID uint) *gorm.DB {
+	return db.Raw(fmt.Sprintf(subtree, "SELECT id FROM departments WHERE commander_id = ?"), conscriptID)
+}
+
+// SubtreeIDs returns the IDs of a department and of every department under it.
+func SubtreeIDs(db *gorm.DB, departmentID uint) ([]uint, error) {
+	var ids []uint
+	err := Subtree(db, departmentID).Scan(&ids).Error
+	return ids, err
+}
+
+// AncestorIDs returns the IDs of the departments above a department, from
+// its parent up to the root.
+func AncestorIDs(db *gorm.DB, departmentID uint) ([]uint, error) {
+	var ids []uint
+	err := db.Raw(`WITH RECURSIVE ancestors(id, parent_id, depth) AS (
+		SELECT id, parent_id, 0 FROM departments WHERE id = ?
+		UNION
+		SELECT departments.id, departments.parent_id, ancestors.depth + 1
+		FROM departments JOIN ancestors ON departments.id = ancestors.parent_id
+		WHERE ancestors.depth < ?
+	) SELECT id FROM ancestors WHERE depth > 0 ORDER BY depth`, departmentID, maxDepth).Scan(&ids).Error
+	return ids, err
+}
+
+// maxDepth bounds the walk up the tree in case the stored hierarchy
+// contains a cycle.
+const maxDepth = 64
+
+// CheckParent returns ErrCycle if placing the department under the parent
+// would make it its own ancestor.
+func CheckParent(db *gorm.DB, departmentID, parentID uint) error {
+	if departmentID == 0 {
+		return nil
+	}
+	ids, err := SubtreeIDs(db, departmentID)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if id == parentID {
+			return ErrCycle
+		}
+	}
+	return nil
+}
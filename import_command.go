@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/alexandrosraikos/pixis/config"
+	"github.com/alexandrosraikos/pixis/importer"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// runImport implements `pixis import conscripts [flags] FILE`.
+func runImport(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] != "conscripts" {
+		fmt.Fprintln(stderr, "usage: pixis import conscripts [-format csv|xlsx] [-mapping FILE] [-commit] FILE")
+		return 2
+	}
+	flags := flag.NewFlagSet("import conscripts", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	formatName := flags.String("format", "", "file format (csv or xlsx), guessed from the file name by default")
+	mappingPath := flags.String("mapping", "", "JSON file mapping conscript fields to column headers")
+	commit := flags.Bool("commit", false, "create the conscripts instead of only validating them")
+	loader := config.Bind(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+	path := flags.Arg(0)
+
+	var format importer.Format
+	var err error
+	if *formatName != "" {
+		format, err = importer.ParseFormat(*formatName)
+	} else {
+		format, err = importer.FormatFromFilename(path)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	var mapping importer.ColumnMapping
+	if *mappingPath != "" {
+		raw, err := os.ReadFile(*mappingPath)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if err := json.Unmarshal(raw, &mapping); err != nil {
+			fmt.Fprintln(stderr, "invalid mapping:", err)
+			return 1
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer file.Close()
+	rows, err := importer.ReadRows(file, format)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	db, err := connect(loader)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	store := repository.NewGormStore(db)
+	report, err := importer.Validate(store, rows, mapping)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	for _, row := range report.Rows {
+		for _, message := range row.Errors {
+			fmt.Fprintf(stdout, "line %d: %s\n", row.Line, message)
+		}
+	}
+	fmt.Fprintf(stdout, "%d rows, %d invalid\n", report.Total, report.Invalid)
+	if !report.Valid() {
+		return 1
+	}
+	if !*commit {
+		fmt.Fprintln(stdout, "dry run, nothing imported (use -commit to import)")
+		return 0
+	}
+	if err := importer.Commit(store, report); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "imported %d conscripts\n", report.Total)
+	return 0
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestImportConscriptsConnectError(t *testing.T) {
+	t.Parallel()
+	file := filepath.Join(t.TempDir(), "conscripts.csv")
+	if err := os.WriteFile(file, []byte("username\nconscript01\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runImport([]string{"conscripts", "-database", "redis://localhost", file}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), "unsupported database") {
+		t.Errorf("expected the connection error to be reported, got %q", stderr.String())
+	}
+}
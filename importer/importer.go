@@ -0,0 +1,286 @@
+// Package importer reads conscript intakes from CSV and XLSX spreadsheets,
+// validates them against the stored records and creates the conscripts.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/passwords"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is the spreadsheet format of an intake file.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// FormatFromFilename guesses the format of a file from its extension.
+func FormatFromFilename(name string) (Format, error) {
+	return ParseFormat(strings.TrimPrefix(filepath.Ext(name), "."))
+}
+
+// ParseFormat validates a format name such as "csv" or "xlsx".
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", fmt.Errorf("unsupported import format %q", name)
+}
+
+// ColumnMapping maps conscript fields to spreadsheet column headers.
+// Headers are matched case-insensitively. Username and password are optional:
+// the username defaults to the registry number and conscripts without a
+// password cannot log in until one is set.
+type ColumnMapping struct {
+	FirstName      string `json:"first_name"`
+	LastName       string `json:"last_name"`
+	RegistryNumber string `json:"registry_number"`
+	Username       string `json:"username"`
+	Password       string `json:"password"`
+	Department     string `json:"department"`
+}
+
+// DefaultMapping expects the column headers to be the snake_case field names.
+var DefaultMapping = ColumnMapping{
+	FirstName:      "first_name",
+	LastName:       "last_name",
+	RegistryNumber: "registry_number",
+	Username:       "username",
+	Password:       "password",
+	Department:     "department",
+}
+
+// withDefaults fills the unset columns of m from DefaultMapping.
+func (m ColumnMapping) withDefaults() ColumnMapping {
+	pick := func(value, fallback string) string {
+		if value != "" {
+			return value
+		}
+		return fallback
+	}
+	return ColumnMapping{
+		FirstName:      pick(m.FirstName, DefaultMapping.FirstName),
+		LastName:       pick(m.LastName, DefaultMapping.LastName),
+		RegistryNumber: pick(m.RegistryNumber, DefaultMapping.RegistryNumber),
+		Username:       pick(m.Username, DefaultMapping.Username),
+		Password:       pick(m.Password, DefaultMapping.Password),
+		Department:     pick(m.Department, DefaultMapping.Department),
+	}
+}
+
+// ReadRows reads all rows, header included, from a CSV file or from the first
+// sheet of an XLSX workbook.
+func ReadRows(r io.Reader, format Format) ([][]string, error) {
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		return reader.ReadAll()
+	case FormatXLSX:
+		workbook, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, err
+		}
+		defer workbook.Close()
+		sheets := workbook.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("workbook has no sheets")
+		}
+		return workbook.GetRows(sheets[0])
+	}
+	return nil, fmt.Errorf("unsupported import format %q", format)
+}
+
+// RowResult is the validation outcome of a single spreadsheet row.
+type RowResult struct {
+	Line      int              `json:"line"`
+	Conscript models.Conscript `json:"conscript"`
+	Errors    []string         `json:"errors,omitempty"`
+}
+
+// Report is the dry-run validation report of an intake.
+type Report struct {
+	Rows      []RowResult `json:"rows"`
+	Total     int         `json:"total"`
+	Invalid   int         `json:"invalid"`
+	Committed bool        `json:"committed"`
+}
+
+// Valid reports whether every row of the intake can be imported.
+func (r *Report) Valid() bool {
+	return r.Invalid == 0
+}
+
+// Validate maps the rows to conscripts and checks them for missing fields,
+// duplicate registry numbers and usernames, both within the file and against
+// existing conscripts, and departments that do not exist.
+func Validate(store repository.Store, rows [][]string, mapping ColumnMapping) (*Report, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("the file is empty")
+	}
+	mapping = mapping.withDefaults()
+	columns := make(map[string]int, len(rows[0]))
+	for i, header := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(header))] = i
+	}
+	required := map[string]string{
+		"first_name":      mapping.FirstName,
+		"last_name":       mapping.LastName,
+		"registry_number": mapping.RegistryNumber,
+		"department":      mapping.Department,
+	}
+	for field, header := range required {
+		if _, ok := columns[strings.ToLower(header)]; !ok {
+			return nil, fmt.Errorf("missing column %q for %s", header, field)
+		}
+	}
+
+	departments, err := store.Departments().List()
+	if err != nil {
+		return nil, err
+	}
+	departmentIDs := make(map[string]uint, len(departments))
+	for _, d := range departments {
+		departmentIDs[strings.ToLower(d.Label)] = d.ID
+	}
+
+	report := &Report{}
+	registryLines := map[string]int{}
+	usernameLines := map[string]int{}
+	for i, row := range rows[1:] {
+		cell := func(header string) string {
+			index, ok := columns[strings.ToLower(header)]
+			if !ok || index >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[index])
+		}
+		if isBlank(row) {
+			continue
+		}
+		result := RowResult{
+			Line: i + 2,
+			Conscript: models.Conscript{
+				FirstName:      cell(mapping.FirstName),
+				LastName:       cell(mapping.LastName),
+				RegistryNumber: cell(mapping.RegistryNumber),
+				Username:       cell(mapping.Username),
+				Password:       cell(mapping.Password),
+			},
+		}
+		c := &result.Conscript
+		if c.Username == "" {
+			c.Username = c.RegistryNumber
+		}
+		if c.FirstName == "" || c.LastName == "" {
+			result.Errors = append(result.Errors, "first and last name are required")
+		}
+		if c.RegistryNumber == "" {
+			result.Errors = append(result.Errors, "registry number is required")
+		} else if line, ok := registryLines[c.RegistryNumber]; ok {
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate registry number %q (line %d)", c.RegistryNumber, line))
+		} else {
+			registryLines[c.RegistryNumber] = result.Line
+		}
+		if line, ok := usernameLines[c.Username]; ok && c.Username != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate username %q (line %d)", c.Username, line))
+		} else {
+			usernameLines[c.Username] = result.Line
+		}
+		if len(c.Password) > passwords.MaxLength {
+			result.Errors = append(result.Errors, passwords.ErrTooLong.Error())
+		}
+		label := cell(mapping.Department)
+		if id, ok := departmentIDs[strings.ToLower(label)]; ok {
+			c.DepartmentID = id
+		} else {
+			result.Errors = append(result.Errors, fmt.Sprintf("unknown department %q", label))
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if err := checkExisting(store, report, registryLines, usernameLines); err != nil {
+		return nil, err
+	}
+	report.Total = len(report.Rows)
+	for _, row := range report.Rows {
+		if len(row.Errors) > 0 {
+			report.Invalid++
+		}
+	}
+	return report, nil
+}
+
+// checkExisting flags rows whose registry number or username is already taken.
+func checkExisting(store repository.Store, report *Report, registryLines, usernameLines map[string]int) error {
+	lines := make(map[int]*RowResult, len(report.Rows))
+	for i := range report.Rows {
+		lines[report.Rows[i].Line] = &report.Rows[i]
+	}
+	existing, err := store.Conscripts().List(nil)
+	if err != nil {
+		return err
+	}
+	for _, c := range existing {
+		if line, ok := registryLines[c.RegistryNumber]; ok {
+			row := lines[line]
+			row.Errors = append(row.Errors, fmt.Sprintf("registry number %q already exists", c.RegistryNumber))
+		}
+		if line, ok := usernameLines[c.Username]; ok {
+			row := lines[line]
+			row.Errors = append(row.Errors, fmt.Sprintf("username %q already exists", c.Username))
+		}
+	}
+	return nil
+}
+
+// Commit creates the conscripts of a valid report in a single transaction,
+// with the hashes of their passwords.
+func Commit(store repository.Store, report *Report) error {
+	if !report.Valid() {
+		return errors.New("the intake has invalid rows")
+	}
+	err := store.Transaction(func(tx repository.Store) error {
+		for i := range report.Rows {
+			if password := report.Rows[i].Conscript.Password; password != "" {
+				hash, err := passwords.Hash(password)
+				if err != nil {
+					return fmt.Errorf("line %d: %w", report.Rows[i].Line, err)
+				}
+				report.Rows[i].Conscript.Password = hash
+			}
+			if err := tx.Conscripts().Create(&report.Rows[i].Conscript); err != nil {
+				return fmt.Errorf("line %d: %w", report.Rows[i].Line, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	report.Committed = true
+	return nil
+}
+
+func isBlank(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
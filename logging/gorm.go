@@ -0,0 +1,73 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// SlowQuery is the duration from which queries are logged as warnings.
+const SlowQuery = 200 * time.Millisecond
+
+// Gorm returns a Gorm logger writing to the logger: failed queries as
+// errors, slow ones as warnings and the others at the debug level. Queries
+// are logged without their parameters, which can hold passwords and
+// tokens.
+func Gorm(logger *slog.Logger) gormlogger.Interface {
+	return gormLogger{logger: logger}
+}
+
+type gormLogger struct {
+	logger *slog.Logger
+}
+
+// LogMode keeps the levels of the slog logger.
+func (l gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l gormLogger) Info(ctx context.Context, message string, args ...any) {
+	l.logger.InfoContext(ctx, fmt.Sprintf(message, args...))
+}
+
+func (l gormLogger) Warn(ctx context.Context, message string, args ...any) {
+	l.logger.WarnContext(ctx, fmt.Sprintf(message, args...))
+}
+
+func (l gormLogger) Error(ctx context.Context, message string, args ...any) {
+	l.logger.ErrorContext(ctx, fmt.Sprintf(message, args...))
+}
+
+func (l gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	level := slog.LevelDebug
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level = slog.LevelError
+	case elapsed >= SlowQuery:
+		level = slog.LevelWarn
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("latency_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, "query", attrs...)
+}
+
+// ParamsFilter leaves the parameters out of the logged queries.
+func (l gormLogger) ParamsFilter(ctx context.Context, sql string, params ...any) (string, []any) {
+	return sql, nil
+}
@@ -0,0 +1,82 @@
+// Package logging writes the structured logs of the server with log/slog:
+// one line per request, identified by its request ID, with the secrets of
+// every line redacted.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats of the logs.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// redacted replaces the values of sensitive attributes.
+const redacted = "<redacted>"
+
+// sensitiveWords mark the attribute keys, route parameters and query
+// parameters whose values are redacted.
+var sensitiveWords = []string{"password", "token", "secret", "authorization", "cookie"}
+
+// sensitive reports whether the value of a key must be redacted.
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, word := range sensitiveWords {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLevel parses a level name: debug, info, warn or error.
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("unknown level %q, expected debug, info, warn or error", name)
+	}
+	return level, nil
+}
+
+// New returns a logger writing the lines of the level and above to w in the
+// format, redacting the values of sensitive attributes.
+func New(w io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if sensitive(a.Key) {
+				return slog.String(a.Key, redacted)
+			}
+			return a
+		},
+	}
+	switch format {
+	case FormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	case FormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	}
+	return nil, fmt.Errorf("unknown format %q, expected %s or %s", format, FormatJSON, FormatText)
+}
+
+type contextKey struct{}
+
+// NewContext returns a context carrying the logger.
+func NewContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext returns the logger of a context, which carries the request
+// ID in requests, or the default logger.
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
@@ -0,0 +1,109 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+)
+
+// lines decodes the JSON lines of a log.
+func lines(t *testing.T, log *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(log.String()), "\n") {
+		var entry map[string]any
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("expected a JSON line, got %q", line)
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+func TestRedaction(t *testing.T) {
+	var log bytes.Buffer
+	logger, err := New(&log, slog.LevelInfo, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.Debug("hidden")
+	logger.Info("login", slog.String("username", "admin"), slog.String("password", "hunter2"), slog.Group("auth", slog.String("jwt_token", "hunter2")))
+	if strings.Contains(log.String(), "hunter2") || strings.Contains(log.String(), "hidden") {
+		t.Errorf("expected the secrets to be redacted and debug lines to be left out, got %s", log.String())
+	}
+	if entry := lines(t, &log)[0]; entry["username"] != "admin" || entry["password"] != redacted {
+		t.Errorf("expected only the password to be redacted, got %v", entry)
+	}
+	if _, err := New(&log, slog.LevelInfo, "xml"); err == nil {
+		t.Error("expected an unknown format to be refused")
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected an unknown level to be refused")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var log bytes.Buffer
+	logger, _ := New(&log, slog.LevelInfo, FormatJSON)
+	r := gin.New()
+	r.Use(Middleware(logger, func(c *gin.Context) (uint, bool) { return 7, c.GetHeader("Authorization") != "" }), Recovery())
+	r.GET("/calendar/feeds/:token", func(c *gin.Context) {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{Error: "Feed not found"})
+	})
+	r.GET("/conscripts", func(c *gin.Context) {
+		FromContext(c.Request.Context()).Info("listing")
+		c.JSON(http.StatusOK, gin.H{"error": "not an error response"})
+	})
+	r.GET("/panic", func(c *gin.Context) { panic("boom") })
+	send := func(path, requestID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", path, nil)
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send("/calendar/feeds/s3cret?token=s3cret&from=2025-03-01", "trace-1")
+	var response models.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	if w.Header().Get(RequestIDHeader) != "trace-1" || response.RequestID != "trace-1" || response.Error != "Feed not found" {
+		t.Errorf("expected the request ID in the header and the error response, got %v: %s", w.Header(), w.Body.String())
+	}
+	w = send("/conscripts", "not a valid\nid")
+	generated := w.Header().Get(RequestIDHeader)
+	if len(generated) != 32 || strings.Contains(w.Body.String(), "request_id") {
+		t.Errorf("expected a generated request ID, only in the header of a success, got %q: %s", generated, w.Body.String())
+	}
+	if w := send("/panic", ""); w.Code != http.StatusInternalServerError || !strings.Contains(w.Body.String(), "request_id") {
+		t.Errorf("expected a panic to be an error response, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if strings.Contains(log.String(), "s3cret") {
+		t.Errorf("expected the feed token to be redacted, got %s", log.String())
+	}
+	entries := lines(t, &log)
+	if len(entries) != 5 {
+		t.Fatalf("expected 5 lines, got %d: %s", len(entries), log.String())
+	}
+	feed, listing, panicked := entries[0], entries[1], entries[4]
+	if feed["level"] != "WARN" || feed["route"] != "/calendar/feeds/:token" || feed["status"] != float64(404) || feed["error"] != "Feed not found" || feed["conscript_id"] != float64(7) || feed["query"] != "token="+redacted+"&from=2025-03-01" {
+		t.Errorf("unexpected request line %v", feed)
+	}
+	if listing["msg"] != "listing" || listing["request_id"] != generated {
+		t.Errorf("expected the handler to log with the request ID, got %v", listing)
+	}
+	if panicked["level"] != "ERROR" || panicked["status"] != float64(500) {
+		t.Errorf("expected the panic to be logged as an error, got %v", panicked)
+	}
+}
@@ -0,0 +1,200 @@
+package logging
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the ID of a request, given by the client or a
+// proxy in front of the server, or generated, and returned in the response.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength limits the request IDs accepted from clients.
+const maxRequestIDLength = 128
+
+// requestIDKey is the gin context key holding the ID of the request.
+const requestIDKey = "request_id"
+
+// RequestID returns the ID of a request handled by Middleware.
+func RequestID(c *gin.Context) string {
+	return c.GetString(requestIDKey)
+}
+
+// validRequestID reports whether a request ID given by a client can be
+// logged and returned as is.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !strings.ContainsRune("-_.:/+=", r) && (r < '0' || r > '9') && (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random request ID.
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Middleware identifies each request by its X-Request-ID header, or a
+// generated ID, and logs it once handled: its route, status, latency, the
+// conscript authenticated by principal and the error of error responses.
+// The request ID is returned in the X-Request-ID header and added to the
+// JSON error responses, and the handlers can log with it through
+// FromContext.
+func Middleware(logger *slog.Logger, principal func(*gin.Context) (uint, bool)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(requestIDKey, id)
+		c.Request.Header.Set(RequestIDHeader, id)
+		c.Header(RequestIDHeader, id)
+		requestLogger := logger.With(slog.String("request_id", id))
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), requestLogger))
+		writer := &errorWriter{ResponseWriter: c.Writer, requestID: id}
+		c.Writer = writer
+
+		c.Next()
+
+		status := c.Writer.Status()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("route", c.FullPath()),
+			slog.String("path", redactPath(c)),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", max(c.Writer.Size(), 0)),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if query := redactQuery(c.Request.URL.RawQuery); query != "" {
+			attrs = append(attrs, slog.String("query", query))
+		}
+		if id, ok := principal(c); ok {
+			attrs = append(attrs, slog.Uint64("conscript_id", uint64(id)))
+		}
+		if message := errorMessage(c, writer); message != "" {
+			attrs = append(attrs, slog.String("error", message))
+		}
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+		requestLogger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// Recovery turns a panic in a handler into a 500 response, logging it with
+// its stack. It must come after Middleware, and any other middleware that
+// observes the response.
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				FromContext(c.Request.Context()).Error("panic",
+					slog.String("panic", fmt.Sprint(recovered)),
+					slog.String("stack", string(debug.Stack())))
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{Error: "Internal server error"})
+			}
+		}()
+		c.Next()
+	}
+}
+
+// errorWriter adds the request ID to the JSON error responses, and keeps
+// their error for the request log.
+type errorWriter struct {
+	gin.ResponseWriter
+	requestID string
+	written   bool
+	message   string
+}
+
+func (w *errorWriter) Write(b []byte) (int, error) {
+	if w.written || w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") || !bytes.HasPrefix(b, []byte("{")) {
+		w.written = true
+		return w.ResponseWriter.Write(b)
+	}
+	w.written = true
+	var body struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(b, &body) == nil {
+		w.message = body.Error
+	}
+	field, _ := json.Marshal(w.requestID)
+	injected := append([]byte(`{"request_id":`), field...)
+	if !bytes.HasPrefix(bytes.TrimSpace(b[1:]), []byte("}")) {
+		injected = append(injected, ',')
+	}
+	if _, err := w.ResponseWriter.Write(append(injected, b[1:]...)); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (w *errorWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// errorMessage returns the error of a response: the error of its JSON body,
+// or else those added to the context.
+func errorMessage(c *gin.Context, writer *errorWriter) string {
+	if writer.message != "" {
+		return writer.message
+	}
+	return c.Errors.String()
+}
+
+// redactPath returns the path of a request with its sensitive route
+// parameters, such as the token of a calendar feed, redacted.
+func redactPath(c *gin.Context) string {
+	path := c.Request.URL.Path
+	for _, param := range c.Params {
+		if sensitive(param.Key) && param.Value != "" {
+			path = strings.Replace(path, "/"+param.Value, "/"+redacted, 1)
+		}
+	}
+	return path
+}
+
+// redactQuery returns a query string with the values of its sensitive
+// parameters redacted.
+func redactQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if sensitive(key) {
+			pairs[i] = key + "=" + redacted
+		}
+	}
+	return strings.Join(pairs, "&")
+}
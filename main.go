@@ -1,63 +1,84 @@
 package main
 
 import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/config"
 	"github.com/alexandrosraikos/pixis/database"
-	"github.com/alexandrosraikos/pixis/handlers"
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
 
 	// This blank import is required for swaggo/swag to serve the generated docs.
 	_ "github.com/alexandrosraikos/pixis/docs"
 )
 
+const usage = `usage: pixis <command> [arguments]
+
+commands:
+  serve                     run the HTTP server (the default)
+  config print              print the effective configuration
+  migrate                   apply, roll back or list database migrations
+  user                      create administrators and conscripts, reset passwords, disable accounts
+  department import         create departments from a CSV or XLSX file
+  import conscripts         create conscripts from a CSV or XLSX intake
+  seed                      fill an empty database with demonstration data
+  backup                    copy the SQLite database while it is in use
+  restore                   replace the SQLite database with a backup
+
+Every command accepts -config FILE and the flags of the settings it uses;
+run "pixis <command> -h" for its flags.`
+
 func main() {
-	database.ConnectDatabase("database/main.db")
-
-	r := gin.Default()
-
-	// Authentication routes.
-	r.POST("/auth/login", handlers.Login)
-
-	// Protected CRUD routes.
-	auth := r.Group("", handlers.AuthMiddleware())
-
-	// Conscript CRUD routes.
-	auth.POST("/conscripts", handlers.CreateConscript)
-	auth.GET("/conscripts", handlers.GetConscripts)
-	auth.GET("/conscripts/:id", handlers.GetConscript)
-	auth.PUT("/conscripts/:id", handlers.UpdateConscript)
-	auth.DELETE("/conscripts/:id", handlers.DeleteConscript)
-
-	// Department CRUD routes.
-	auth.POST("/departments", handlers.CreateDepartment)
-	auth.GET("/departments", handlers.GetDepartments)
-	auth.GET("/departments/:id", handlers.GetDepartment)
-	auth.PUT("/departments/:id", handlers.UpdateDepartment)
-	auth.DELETE("/departments/:id", handlers.DeleteDepartment)
-
-	// Duty CRUD routes.
-	auth.POST("/duties", handlers.CreateDuty)
-	auth.GET("/duties", handlers.GetDuties)
-	auth.GET("/duties/:id", handlers.GetDuty)
-	auth.PUT("/duties/:id", handlers.UpdateDuty)
-	auth.DELETE("/duties/:id", handlers.DeleteDuty)
-
-	// Service CRUD routes.
-	auth.POST("/services", handlers.CreateService)
-	auth.GET("/services", handlers.GetServices)
-	auth.GET("/services/:id", handlers.GetService)
-	auth.PUT("/services/:id", handlers.UpdateService)
-	auth.DELETE("/services/:id", handlers.DeleteService)
-
-	// Conscript duties relationships CRUD routes.
-	auth.POST("/conscript_duties", handlers.CreateConscriptDuty)
-	auth.GET("/conscript_duties", handlers.GetConscriptDuties)
-	auth.PUT("/conscript_duties", handlers.UpdateConscriptDuty)
-	auth.DELETE("/conscript_duties", handlers.DeleteConscriptDuty)
-
-	// Auto-generated documentation endpoints.
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	r.Run()
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command of the arguments. Without a command, or with flags
+// only, it runs the server.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runServe(args, stderr)
+	}
+	switch args[0] {
+	case "serve":
+		return runServe(args[1:], stderr)
+	case "config":
+		return runConfig(args[1:], stdout, stderr)
+	case "migrate":
+		return runMigrate(args[1:], stdout, stderr)
+	case "user":
+		return runUser(args[1:], stdin, stdout, stderr)
+	case "department":
+		return runDepartment(args[1:], stdout, stderr)
+	case "import":
+		return runImport(args[1:], stdout, stderr)
+	case "seed":
+		return runSeed(args[1:], stdout, stderr)
+	case "backup":
+		return runBackup(args[1:], stdout, stderr)
+	case "restore":
+		return runRestore(args[1:], stdout, stderr)
+	case "help":
+		fmt.Fprintln(stdout, usage)
+		return 0
+	}
+	fmt.Fprintln(stderr, usage)
+	return 2
+}
+
+// connect loads the configuration of a command and connects to its
+// database, applying the pending migrations. Queries are not logged, since
+// the commands report their errors themselves.
+func connect(loader *config.Loader) (*gorm.DB, error) {
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	db, err := database.Connect(cfg.Database.DSN)
+	if err != nil {
+		return nil, err
+	}
+	return db.Session(&gorm.Session{NewDB: true, Logger: logger.Discard}), nil
 }
@@ -0,0 +1,184 @@
+// Package metrics exposes the Prometheus metrics of the server: the
+// requests it serves, its database connections, logins and gauges about
+// the state of the units, collected periodically.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"gorm.io/gorm"
+)
+
+// namespace prefixes the names of the metrics.
+const namespace = "pixis"
+
+// unmatchedRoute labels the requests that match no route, so that unknown
+// paths do not each add a series.
+const unmatchedRoute = "unmatched"
+
+// SlotWindow is how far ahead the open slots are counted.
+const SlotWindow = 7 * 24 * time.Hour
+
+// Domain is a snapshot of the state of the units.
+type Domain struct {
+	// ActiveConscripts counts the active conscripts of each department.
+	ActiveConscripts []DepartmentCount
+	// OpenSlots counts the places left to staff in the upcoming shifts.
+	OpenSlots int
+}
+
+// DepartmentCount is a count of conscripts in a department.
+type DepartmentCount struct {
+	DepartmentID uint
+	Department   string
+	Count        int
+}
+
+// Metrics holds the metrics of the server in their own registry.
+type Metrics struct {
+	registry         *prometheus.Registry
+	requests         *prometheus.CounterVec
+	durations        *prometheus.HistogramVec
+	logins           *prometheus.CounterVec
+	activeConscripts *prometheus.GaugeVec
+	openSlots        prometheus.Gauge
+	collectionErrors prometheus.Counter
+}
+
+// New returns the metrics of a server using the database.
+func New(db *gorm.DB) (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		logins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "logins_total",
+			Help:      "Login attempts, by result: success or failure.",
+		}, []string{"result"}),
+		activeConscripts: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "active_conscripts",
+			Help:      "Active conscripts, by department.",
+		}, []string{"department_id", "department"}),
+		openSlots: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "open_slots",
+			Help:      "Places left to staff in the shifts of the next week.",
+		}),
+		collectionErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "domain_collection_errors_total",
+			Help:      "Failed collections of the active conscripts and open slots.",
+		}),
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	for _, collector := range []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, db.Dialector.Name()),
+		m.requests, m.durations, m.logins, m.activeConscripts, m.openSlots, m.collectionErrors,
+	} {
+		if err := m.registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	// Both results are exposed from the start, so that rates of failures
+	// are defined before the first one.
+	for _, result := range []string{loginSuccess, loginFailure} {
+		m.logins.WithLabelValues(result)
+	}
+	return m, nil
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware counts and times the requests by route, the pattern matched
+// rather than the path, so that IDs do not each add a series.
+func (m *Metrics) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.durations.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Results of logins.
+const (
+	loginSuccess = "success"
+	loginFailure = "failure"
+)
+
+// Logins counts the logins handled after it: successful ones, and those
+// refused for invalid credentials or a disabled or discharged account.
+// Invalid requests and server errors are left to the request metrics.
+func (m *Metrics) Logins() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		switch c.Writer.Status() {
+		case http.StatusOK:
+			m.logins.WithLabelValues(loginSuccess).Inc()
+		case http.StatusUnauthorized, http.StatusForbidden:
+			m.logins.WithLabelValues(loginFailure).Inc()
+		}
+	}
+}
+
+// SetDomain updates the gauges about the state of the units, removing the
+// departments that are no longer counted.
+func (m *Metrics) SetDomain(domain Domain) {
+	m.activeConscripts.Reset()
+	for _, department := range domain.ActiveConscripts {
+		id := strconv.FormatUint(uint64(department.DepartmentID), 10)
+		m.activeConscripts.WithLabelValues(id, department.Department).Set(float64(department.Count))
+	}
+	m.openSlots.Set(float64(domain.OpenSlots))
+}
+
+// Run collects the state of the units right away and then every interval
+// until the context is done. The gauges keep their last values when a
+// collection fails, which is reported and counted.
+func (m *Metrics) Run(ctx context.Context, interval time.Duration, collect func() (Domain, error), report func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if domain, err := collect(); err != nil {
+			m.collectionErrors.Inc()
+			report(err)
+		} else {
+			m.SetDomain(domain)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestMetrics(t *testing.T) *Metrics {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "metrics.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRequestsAndLogins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	m := newTestMetrics(t)
+	r := gin.New()
+	r.Use(m.Middleware())
+	r.GET("/conscripts/:id", func(c *gin.Context) { c.Status(http.StatusNotFound) })
+	statuses := []int{http.StatusOK, http.StatusUnauthorized, http.StatusForbidden, http.StatusBadRequest}
+	r.POST("/auth/login", m.Logins(), func(c *gin.Context) {
+		c.Status(statuses[0])
+		statuses = statuses[1:]
+	})
+	send := func(method, path string) {
+		req, _ := http.NewRequest(method, path, nil)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	send("GET", "/conscripts/1")
+	send("GET", "/conscripts/2")
+	send("GET", "/unknown/path")
+	for range 4 {
+		send("POST", "/auth/login")
+	}
+
+	if count := testutil.ToFloat64(m.requests.WithLabelValues("GET", "/conscripts/:id", "404")); count != 2 {
+		t.Errorf("expected the requests to be counted by route, got %v", count)
+	}
+	if count := testutil.ToFloat64(m.requests.WithLabelValues("GET", unmatchedRoute, "404")); count != 1 {
+		t.Errorf("expected the unmatched requests to share a route, got %v", count)
+	}
+	if count := testutil.ToFloat64(m.logins.WithLabelValues(loginSuccess)); count != 1 {
+		t.Errorf("expected 1 successful login, got %v", count)
+	}
+	if count := testutil.ToFloat64(m.logins.WithLabelValues(loginFailure)); count != 2 {
+		t.Errorf("expected the refused logins to be failures, got %v", count)
+	}
+	if count := testutil.CollectAndCount(m.durations); count != 3 {
+		t.Errorf("expected a histogram per method and route, got %d", count)
+	}
+}
+
+func TestDomainAndExposition(t *testing.T) {
+	m := newTestMetrics(t)
+	m.SetDomain(Domain{
+		ActiveConscripts: []DepartmentCount{{1, "1st Platoon", 8}, {2, "2nd Platoon", 5}},
+		OpenSlots:        3,
+	})
+	m.SetDomain(Domain{ActiveConscripts: []DepartmentCount{{1, "1st Platoon", 7}}, OpenSlots: 2})
+	expected := `
+# HELP pixis_active_conscripts Active conscripts, by department.
+# TYPE pixis_active_conscripts gauge
+pixis_active_conscripts{department="1st Platoon",department_id="1"} 7
+# HELP pixis_open_slots Places left to staff in the shifts of the next week.
+# TYPE pixis_open_slots gauge
+pixis_open_slots 2
+`
+	if err := testutil.CollectAndCompare(m.registry, strings.NewReader(expected), "pixis_active_conscripts", "pixis_open_slots"); err != nil {
+		t.Error(err)
+	}
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	for _, name := range []string{"pixis_logins_total", "go_sql_max_open_connections", "go_goroutines"} {
+		if !strings.Contains(w.Body.String(), name) {
+			t.Errorf("expected %s to be exposed", name)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/config"
+	"github.com/alexandrosraikos/pixis/database"
+)
+
+const migrateUsage = "usage: pixis migrate up | down [-steps N] | status | unlock"
+
+// runMigrate implements `pixis migrate up|down|status|unlock`.
+func runMigrate(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	steps := 1
+	if args[0] == "down" {
+		flags.IntVar(&steps, "steps", 1, "number of migrations to roll back")
+	}
+	loader := config.Bind(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	db, err := database.Open(cfg.Database.DSN)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Fprintf(stdout, "applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(stdout, "database is up to date")
+		}
+	case "down":
+		rolledBack, err := migrator.Down(steps)
+		for _, m := range rolledBack {
+			fmt.Fprintf(stdout, "rolled back %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	case "status":
+		status, err := migrator.Status()
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		w := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+		for _, s := range status {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = s.AppliedAt.Local().Format(time.DateTime)
+			}
+			if s.Modified {
+				applied += " (modified since)"
+			}
+			if s.Unknown {
+				applied += " (unknown to this version)"
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, applied)
+		}
+		w.Flush()
+	case "unlock":
+		if err := migrator.Unlock(); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintln(stdout, "migration lock released")
+	default:
+		fmt.Fprintln(stderr, migrateUsage)
+		return 2
+	}
+	return 0
+}
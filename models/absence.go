@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AbsenceType is the reason a conscript is absent.
+type AbsenceType string
+
+const (
+	AbsenceLeave    AbsenceType = "leave"
+	AbsenceSick     AbsenceType = "sick"
+	AbsenceTraining AbsenceType = "training"
+	AbsenceOther    AbsenceType = "other"
+)
+
+// AbsenceStatus is the state of an absence in the approval workflow.
+type AbsenceStatus string
+
+const (
+	AbsencePending  AbsenceStatus = "pending"
+	AbsenceApproved AbsenceStatus = "approved"
+	AbsenceRejected AbsenceStatus = "rejected"
+)
+
+// Absence represents a period during which a conscript is not available for duty.
+// @Description Absence is a leave, sick day, training or other absence of a conscript from StartTime to EndTime. Absences are requested as pending and approved or rejected by an administrator, the approver, at DecidedAt. Conscripts cannot be assigned duties during approved absences. Timestamps are managed by Gorm.
+type Absence struct {
+	ID          uint `gorm:"primaryKey;autoIncrement"`
+	ConscriptID uint `gorm:"index"`
+	Type        AbsenceType
+	StartTime   time.Time `gorm:"index"`
+	EndTime     time.Time
+	Reason      string
+	Status      AbsenceStatus `gorm:"default:pending"`
+	ApproverID  uint
+	DecidedAt   *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// BeforeSave stores absence times in UTC, like assignment times.
+func (a *Absence) BeforeSave(tx *gorm.DB) error {
+	a.StartTime = a.StartTime.UTC()
+	a.EndTime = a.EndTime.UTC()
+	return nil
+}
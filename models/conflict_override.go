@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ConflictOverride records an assignment booked by an administrator despite scheduling conflicts.
+// @Description ConflictOverride is an audit record of an administrator overriding the conflict checks for a conscript-duty assignment. Conflicts lists the kinds of conflicts that were overridden. Timestamps are managed by Gorm.
+type ConflictOverride struct {
+	ID          uint `gorm:"primaryKey;autoIncrement"`
+	ConscriptID uint
+	DutyID      uint
+	StartTime   time.Time
+	EndTime     time.Time
+	AdminID     uint
+	Conflicts   string
+	CreatedAt   time.Time
+}
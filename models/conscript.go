@@ -1,18 +1,98 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Role determines what a conscript is allowed to do in the system.
+type Role string
+
+const (
+	RoleConscript Role = "conscript"
+	RoleAdmin     Role = "admin"
+)
+
+// ConscriptStatus is the stage of the military service of a conscript.
+type ConscriptStatus string
+
+const (
+	StatusActive      ConscriptStatus = "active"
+	StatusOnLeave     ConscriptStatus = "on_leave"
+	StatusTransferred ConscriptStatus = "transferred"
+	StatusDischarged  ConscriptStatus = "discharged"
+)
 
 // Conscript represents a user of the system.
-// @Description Conscript is a user entity used for authentication and as a foreign key in other models. It includes unique registry and username fields, a password (should be hashed in production), and belongs to a department. Timestamps are managed by Gorm.
+// @Description Conscript is a user entity used for authentication and as a foreign key in other models. It includes unique registry and username fields, a password, stored as a bcrypt hash and never returned, a role (conscript or admin), belongs to a department, and holds qualifications. Status follows the service of the conscript from enlistment: active, on leave, transferred out of the unit or discharged. DepartmentID is the department of the current membership; changes of department are recorded as memberships, and discharged conscripts can neither log in nor be assigned duties. Operators can disable an account with the pixis command, which blocks login until it is enabled again; this is not exposed by the API. Timestamps are managed by Gorm.
 type Conscript struct {
-	ID             uint `gorm:"primaryKey;autoIncrement"`
-	FirstName      string
-	LastName       string
-	RegistryNumber string `gorm:"uniqueIndex"`
-	Username       string `gorm:"uniqueIndex"`
-	Password       string
-	DepartmentID   uint
-	Department     Department
-	CreatedAt      time.Time
-	UpdatedAt      time.Time
+	ID                    uint `gorm:"primaryKey;autoIncrement"`
+	FirstName             string
+	LastName              string
+	RegistryNumber        string `gorm:"uniqueIndex"`
+	Username              string `gorm:"uniqueIndex"`
+	Password              string `json:",omitempty"`
+	Role                  Role   `gorm:"default:conscript"`
+	Rank                  string
+	Status                ConscriptStatus `gorm:"default:active"`
+	EnlistmentDate        *time.Time
+	ExpectedDischargeDate *time.Time
+	DischargeDate         *time.Time
+	DisabledAt            *time.Time `json:"-"`
+	DepartmentID          uint
+	Department            Department
+	Qualifications        []ConscriptQualification
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+}
+
+// MarshalJSON leaves the password out of the conscript, which is only
+// written: it is given to create or update the conscript and never
+// returned, not even as its hash.
+func (c Conscript) MarshalJSON() ([]byte, error) {
+	type conscript Conscript
+	c.Password = ""
+	return json.Marshal(conscript(c))
+}
+
+// AfterSave keeps the department memberships of the conscript in line with
+// DepartmentID, which is the department of the current membership. Changing
+// the department ends the current membership and starts one of the new
+// department from now; the first membership starts at the enlistment, when
+// known.
+func (c *Conscript) AfterSave(tx *gorm.DB) error {
+	if c.ID == 0 {
+		return nil
+	}
+	db := tx.Session(&gorm.Session{NewDB: true})
+	var current DepartmentMembership
+	if err := db.Where("conscript_id = ? AND end_date IS NULL", c.ID).Limit(1).Find(&current).Error; err != nil {
+		return err
+	}
+	if current.ID != 0 && current.DepartmentID == c.DepartmentID {
+		return nil
+	}
+	now := time.Now()
+	if current.ID != 0 {
+		current.EndDate = &now
+		if err := db.Save(&current).Error; err != nil {
+			return err
+		}
+	}
+	if c.DepartmentID == 0 || c.Status == StatusDischarged {
+		return nil
+	}
+	start := now
+	if current.ID == 0 && c.EnlistmentDate != nil {
+		var past int64
+		if err := db.Model(&DepartmentMembership{}).Where("conscript_id = ?", c.ID).Count(&past).Error; err != nil {
+			return err
+		}
+		if past == 0 {
+			start = *c.EnlistmentDate
+		}
+	}
+	return db.Create(&DepartmentMembership{ConscriptID: c.ID, DepartmentID: c.DepartmentID, StartDate: start}).Error
 }
@@ -1,14 +1,27 @@
 package models
 
-import "time"
+import (
+	"time"
 
-// ConscriptDuty represents the assignment of a duty to a conscript, with metadata.
-// @Description ConscriptDuty is the join table for conscripts and duties, with assignment period and timestamps. Composite primary key: conscript_id, duty_id.
+	"gorm.io/gorm"
+)
+
+// ConscriptDuty represents the assignment of a duty to a conscript for a period of time.
+// @Description ConscriptDuty is an assignment of a duty to a conscript from StartTime to EndTime. Assignments have their own ID, so a conscript may hold the same duty many times in different periods.
 type ConscriptDuty struct {
-	ConscriptID uint `gorm:"primaryKey"`
-	DutyID      uint `gorm:"primaryKey"`
-	StartTime   time.Time
+	ID          uint      `gorm:"primaryKey;autoIncrement"`
+	ConscriptID uint      `gorm:"index"`
+	DutyID      uint      `gorm:"index"`
+	StartTime   time.Time `gorm:"index"`
 	EndTime     time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
+
+// BeforeSave stores assignment times in UTC, so that they compare correctly
+// as text in SQLite regardless of the offset they were submitted with.
+func (cd *ConscriptDuty) BeforeSave(tx *gorm.DB) error {
+	cd.StartTime = cd.StartTime.UTC()
+	cd.EndTime = cd.EndTime.UTC()
+	return nil
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ConscriptQualification represents a qualification held by a conscript.
+// @Description ConscriptQualification grants a qualification to a conscript from ValidFrom until ValidUntil, or indefinitely when ValidUntil is null. A conscript holds each qualification at most once; renewals update the validity. Timestamps are managed by Gorm.
+type ConscriptQualification struct {
+	ID              uint `gorm:"primaryKey;autoIncrement"`
+	ConscriptID     uint `gorm:"uniqueIndex:idx_conscript_qualification"`
+	QualificationID uint `gorm:"uniqueIndex:idx_conscript_qualification"`
+	ValidFrom       time.Time
+	ValidUntil      *time.Time
+	Qualification   Qualification
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// ValidAt reports whether the qualification is valid at t.
+func (q ConscriptQualification) ValidAt(t time.Time) bool {
+	return !q.ValidFrom.After(t) && (q.ValidUntil == nil || q.ValidUntil.After(t))
+}
+
+// BeforeSave stores the validity in UTC, like assignment times.
+func (q *ConscriptQualification) BeforeSave(tx *gorm.DB) error {
+	q.ValidFrom = q.ValidFrom.UTC()
+	if q.ValidUntil != nil {
+		until := q.ValidUntil.UTC()
+		q.ValidUntil = &until
+	}
+	return nil
+}
@@ -3,12 +3,15 @@ package models
 import "time"
 
 // Department represents a group of conscripts and services.
-// @Description Department is a unique grouping for conscripts and services. It is referenced by conscripts and services, and includes a unique label. Timestamps are managed by Gorm.
+// @Description Department is a unique grouping for conscripts and services. It is referenced by conscripts and services, and includes a unique label. Departments form a hierarchy, such as battalion, company and platoon, through their optional parent, and may have a commander who oversees the department and every department under it. Timestamps are managed by Gorm.
 type Department struct {
-	ID         uint   `gorm:"primaryKey;autoIncrement"`
-	Label      string `gorm:"uniqueIndex"`
-	Conscripts []Conscript
-	Services   []Service
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID          uint         `gorm:"primaryKey;autoIncrement"`
+	Label       string       `gorm:"uniqueIndex"`
+	ParentID    *uint        `gorm:"index"`
+	CommanderID *uint        `gorm:"index"`
+	Children    []Department `gorm:"foreignKey:ParentID"`
+	Conscripts  []Conscript
+	Services    []Service
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DepartmentMembership represents a period a conscript served in a department.
+// @Description DepartmentMembership records that a conscript belonged to a department from StartDate until EndDate, or to this day when EndDate is null. Transfers close the current membership and open a new one. Timestamps are managed by Gorm.
+type DepartmentMembership struct {
+	ID           uint `gorm:"primaryKey;autoIncrement"`
+	ConscriptID  uint `gorm:"index"`
+	DepartmentID uint `gorm:"index"`
+	StartDate    time.Time
+	EndDate      *time.Time
+	Reason       string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// BeforeSave stores the membership period in UTC, like assignment times.
+func (m *DepartmentMembership) BeforeSave(tx *gorm.DB) error {
+	m.StartDate = m.StartDate.UTC()
+	if m.EndDate != nil {
+		end := m.EndDate.UTC()
+		m.EndDate = &end
+	}
+	return nil
+}
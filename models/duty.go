@@ -3,13 +3,20 @@ package models
 import "time"
 
 // Duty represents a task or responsibility assigned to conscripts.
-// @Description Duty is a task or responsibility assigned to conscripts, linked to a service, and can be assigned to many conscripts. Only the label and service_id are required for creation; timestamps and IDs are managed by Gorm.
+// @Description Duty is a task or responsibility assigned to conscripts, linked to a service, and can be assigned to many conscripts through assignments. Capacity limits how many conscripts may hold the duty at the same time (0 means unlimited). Points weigh each assignment of the duty for fairness, multiplied by the weekend, night and holiday multipliers when they apply; all default to 1. Shift templates describe when the duty recurs. Only conscripts holding all of its qualifications may be assigned the duty. Only the label and service_id are required for creation; timestamps and IDs are managed by Gorm.
 type Duty struct {
-	ID              uint `gorm:"primaryKey;autoIncrement"`
-	Label           string
-	ServiceID       uint
-	Service         Service
-	ConscriptDuties []ConscriptDuty `gorm:"many2many:conscript_duties;"`
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	ID                uint `gorm:"primaryKey;autoIncrement"`
+	Label             string
+	ServiceID         uint
+	Capacity          int
+	Points            float64 `gorm:"default:1"`
+	WeekendMultiplier float64 `gorm:"default:1"`
+	NightMultiplier   float64 `gorm:"default:1"`
+	HolidayMultiplier float64 `gorm:"default:1"`
+	Service           Service
+	ConscriptDuties   []ConscriptDuty
+	ShiftTemplates    []ShiftTemplate
+	Qualifications    []Qualification `gorm:"many2many:duty_qualifications"`
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
 }
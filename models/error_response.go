@@ -1,5 +1,9 @@
 package models
 
+// ErrorResponse is the body of the error responses. RequestID identifies
+// the request in the logs of the server, and is added to every JSON error
+// response by the logging middleware.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	RequestID string `json:"request_id,omitempty"`
+	Error     string `json:"error"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// FeedToken grants read access to the calendar feeds of the system.
+// @Description FeedToken is a revocable secret embedded in calendar feed URLs, since calendar apps cannot send Bearer headers. Each conscript has at most one token and only its SHA-256 hash is stored. Timestamps are managed by Gorm.
+type FeedToken struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	ConscriptID uint   `gorm:"uniqueIndex"`
+	TokenHash   string `gorm:"uniqueIndex" json:"-"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Holiday represents a public holiday, on which duties weigh more.
+// @Description Holiday is a day, given as YYYY-MM-DD, on which assignments earn the holiday multiplier of their duty. Date is unique. Timestamps are managed by Gorm.
+type Holiday struct {
+	ID        uint   `gorm:"primaryKey;autoIncrement"`
+	Date      string `gorm:"uniqueIndex"`
+	Label     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Qualification represents a licence or training a duty may require.
+// @Description Qualification is an entry of the qualifications catalogue, such as a driving licence or a weapons qualification. Duties require qualifications, and conscripts hold them for a period of validity. Label is unique. Timestamps are managed by Gorm.
+type Qualification struct {
+	ID          uint   `gorm:"primaryKey;autoIncrement"`
+	Label       string `gorm:"uniqueIndex"`
+	Description string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
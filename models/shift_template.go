@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ShiftTemplate represents a recurring shift of a duty.
+// @Description ShiftTemplate describes when a duty recurs. DTStart is the local start of the first shift (YYYY-MM-DDTHH:MM[:SS]) in TimeZone, an IANA name defaulting to the server's time zone. RRule is an RFC 5545 recurrence rule such as FREQ=DAILY or FREQ=WEEKLY;BYDAY=MO,WE,FR. ExDates is a comma-separated list of excluded shift starts, or of dates (YYYY-MM-DD) on which no shift takes place. Count is the number of conscripts each shift needs; 0 staffs the duty up to its capacity, or with one conscript when unlimited.
+type ShiftTemplate struct {
+	ID              uint `gorm:"primaryKey;autoIncrement"`
+	DutyID          uint `gorm:"index"`
+	Label           string
+	DTStart         string
+	TimeZone        string
+	DurationMinutes int
+	RRule           string
+	ExDates         string
+	Count           int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
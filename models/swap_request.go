@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SwapStatus is the state of a swap request in the swap workflow.
+type SwapStatus string
+
+const (
+	SwapProposed  SwapStatus = "proposed"
+	SwapAccepted  SwapStatus = "accepted"
+	SwapApproved  SwapStatus = "approved"
+	SwapDeclined  SwapStatus = "declined"
+	SwapRejected  SwapStatus = "rejected"
+	SwapCancelled SwapStatus = "cancelled"
+	SwapExpired   SwapStatus = "expired"
+)
+
+// Pending reports whether the swap request still awaits a decision.
+func (s SwapStatus) Pending() bool {
+	return s == SwapProposed || s == SwapAccepted
+}
+
+// SwapRequest represents a proposal of two conscripts to exchange assignments.
+// @Description SwapRequest is a proposal of the requester to exchange their assignment, AssignmentID, with the assignment of the counterparty, CounterpartAssignmentID. The counterparty accepts or declines it at RespondedAt, then an administrator approves or rejects it at DecidedAt, the approver. Approved swaps exchange the conscripts of the two assignments. Requests still proposed or accepted at ExpiresAt expire. Timestamps are managed by Gorm.
+type SwapRequest struct {
+	ID                      uint `gorm:"primaryKey;autoIncrement"`
+	RequesterID             uint `gorm:"index"`
+	AssignmentID            uint `gorm:"index"`
+	CounterpartyID          uint `gorm:"index"`
+	CounterpartAssignmentID uint `gorm:"index"`
+	Message                 string
+	Status                  SwapStatus `gorm:"default:proposed;index"`
+	ExpiresAt               time.Time
+	RespondedAt             *time.Time
+	ApproverID              uint
+	DecidedAt               *time.Time
+	CreatedAt               time.Time
+	UpdatedAt               time.Time
+}
+
+// BeforeSave stores the expiry in UTC, like assignment times.
+func (s *SwapRequest) BeforeSave(tx *gorm.DB) error {
+	s.ExpiresAt = s.ExpiresAt.UTC()
+	return nil
+}
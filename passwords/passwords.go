@@ -0,0 +1,36 @@
+// Package passwords hashes the passwords of conscripts with bcrypt, so that
+// the database never holds them in plaintext.
+package passwords
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxLength is the length, in bytes, of the longest password bcrypt hashes.
+const MaxLength = 72
+
+// ErrTooLong is returned for passwords longer than MaxLength.
+var ErrTooLong = errors.New("passwords cannot be longer than 72 bytes")
+
+// Hash returns the bcrypt hash of a password.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", ErrTooLong
+	}
+	return string(hash), err
+}
+
+// IsHash reports whether a stored password is a bcrypt hash, rather than a
+// plaintext password stored before passwords were hashed.
+func IsHash(password string) bool {
+	_, err := bcrypt.Cost([]byte(password))
+	return err == nil
+}
+
+// Check reports whether a password matches its stored hash.
+func Check(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
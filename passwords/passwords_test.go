@@ -0,0 +1,26 @@
+package passwords
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestHashAndCheck(t *testing.T) {
+	hash, err := Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "correct horse battery staple" || !IsHash(hash) {
+		t.Errorf("expected a bcrypt hash, got %q", hash)
+	}
+	if !Check(hash, "correct horse battery staple") || Check(hash, "wrong") {
+		t.Error("expected only the password to match its hash")
+	}
+	if IsHash("correct horse battery staple") || Check("correct horse battery staple", "correct horse battery staple") {
+		t.Error("expected a plaintext password to be neither a hash nor to match")
+	}
+	if _, err := Hash(strings.Repeat("a", 73)); !errors.Is(err, ErrTooLong) {
+		t.Errorf("expected a long password to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+// Package recurrence expands recurring shift templates, described by RFC 5545
+// recurrence rules, into the concrete shifts within a time window.
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // Shift templates name IANA time zones.
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/teambition/rrule-go"
+)
+
+// Layouts accepted for the start of the first shift and for excluded starts.
+var localLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"20060102T150405",
+}
+
+// Shift is a single occurrence of a shift template.
+type Shift struct {
+	TemplateID uint
+	DutyID     uint
+	StartTime  time.Time
+	EndTime    time.Time
+	Count      int
+}
+
+// rule is a parsed shift template.
+type rule struct {
+	set      rrule.Set
+	location *time.Location
+	duration time.Duration
+	// exDays holds the dates, formatted as 2006-01-02, without shifts.
+	exDays map[string]bool
+}
+
+func parseLocal(value string, location *time.Location) (time.Time, error) {
+	for _, layout := range localLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid local time %q, expected YYYY-MM-DDTHH:MM[:SS]", value)
+}
+
+func parse(t models.ShiftTemplate) (*rule, error) {
+	location := time.Local
+	if t.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(t.TimeZone); err != nil {
+			return nil, fmt.Errorf("invalid time zone %q", t.TimeZone)
+		}
+	}
+	if t.DurationMinutes <= 0 {
+		return nil, errors.New("DurationMinutes must be positive")
+	}
+	if t.Count < 0 {
+		return nil, errors.New("Count cannot be negative")
+	}
+	dtstart, err := parseLocal(t.DTStart, location)
+	if err != nil {
+		return nil, fmt.Errorf("DTStart: %w", err)
+	}
+	value := strings.TrimPrefix(strings.TrimSpace(t.RRule), "RRULE:")
+	if value == "" {
+		return nil, errors.New("RRule is required")
+	}
+	option, err := rrule.StrToROptionInLocation(value, location)
+	if err != nil {
+		return nil, fmt.Errorf("RRule: %w", err)
+	}
+	option.Dtstart = dtstart
+	recurrence, err := rrule.NewRRule(*option)
+	if err != nil {
+		return nil, fmt.Errorf("RRule: %w", err)
+	}
+
+	r := &rule{location: location, duration: time.Duration(t.DurationMinutes) * time.Minute, exDays: map[string]bool{}}
+	r.set.RRule(recurrence)
+	for _, value := range strings.Split(t.ExDates, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if day, err := time.ParseInLocation(time.DateOnly, value, location); err == nil {
+			r.exDays[day.Format(time.DateOnly)] = true
+			continue
+		}
+		exdate, err := parseLocal(value, location)
+		if err != nil {
+			return nil, fmt.Errorf("ExDates: %w", err)
+		}
+		r.set.ExDate(exdate)
+	}
+	return r, nil
+}
+
+// Validate checks that the template can be expanded.
+func Validate(t models.ShiftTemplate) error {
+	_, err := parse(t)
+	return err
+}
+
+// Expand returns the shifts of the template overlapping [from, to), in
+// chronological order. Shifts keep their local start time across daylight
+// saving time changes.
+func Expand(t models.ShiftTemplate, from, to time.Time) ([]Shift, error) {
+	r, err := parse(t)
+	if err != nil {
+		return nil, err
+	}
+	var shifts []Shift
+	for _, start := range r.set.Between(from.Add(-r.duration), to, false) {
+		if r.exDays[start.In(r.location).Format(time.DateOnly)] {
+			continue
+		}
+		end := start.Add(r.duration)
+		if !end.After(from) {
+			continue
+		}
+		shifts = append(shifts, Shift{TemplateID: t.ID, DutyID: t.DutyID, StartTime: start, EndTime: end, Count: t.Count})
+	}
+	return shifts, nil
+}
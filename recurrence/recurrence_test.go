@@ -0,0 +1,80 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+func TestExpandDaily(t *testing.T) {
+	template := models.ShiftTemplate{
+		ID:              1,
+		DutyID:          2,
+		DTStart:         "2025-03-24T08:00",
+		TimeZone:        "Europe/Athens",
+		DurationMinutes: 8 * 60,
+		RRule:           "FREQ=DAILY",
+		ExDates:         "2025-03-25, 2025-03-27T08:00:00",
+	}
+	athens, _ := time.LoadLocation("Europe/Athens")
+	from := time.Date(2025, 3, 24, 12, 0, 0, 0, athens)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, athens)
+	shifts, err := Expand(template, from, to)
+	if err != nil {
+		t.Fatalf("failed to expand: %v", err)
+	}
+	// The shift in progress at from is included; the 25th and 27th are excluded.
+	days := []int{24, 26, 28, 29, 30, 31}
+	if len(shifts) != len(days) {
+		t.Fatalf("expected %d shifts, got %+v", len(days), shifts)
+	}
+	for i, shift := range shifts {
+		local := shift.StartTime.In(athens)
+		if local.Day() != days[i] || local.Hour() != 8 {
+			t.Errorf("shift %d: expected 08:00 on the %d, got %s", i, days[i], local)
+		}
+		if shift.EndTime.Sub(shift.StartTime) != 8*time.Hour || shift.TemplateID != 1 || shift.DutyID != 2 {
+			t.Errorf("shift %d: unexpected %+v", i, shift)
+		}
+	}
+	// Daylight saving time starts on the 30th; the shift keeps its local time.
+	if shifts[3].StartTime.UTC().Hour() != 6 || shifts[4].StartTime.UTC().Hour() != 5 {
+		t.Errorf("expected shifts at 08:00 local across the DST change, got %s and %s", shifts[3].StartTime.UTC(), shifts[4].StartTime.UTC())
+	}
+}
+
+func TestExpandWeekly(t *testing.T) {
+	template := models.ShiftTemplate{
+		DTStart:         "2025-03-03T22:00:00",
+		TimeZone:        "UTC",
+		DurationMinutes: 8 * 60,
+		RRule:           "RRULE:FREQ=WEEKLY;BYDAY=MO,FR;COUNT=3",
+	}
+	shifts, err := Expand(template, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("failed to expand: %v", err)
+	}
+	if len(shifts) != 3 || shifts[1].StartTime.Weekday() != time.Friday || shifts[2].StartTime.Day() != 10 {
+		t.Errorf("expected shifts on Monday 3, Friday 7 and Monday 10, got %+v", shifts)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.ShiftTemplate{DTStart: "2025-03-03T08:00", DurationMinutes: 60, RRule: "FREQ=DAILY"}
+	if err := Validate(valid); err != nil {
+		t.Errorf("expected a valid template, got %v", err)
+	}
+	invalid := []models.ShiftTemplate{
+		{DTStart: "2025-03-03", DurationMinutes: 60, RRule: "FREQ=DAILY"},
+		{DTStart: "2025-03-03T08:00", DurationMinutes: 0, RRule: "FREQ=DAILY"},
+		{DTStart: "2025-03-03T08:00", DurationMinutes: 60, RRule: "FREQ=SOMETIMES"},
+		{DTStart: "2025-03-03T08:00", DurationMinutes: 60, RRule: "FREQ=DAILY", TimeZone: "Mars/Olympus"},
+		{DTStart: "2025-03-03T08:00", DurationMinutes: 60, RRule: "FREQ=DAILY", ExDates: "tomorrow"},
+	}
+	for i, template := range invalid {
+		if err := Validate(template); err == nil {
+			t.Errorf("template %d: expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,544 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/hierarchy"
+	"github.com/alexandrosraikos/pixis/models"
+	"gorm.io/gorm"
+)
+
+// gormStore stores the aggregates in the database.
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGormStore returns a store over the database.
+func NewGormStore(db *gorm.DB) Store {
+	return &gormStore{db: db}
+}
+
+func (s *gormStore) Conscripts() Conscripts {
+	return gormConscripts{gormRecords[models.Conscript]{s.db}}
+}
+
+func (s *gormStore) Departments() Departments {
+	return gormDepartments{gormRecords[models.Department]{s.db}}
+}
+
+func (s *gormStore) Services() Services {
+	return gormServices{gormRecords[models.Service]{s.db}}
+}
+
+func (s *gormStore) Duties() Duties {
+	return gormDuties{gormRecords[models.Duty]{s.db}}
+}
+
+func (s *gormStore) Assignments() Assignments {
+	return gormAssignments{gormRecords[models.ConscriptDuty]{s.db}}
+}
+
+func (s *gormStore) Absences() Absences {
+	return gormAbsences{gormRecords[models.Absence]{s.db}}
+}
+
+func (s *gormStore) Holidays() Holidays {
+	return gormHolidays{gormRecords[models.Holiday]{s.db}}
+}
+
+func (s *gormStore) ShiftTemplates() ShiftTemplates {
+	return gormShiftTemplates{gormRecords[models.ShiftTemplate]{s.db}}
+}
+
+func (s *gormStore) Qualifications() Qualifications {
+	return gormQualifications{gormRecords[models.Qualification]{s.db}}
+}
+
+func (s *gormStore) Memberships() Memberships {
+	return gormMemberships{gormRecords[models.DepartmentMembership]{s.db}}
+}
+
+func (s *gormStore) Swaps() Swaps {
+	return gormSwaps{gormRecords[models.SwapRequest]{s.db}}
+}
+
+func (s *gormStore) FeedTokens() FeedTokens {
+	return gormFeedTokens{s.db}
+}
+
+// Transaction runs fn in a database transaction, or in a savepoint when
+// the store is already in one.
+func (s *gormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&gormStore{db: tx})
+	})
+}
+
+// gormRecords implements Records for a model identified by its ID.
+type gormRecords[T any] struct {
+	db *gorm.DB
+}
+
+func (r gormRecords[T]) Create(record *T) error {
+	return r.db.Create(record).Error
+}
+
+func (r gormRecords[T]) Find(id uint) (T, error) {
+	var record T
+	err := r.db.First(&record, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrNotFound
+	}
+	return record, err
+}
+
+func (r gormRecords[T]) Save(record *T) error {
+	return r.db.Save(record).Error
+}
+
+func (r gormRecords[T]) Update(record *T, changes T) error {
+	return r.db.Model(record).Updates(changes).Error
+}
+
+func (r gormRecords[T]) Delete(id uint) error {
+	result := r.db.Delete(new(T), id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type gormConscripts struct {
+	gormRecords[models.Conscript]
+}
+
+func (r gormConscripts) List(departmentIDs []uint) ([]models.Conscript, error) {
+	query := r.db
+	if departmentIDs != nil {
+		query = query.Where("department_id IN ?", departmentIDs)
+	}
+	var conscripts []models.Conscript
+	err := query.Order("id").Find(&conscripts).Error
+	return conscripts, err
+}
+
+func (r gormConscripts) FindByUsername(username string) (models.Conscript, error) {
+	var conscript models.Conscript
+	result := r.db.Where("username = ?", username).Limit(1).Find(&conscript)
+	if result.Error == nil && result.RowsAffected == 0 {
+		return conscript, ErrNotFound
+	}
+	return conscript, result.Error
+}
+
+func (r gormConscripts) HasRole(role models.Role) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Conscript{}).Where("role = ?", role).Count(&count).Error
+	return count > 0, err
+}
+
+// ListAsOf reads the department of every conscript at the time from their
+// memberships, leaving out those not yet enlisted or already discharged.
+func (r gormConscripts) ListAsOf(at time.Time, departmentIDs []uint) ([]models.Conscript, error) {
+	var memberships []models.DepartmentMembership
+	if err := r.db.Where("start_date <= ? AND (end_date IS NULL OR end_date > ?)", at.UTC(), at.UTC()).
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	included := make(map[uint]bool, len(departmentIDs))
+	for _, id := range departmentIDs {
+		included[id] = true
+	}
+	departments := make(map[uint]uint, len(memberships))
+	ids := []uint{0}
+	for _, m := range memberships {
+		departments[m.ConscriptID] = m.DepartmentID
+		if departmentIDs == nil || included[m.DepartmentID] {
+			ids = append(ids, m.ConscriptID)
+		}
+	}
+	var conscripts []models.Conscript
+	if err := r.db.Where("id IN ?", ids).Order("id").Find(&conscripts).Error; err != nil {
+		return nil, err
+	}
+	for i := range conscripts {
+		conscripts[i].DepartmentID = departments[conscripts[i].ID]
+	}
+	return conscripts, nil
+}
+
+type gormMemberships struct {
+	gormRecords[models.DepartmentMembership]
+}
+
+func (r gormMemberships) List(conscriptID uint) ([]models.DepartmentMembership, error) {
+	var memberships []models.DepartmentMembership
+	err := r.db.Where("conscript_id = ?", conscriptID).Order("start_date, id").Find(&memberships).Error
+	return memberships, err
+}
+
+type gormDepartments struct {
+	gormRecords[models.Department]
+}
+
+func (r gormDepartments) List() ([]models.Department, error) {
+	var departments []models.Department
+	err := r.db.Order("id").Find(&departments).Error
+	return departments, err
+}
+
+func (r gormDepartments) HasChildren(id uint) (bool, error) {
+	var children int64
+	err := r.db.Model(&models.Department{}).Where("parent_id = ?", id).Count(&children).Error
+	return children > 0, err
+}
+
+func (r gormDepartments) SubtreeIDs(id uint) ([]uint, error) {
+	return hierarchy.SubtreeIDs(r.db, id)
+}
+
+func (r gormDepartments) Descendants(id uint) ([]models.Department, error) {
+	var departments []models.Department
+	err := r.db.Where("id IN (?) AND id <> ?", hierarchy.Subtree(r.db, id), id).Order("label").Find(&departments).Error
+	return departments, err
+}
+
+func (r gormDepartments) Ancestors(id uint) ([]models.Department, error) {
+	ids, err := hierarchy.AncestorIDs(r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	var departments []models.Department
+	if err := r.db.Where("id IN ?", append(ids, 0)).Find(&departments).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]models.Department, len(departments))
+	for _, d := range departments {
+		byID[d.ID] = d
+	}
+	ancestors := make([]models.Department, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		ancestors = append(ancestors, byID[ids[i]])
+	}
+	return ancestors, nil
+}
+
+func (r gormDepartments) Commanded(conscriptID uint) ([]uint, error) {
+	var ids []uint
+	err := hierarchy.Commanded(r.db, conscriptID).Scan(&ids).Error
+	return ids, err
+}
+
+type gormServices struct {
+	gormRecords[models.Service]
+}
+
+func (r gormServices) List(departmentIDs []uint) ([]models.Service, error) {
+	query := r.db
+	if departmentIDs != nil {
+		query = query.Where("department_id IN ?", departmentIDs)
+	}
+	var services []models.Service
+	err := query.Order("id").Find(&services).Error
+	return services, err
+}
+
+type gormDuties struct {
+	gormRecords[models.Duty]
+}
+
+func (r gormDuties) List() ([]models.Duty, error) {
+	var duties []models.Duty
+	err := r.db.Order("id").Find(&duties).Error
+	return duties, err
+}
+
+func (r gormDuties) FindWithQualifications(id uint) (models.Duty, error) {
+	var duty models.Duty
+	err := r.db.Preload("Qualifications").First(&duty, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = ErrNotFound
+	}
+	return duty, err
+}
+
+type gormAssignments struct {
+	gormRecords[models.ConscriptDuty]
+}
+
+func (r gormAssignments) List(filter AssignmentFilter) ([]models.ConscriptDuty, error) {
+	query := r.db.Order("start_time")
+	if filter.ConscriptID != 0 {
+		query = query.Where("conscript_id = ?", filter.ConscriptID)
+	}
+	if filter.DutyID != 0 {
+		query = query.Where("duty_id = ?", filter.DutyID)
+	}
+	if filter.ServiceID != 0 {
+		query = query.Where("duty_id IN (?)", r.db.Model(&models.Duty{}).Select("id").Where("service_id = ?", filter.ServiceID))
+	}
+	if filter.From != nil {
+		query = query.Where("end_time > ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("start_time < ?", filter.To.UTC())
+	}
+	var assignments []models.ConscriptDuty
+	err := query.Order("id").Find(&assignments).Error
+	return assignments, err
+}
+
+func (r gormAssignments) ConflictRecords(a models.ConscriptDuty) (conflicts.Records, error) {
+	var records conflicts.Records
+	start, end := conflicts.Window(a)
+	if err := r.db.Where("id <> ?", a.ID).
+		Where("(conscript_id = ? AND start_time < ? AND end_time > ?) OR (duty_id = ? AND start_time < ? AND end_time > ?)",
+			a.ConscriptID, end, start, a.DutyID, a.EndTime.UTC(), a.StartTime.UTC()).
+		Order("start_time").
+		Find(&records.Assignments).Error; err != nil {
+		return records, err
+	}
+	if err := r.db.Where("conscript_id = ? AND status = ? AND start_time < ? AND end_time > ?", a.ConscriptID, models.AbsenceApproved, a.EndTime.UTC(), a.StartTime.UTC()).
+		Order("start_time").
+		Find(&records.Absences).Error; err != nil {
+		return records, err
+	}
+	if err := r.db.Where("id = ?", a.ConscriptID).Find(&records.Conscripts).Error; err != nil {
+		return records, err
+	}
+	if err := r.db.Preload("Qualifications").Where("id = ?", a.DutyID).Find(&records.Duties).Error; err != nil {
+		return records, err
+	}
+	err := r.db.Where("conscript_id = ?", a.ConscriptID).Find(&records.Qualifications).Error
+	return records, err
+}
+
+func (r gormAssignments) ReportRecords(from, to time.Time) (conflicts.Records, error) {
+	var records conflicts.Records
+	start, end := conflicts.ReportWindow(from, to)
+	if err := r.db.Where("start_time < ? AND end_time > ?", end, start).
+		Order("start_time").
+		Find(&records.Assignments).Error; err != nil {
+		return records, err
+	}
+	if err := r.db.Where("status = ? AND start_time < ? AND end_time > ?", models.AbsenceApproved, to.UTC(), from.UTC()).
+		Order("start_time").
+		Find(&records.Absences).Error; err != nil {
+		return records, err
+	}
+	if err := r.db.Where("status = ?", models.StatusDischarged).Find(&records.Conscripts).Error; err != nil {
+		return records, err
+	}
+	if err := r.db.Preload("Qualifications").
+		Where("capacity > 0 OR id IN (SELECT duty_id FROM duty_qualifications)").
+		Order("id").
+		Find(&records.Duties).Error; err != nil {
+		return records, err
+	}
+	var conscriptIDs []uint
+	seen := map[uint]bool{}
+	for _, a := range records.Assignments {
+		if !seen[a.ConscriptID] {
+			seen[a.ConscriptID] = true
+			conscriptIDs = append(conscriptIDs, a.ConscriptID)
+		}
+	}
+	if len(conscriptIDs) > 0 {
+		err := r.db.Where("conscript_id IN ?", conscriptIDs).Find(&records.Qualifications).Error
+		return records, err
+	}
+	return records, nil
+}
+
+func (r gormAssignments) RecordOverride(override *models.ConflictOverride) error {
+	return r.db.Create(override).Error
+}
+
+type gormAbsences struct {
+	gormRecords[models.Absence]
+}
+
+func (r gormAbsences) List(filter AbsenceFilter) ([]models.Absence, error) {
+	query := r.db.Order("start_time")
+	if filter.ConscriptID != 0 {
+		query = query.Where("conscript_id = ?", filter.ConscriptID)
+	}
+	if filter.ConscriptIDs != nil {
+		query = query.Where("conscript_id IN ?", append([]uint{0}, filter.ConscriptIDs...))
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.From != nil {
+		query = query.Where("end_time > ?", filter.From.UTC())
+	}
+	if filter.To != nil {
+		query = query.Where("start_time < ?", filter.To.UTC())
+	}
+	if filter.At != nil {
+		query = query.Where("start_time <= ? AND end_time > ?", filter.At.UTC(), filter.At.UTC())
+	}
+	var absences []models.Absence
+	err := query.Order("id").Find(&absences).Error
+	return absences, err
+}
+
+type gormHolidays struct {
+	gormRecords[models.Holiday]
+}
+
+func (r gormHolidays) List() ([]models.Holiday, error) {
+	var holidays []models.Holiday
+	err := r.db.Order("date").Find(&holidays).Error
+	return holidays, err
+}
+
+type gormShiftTemplates struct {
+	gormRecords[models.ShiftTemplate]
+}
+
+func (r gormShiftTemplates) List(filter ShiftTemplateFilter) ([]models.ShiftTemplate, error) {
+	query := r.db.Order("id")
+	if filter.DutyID != 0 {
+		query = query.Where("duty_id = ?", filter.DutyID)
+	}
+	if filter.ServiceID != 0 {
+		query = query.Where("duty_id IN (?)", r.db.Model(&models.Duty{}).Select("id").Where("service_id = ?", filter.ServiceID))
+	}
+	var templates []models.ShiftTemplate
+	err := query.Find(&templates).Error
+	return templates, err
+}
+
+type gormQualifications struct {
+	gormRecords[models.Qualification]
+}
+
+func (r gormQualifications) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("qualification_id = ?", id).Delete(&models.ConscriptQualification{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM duty_qualifications WHERE qualification_id = ?", id).Error; err != nil {
+			return err
+		}
+		return gormRecords[models.Qualification]{tx}.Delete(id)
+	})
+}
+
+func (r gormQualifications) List() ([]models.Qualification, error) {
+	var qualifications []models.Qualification
+	err := r.db.Order("label").Find(&qualifications).Error
+	return qualifications, err
+}
+
+func (r gormQualifications) Held(conscriptIDs []uint) ([]models.ConscriptQualification, error) {
+	query := r.db.Preload("Qualification").Order("conscript_id, qualification_id")
+	if conscriptIDs != nil {
+		query = query.Where("conscript_id IN ?", append([]uint{0}, conscriptIDs...))
+	}
+	var held []models.ConscriptQualification
+	err := query.Find(&held).Error
+	return held, err
+}
+
+func (r gormQualifications) Grant(held *models.ConscriptQualification) error {
+	var existing models.ConscriptQualification
+	if err := r.db.Where("conscript_id = ? AND qualification_id = ?", held.ConscriptID, held.QualificationID).
+		Limit(1).Find(&existing).Error; err != nil {
+		return err
+	}
+	held.ID, held.CreatedAt = existing.ID, existing.CreatedAt
+	return r.db.Omit("Qualification").Save(held).Error
+}
+
+func (r gormQualifications) Revoke(conscriptID, qualificationID uint) error {
+	result := r.db.Where("conscript_id = ? AND qualification_id = ?", conscriptID, qualificationID).Delete(&models.ConscriptQualification{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r gormQualifications) SetRequired(dutyID uint, qualifications []models.Qualification) error {
+	return r.db.Model(&models.Duty{ID: dutyID}).Association("Qualifications").Replace(qualifications)
+}
+
+// pendingSwapStatuses are the statuses of swaps awaiting a decision.
+var pendingSwapStatuses = []models.SwapStatus{models.SwapProposed, models.SwapAccepted}
+
+type gormSwaps struct {
+	gormRecords[models.SwapRequest]
+}
+
+func (r gormSwaps) List(filter SwapFilter) ([]models.SwapRequest, error) {
+	query := r.db.Order("created_at, id")
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Pending {
+		query = query.Where("status IN ?", pendingSwapStatuses)
+	}
+	for _, id := range filter.Involving {
+		query = query.Where("requester_id = ? OR counterparty_id = ?", id, id)
+	}
+	if filter.AssignmentIDs != nil {
+		ids := append([]uint{0}, filter.AssignmentIDs...)
+		query = query.Where("assignment_id IN ? OR counterpart_assignment_id IN ?", ids, ids)
+	}
+	if filter.ServiceID != 0 {
+		assignments := r.db.Model(&models.ConscriptDuty{}).Select("id").
+			Where("duty_id IN (?)", r.db.Model(&models.Duty{}).Select("id").Where("service_id = ?", filter.ServiceID))
+		query = query.Where("assignment_id IN (?) OR counterpart_assignment_id IN (?)", assignments, assignments)
+	}
+	var swaps []models.SwapRequest
+	err := query.Find(&swaps).Error
+	return swaps, err
+}
+
+func (r gormSwaps) Expire(at time.Time) error {
+	return r.db.Model(&models.SwapRequest{}).
+		Where("status IN ? AND expires_at <= ?", pendingSwapStatuses, at.UTC()).
+		Update("status", models.SwapExpired).Error
+}
+
+type gormFeedTokens struct {
+	db *gorm.DB
+}
+
+func (r gormFeedTokens) Issue(token *models.FeedToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("conscript_id = ?", token.ConscriptID).Delete(&models.FeedToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+func (r gormFeedTokens) FindByHash(hash string) (models.FeedToken, error) {
+	var token models.FeedToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return token, ErrNotFound
+	}
+	return token, err
+}
+
+func (r gormFeedTokens) Revoke(conscriptID uint) error {
+	result := r.db.Where("conscript_id = ?", conscriptID).Delete(&models.FeedToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,645 @@
+package repository
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// memoryData holds the records of a memory store.
+type memoryData struct {
+	conscripts  memoryTable[models.Conscript]
+	departments memoryTable[models.Department]
+	services    memoryTable[models.Service]
+	duties      memoryTable[models.Duty]
+	assignments memoryTable[models.ConscriptDuty]
+	absences    memoryTable[models.Absence]
+	holidays    memoryTable[models.Holiday]
+	templates   memoryTable[models.ShiftTemplate]
+	// qualifications is the catalogue and held the qualifications held by
+	// conscripts. The qualifications duties require are kept on the duties.
+	qualifications memoryTable[models.Qualification]
+	held           memoryTable[models.ConscriptQualification]
+	memberships    memoryTable[models.DepartmentMembership]
+	swaps          memoryTable[models.SwapRequest]
+	feedTokens     memoryTable[models.FeedToken]
+	overrides      []models.ConflictOverride
+}
+
+func (d *memoryData) clone() memoryData {
+	return memoryData{
+		conscripts:     d.conscripts.clone(),
+		departments:    d.departments.clone(),
+		services:       d.services.clone(),
+		duties:         d.duties.clone(),
+		assignments:    d.assignments.clone(),
+		absences:       d.absences.clone(),
+		holidays:       d.holidays.clone(),
+		templates:      d.templates.clone(),
+		qualifications: d.qualifications.clone(),
+		held:           d.held.clone(),
+		memberships:    d.memberships.clone(),
+		swaps:          d.swaps.clone(),
+		feedTokens:     d.feedTokens.clone(),
+		overrides:      append([]models.ConflictOverride(nil), d.overrides...),
+	}
+}
+
+// MemoryStore keeps the aggregates in memory, for unit tests of the services
+// and handlers that need no database and can run in parallel, each with its
+// own store. It is not safe for concurrent use.
+//
+// It is a simplified fake: unique indexes and database defaults are not
+// applied, and saving a conscript records no membership: only the
+// memberships stored through Memberships are kept.
+type MemoryStore struct {
+	data *memoryData
+}
+
+// NewMemoryStore returns an empty memory store.
+func NewMemoryStore() *MemoryStore {
+	data := memoryData{}
+	return &MemoryStore{data: &data}
+}
+
+func (s *MemoryStore) Conscripts() Conscripts {
+	return memoryConscripts{&s.data.conscripts}
+}
+
+func (s *MemoryStore) Departments() Departments {
+	return memoryDepartments{&s.data.departments}
+}
+
+func (s *MemoryStore) Services() Services {
+	return memoryServices{&s.data.services}
+}
+
+func (s *MemoryStore) Duties() Duties {
+	return memoryDuties{&s.data.duties}
+}
+
+func (s *MemoryStore) Assignments() Assignments {
+	return memoryAssignments{&s.data.assignments, s.data}
+}
+
+func (s *MemoryStore) Absences() Absences {
+	return memoryAbsences{&s.data.absences}
+}
+
+func (s *MemoryStore) Holidays() Holidays {
+	return memoryHolidays{&s.data.holidays}
+}
+
+func (s *MemoryStore) ShiftTemplates() ShiftTemplates {
+	return memoryShiftTemplates{&s.data.templates, s.data}
+}
+
+func (s *MemoryStore) Qualifications() Qualifications {
+	return memoryQualifications{&s.data.qualifications, s.data}
+}
+
+func (s *MemoryStore) Memberships() Memberships {
+	return memoryMemberships{&s.data.memberships}
+}
+
+func (s *MemoryStore) Swaps() Swaps {
+	return memorySwaps{&s.data.swaps, s.data}
+}
+
+func (s *MemoryStore) FeedTokens() FeedTokens {
+	return memoryFeedTokens{&s.data.feedTokens}
+}
+
+// Transaction restores the records as they were before fn if it fails.
+func (s *MemoryStore) Transaction(fn func(tx Store) error) error {
+	snapshot := s.data.clone()
+	if err := fn(s); err != nil {
+		*s.data = snapshot
+		return err
+	}
+	return nil
+}
+
+// Overrides returns the recorded conflict overrides.
+func (s *MemoryStore) Overrides() []models.ConflictOverride {
+	return s.data.overrides
+}
+
+// memoryTable implements Records for a model with an ID field, and the
+// CreatedAt and UpdatedAt timestamps when it has them.
+type memoryTable[T any] struct {
+	rows   map[uint]T
+	lastID uint
+}
+
+func (t *memoryTable[T]) clone() memoryTable[T] {
+	rows := make(map[uint]T, len(t.rows))
+	for id, row := range t.rows {
+		rows[id] = row
+	}
+	return memoryTable[T]{rows: rows, lastID: t.lastID}
+}
+
+// field returns the named field of a record, which is invalid when the
+// model has no such field.
+func field[T any](record *T, name string) reflect.Value {
+	return reflect.ValueOf(record).Elem().FieldByName(name)
+}
+
+func recordID[T any](record *T) uint {
+	return uint(field(record, "ID").Uint())
+}
+
+func touch[T any](record *T, fields ...string) {
+	now := time.Now()
+	for _, name := range fields {
+		if f := field(record, name); f.IsValid() {
+			f.Set(reflect.ValueOf(now))
+		}
+	}
+}
+
+func (t *memoryTable[T]) Create(record *T) error {
+	if t.rows == nil {
+		t.rows = map[uint]T{}
+	}
+	id := recordID(record)
+	if id == 0 {
+		t.lastID++
+		id = t.lastID
+		field(record, "ID").SetUint(uint64(id))
+	} else if _, ok := t.rows[id]; ok {
+		return fmt.Errorf("record %d already exists", id)
+	} else if id > t.lastID {
+		t.lastID = id
+	}
+	touch(record, "CreatedAt", "UpdatedAt")
+	t.rows[id] = *record
+	return nil
+}
+
+func (t *memoryTable[T]) Find(id uint) (T, error) {
+	record, ok := t.rows[id]
+	if !ok {
+		return record, ErrNotFound
+	}
+	return record, nil
+}
+
+func (t *memoryTable[T]) Save(record *T) error {
+	id := recordID(record)
+	if _, ok := t.rows[id]; !ok {
+		return t.Create(record)
+	}
+	touch(record, "UpdatedAt")
+	t.rows[id] = *record
+	return nil
+}
+
+func (t *memoryTable[T]) Update(record *T, changes T) error {
+	stored, ok := t.rows[recordID(record)]
+	if !ok {
+		return ErrNotFound
+	}
+	from, to := reflect.ValueOf(changes), reflect.ValueOf(&stored).Elem()
+	for i := 0; i < from.NumField(); i++ {
+		if value := from.Field(i); !value.IsZero() && from.Type().Field(i).Name != "ID" {
+			to.Field(i).Set(value)
+		}
+	}
+	touch(&stored, "UpdatedAt")
+	t.rows[recordID(&stored)] = stored
+	*record = stored
+	return nil
+}
+
+func (t *memoryTable[T]) Delete(id uint) error {
+	if _, ok := t.rows[id]; !ok {
+		return ErrNotFound
+	}
+	delete(t.rows, id)
+	return nil
+}
+
+// list returns the records kept by keep, ordered by ID.
+func (t *memoryTable[T]) list(keep func(T) bool) []T {
+	ids := make([]uint, 0, len(t.rows))
+	for id, row := range t.rows {
+		if keep == nil || keep(row) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	records := make([]T, len(ids))
+	for i, id := range ids {
+		records[i] = t.rows[id]
+	}
+	return records
+}
+
+// inDepartments returns a filter keeping the records of the departments,
+// or nil when departmentIDs is nil.
+func inDepartments[T any](departmentIDs []uint, department func(T) uint) func(T) bool {
+	if departmentIDs == nil {
+		return nil
+	}
+	included := make(map[uint]bool, len(departmentIDs))
+	for _, id := range departmentIDs {
+		included[id] = true
+	}
+	return func(record T) bool { return included[department(record)] }
+}
+
+type memoryConscripts struct {
+	*memoryTable[models.Conscript]
+}
+
+func (r memoryConscripts) List(departmentIDs []uint) ([]models.Conscript, error) {
+	return r.list(inDepartments(departmentIDs, func(c models.Conscript) uint { return c.DepartmentID })), nil
+}
+
+func (r memoryConscripts) FindByUsername(username string) (models.Conscript, error) {
+	found := r.list(func(c models.Conscript) bool { return c.Username == username })
+	if len(found) == 0 {
+		return models.Conscript{}, ErrNotFound
+	}
+	return found[0], nil
+}
+
+func (r memoryConscripts) HasRole(role models.Role) (bool, error) {
+	return len(r.list(func(c models.Conscript) bool { return c.Role == role })) > 0, nil
+}
+
+// ListAsOf lists the conscripts by their current department, as the memory
+// store keeps no membership history of its own, leaving out those enlisted
+// after the time or discharged before it.
+func (r memoryConscripts) ListAsOf(at time.Time, departmentIDs []uint) ([]models.Conscript, error) {
+	scoped := inDepartments(departmentIDs, func(c models.Conscript) uint { return c.DepartmentID })
+	return r.list(func(c models.Conscript) bool {
+		if c.EnlistmentDate != nil && c.EnlistmentDate.After(at) || c.DischargeDate != nil && !c.DischargeDate.After(at) {
+			return false
+		}
+		return scoped == nil || scoped(c)
+	}), nil
+}
+
+type memoryMemberships struct {
+	*memoryTable[models.DepartmentMembership]
+}
+
+func (r memoryMemberships) List(conscriptID uint) ([]models.DepartmentMembership, error) {
+	memberships := r.list(func(m models.DepartmentMembership) bool { return m.ConscriptID == conscriptID })
+	sort.SliceStable(memberships, func(i, j int) bool { return memberships[i].StartDate.Before(memberships[j].StartDate) })
+	return memberships, nil
+}
+
+type memoryDepartments struct {
+	*memoryTable[models.Department]
+}
+
+func (r memoryDepartments) List() ([]models.Department, error) {
+	return r.list(nil), nil
+}
+
+func (r memoryDepartments) children(id uint) []models.Department {
+	return r.list(func(d models.Department) bool { return d.ParentID != nil && *d.ParentID == id })
+}
+
+func (r memoryDepartments) HasChildren(id uint) (bool, error) {
+	return len(r.children(id)) > 0, nil
+}
+
+func (r memoryDepartments) SubtreeIDs(id uint) ([]uint, error) {
+	if _, ok := r.rows[id]; !ok {
+		return nil, nil
+	}
+	ids := []uint{id}
+	seen := map[uint]bool{id: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range r.children(ids[i]) {
+			if !seen[child.ID] {
+				seen[child.ID] = true
+				ids = append(ids, child.ID)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func (r memoryDepartments) Descendants(id uint) ([]models.Department, error) {
+	ids, _ := r.SubtreeIDs(id)
+	var departments []models.Department
+	for _, descendant := range ids {
+		if descendant != id {
+			departments = append(departments, r.rows[descendant])
+		}
+	}
+	sort.Slice(departments, func(i, j int) bool { return departments[i].Label < departments[j].Label })
+	return departments, nil
+}
+
+func (r memoryDepartments) Ancestors(id uint) ([]models.Department, error) {
+	var ancestors []models.Department
+	department, ok := r.rows[id]
+	for ok && department.ParentID != nil && len(ancestors) < 64 {
+		if department, ok = r.rows[*department.ParentID]; ok {
+			ancestors = append([]models.Department{department}, ancestors...)
+		}
+	}
+	return ancestors, nil
+}
+
+func (r memoryDepartments) Commanded(conscriptID uint) ([]uint, error) {
+	var ids []uint
+	seen := map[uint]bool{}
+	for _, department := range r.list(func(d models.Department) bool { return d.CommanderID != nil && *d.CommanderID == conscriptID }) {
+		subtree, _ := r.SubtreeIDs(department.ID)
+		for _, id := range subtree {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+type memoryServices struct {
+	*memoryTable[models.Service]
+}
+
+func (r memoryServices) List(departmentIDs []uint) ([]models.Service, error) {
+	return r.list(inDepartments(departmentIDs, func(s models.Service) uint { return s.DepartmentID })), nil
+}
+
+type memoryDuties struct {
+	*memoryTable[models.Duty]
+}
+
+func (r memoryDuties) List() ([]models.Duty, error) {
+	return r.list(nil), nil
+}
+
+func (r memoryDuties) FindWithQualifications(id uint) (models.Duty, error) {
+	return r.Find(id)
+}
+
+type memoryAssignments struct {
+	*memoryTable[models.ConscriptDuty]
+	data *memoryData
+}
+
+func (r memoryAssignments) List(filter AssignmentFilter) ([]models.ConscriptDuty, error) {
+	assignments := r.list(func(a models.ConscriptDuty) bool {
+		return (filter.ConscriptID == 0 || a.ConscriptID == filter.ConscriptID) &&
+			(filter.DutyID == 0 || a.DutyID == filter.DutyID) &&
+			(filter.ServiceID == 0 || r.data.duties.rows[a.DutyID].ServiceID == filter.ServiceID) &&
+			(filter.From == nil || a.EndTime.After(*filter.From)) &&
+			(filter.To == nil || a.StartTime.Before(*filter.To))
+	})
+	sort.SliceStable(assignments, func(i, j int) bool { return assignments[i].StartTime.Before(assignments[j].StartTime) })
+	return assignments, nil
+}
+
+func (r memoryAssignments) ConflictRecords(a models.ConscriptDuty) (conflicts.Records, error) {
+	start, end := conflicts.Window(a)
+	records := conflicts.Records{
+		Assignments: r.list(func(other models.ConscriptDuty) bool {
+			return other.ID != a.ID && (other.ConscriptID == a.ConscriptID && other.StartTime.Before(end) && other.EndTime.After(start) ||
+				other.DutyID == a.DutyID && other.StartTime.Before(a.EndTime) && other.EndTime.After(a.StartTime))
+		}),
+	}
+	records.Absences = r.data.absences.list(func(absence models.Absence) bool {
+		return absence.ConscriptID == a.ConscriptID && absence.Status == models.AbsenceApproved &&
+			absence.StartTime.Before(a.EndTime) && absence.EndTime.After(a.StartTime)
+	})
+	sortByStart(records.Absences)
+	if conscript, ok := r.data.conscripts.rows[a.ConscriptID]; ok {
+		records.Conscripts = append(records.Conscripts, conscript)
+	}
+	if duty, ok := r.data.duties.rows[a.DutyID]; ok {
+		records.Duties = append(records.Duties, duty)
+	}
+	records.Qualifications = r.data.held.list(func(q models.ConscriptQualification) bool { return q.ConscriptID == a.ConscriptID })
+	return records, nil
+}
+
+func (r memoryAssignments) ReportRecords(from, to time.Time) (conflicts.Records, error) {
+	start, end := conflicts.ReportWindow(from, to)
+	assignments, _ := r.List(AssignmentFilter{From: &start, To: &end})
+	absences, _ := memoryAbsences{&r.data.absences}.List(AbsenceFilter{Status: models.AbsenceApproved, From: &from, To: &to})
+	assigned := map[uint]bool{}
+	for _, a := range assignments {
+		assigned[a.ConscriptID] = true
+	}
+	return conflicts.Records{
+		Assignments:    assignments,
+		Absences:       absences,
+		Conscripts:     r.data.conscripts.list(func(c models.Conscript) bool { return c.Status == models.StatusDischarged }),
+		Duties:         r.data.duties.list(nil),
+		Qualifications: r.data.held.list(func(q models.ConscriptQualification) bool { return assigned[q.ConscriptID] }),
+	}, nil
+}
+
+func (r memoryAssignments) RecordOverride(override *models.ConflictOverride) error {
+	override.ID = uint(len(r.data.overrides) + 1)
+	r.data.overrides = append(r.data.overrides, *override)
+	return nil
+}
+
+type memoryAbsences struct {
+	*memoryTable[models.Absence]
+}
+
+func (r memoryAbsences) List(filter AbsenceFilter) ([]models.Absence, error) {
+	scoped := map[uint]bool{}
+	for _, id := range filter.ConscriptIDs {
+		scoped[id] = true
+	}
+	absences := r.list(func(a models.Absence) bool {
+		return (filter.ConscriptID == 0 || a.ConscriptID == filter.ConscriptID) &&
+			(filter.ConscriptIDs == nil || scoped[a.ConscriptID]) &&
+			(filter.Status == "" || a.Status == filter.Status) &&
+			(filter.From == nil || a.EndTime.After(*filter.From)) &&
+			(filter.To == nil || a.StartTime.Before(*filter.To)) &&
+			(filter.At == nil || !a.StartTime.After(*filter.At) && a.EndTime.After(*filter.At))
+	})
+	sortByStart(absences)
+	return absences, nil
+}
+
+// sortByStart orders absences by their start time, keeping the order of
+// those starting together.
+func sortByStart(absences []models.Absence) {
+	sort.SliceStable(absences, func(i, j int) bool { return absences[i].StartTime.Before(absences[j].StartTime) })
+}
+
+type memoryHolidays struct {
+	*memoryTable[models.Holiday]
+}
+
+func (r memoryHolidays) List() ([]models.Holiday, error) {
+	holidays := r.list(nil)
+	sort.SliceStable(holidays, func(i, j int) bool { return holidays[i].Date < holidays[j].Date })
+	return holidays, nil
+}
+
+type memoryShiftTemplates struct {
+	*memoryTable[models.ShiftTemplate]
+	data *memoryData
+}
+
+func (r memoryShiftTemplates) List(filter ShiftTemplateFilter) ([]models.ShiftTemplate, error) {
+	return r.list(func(t models.ShiftTemplate) bool {
+		return (filter.DutyID == 0 || t.DutyID == filter.DutyID) &&
+			(filter.ServiceID == 0 || r.data.duties.rows[t.DutyID].ServiceID == filter.ServiceID)
+	}), nil
+}
+
+type memoryQualifications struct {
+	*memoryTable[models.Qualification]
+	data *memoryData
+}
+
+func (r memoryQualifications) Delete(id uint) error {
+	if err := r.memoryTable.Delete(id); err != nil {
+		return err
+	}
+	for _, held := range r.data.held.list(func(q models.ConscriptQualification) bool { return q.QualificationID == id }) {
+		delete(r.data.held.rows, held.ID)
+	}
+	for dutyID, duty := range r.data.duties.rows {
+		var kept []models.Qualification
+		for _, q := range duty.Qualifications {
+			if q.ID != id {
+				kept = append(kept, q)
+			}
+		}
+		duty.Qualifications = kept
+		r.data.duties.rows[dutyID] = duty
+	}
+	return nil
+}
+
+func (r memoryQualifications) List() ([]models.Qualification, error) {
+	qualifications := r.list(nil)
+	sort.SliceStable(qualifications, func(i, j int) bool { return qualifications[i].Label < qualifications[j].Label })
+	return qualifications, nil
+}
+
+func (r memoryQualifications) Held(conscriptIDs []uint) ([]models.ConscriptQualification, error) {
+	scoped := map[uint]bool{}
+	for _, id := range conscriptIDs {
+		scoped[id] = true
+	}
+	held := r.data.held.list(func(q models.ConscriptQualification) bool { return conscriptIDs == nil || scoped[q.ConscriptID] })
+	for i := range held {
+		held[i].Qualification = r.rows[held[i].QualificationID]
+	}
+	sort.SliceStable(held, func(i, j int) bool {
+		if held[i].ConscriptID != held[j].ConscriptID {
+			return held[i].ConscriptID < held[j].ConscriptID
+		}
+		return held[i].QualificationID < held[j].QualificationID
+	})
+	return held, nil
+}
+
+func (r memoryQualifications) Grant(held *models.ConscriptQualification) error {
+	held.ID = 0
+	for _, existing := range r.data.held.list(func(q models.ConscriptQualification) bool {
+		return q.ConscriptID == held.ConscriptID && q.QualificationID == held.QualificationID
+	}) {
+		held.ID, held.CreatedAt = existing.ID, existing.CreatedAt
+	}
+	return r.data.held.Save(held)
+}
+
+func (r memoryQualifications) Revoke(conscriptID, qualificationID uint) error {
+	for _, held := range r.data.held.list(func(q models.ConscriptQualification) bool {
+		return q.ConscriptID == conscriptID && q.QualificationID == qualificationID
+	}) {
+		return r.data.held.Delete(held.ID)
+	}
+	return ErrNotFound
+}
+
+func (r memoryQualifications) SetRequired(dutyID uint, qualifications []models.Qualification) error {
+	duty, ok := r.data.duties.rows[dutyID]
+	if !ok {
+		return ErrNotFound
+	}
+	duty.Qualifications = append([]models.Qualification(nil), qualifications...)
+	r.data.duties.rows[dutyID] = duty
+	return nil
+}
+
+type memorySwaps struct {
+	*memoryTable[models.SwapRequest]
+	data *memoryData
+}
+
+// ofService reports whether the assignment is of a duty of the service.
+func (r memorySwaps) ofService(assignmentID, serviceID uint) bool {
+	assignment, ok := r.data.assignments.rows[assignmentID]
+	return ok && r.data.duties.rows[assignment.DutyID].ServiceID == serviceID
+}
+
+func (r memorySwaps) List(filter SwapFilter) ([]models.SwapRequest, error) {
+	assignments := map[uint]bool{}
+	for _, id := range filter.AssignmentIDs {
+		assignments[id] = true
+	}
+	swaps := r.list(func(swap models.SwapRequest) bool {
+		for _, id := range filter.Involving {
+			if swap.RequesterID != id && swap.CounterpartyID != id {
+				return false
+			}
+		}
+		return (filter.Status == "" || swap.Status == filter.Status) &&
+			(!filter.Pending || swap.Status.Pending()) &&
+			(filter.AssignmentIDs == nil || assignments[swap.AssignmentID] || assignments[swap.CounterpartAssignmentID]) &&
+			(filter.ServiceID == 0 || r.ofService(swap.AssignmentID, filter.ServiceID) || r.ofService(swap.CounterpartAssignmentID, filter.ServiceID))
+	})
+	sort.SliceStable(swaps, func(i, j int) bool { return swaps[i].CreatedAt.Before(swaps[j].CreatedAt) })
+	return swaps, nil
+}
+
+func (r memorySwaps) Expire(at time.Time) error {
+	for _, swap := range r.list(func(swap models.SwapRequest) bool { return swap.Status.Pending() && !swap.ExpiresAt.After(at) }) {
+		swap.Status = models.SwapExpired
+		r.rows[swap.ID] = swap
+	}
+	return nil
+}
+
+type memoryFeedTokens struct {
+	*memoryTable[models.FeedToken]
+}
+
+func (r memoryFeedTokens) Issue(token *models.FeedToken) error {
+	r.Revoke(token.ConscriptID)
+	return r.Create(token)
+}
+
+func (r memoryFeedTokens) FindByHash(hash string) (models.FeedToken, error) {
+	for _, token := range r.list(func(t models.FeedToken) bool { return t.TokenHash == hash }) {
+		return token, nil
+	}
+	return models.FeedToken{}, ErrNotFound
+}
+
+func (r memoryFeedTokens) Revoke(conscriptID uint) error {
+	tokens := r.list(func(t models.FeedToken) bool { return t.ConscriptID == conscriptID })
+	if len(tokens) == 0 {
+		return ErrNotFound
+	}
+	for _, token := range tokens {
+		delete(r.rows, token.ID)
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+// Package repository stores the aggregates of Pixis, such as conscripts,
+// departments, services, duties and assignments, behind interfaces, so that
+// the service layer and the handlers can run against the database or against
+// the in-memory store of their unit tests.
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// ErrNotFound is returned when a record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// Records stores the records of a model identified by their ID.
+type Records[T any] interface {
+	// Create stores a new record, filling in its ID.
+	Create(record *T) error
+	// Find returns the record with the ID, or ErrNotFound.
+	Find(id uint) (T, error)
+	// Save stores every field of the record.
+	Save(record *T) error
+	// Update stores the non-zero fields of changes on the record, which is
+	// updated in place.
+	Update(record *T, changes T) error
+	// Delete removes the record with the ID, or returns ErrNotFound.
+	Delete(id uint) error
+}
+
+// Conscripts stores conscripts.
+type Conscripts interface {
+	Records[models.Conscript]
+	// List returns the conscripts ordered by ID, only those of the given
+	// departments unless departmentIDs is nil.
+	List(departmentIDs []uint) ([]models.Conscript, error)
+	// ListAsOf returns the conscripts that belonged to a department at the
+	// time, with DepartmentID set to that department, only those of the
+	// given departments unless departmentIDs is nil.
+	ListAsOf(at time.Time, departmentIDs []uint) ([]models.Conscript, error)
+	// FindByUsername returns the conscript with the username, or
+	// ErrNotFound.
+	FindByUsername(username string) (models.Conscript, error)
+	// HasRole reports whether any conscript has the role.
+	HasRole(role models.Role) (bool, error)
+}
+
+// Memberships stores the department history of conscripts.
+type Memberships interface {
+	Records[models.DepartmentMembership]
+	// List returns the memberships of the conscript, oldest first.
+	List(conscriptID uint) ([]models.DepartmentMembership, error)
+}
+
+// Departments stores departments and their hierarchy.
+type Departments interface {
+	Records[models.Department]
+	// List returns the departments ordered by ID.
+	List() ([]models.Department, error)
+	// HasChildren reports whether departments are placed under the department.
+	HasChildren(id uint) (bool, error)
+	// SubtreeIDs returns the IDs of the department and of every department
+	// under it.
+	SubtreeIDs(id uint) ([]uint, error)
+	// Descendants returns the departments under the department, at any
+	// depth, ordered by label.
+	Descendants(id uint) ([]models.Department, error)
+	// Ancestors returns the departments above the department, from the root
+	// of the hierarchy down to its parent.
+	Ancestors(id uint) ([]models.Department, error)
+	// Commanded returns the IDs of the departments the conscript commands
+	// and of every department under them.
+	Commanded(conscriptID uint) ([]uint, error)
+}
+
+// Services stores services.
+type Services interface {
+	Records[models.Service]
+	// List returns the services ordered by ID, only those of the given
+	// departments unless departmentIDs is nil.
+	List(departmentIDs []uint) ([]models.Service, error)
+}
+
+// Duties stores duties.
+type Duties interface {
+	Records[models.Duty]
+	// List returns the duties ordered by ID.
+	List() ([]models.Duty, error)
+	// FindWithQualifications returns the duty with the qualifications it
+	// requires, or ErrNotFound.
+	FindWithQualifications(id uint) (models.Duty, error)
+}
+
+// AssignmentFilter restricts the assignments listed. Zero fields do not
+// restrict the list.
+type AssignmentFilter struct {
+	ConscriptID uint
+	DutyID      uint
+	// ServiceID lists only the assignments to duties of the service.
+	ServiceID uint
+	// From and To list only the assignments overlapping [From, To).
+	From *time.Time
+	To   *time.Time
+}
+
+// Assignments stores the assignments of duties to conscripts.
+type Assignments interface {
+	Records[models.ConscriptDuty]
+	// List returns the assignments ordered by start time.
+	List(filter AssignmentFilter) ([]models.ConscriptDuty, error)
+	// ConflictRecords loads the records the conflicts of the assignment are
+	// checked against, see conflicts.Window.
+	ConflictRecords(assignment models.ConscriptDuty) (conflicts.Records, error)
+	// ReportRecords loads the records the conflicts among the assignments
+	// in [from, to) are found in, see conflicts.ReportWindow.
+	ReportRecords(from, to time.Time) (conflicts.Records, error)
+	// RecordOverride records an administrator booking an assignment despite
+	// its conflicts.
+	RecordOverride(override *models.ConflictOverride) error
+}
+
+// AbsenceFilter restricts the absences listed. Zero fields do not restrict
+// the list.
+type AbsenceFilter struct {
+	ConscriptID uint
+	// ConscriptIDs lists only the absences of the conscripts, unless nil.
+	ConscriptIDs []uint
+	Status       models.AbsenceStatus
+	// From and To list only the absences overlapping [From, To).
+	From *time.Time
+	To   *time.Time
+	// At lists only the absences under way at the time.
+	At *time.Time
+}
+
+// Absences stores the absences of conscripts.
+type Absences interface {
+	Records[models.Absence]
+	// List returns the absences ordered by start time.
+	List(filter AbsenceFilter) ([]models.Absence, error)
+}
+
+// Holidays stores the public holidays.
+type Holidays interface {
+	Records[models.Holiday]
+	// List returns the holidays ordered by date.
+	List() ([]models.Holiday, error)
+}
+
+// ShiftTemplateFilter restricts the shift templates listed. Zero fields do
+// not restrict the list.
+type ShiftTemplateFilter struct {
+	DutyID uint
+	// ServiceID lists only the shift templates of the duties of the service.
+	ServiceID uint
+}
+
+// ShiftTemplates stores the recurring shifts of duties.
+type ShiftTemplates interface {
+	Records[models.ShiftTemplate]
+	// List returns the shift templates ordered by ID.
+	List(filter ShiftTemplateFilter) ([]models.ShiftTemplate, error)
+}
+
+// Qualifications stores the qualifications catalogue, the qualifications
+// conscripts hold and those duties require.
+type Qualifications interface {
+	// Delete also removes the qualification from the conscripts holding it
+	// and the duties requiring it.
+	Records[models.Qualification]
+	// List returns the qualifications ordered by label.
+	List() ([]models.Qualification, error)
+	// Held returns the qualifications held by the conscripts, loaded with
+	// their qualification, ordered by conscript and qualification, or those
+	// of every conscript when conscriptIDs is nil.
+	Held(conscriptIDs []uint) ([]models.ConscriptQualification, error)
+	// Grant stores a qualification held by a conscript, replacing the
+	// validity of the one already held.
+	Grant(held *models.ConscriptQualification) error
+	// Revoke removes a qualification from a conscript, or returns
+	// ErrNotFound if the conscript does not hold it.
+	Revoke(conscriptID, qualificationID uint) error
+	// SetRequired replaces the qualifications the duty requires.
+	SetRequired(dutyID uint, qualifications []models.Qualification) error
+}
+
+// SwapFilter selects swap requests. Zero fields do not restrict the
+// selection.
+type SwapFilter struct {
+	Status models.SwapStatus
+	// Pending selects only the swaps still proposed or accepted.
+	Pending bool
+	// Involving selects only the swaps every one of the conscripts takes
+	// part in, as requester or counterparty.
+	Involving []uint
+	// AssignmentIDs selects only the swaps of any of the assignments.
+	AssignmentIDs []uint
+	// ServiceID selects only the swaps of assignments to duties of the
+	// service.
+	ServiceID uint
+}
+
+// Swaps stores the requests of conscripts to exchange assignments.
+type Swaps interface {
+	Records[models.SwapRequest]
+	// List returns the swaps of the filter, oldest first.
+	List(filter SwapFilter) ([]models.SwapRequest, error)
+	// Expire marks the pending swaps past their expiry at the time as
+	// expired.
+	Expire(at time.Time) error
+}
+
+// FeedTokens stores the calendar feed tokens of conscripts by their hash.
+type FeedTokens interface {
+	// Issue stores the token, replacing the one the conscript had.
+	Issue(token *models.FeedToken) error
+	// FindByHash returns the token with the hash, or ErrNotFound.
+	FindByHash(hash string) (models.FeedToken, error)
+	// Revoke removes the token of the conscript, or returns ErrNotFound if
+	// they have none.
+	Revoke(conscriptID uint) error
+}
+
+// Store gives access to the repositories of every aggregate.
+type Store interface {
+	Conscripts() Conscripts
+	Departments() Departments
+	Services() Services
+	Duties() Duties
+	Assignments() Assignments
+	Absences() Absences
+	Holidays() Holidays
+	ShiftTemplates() ShiftTemplates
+	Qualifications() Qualifications
+	Memberships() Memberships
+	Swaps() Swaps
+	FeedTokens() FeedTokens
+	// Transaction runs fn with a store whose changes are kept only if fn
+	// returns nil. Transactions may be nested, in which case a failing inner
+	// transaction only undoes its own changes.
+	Transaction(fn func(tx Store) error) error
+}
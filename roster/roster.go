@@ -0,0 +1,336 @@
+// Package roster generates duty rosters: it assigns eligible conscripts to
+// duty slots without overlaps, with enough rest between duties, within a
+// weekly limit and only to duties they are qualified for, preferring the
+// conscripts with the fewest duty points so far.
+package roster
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/fairness"
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// ErrInvalidRequest is wrapped by the errors reporting an invalid request.
+var ErrInvalidRequest = errors.New("invalid roster request")
+
+// Slot is a period during which a duty must be staffed by Count conscripts.
+// A zero Count staffs the duty up to its capacity, or with one conscript
+// when the capacity is unlimited.
+type Slot struct {
+	DutyID    uint      `json:"duty_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Count     int       `json:"count"`
+}
+
+// Unavailability is a period during which a conscript cannot be assigned.
+type Unavailability struct {
+	ConscriptID uint      `json:"conscript_id"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+// Request describes the roster to generate.
+type Request struct {
+	Slots []Slot
+	// Candidates are the IDs of the conscripts eligible for the slots.
+	Candidates []uint
+	// MinimumRest is the minimum time between two duties of a conscript.
+	MinimumRest time.Duration
+	// MaxPerWeek limits the duties of a conscript per ISO week, counting
+	// existing assignments too. Zero means unlimited.
+	MaxPerWeek int
+	// Unavailable periods are respected in addition to the approved
+	// absences of the candidates.
+	Unavailable []Unavailability
+	// HistorySince is the start of the history that counts towards the load
+	// of each conscript.
+	HistorySince time.Time
+	// Location is the time zone of the unit, in which the ISO weeks of
+	// MaxPerWeek are counted. Nil means UTC.
+	Location *time.Location
+}
+
+// location returns the time zone the weeks of the request are counted in.
+func (req Request) location() *time.Location {
+	if req.Location == nil {
+		return time.UTC
+	}
+	return req.Location
+}
+
+// UnfilledSlot is a slot that could not be fully staffed.
+type UnfilledSlot struct {
+	Slot
+	Missing int `json:"missing"`
+}
+
+// Load is the duty points of a conscript before and within the generated
+// roster.
+type Load struct {
+	ConscriptID   uint    `json:"conscript_id"`
+	HistoryPoints float64 `json:"history_points"`
+	PlannedPoints float64 `json:"planned_points"`
+	Planned       int     `json:"planned"`
+}
+
+// Plan is a generated roster. Its assignments are not stored.
+type Plan struct {
+	Assignments []models.ConscriptDuty `json:"assignments"`
+	Unfilled    []UnfilledSlot         `json:"unfilled"`
+	Load        []Load                 `json:"load"`
+}
+
+// candidate tracks the state of a conscript while the roster is generated.
+type candidate struct {
+	load        *Load
+	assignments []models.ConscriptDuty
+	unavailable []Unavailability
+	weekly      map[int]int
+	location    *time.Location
+}
+
+// weekOf identifies the ISO week of t in the time zone.
+func weekOf(t time.Time, location *time.Location) int {
+	year, week := t.In(location).ISOWeek()
+	return year*100 + week
+}
+
+func (c *candidate) add(a models.ConscriptDuty) {
+	c.assignments = append(c.assignments, a)
+	c.weekly[weekOf(a.StartTime, c.location)]++
+}
+
+// fits reports whether the conscript can take the slot.
+func (c *candidate) fits(slot Slot, req Request) bool {
+	for _, u := range c.unavailable {
+		if u.StartTime.Before(slot.EndTime) && u.EndTime.After(slot.StartTime) {
+			return false
+		}
+	}
+	for _, a := range c.assignments {
+		if a.StartTime.Before(slot.EndTime.Add(req.MinimumRest)) && a.EndTime.After(slot.StartTime.Add(-req.MinimumRest)) {
+			return false
+		}
+	}
+	return req.MaxPerWeek == 0 || c.weekly[weekOf(slot.StartTime, c.location)] < req.MaxPerWeek
+}
+
+// Records are the stored records a roster is generated among, loaded by
+// the caller.
+type Records struct {
+	// Duties are the duties of the slots, with the qualifications they
+	// require.
+	Duties []models.Duty
+	// Assignments are the assignments overlapping the Window of the request.
+	Assignments []models.ConscriptDuty
+	// Absences are the approved absences of the candidates overlapping the
+	// Window of the request.
+	Absences []models.Absence
+	// Qualifications are the qualifications held by the candidates.
+	Qualifications []models.ConscriptQualification
+}
+
+// span returns the start of the first slot and the end of the last one.
+func span(slots []Slot) (first, last time.Time) {
+	for i, slot := range slots {
+		if i == 0 || slot.StartTime.Before(first) {
+			first = slot.StartTime
+		}
+		if i == 0 || slot.EndTime.After(last) {
+			last = slot.EndTime
+		}
+	}
+	return first.UTC(), last.UTC()
+}
+
+// weeks returns the start of the week of the first slot and the end of the
+// week of the last one, in the time zone of the request, widened by the rest period: the existing
+// assignments of the candidates within count towards the rest and weekly
+// limits.
+func weeks(req Request) (from, to time.Time) {
+	first, last := span(req.Slots)
+	location := req.location()
+	from = first.In(location)
+	from = time.Date(from.Year(), from.Month(), from.Day()-(int(from.Weekday())+6)%7, 0, 0, 0, 0, location)
+	to = last.In(location)
+	to = time.Date(to.Year(), to.Month(), to.Day()+7-(int(to.Weekday())+6)%7, 0, 0, 0, 0, location)
+	return from.UTC().Add(-req.MinimumRest), to.UTC().Add(req.MinimumRest)
+}
+
+// Window returns the range of the stored records that Generate needs for
+// the request: the history of the candidates since HistorySince and every
+// week the roster touches, widened by the rest period.
+func Window(req Request) (start, end time.Time) {
+	start, end = weeks(req)
+	if req.HistorySince.Before(start) {
+		start = req.HistorySince
+	}
+	return start, end
+}
+
+// Validate checks the slots and candidates of the request.
+func (req Request) Validate() error {
+	if len(req.Slots) == 0 {
+		return fmt.Errorf("%w: no slots", ErrInvalidRequest)
+	}
+	if len(req.Candidates) == 0 {
+		return fmt.Errorf("%w: no eligible conscripts", ErrInvalidRequest)
+	}
+	for i, slot := range req.Slots {
+		if !slot.EndTime.After(slot.StartTime) {
+			return fmt.Errorf("%w: slot %d must end after it starts", ErrInvalidRequest, i)
+		}
+		if slot.Count < 0 {
+			return fmt.Errorf("%w: slot %d has a negative count", ErrInvalidRequest, i)
+		}
+	}
+	return nil
+}
+
+// overlaps reports whether [start, end) overlaps [from, to).
+func overlaps(start, end, from, to time.Time) bool {
+	return start.Before(to) && end.After(from)
+}
+
+// Generate assigns the candidates to the slots in chronological order. Each
+// seat goes to the fitting candidate with the fewest duty points, weighed by
+// the scorer, in the history and the roster so far, so that the load evens
+// out over time. Existing assignments of the candidates are respected and
+// existing assignments of a duty count towards the staffing of its slots.
+// The records are loaded as documented by Window.
+func Generate(req Request, records Records, scorer *fairness.Scorer) (*Plan, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	slots := make([]Slot, len(req.Slots))
+	copy(slots, req.Slots)
+	for i, slot := range slots {
+		slots[i].StartTime, slots[i].EndTime = slot.StartTime.UTC(), slot.EndTime.UTC()
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].StartTime.Before(slots[j].StartTime)
+	})
+	first, last := span(slots)
+
+	duties := make(map[uint]models.Duty, len(records.Duties))
+	for _, duty := range records.Duties {
+		duties[duty.ID] = duty
+	}
+	for i, slot := range slots {
+		duty, ok := duties[slot.DutyID]
+		if !ok {
+			return nil, fmt.Errorf("%w: duty %d not found", ErrInvalidRequest, slot.DutyID)
+		}
+		if slot.Count == 0 {
+			slots[i].Count = max(duty.Capacity, 1)
+		}
+	}
+
+	candidates := make(map[uint]*candidate, len(req.Candidates))
+	plan := &Plan{Assignments: []models.ConscriptDuty{}, Unfilled: []UnfilledSlot{}}
+	for _, id := range req.Candidates {
+		if _, ok := candidates[id]; ok {
+			continue
+		}
+		plan.Load = append(plan.Load, Load{ConscriptID: id})
+		candidates[id] = &candidate{weekly: map[int]int{}, location: req.location()}
+	}
+	for i := range plan.Load {
+		candidates[plan.Load[i].ConscriptID].load = &plan.Load[i]
+	}
+	for _, u := range req.Unavailable {
+		if c, ok := candidates[u.ConscriptID]; ok {
+			c.unavailable = append(c.unavailable, u)
+		}
+	}
+	for _, absence := range records.Absences {
+		if c, ok := candidates[absence.ConscriptID]; ok && overlaps(absence.StartTime, absence.EndTime, first, last) {
+			c.unavailable = append(c.unavailable, Unavailability{ConscriptID: absence.ConscriptID, StartTime: absence.StartTime, EndTime: absence.EndTime})
+		}
+	}
+
+	// The existing assignments of the candidates in every week the roster
+	// touches count towards their rest and weekly limits, and those before
+	// the roster towards their history.
+	from, to := weeks(req)
+	var history []models.ConscriptDuty
+	for _, a := range records.Assignments {
+		c, ok := candidates[a.ConscriptID]
+		if !ok {
+			continue
+		}
+		if overlaps(a.StartTime, a.EndTime, from, to) {
+			c.add(a)
+		}
+		history = append(history, a)
+	}
+	for id, points := range fairness.Totals(scorer, history, req.HistorySince, first) {
+		candidates[id].load.HistoryPoints = points
+	}
+
+	for _, slot := range slots {
+		duty := duties[slot.DutyID]
+		missing := slot.Count
+		for _, assignments := range [][]models.ConscriptDuty{records.Assignments, plan.Assignments} {
+			for _, a := range assignments {
+				if a.DutyID == slot.DutyID && overlaps(a.StartTime, a.EndTime, slot.StartTime, slot.EndTime) {
+					missing--
+				}
+			}
+		}
+		points := scorer.Points(slot.DutyID, slot.StartTime, slot.EndTime)
+		for ; missing > 0; missing-- {
+			c := pick(plan.Load, candidates, duty, records.Qualifications, slot, req)
+			if c == nil {
+				break
+			}
+			a := models.ConscriptDuty{ConscriptID: c.load.ConscriptID, DutyID: slot.DutyID, StartTime: slot.StartTime, EndTime: slot.EndTime}
+			c.add(a)
+			c.load.Planned++
+			c.load.PlannedPoints += points
+			plan.Assignments = append(plan.Assignments, a)
+		}
+		if missing > 0 {
+			plan.Unfilled = append(plan.Unfilled, UnfilledSlot{Slot: slot, Missing: missing})
+		}
+	}
+	return plan, nil
+}
+
+// pick returns the fitting candidate holding the qualifications the duty
+// requires with the least load, or nil if no candidate fits the slot. Ties
+// go to the candidate with fewer planned duties, then to the lowest ID, so
+// that generation is deterministic.
+func pick(loads []Load, candidates map[uint]*candidate, duty models.Duty, held []models.ConscriptQualification, slot Slot, req Request) *candidate {
+	var best *candidate
+	for _, load := range loads {
+		c := candidates[load.ConscriptID]
+		if !c.fits(slot, req) {
+			continue
+		}
+		a := models.ConscriptDuty{ConscriptID: load.ConscriptID, DutyID: slot.DutyID, StartTime: slot.StartTime, EndTime: slot.EndTime}
+		if len(conflicts.Missing(duty, a, held)) > 0 {
+			continue
+		}
+		if best == nil || less(c.load, best.load) {
+			best = c
+		}
+	}
+	return best
+}
+
+func less(a, b *Load) bool {
+	if total := a.HistoryPoints + a.PlannedPoints; total != b.HistoryPoints+b.PlannedPoints {
+		return total < b.HistoryPoints+b.PlannedPoints
+	}
+	if a.Planned != b.Planned {
+		return a.Planned < b.Planned
+	}
+	return a.ConscriptID < b.ConscriptID
+}
@@ -0,0 +1,135 @@
+package roster
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/fairness"
+	"github.com/alexandrosraikos/pixis/models"
+)
+
+// at returns the time on a day of March 2025, in UTC. March 10 is a Monday.
+func at(day, hour int) time.Time {
+	return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+}
+
+func TestGenerate(t *testing.T) {
+	licence := models.Qualification{ID: 1, Label: "Driving licence"}
+	gate := models.Duty{ID: 1, Points: 1, Capacity: 2}
+	truck := models.Duty{ID: 2, Points: 1, Qualifications: []models.Qualification{licence}}
+	scorer := fairness.NewScorer([]models.Duty{gate, truck}, nil, nil)
+	records := Records{
+		Duties: []models.Duty{gate, truck},
+		Assignments: []models.ConscriptDuty{
+			// History: the first conscript has served the most.
+			{ConscriptID: 1, DutyID: 1, StartTime: at(3, 8), EndTime: at(3, 16)},
+			{ConscriptID: 1, DutyID: 1, StartTime: at(4, 8), EndTime: at(4, 16)},
+			{ConscriptID: 2, DutyID: 1, StartTime: at(5, 8), EndTime: at(5, 16)},
+			// Someone else already holds one seat of the gate.
+			{ConscriptID: 9, DutyID: 1, StartTime: at(12, 8), EndTime: at(12, 16)},
+		},
+		Absences: []models.Absence{{ConscriptID: 3, Status: models.AbsenceApproved, StartTime: at(12, 0), EndTime: at(13, 0)}},
+		Qualifications: []models.ConscriptQualification{
+			{ConscriptID: 1, QualificationID: licence.ID, ValidFrom: at(1, 0)},
+		},
+	}
+	req := Request{
+		Slots: []Slot{
+			{DutyID: gate.ID, StartTime: at(12, 8), EndTime: at(12, 16)},
+			{DutyID: truck.ID, StartTime: at(14, 8), EndTime: at(14, 16), Count: 2},
+		},
+		Candidates:   []uint{1, 2, 3},
+		MinimumRest:  8 * time.Hour,
+		HistorySince: at(1, 0),
+	}
+	plan, err := Generate(req, records, scorer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []models.ConscriptDuty{
+		// One seat of the gate is left, which goes to the least loaded
+		// conscript who is not absent.
+		{ConscriptID: 2, DutyID: gate.ID, StartTime: at(12, 8), EndTime: at(12, 16)},
+		// Only the first conscript holds the licence the truck requires.
+		{ConscriptID: 1, DutyID: truck.ID, StartTime: at(14, 8), EndTime: at(14, 16)},
+	}
+	if len(plan.Assignments) != len(expected) {
+		t.Fatalf("expected %+v, got %+v", expected, plan.Assignments)
+	}
+	for i, a := range expected {
+		if plan.Assignments[i] != a {
+			t.Errorf("expected %+v, got %+v", a, plan.Assignments[i])
+		}
+	}
+	if len(plan.Unfilled) != 1 || plan.Unfilled[0].DutyID != truck.ID || plan.Unfilled[0].Missing != 1 {
+		t.Errorf("expected the second seat of the truck to be unfilled, got %+v", plan.Unfilled)
+	}
+	if plan.Load[0].HistoryPoints != 2 || plan.Load[1].HistoryPoints != 1 || plan.Load[2].HistoryPoints != 0 {
+		t.Errorf("expected the history points of the candidates, got %+v", plan.Load)
+	}
+}
+
+func TestGenerateCountsWeeksInTimeZone(t *testing.T) {
+	athens, err := time.LoadLocation("Europe/Athens")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+	gate := models.Duty{ID: 1, Points: 1}
+	records := Records{
+		Duties:      []models.Duty{gate},
+		Assignments: []models.ConscriptDuty{{ConscriptID: 1, DutyID: 1, StartTime: at(10, 10), EndTime: at(10, 12)}},
+	}
+	// Late on Sunday in UTC is already Monday, the next week, in Athens.
+	req := Request{
+		Slots:        []Slot{{DutyID: gate.ID, StartTime: at(16, 23), EndTime: at(17, 1)}},
+		Candidates:   []uint{1},
+		MaxPerWeek:   1,
+		HistorySince: at(1, 0),
+	}
+	scorer := fairness.NewScorer(records.Duties, nil, nil)
+	plan, err := Generate(req, records, scorer)
+	if err != nil || len(plan.Assignments) != 0 {
+		t.Errorf("expected the weekly limit to be reached in UTC, got %+v, %v", plan, err)
+	}
+	req.Location = athens
+	plan, err = Generate(req, records, scorer)
+	if err != nil || len(plan.Assignments) != 1 {
+		t.Errorf("expected the slot to fall in the next week in Athens, got %+v, %v", plan, err)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	req := Request{
+		Slots:        []Slot{{DutyID: 1, StartTime: at(12, 8), EndTime: at(12, 16)}},
+		MinimumRest:  8 * time.Hour,
+		HistorySince: at(1, 0),
+	}
+	start, end := Window(req)
+	if !start.Equal(at(1, 0)) || !end.Equal(at(17, 8)) {
+		t.Errorf("expected the history and the week of the slot, got %v - %v", start, end)
+	}
+	req.HistorySince = at(12, 0)
+	if start, _ := Window(req); !start.Equal(at(9, 16)) {
+		t.Errorf("expected the start of the week widened by the rest, got %v", start)
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	slot := Slot{DutyID: 1, StartTime: at(12, 8), EndTime: at(12, 16)}
+	inverted := Slot{DutyID: 1, StartTime: at(12, 16), EndTime: at(12, 8)}
+	negative := Slot{DutyID: 1, StartTime: at(12, 8), EndTime: at(12, 16), Count: -1}
+	missing := Slot{DutyID: 2, StartTime: at(12, 8), EndTime: at(12, 16)}
+	records := Records{Duties: []models.Duty{{ID: 1}}}
+	for name, req := range map[string]Request{
+		"no slots":       {Candidates: []uint{1}},
+		"no candidates":  {Slots: []Slot{slot}},
+		"inverted slot":  {Slots: []Slot{inverted}, Candidates: []uint{1}},
+		"negative count": {Slots: []Slot{negative}, Candidates: []uint{1}},
+		"missing duty":   {Slots: []Slot{missing}, Candidates: []uint{1}},
+	} {
+		if _, err := Generate(req, records, fairness.NewScorer(nil, nil, nil)); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("%s: expected an invalid request, got %v", name, err)
+		}
+	}
+}
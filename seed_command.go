@@ -0,0 +1,120 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/alexandrosraikos/pixis/config"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+)
+
+// seedNames are the names of the demonstration conscripts.
+var seedNames = [][2]string{
+	{"Nikos", "Papadopoulos"}, {"Giorgos", "Georgiou"}, {"Kostas", "Dimitriou"}, {"Yannis", "Ioannou"},
+	{"Dimitris", "Konstantinou"}, {"Christos", "Nikolaou"}, {"Panagiotis", "Vasileiou"}, {"Vasilis", "Athanasiou"},
+}
+
+// runSeed implements `pixis seed`, which fills a database without
+// departments with a battalion, its company and platoons, guard and kitchen
+// duties and a few conscripts, all sharing one generated password.
+func runSeed(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	loader := config.Bind(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		fmt.Fprintln(stderr, "usage: pixis seed [-config FILE] [flags]")
+		return 2
+	}
+	db, err := connect(loader)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	password := generatePassword()
+	var created int
+	err = repository.NewGormStore(db).Transaction(func(tx repository.Store) error {
+		created, err = seed(tx, password)
+		return err
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "created 4 departments, 2 services, 3 duties and %d conscripts (conscript01 to conscript%02d)\n", created, created)
+	fmt.Fprintf(stdout, "password: %s\n", password)
+	return 0
+}
+
+// seed creates the demonstration data through the services and returns the
+// number of conscripts created.
+func seed(store repository.Store, password string) (int, error) {
+	existing, err := store.Departments().List()
+	if err != nil {
+		return 0, err
+	}
+	if len(existing) > 0 {
+		return 0, errors.New("the database already has departments, seed only fills new databases")
+	}
+
+	departments := service.NewDepartments(store)
+	battalion := models.Department{Label: "1st Battalion"}
+	if err := departments.Create(&battalion, true); err != nil {
+		return 0, err
+	}
+	company := models.Department{Label: "Alpha Company", ParentID: &battalion.ID}
+	if err := departments.Create(&company, true); err != nil {
+		return 0, err
+	}
+	platoons := []models.Department{
+		{Label: "1st Platoon", ParentID: &company.ID},
+		{Label: "2nd Platoon", ParentID: &company.ID},
+	}
+	for i := range platoons {
+		if err := departments.Create(&platoons[i], true); err != nil {
+			return 0, err
+		}
+	}
+
+	services := service.NewServices(store)
+	guard := models.Service{Label: "Alpha Guard", DepartmentID: company.ID}
+	kitchen := models.Service{Label: "Battalion Kitchen", DepartmentID: battalion.ID}
+	for _, s := range []*models.Service{&guard, &kitchen} {
+		if err := services.Create(s); err != nil {
+			return 0, err
+		}
+	}
+	duties := service.NewDuties(store)
+	for _, duty := range []models.Duty{
+		{Label: "Gate Guard", ServiceID: guard.ID, Capacity: 2, Points: 1, WeekendMultiplier: 1.5, NightMultiplier: 1.5, HolidayMultiplier: 2},
+		{Label: "Patrol", ServiceID: guard.ID, Capacity: 2, Points: 1.5, WeekendMultiplier: 1.5, NightMultiplier: 1.5, HolidayMultiplier: 2},
+		{Label: "Kitchen", ServiceID: kitchen.ID, Capacity: 3, Points: 1, WeekendMultiplier: 1, NightMultiplier: 1, HolidayMultiplier: 1.5},
+	} {
+		if err := duties.Create(&duty); err != nil {
+			return 0, err
+		}
+	}
+
+	conscripts := service.NewConscripts(store)
+	for i, name := range seedNames {
+		conscript := models.Conscript{
+			FirstName:      name[0],
+			LastName:       name[1],
+			RegistryNumber: fmt.Sprintf("SEED-%03d", i+1),
+			Username:       fmt.Sprintf("conscript%02d", i+1),
+			Password:       password,
+			Rank:           "Private",
+			DepartmentID:   platoons[i%len(platoons)].ID,
+		}
+		if err := conscripts.Create(&conscript, true); err != nil {
+			return 0, err
+		}
+	}
+	return len(seedNames), nil
+}
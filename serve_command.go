@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/backup"
+	"github.com/alexandrosraikos/pixis/config"
+	"github.com/alexandrosraikos/pixis/database"
+	"github.com/alexandrosraikos/pixis/handlers"
+	"github.com/alexandrosraikos/pixis/logging"
+	"github.com/alexandrosraikos/pixis/metrics"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"gorm.io/gorm"
+)
+
+// bootstrap hashes the passwords stored in plaintext by earlier versions
+// and creates the first administrator from the configuration, or warns when
+// nobody could log in.
+func bootstrap(store repository.Store, credentials config.Bootstrap, logger *slog.Logger) error {
+	hashed, err := service.NewConscripts(store).HashPasswords()
+	if hashed > 0 {
+		logger.Info("hashed the passwords stored in plaintext", slog.Int("conscripts", hashed))
+	}
+	if err != nil {
+		return err
+	}
+	if credentials.AdminUsername == "" {
+		exists, err := store.Conscripts().HasRole(models.RoleAdmin)
+		if err == nil && !exists {
+			logger.Warn("no administrator exists; set PIXIS_ADMIN_USERNAME and PIXIS_ADMIN_PASSWORD or run `pixis user create -admin`")
+		}
+		return err
+	}
+	created, err := service.NewConscripts(store).Bootstrap(credentials.AdminUsername, credentials.AdminPassword)
+	if created {
+		logger.Info("created the first administrator", slog.String("username", credentials.AdminUsername))
+	}
+	return err
+}
+
+// runServe runs the HTTP server with the configuration given by the flags,
+// the environment and the configuration file, refusing to start when it is
+// invalid.
+func runServe(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("pixis", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	loader := config.Bind(flags)
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := loader.Load()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "invalid configuration:", err)
+		return 1
+	}
+
+	// The configuration is valid, so its level and format are.
+	level, _ := logging.ParseLevel(cfg.Log.Level)
+	logger, _ := logging.New(stderr, level, cfg.Log.Format)
+	slog.SetDefault(logger)
+	if _, set := os.LookupEnv(gin.EnvGinMode); !set && level > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	db, err := database.Connect(cfg.Database.DSN)
+	if err != nil {
+		logger.Error("cannot start", slog.String("error", err.Error()))
+		return 1
+	}
+	db = db.Session(&gorm.Session{Logger: logging.Gorm(logger)})
+	handlers.ConfigureAuth(cfg.Auth.JWTSecret, cfg.Auth.TokenLifetime)
+	store := repository.NewGormStore(db)
+	if err := bootstrap(store, cfg.Bootstrap, logger); err != nil {
+		logger.Error("bootstrap failed", slog.String("error", err.Error()))
+		return 1
+	}
+	h := handlers.New(store)
+	health, err := handlers.NewHealthHandler(db)
+	if err != nil {
+		logger.Error("cannot start", slog.String("error", err.Error()))
+		return 1
+	}
+
+	// SIGTERM and SIGINT stop the scheduled backups and metrics collections
+	// and drain the server.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	var scheduled sync.WaitGroup
+	manager, err := backup.NewManager(db, backupOptions(cfg.Backup))
+	if err == nil && cfg.Backup.Interval > 0 {
+		scheduled.Add(1)
+		go func() {
+			defer scheduled.Done()
+			manager.Run(ctx, cfg.Backup.Interval, func(info backup.Info, err error) {
+				if err != nil {
+					logger.Error("scheduled backup failed", slog.String("error", err.Error()))
+					return
+				}
+				logger.Info("scheduled backup", slog.String("name", info.Name), slog.Int64("size", info.Size))
+			})
+		}()
+	}
+	backups := handlers.NewBackupHandler(store, manager)
+	observed, err := metrics.New(db)
+	if err != nil {
+		logger.Error("cannot start", slog.String("error", err.Error()))
+		return 1
+	}
+	scheduled.Add(1)
+	go func() {
+		defer scheduled.Done()
+		observed.Run(ctx, cfg.Metrics.Interval, handlers.CollectDomain(db), func(err error) {
+			logger.Error("metrics collection failed", slog.String("error", err.Error()))
+		})
+	}()
+
+	r := gin.New()
+	r.Use(logging.Middleware(logger, handlers.Principal), observed.Middleware(), logging.Recovery())
+
+	// Probes for orchestrators, which need no token.
+	r.GET("/healthz", health.Live)
+	r.GET("/readyz", health.Ready)
+
+	// Prometheus metrics, behind their own token when configured.
+	r.GET("/metrics", handlers.MetricsTokenMiddleware(cfg.Metrics.Token), gin.WrapH(observed.Handler()))
+
+	// Authentication routes.
+	r.POST("/auth/login", observed.Logins(), h.Auth.Login)
+
+	// Protected CRUD routes.
+	auth := r.Group("", h.Auth.Middleware())
+
+	// Conscript CRUD routes.
+	auth.POST("/conscripts", h.Conscripts.Create)
+	auth.POST("/conscripts:method", handlers.CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Conscripts.Batch,
+	}))
+	auth.GET("/conscripts", h.Conscripts.List)
+	auth.GET("/conscripts/:id", h.Conscripts.Get)
+	auth.PUT("/conscripts/:id", h.Conscripts.Update)
+	auth.DELETE("/conscripts/:id", h.Conscripts.Delete)
+	auth.POST("/conscripts/:id/transfer", h.Conscripts.Transfer)
+	auth.POST("/conscripts/:id/discharge", h.Conscripts.Discharge)
+	auth.GET("/conscripts/:id/memberships", h.Conscripts.Memberships)
+
+	// Department CRUD routes.
+	auth.POST("/departments", h.Departments.Create)
+	auth.POST("/departments:method", handlers.CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Departments.Batch,
+	}))
+	auth.GET("/departments", h.Departments.List)
+	auth.GET("/departments/:id", h.Departments.Get)
+	auth.GET("/departments/:id/tree", h.Departments.Tree)
+	auth.GET("/departments/:id/ancestors", h.Departments.Ancestors)
+	auth.GET("/departments/:id/descendants", h.Departments.Descendants)
+	auth.PUT("/departments/:id", h.Departments.Update)
+	auth.DELETE("/departments/:id", h.Departments.Delete)
+
+	// Duty CRUD routes.
+	auth.POST("/duties", h.Duties.Create)
+	auth.POST("/duties:method", handlers.CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Duties.Batch,
+	}))
+	auth.GET("/duties", h.Duties.List)
+	auth.GET("/duties/:id", h.Duties.Get)
+	auth.PUT("/duties/:id", h.Duties.Update)
+	auth.DELETE("/duties/:id", h.Duties.Delete)
+
+	// Service CRUD routes.
+	auth.POST("/services", h.Services.Create)
+	auth.POST("/services:method", handlers.CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Services.Batch,
+	}))
+	auth.GET("/services", h.Services.List)
+	auth.GET("/services/:id", h.Services.Get)
+	auth.PUT("/services/:id", h.Services.Update)
+	auth.DELETE("/services/:id", h.Services.Delete)
+
+	// Assignment CRUD routes.
+	auth.POST("/assignments", h.Assignments.Create)
+	auth.POST("/assignments:method", handlers.CustomMethods(map[string]gin.HandlerFunc{
+		"batch": h.Assignments.Batch,
+	}))
+	auth.GET("/assignments", h.Assignments.List)
+	auth.GET("/assignments/conflicts", h.Assignments.Conflicts)
+	auth.GET("/assignments/:id", h.Assignments.Get)
+	auth.PUT("/assignments/:id", h.Assignments.Update)
+	auth.DELETE("/assignments/:id", h.Assignments.Delete)
+
+	// Calendar feed routes, authenticated by the feed token in the URL.
+	auth.POST("/calendar/token", h.Calendar.CreateToken)
+	auth.DELETE("/calendar/token", h.Calendar.RevokeToken)
+	feeds := r.Group("/calendar/feeds/:token", h.Calendar.Authenticate())
+	feeds.GET("/conscripts/:id", h.Calendar.ConscriptFeed)
+	feeds.GET("/duties/:id", h.Calendar.DutyFeed)
+	feeds.GET("/services/:id", h.Calendar.ServiceFeed)
+
+	// Shift template CRUD routes.
+	auth.POST("/shift_templates", h.ShiftTemplates.Create)
+	auth.GET("/shift_templates", h.ShiftTemplates.List)
+	auth.GET("/shift_templates/:id", h.ShiftTemplates.Get)
+	auth.PUT("/shift_templates/:id", h.ShiftTemplates.Update)
+	auth.DELETE("/shift_templates/:id", h.ShiftTemplates.Delete)
+	auth.GET("/slots", h.ShiftTemplates.Slots)
+
+	// Absence routes, with the approval workflow.
+	auth.POST("/absences", h.Absences.Create)
+	auth.GET("/absences", h.Absences.List)
+	auth.GET("/absences/:id", h.Absences.Get)
+	auth.PUT("/absences/:id", h.Absences.Update)
+	auth.DELETE("/absences/:id", h.Absences.Delete)
+	auth.POST("/absences/:id/approve", h.Absences.Approve)
+	auth.POST("/absences/:id/reject", h.Absences.Reject)
+
+	// Qualification routes: the catalogue, the qualifications of conscripts
+	// and the requirements of duties.
+	auth.POST("/qualifications", h.Qualifications.Create)
+	auth.GET("/qualifications", h.Qualifications.List)
+	auth.GET("/qualifications/:id", h.Qualifications.Get)
+	auth.PUT("/qualifications/:id", h.Qualifications.Update)
+	auth.DELETE("/qualifications/:id", h.Qualifications.Delete)
+	auth.GET("/conscripts/:id/qualifications", h.Qualifications.Held)
+	auth.PUT("/conscripts/:id/qualifications/:qualification_id", h.Qualifications.Grant)
+	auth.DELETE("/conscripts/:id/qualifications/:qualification_id", h.Qualifications.Revoke)
+	auth.PUT("/duties/:id/qualifications", h.Qualifications.SetRequired)
+	auth.GET("/duties/:id/eligible-conscripts", h.Qualifications.Eligible)
+
+	// Swap routes, with the acceptance and approval workflow.
+	auth.POST("/swaps", h.Swaps.Create)
+	auth.GET("/swaps", h.Swaps.List)
+	auth.GET("/swaps/:id", h.Swaps.Get)
+	auth.POST("/swaps/:id/accept", h.Swaps.Accept)
+	auth.POST("/swaps/:id/decline", h.Swaps.Decline)
+	auth.POST("/swaps/:id/cancel", h.Swaps.Cancel)
+	auth.POST("/swaps/:id/approve", h.Swaps.Approve)
+	auth.POST("/swaps/:id/reject", h.Swaps.Reject)
+	auth.GET("/conscripts/:id/swaps", h.Swaps.Pending)
+	auth.GET("/services/:id/swaps", h.Swaps.PendingOfService)
+
+	// Holiday CRUD routes.
+	auth.POST("/holidays", h.Holidays.Create)
+	auth.GET("/holidays", h.Holidays.List)
+	auth.PUT("/holidays/:id", h.Holidays.Update)
+	auth.DELETE("/holidays/:id", h.Holidays.Delete)
+
+	// Report routes.
+	auth.GET("/reports/fairness", h.Fairness.Report)
+	auth.GET("/reports/strength", h.Absences.Strength)
+	auth.GET("/conscripts/:id/ledger", h.Fairness.Ledger)
+
+	// Roster routes.
+	auth.GET("/rosters/export", h.Rosters.Export)
+	auth.POST("/rosters/generate", h.Rosters.Generate)
+	auth.POST("/rosters/commit", h.Rosters.Commit)
+
+	// Import routes.
+	auth.POST("/imports/conscripts", h.Imports.Conscripts)
+
+	// Administration routes.
+	auth.POST("/admin/backups", backups.Create)
+	auth.GET("/admin/backups", backups.List)
+
+	// Auto-generated documentation endpoints.
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	server := &http.Server{
+		Addr:         cfg.Server.Address,
+		Handler:      r,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+	}
+	err = serve(ctx, server, cfg.Server.ShutdownTimeout, logger)
+	stop()
+	scheduled.Wait()
+	if sqlDB, dbErr := db.DB(); dbErr == nil {
+		err = errors.Join(err, sqlDB.Close())
+	}
+	if err != nil {
+		logger.Error("stopped", slog.String("error", err.Error()))
+		return 1
+	}
+	logger.Info("stopped")
+	return 0
+}
+
+// serve runs the server until ctx is done, and then stops accepting
+// connections and waits up to timeout for the requests in flight.
+func serve(ctx context.Context, server *http.Server, timeout time.Duration, logger *slog.Logger) error {
+	logger.Info("listening", slog.String("address", server.Addr))
+	errs := make(chan error, 1)
+	go func() { errs <- server.ListenAndServe() }()
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+	logger.Info("shutting down, waiting for the requests in flight")
+	shutdown, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		return fmt.Errorf("requests still in flight after %s: %w", timeout, err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+var absenceTypes = map[models.AbsenceType]bool{
+	models.AbsenceLeave:    true,
+	models.AbsenceSick:     true,
+	models.AbsenceTraining: true,
+	models.AbsenceOther:    true,
+}
+
+// DepartmentStrength is the number of conscripts of a department present and
+// absent at a point in time.
+type DepartmentStrength struct {
+	DepartmentID uint                       `json:"department_id"`
+	Label        string                     `json:"label"`
+	Total        int                        `json:"total"`
+	Present      int                        `json:"present"`
+	Absent       int                        `json:"absent"`
+	AbsentByType map[models.AbsenceType]int `json:"absent_by_type"`
+}
+
+// Absences manages the absences of conscripts and their approval.
+// Conscripts manage their own absences, commanders those of the conscripts
+// under their command and administrators anyone's.
+type Absences struct {
+	store repository.Store
+}
+
+// NewAbsences returns the absence service of the store.
+func NewAbsences(store repository.Store) *Absences {
+	return &Absences{store: store}
+}
+
+// checkAbsence validates the type and period of an absence.
+func checkAbsence(absence models.Absence) error {
+	if !absenceTypes[absence.Type] {
+		return &Error{KindInvalid, "Type must be one of leave, sick, training or other"}
+	}
+	if !absence.EndTime.After(absence.StartTime) {
+		return &Error{KindInvalid, "EndTime must be after StartTime"}
+	}
+	return nil
+}
+
+// Create requests a pending absence for the actor, or for the conscript it
+// names when the actor may act for them.
+func (s *Absences) Create(absence *models.Absence, actorID uint, admin bool) error {
+	if absence.ConscriptID == 0 {
+		absence.ConscriptID = actorID
+	}
+	allowed, err := canActFor(s.store, actorID, admin, absence.ConscriptID)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return &Error{KindForbidden, "Only administrators and commanders can request absences for others"}
+	}
+	if err := checkAbsence(*absence); err != nil {
+		return err
+	}
+	absence.ID = 0
+	absence.Status = models.AbsencePending
+	absence.ApproverID = 0
+	absence.DecidedAt = nil
+	return s.store.Absences().Create(absence)
+}
+
+// Get returns an absence the actor may access.
+func (s *Absences) Get(id, actorID uint, admin bool) (models.Absence, error) {
+	absence, err := s.store.Absences().Find(id)
+	if err != nil {
+		return absence, notFound(err, "Absence not found")
+	}
+	allowed, err := canActFor(s.store, actorID, admin, absence.ConscriptID)
+	if err != nil {
+		return absence, err
+	}
+	if !allowed {
+		return absence, &Error{KindForbidden, "Not allowed to access this absence"}
+	}
+	return absence, nil
+}
+
+// List returns the absences of the filter that the actor may access:
+// their own and those of the conscripts under their command, or anyone's
+// as an administrator.
+func (s *Absences) List(filter repository.AbsenceFilter, actorID uint, admin bool) ([]models.Absence, error) {
+	if !admin {
+		ids, err := commandedConscriptIDs(s.store, actorID)
+		if err != nil {
+			return nil, err
+		}
+		filter.ConscriptIDs = append(ids, actorID)
+	}
+	return s.store.Absences().List(filter)
+}
+
+// Update stores the non-zero type, period and reason of changes on a
+// pending absence.
+func (s *Absences) Update(id uint, changes models.Absence, actorID uint, admin bool) (models.Absence, error) {
+	absence, err := s.Get(id, actorID, admin)
+	if err != nil {
+		return absence, err
+	}
+	if absence.Status != models.AbsencePending {
+		return absence, &Error{KindConflict, "Only pending absences can be changed"}
+	}
+	if changes.Type != "" {
+		absence.Type = changes.Type
+	}
+	if !changes.StartTime.IsZero() {
+		absence.StartTime = changes.StartTime
+	}
+	if !changes.EndTime.IsZero() {
+		absence.EndTime = changes.EndTime
+	}
+	if changes.Reason != "" {
+		absence.Reason = changes.Reason
+	}
+	if err := checkAbsence(absence); err != nil {
+		return absence, err
+	}
+	return absence, s.store.Absences().Save(&absence)
+}
+
+// Delete cancels an absence. Only administrators may cancel absences that
+// have been decided.
+func (s *Absences) Delete(id, actorID uint, admin bool) error {
+	absence, err := s.Get(id, actorID, admin)
+	if err != nil {
+		return err
+	}
+	if absence.Status != models.AbsencePending && !admin {
+		return &Error{KindConflict, "Only pending absences can be cancelled"}
+	}
+	return notFound(s.store.Absences().Delete(id), "Absence not found")
+}
+
+// Decide approves or rejects a pending absence. Administrators decide on
+// any absence, and commanders on those of the conscripts under their
+// command other than themselves.
+func (s *Absences) Decide(id uint, status models.AbsenceStatus, actorID uint, admin bool) (models.Absence, error) {
+	absence, err := s.Get(id, actorID, admin)
+	if err != nil {
+		return absence, err
+	}
+	if !admin {
+		commands, err := commandsConscript(s.store, actorID, absence.ConscriptID)
+		if err != nil {
+			return absence, err
+		}
+		if actorID == absence.ConscriptID || !commands {
+			return absence, &Error{KindForbidden, "Only administrators and commanders can approve or reject absences"}
+		}
+	}
+	if absence.Status != models.AbsencePending {
+		return absence, &Error{KindConflict, "The absence has already been " + string(absence.Status)}
+	}
+	now := time.Now()
+	absence.Status = status
+	absence.ApproverID = actorID
+	absence.DecidedAt = &now
+	return absence, s.store.Absences().Save(&absence)
+}
+
+// Strength counts the conscripts of each department, or only of the
+// department unless departmentID is 0, present and absent by type of
+// approved absence at the time, ordered by the label of the department.
+func (s *Absences) Strength(at time.Time, departmentID uint) ([]DepartmentStrength, error) {
+	departments, err := s.store.Departments().List()
+	if err != nil {
+		return nil, err
+	}
+	conscripts, err := s.store.Conscripts().List(nil)
+	if err != nil {
+		return nil, err
+	}
+	absences, err := s.store.Absences().List(repository.AbsenceFilter{Status: models.AbsenceApproved, At: &at})
+	if err != nil {
+		return nil, err
+	}
+	absentAs := make(map[uint]models.AbsenceType, len(absences))
+	for _, absence := range absences {
+		absentAs[absence.ConscriptID] = absence.Type
+	}
+
+	strengths := []DepartmentStrength{}
+	for _, department := range departments {
+		if departmentID == 0 || department.ID == departmentID {
+			strengths = append(strengths, DepartmentStrength{DepartmentID: department.ID, Label: department.Label, AbsentByType: map[models.AbsenceType]int{}})
+		}
+	}
+	sort.SliceStable(strengths, func(i, j int) bool { return strengths[i].Label < strengths[j].Label })
+	byID := make(map[uint]*DepartmentStrength, len(strengths))
+	for i := range strengths {
+		byID[strengths[i].DepartmentID] = &strengths[i]
+	}
+	for _, conscript := range conscripts {
+		strength, ok := byID[conscript.DepartmentID]
+		if !ok {
+			continue
+		}
+		strength.Total++
+		if absenceType, absent := absentAs[conscript.ID]; absent {
+			strength.Absent++
+			strength.AbsentByType[absenceType]++
+		} else {
+			strength.Present++
+		}
+	}
+	return strengths, nil
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"strings"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// ConflictError rejects an assignment that has conflicts.
+type ConflictError struct {
+	Conflicts []conflicts.Conflict
+}
+
+func (e *ConflictError) Error() string {
+	return "Assignment conflicts with existing assignments: " + strings.Join(conflictKinds(e.Conflicts), ", ")
+}
+
+func conflictKinds(found []conflicts.Conflict) []string {
+	kinds := make([]string, len(found))
+	for i, conflict := range found {
+		kinds[i] = string(conflict.Kind)
+	}
+	return kinds
+}
+
+// errAssignmentsForbidden refuses to change an assignment to someone who
+// does not oversee its duty.
+var errAssignmentsForbidden = &Error{KindForbidden, "Only administrators and commanders of the duty can change its assignments"}
+
+// Assignments manages the assignments of duties to conscripts, which are
+// checked for conflicts before they are stored. Only administrators and the
+// commanders of the duty change assignments, so that conscripts exchange
+// theirs through swaps.
+type Assignments struct {
+	store repository.Store
+}
+
+// NewAssignments returns the assignment service of the store.
+func NewAssignments(store repository.Store) *Assignments {
+	return &Assignments{store: store}
+}
+
+// Conflicts returns the conflicts that saving the assignment would cause
+// with the stored records, found by the rules of the conflicts package.
+func (s *Assignments) Conflicts(assignment models.ConscriptDuty) ([]conflicts.Conflict, error) {
+	if err := conflicts.ValidateWindow(assignment); err != nil {
+		return nil, &Error{KindInvalid, err.Error()}
+	}
+	records, err := s.store.Assignments().ConflictRecords(assignment)
+	if err != nil {
+		return nil, err
+	}
+	return conflicts.Check(assignment, records)
+}
+
+// Check runs the conflict checks for an assignment about to be saved.
+// Conflicts are reported as a *ConflictError, unless adminID identifies the
+// administrator overriding them, in which case the override is recorded.
+func (s *Assignments) Check(assignment models.ConscriptDuty, adminID uint) error {
+	found, err := s.Conflicts(assignment)
+	if err != nil || len(found) == 0 {
+		return err
+	}
+	if adminID == 0 {
+		return &ConflictError{Conflicts: found}
+	}
+	return s.store.Assignments().RecordOverride(&models.ConflictOverride{
+		ConscriptID: assignment.ConscriptID,
+		DutyID:      assignment.DutyID,
+		StartTime:   assignment.StartTime,
+		EndTime:     assignment.EndTime,
+		AdminID:     adminID,
+		Conflicts:   strings.Join(conflictKinds(found), ","),
+	})
+}
+
+// authorize checks that the actor may change the assignment: administrators
+// change any, and commanders the assignments of other conscripts to duties
+// of the departments under their command, like Swaps.reviewable.
+func (s *Assignments) authorize(assignment models.ConscriptDuty, actorID uint, admin bool) error {
+	if admin {
+		return nil
+	}
+	if actorID == assignment.ConscriptID {
+		return errAssignmentsForbidden
+	}
+	commands, err := commandsDuty(s.store, actorID, assignment.DutyID)
+	if err != nil {
+		return err
+	}
+	if !commands {
+		return errAssignmentsForbidden
+	}
+	return nil
+}
+
+// Create books a new assignment, overriding its conflicts when adminID
+// identifies an administrator, see Check.
+func (s *Assignments) Create(assignment *models.ConscriptDuty, actorID uint, admin bool, adminID uint) error {
+	assignment.ID = 0
+	if err := s.authorize(*assignment, actorID, admin); err != nil {
+		return err
+	}
+	return s.store.Transaction(func(tx repository.Store) error {
+		if err := NewAssignments(tx).Check(*assignment, adminID); err != nil {
+			return err
+		}
+		return tx.Assignments().Create(assignment)
+	})
+}
+
+// Get returns an assignment.
+func (s *Assignments) Get(id uint) (models.ConscriptDuty, error) {
+	assignment, err := s.store.Assignments().Find(id)
+	return assignment, notFound(err, "Assignment not found")
+}
+
+// List returns the assignments matching the filter.
+func (s *Assignments) List(filter repository.AssignmentFilter) ([]models.ConscriptDuty, error) {
+	return s.store.Assignments().List(filter)
+}
+
+// merge applies the non-zero fields of changes to the assignment.
+func merge(assignment *models.ConscriptDuty, changes models.ConscriptDuty) {
+	if changes.ConscriptID != 0 {
+		assignment.ConscriptID = changes.ConscriptID
+	}
+	if changes.DutyID != 0 {
+		assignment.DutyID = changes.DutyID
+	}
+	if !changes.StartTime.IsZero() {
+		assignment.StartTime = changes.StartTime
+	}
+	if !changes.EndTime.IsZero() {
+		assignment.EndTime = changes.EndTime
+	}
+}
+
+// Update changes the conscript, duty, start or end time of an assignment,
+// keeping the fields of changes left zero. The updated assignment is
+// checked like new ones, and the actor must be allowed to change both the
+// assignment and its update.
+func (s *Assignments) Update(id uint, changes models.ConscriptDuty, actorID uint, admin bool, adminID uint) (models.ConscriptDuty, error) {
+	assignment, err := s.Get(id)
+	if err != nil {
+		return assignment, err
+	}
+	if err := s.authorize(assignment, actorID, admin); err != nil {
+		return assignment, err
+	}
+	merge(&assignment, changes)
+	if err := s.authorize(assignment, actorID, admin); err != nil {
+		return assignment, err
+	}
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := NewAssignments(tx).Check(assignment, adminID); err != nil {
+			return err
+		}
+		return tx.Assignments().Save(&assignment)
+	})
+	return assignment, err
+}
+
+// Delete removes an assignment.
+func (s *Assignments) Delete(id, actorID uint, admin bool) error {
+	assignment, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if err := s.authorize(assignment, actorID, admin); err != nil {
+		return err
+	}
+	return notFound(s.store.Assignments().Delete(id), "Assignment not found")
+}
+
+// Report returns the conflicts among the assignments in a range.
+func (s *Assignments) Report(from, to time.Time) ([]conflicts.Conflict, error) {
+	records, err := s.store.Assignments().ReportRecords(from, to)
+	if err != nil {
+		return nil, err
+	}
+	report := conflicts.Report(from, to, records)
+	if report == nil {
+		report = []conflicts.Conflict{}
+	}
+	return report, nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// commandsDepartment reports whether the actor commands the department or a
+// department above it.
+func commandsDepartment(store repository.Store, actorID, departmentID uint) (bool, error) {
+	if actorID == 0 || departmentID == 0 {
+		return false, nil
+	}
+	ids, err := store.Departments().Commanded(actorID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == departmentID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// commandsConscript reports whether the actor commands the department of the
+// conscript or a department above it.
+func commandsConscript(store repository.Store, actorID, conscriptID uint) (bool, error) {
+	conscript, err := store.Conscripts().Find(conscriptID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return commandsDepartment(store, actorID, conscript.DepartmentID)
+}
+
+// commandsDuty reports whether the actor commands the department of the
+// service of the duty or a department above it.
+func commandsDuty(store repository.Store, actorID, dutyID uint) (bool, error) {
+	duty, err := store.Duties().Find(dutyID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	service, err := store.Services().Find(duty.ServiceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return commandsDepartment(store, actorID, service.DepartmentID)
+}
+
+// commandsAssignment reports whether the actor commands the duty of the
+// assignment, see commandsDuty.
+func commandsAssignment(store repository.Store, actorID, assignmentID uint) (bool, error) {
+	assignment, err := store.Assignments().Find(assignmentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return commandsDuty(store, actorID, assignment.DutyID)
+}
+
+// commandedConscriptIDs returns the IDs of the conscripts of the departments
+// the actor commands, including those under them.
+func commandedConscriptIDs(store repository.Store, actorID uint) ([]uint, error) {
+	departmentIDs, err := store.Departments().Commanded(actorID)
+	if err != nil || len(departmentIDs) == 0 {
+		return nil, err
+	}
+	conscripts, err := store.Conscripts().List(departmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(conscripts))
+	for i, conscript := range conscripts {
+		ids[i] = conscript.ID
+	}
+	return ids, nil
+}
+
+// canActFor reports whether the actor may manage the records of the
+// conscript: their own, those of the conscripts under their command, or
+// anyone's as an administrator.
+func canActFor(store repository.Store, actorID uint, admin bool, conscriptID uint) (bool, error) {
+	if admin || actorID != 0 && actorID == conscriptID {
+		return true, nil
+	}
+	return commandsConscript(store, actorID, conscriptID)
+}
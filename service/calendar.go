@@ -0,0 +1,140 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// feedHistory is how far back calendar feeds include past assignments.
+const feedHistory = 90 * 24 * time.Hour
+
+// Feed is the name of a calendar feed and the assignments it lists, in
+// chronological order.
+type Feed struct {
+	Name        string
+	Assignments []models.ConscriptDuty
+}
+
+// Calendar issues the tokens authenticating the calendar feeds and selects
+// the assignments of the feeds. Calendar apps cannot send Bearer headers,
+// so the token is embedded in the feed URLs and only its hash is stored.
+// A token opens the feeds its conscript may access: their own, those of the
+// conscripts under their command and those of the duties and services of
+// the departments they command, or every feed for administrators.
+type Calendar struct {
+	store repository.Store
+}
+
+// NewCalendar returns the calendar service of the store.
+func NewCalendar(store repository.Store) *Calendar {
+	return &Calendar{store: store}
+}
+
+func hashFeedToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IssueToken issues a new feed token for the conscript, revoking any
+// previous one. The token is only returned here.
+func (s *Calendar) IssueToken(conscriptID uint) (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(secret)
+	return token, s.store.FeedTokens().Issue(&models.FeedToken{ConscriptID: conscriptID, TokenHash: hashFeedToken(token)})
+}
+
+// RevokeToken revokes the feed token of the conscript, disabling all feed
+// URLs that embed it.
+func (s *Calendar) RevokeToken(conscriptID uint) error {
+	return notFound(s.store.FeedTokens().Revoke(conscriptID), "Feed token not found")
+}
+
+// Authenticate returns the conscript the feed token was issued to.
+func (s *Calendar) Authenticate(token string) (uint, error) {
+	feedToken, err := s.store.FeedTokens().FindByHash(hashFeedToken(token))
+	return feedToken.ConscriptID, err
+}
+
+// feed lists the assignments of the filter from feedHistory ago.
+func (s *Calendar) feed(name string, filter repository.AssignmentFilter) (Feed, error) {
+	from := time.Now().Add(-feedHistory)
+	filter.From = &from
+	assignments, err := s.store.Assignments().List(filter)
+	return Feed{Name: name, Assignments: assignments}, err
+}
+
+// ConscriptFeed returns the feed of the duties of a conscript, to the
+// conscript, their commanders and administrators.
+func (s *Calendar) ConscriptFeed(id, actorID uint, admin bool) (Feed, error) {
+	conscript, err := s.store.Conscripts().Find(id)
+	if err != nil {
+		return Feed{}, notFound(err, "Conscript not found")
+	}
+	allowed, err := canActFor(s.store, actorID, admin, id)
+	if err != nil {
+		return Feed{}, err
+	}
+	if !allowed {
+		return Feed{}, &Error{KindForbidden, "Not allowed to access the feed of this conscript"}
+	}
+	return s.feed(fmt.Sprintf("Duties of %s %s", conscript.LastName, conscript.FirstName), repository.AssignmentFilter{ConscriptID: id})
+}
+
+// oversees checks that the actor is an administrator or commands the
+// department of the service, whose feeds only they may access.
+func (s *Calendar) oversees(service models.Service, actorID uint, admin bool) error {
+	if admin {
+		return nil
+	}
+	commands, err := commandsDepartment(s.store, actorID, service.DepartmentID)
+	if err != nil {
+		return err
+	}
+	if !commands {
+		return &Error{KindForbidden, "Only administrators and commanders can access the feeds of a service"}
+	}
+	return nil
+}
+
+// DutyFeed returns the feed of the assignments of a duty, to administrators
+// and commanders of the department of its service.
+func (s *Calendar) DutyFeed(id, actorID uint, admin bool) (Feed, error) {
+	duty, err := s.store.Duties().Find(id)
+	if err != nil {
+		return Feed{}, notFound(err, "Duty not found")
+	}
+	var service models.Service
+	if duty.ServiceID != 0 {
+		if service, err = s.store.Services().Find(duty.ServiceID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+			return Feed{}, err
+		}
+	}
+	if err := s.oversees(service, actorID, admin); err != nil {
+		return Feed{}, err
+	}
+	return s.feed(duty.Label, repository.AssignmentFilter{DutyID: id})
+}
+
+// ServiceFeed returns the feed of the assignments of all duties of a
+// service, to administrators and commanders of its department.
+func (s *Calendar) ServiceFeed(id, actorID uint, admin bool) (Feed, error) {
+	service, err := s.store.Services().Find(id)
+	if err != nil {
+		return Feed{}, notFound(err, "Service not found")
+	}
+	if err := s.oversees(service, actorID, admin); err != nil {
+		return Feed{}, err
+	}
+	return s.feed(service.Label, repository.AssignmentFilter{ServiceID: id})
+}
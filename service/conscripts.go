@@ -0,0 +1,322 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/passwords"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Conscripts manages conscripts.
+type Conscripts struct {
+	store repository.Store
+}
+
+// NewConscripts returns the conscript service of the store.
+func NewConscripts(store repository.Store) *Conscripts {
+	return &Conscripts{store: store}
+}
+
+// checkRole validates a requested role, which only administrators may grant.
+func checkRole(role models.Role, admin bool) error {
+	switch role {
+	case "", models.RoleConscript:
+		return nil
+	case models.RoleAdmin:
+		if !admin {
+			return &Error{KindForbidden, "Only administrators can assign roles"}
+		}
+		return nil
+	}
+	return &Error{KindInvalid, "Invalid role"}
+}
+
+// checkStatus validates a requested status. Discharges go through
+// POST /conscripts/:id/discharge, which records them.
+func checkStatus(status models.ConscriptStatus) error {
+	switch status {
+	case "", models.StatusActive, models.StatusOnLeave, models.StatusTransferred:
+		return nil
+	case models.StatusDischarged:
+		return &Error{KindInvalid, "Use POST /conscripts/{id}/discharge to discharge a conscript"}
+	}
+	return &Error{KindInvalid, "Status must be one of active, on_leave, transferred or discharged"}
+}
+
+func checkConscript(conscript models.Conscript, admin bool) error {
+	if err := checkRole(conscript.Role, admin); err != nil {
+		return err
+	}
+	return checkStatus(conscript.Status)
+}
+
+// hashPassword replaces the password of a conscript, when given, by its
+// hash.
+func hashPassword(conscript *models.Conscript) error {
+	if conscript.Password == "" {
+		return nil
+	}
+	hash, err := passwords.Hash(conscript.Password)
+	if errors.Is(err, passwords.ErrTooLong) {
+		return &Error{KindInvalid, "Passwords cannot be longer than 72 bytes"}
+	}
+	conscript.Password = hash
+	return err
+}
+
+// Create stores a new conscript, with the hash of its password. Only
+// administrators may create other administrators.
+func (s *Conscripts) Create(conscript *models.Conscript, admin bool) error {
+	if err := checkConscript(*conscript, admin); err != nil {
+		return err
+	}
+	if err := hashPassword(conscript); err != nil {
+		return err
+	}
+	return s.store.Conscripts().Create(conscript)
+}
+
+// Get returns a conscript.
+func (s *Conscripts) Get(id uint) (models.Conscript, error) {
+	conscript, err := s.store.Conscripts().Find(id)
+	return conscript, notFound(err, "Conscript not found")
+}
+
+// GetByUsername returns the conscript with the username.
+func (s *Conscripts) GetByUsername(username string) (models.Conscript, error) {
+	conscript, err := s.store.Conscripts().FindByUsername(username)
+	return conscript, notFound(err, "Conscript not found")
+}
+
+// List returns the conscripts of the departments, or of every department
+// when departmentIDs is nil. With asOf, the conscripts are listed as they
+// were at that time, with the department they then belonged to.
+func (s *Conscripts) List(departmentIDs []uint, asOf *time.Time) ([]models.Conscript, error) {
+	if asOf != nil {
+		return s.store.Conscripts().ListAsOf(*asOf, departmentIDs)
+	}
+	return s.store.Conscripts().List(departmentIDs)
+}
+
+// checkSelfUpdate validates the changes a conscript who is not an
+// administrator makes to their own account, which are limited to their
+// name and password. The rest, such as the role, the status or the
+// department, goes through administrators and the transfer and discharge
+// routes.
+func checkSelfUpdate(changes models.Conscript) error {
+	restricted := changes
+	restricted.ID, restricted.CreatedAt, restricted.UpdatedAt = 0, time.Time{}, time.Time{}
+	restricted.FirstName, restricted.LastName, restricted.Password = "", "", ""
+	if !reflect.ValueOf(restricted).IsZero() {
+		return &Error{KindForbidden, "Conscripts can only change their own first name, last name and password"}
+	}
+	return nil
+}
+
+// Update stores the non-zero fields of changes on a conscript for the
+// actor. Administrators may update any conscript; other conscripts only
+// their own name and password.
+func (s *Conscripts) Update(id uint, changes models.Conscript, actorID uint, admin bool) (models.Conscript, error) {
+	conscript, err := s.Get(id)
+	if err != nil {
+		return conscript, err
+	}
+	if !admin {
+		if id != actorID {
+			return conscript, &Error{KindForbidden, "Only administrators can update other conscripts"}
+		}
+		if err := checkSelfUpdate(changes); err != nil {
+			return conscript, err
+		}
+	}
+	if err := checkConscript(changes, admin); err != nil {
+		return conscript, err
+	}
+	if err := hashPassword(&changes); err != nil {
+		return conscript, err
+	}
+	err = s.store.Conscripts().Update(&conscript, changes)
+	return conscript, err
+}
+
+// SetDisabled disables the account of a conscript, which can then no longer
+// log in, or enables it again.
+func (s *Conscripts) SetDisabled(id uint, disabled bool) (models.Conscript, error) {
+	conscript, err := s.Get(id)
+	if err != nil {
+		return conscript, err
+	}
+	if disabled == (conscript.DisabledAt != nil) {
+		return conscript, nil
+	}
+	conscript.DisabledAt = nil
+	if disabled {
+		now := time.Now()
+		conscript.DisabledAt = &now
+	}
+	return conscript, s.store.Conscripts().Save(&conscript)
+}
+
+// HashPasswords replaces the plaintext passwords stored before passwords
+// were hashed by their hashes, and returns how many it hashed. It can run on
+// every start.
+func (s *Conscripts) HashPasswords() (int, error) {
+	conscripts, err := s.store.Conscripts().List(nil)
+	if err != nil {
+		return 0, err
+	}
+	hashed := 0
+	for _, conscript := range conscripts {
+		if conscript.Password == "" || passwords.IsHash(conscript.Password) {
+			continue
+		}
+		hash, err := passwords.Hash(conscript.Password)
+		if err != nil {
+			return hashed, fmt.Errorf("conscript %d: %w", conscript.ID, err)
+		}
+		if err := s.store.Conscripts().Update(&conscript, models.Conscript{Password: hash}); err != nil {
+			return hashed, err
+		}
+		hashed++
+	}
+	return hashed, nil
+}
+
+// Bootstrap creates the first administrator with the credentials, unless an
+// administrator already exists, and reports whether it did. It can run on
+// every start, also by several servers at once.
+func (s *Conscripts) Bootstrap(username, password string) (bool, error) {
+	exists, err := s.store.Conscripts().HasRole(models.RoleAdmin)
+	if err != nil || exists {
+		return false, err
+	}
+	if _, err := s.store.Conscripts().FindByUsername(username); err == nil {
+		return false, &Error{KindConflict, "The username of the first administrator belongs to a conscript"}
+	} else if !errors.Is(err, repository.ErrNotFound) {
+		return false, err
+	}
+	admin := models.Conscript{Username: username, RegistryNumber: username, Password: password, Role: models.RoleAdmin}
+	if err := s.Create(&admin, true); err != nil {
+		// Another server may have created the administrator meanwhile.
+		if exists, _ := s.store.Conscripts().HasRole(models.RoleAdmin); exists {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// lifecycleConscript returns a conscript that is still serving, for a
+// transfer or discharge, which only administrators may perform.
+func (s *Conscripts) lifecycleConscript(id uint, admin bool) (models.Conscript, error) {
+	if !admin {
+		return models.Conscript{}, &Error{KindForbidden, "Only administrators can transfer or discharge conscripts"}
+	}
+	conscript, err := s.Get(id)
+	if err != nil {
+		return conscript, err
+	}
+	if conscript.Status == models.StatusDischarged {
+		return conscript, &Error{KindConflict, "The conscript is already discharged"}
+	}
+	return conscript, nil
+}
+
+// closeMembership ends the current department membership of the conscript
+// at the given time. Conscripts without recorded memberships get one for
+// their department from their enlistment, or their creation, until then.
+func closeMembership(store repository.Store, conscript models.Conscript, end time.Time, reason string) error {
+	memberships, err := store.Memberships().List(conscript.ID)
+	if err != nil {
+		return err
+	}
+	for _, current := range memberships {
+		if current.EndDate == nil {
+			current.EndDate = &end
+			return store.Memberships().Save(&current)
+		}
+	}
+	if conscript.DepartmentID == 0 {
+		return nil
+	}
+	start := conscript.CreatedAt
+	if conscript.EnlistmentDate != nil {
+		start = *conscript.EnlistmentDate
+	}
+	return store.Memberships().Create(&models.DepartmentMembership{
+		ConscriptID:  conscript.ID,
+		DepartmentID: conscript.DepartmentID,
+		StartDate:    start,
+		EndDate:      &end,
+		Reason:       reason,
+	})
+}
+
+// Transfer moves a conscript to another department from the date, keeping
+// the membership of the previous department in their history.
+func (s *Conscripts) Transfer(id, departmentID uint, date time.Time, reason string, admin bool) (models.Conscript, error) {
+	conscript, err := s.lifecycleConscript(id, admin)
+	if err != nil {
+		return conscript, err
+	}
+	if departmentID == conscript.DepartmentID {
+		return conscript, &Error{KindConflict, "The conscript already belongs to this department"}
+	}
+	if _, err := s.store.Departments().Find(departmentID); err != nil {
+		return conscript, notFound(err, "Department not found")
+	}
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := closeMembership(tx, conscript, date, ""); err != nil {
+			return err
+		}
+		if err := tx.Memberships().Create(&models.DepartmentMembership{
+			ConscriptID:  conscript.ID,
+			DepartmentID: departmentID,
+			StartDate:    date,
+			Reason:       reason,
+		}); err != nil {
+			return err
+		}
+		return tx.Conscripts().Update(&conscript, models.Conscript{DepartmentID: departmentID})
+	})
+	return conscript, err
+}
+
+// Discharge discharges a conscript from the date, ending their department
+// membership, and returns the assignments they hold after it, which need
+// to be reassigned.
+func (s *Conscripts) Discharge(id uint, date time.Time, reason string, admin bool) (models.Conscript, []models.ConscriptDuty, error) {
+	conscript, err := s.lifecycleConscript(id, admin)
+	if err != nil {
+		return conscript, nil, err
+	}
+	var future []models.ConscriptDuty
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := closeMembership(tx, conscript, date, reason); err != nil {
+			return err
+		}
+		if err := tx.Conscripts().Update(&conscript, models.Conscript{Status: models.StatusDischarged, DischargeDate: &date}); err != nil {
+			return err
+		}
+		future, err = tx.Assignments().List(repository.AssignmentFilter{ConscriptID: conscript.ID, From: &date})
+		return err
+	})
+	return conscript, future, err
+}
+
+// Memberships returns the department history of a conscript, oldest first.
+func (s *Conscripts) Memberships(id uint) ([]models.DepartmentMembership, error) {
+	return s.store.Memberships().List(id)
+}
+
+// Delete removes a conscript. Only administrators may delete conscripts.
+func (s *Conscripts) Delete(id uint, admin bool) error {
+	if !admin {
+		return &Error{KindForbidden, "Only administrators can delete conscripts"}
+	}
+	return notFound(s.store.Conscripts().Delete(id), "Conscript not found")
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Departments manages departments and their hierarchy.
+type Departments struct {
+	store repository.Store
+}
+
+// NewDepartments returns the department service of the store.
+func NewDepartments(store repository.Store) *Departments {
+	return &Departments{store: store}
+}
+
+// check validates the parent and the commander of a department. The parent
+// must exist and must not be the department itself or one of its
+// sub-departments. Only administrators may appoint commanders, who gain
+// authority over the department and every department under it.
+func (s *Departments) check(department models.Department, previousCommander *uint, admin bool) error {
+	if department.ParentID != nil {
+		if _, err := s.store.Departments().Find(*department.ParentID); err != nil {
+			return notFound(err, "Parent department not found")
+		}
+		if department.ID != 0 {
+			ids, err := s.store.Departments().SubtreeIDs(department.ID)
+			if err != nil {
+				return err
+			}
+			for _, id := range ids {
+				if id == *department.ParentID {
+					return &Error{KindConflict, "A department cannot be placed under itself or its sub-departments"}
+				}
+			}
+		}
+	}
+	if department.CommanderID == nil || previousCommander != nil && *previousCommander == *department.CommanderID {
+		return nil
+	}
+	if !admin {
+		return &Error{KindForbidden, "Only administrators can appoint commanders"}
+	}
+	if _, err := s.store.Conscripts().Find(*department.CommanderID); err != nil {
+		return notFound(err, "Commander not found")
+	}
+	return nil
+}
+
+// Create stores a new department, optionally under a parent department.
+// Only administrators may appoint its commander.
+func (s *Departments) Create(department *models.Department, admin bool) error {
+	department.ID = 0
+	if err := s.check(*department, nil, admin); err != nil {
+		return err
+	}
+	return s.store.Departments().Create(department)
+}
+
+// Get returns a department.
+func (s *Departments) Get(id uint) (models.Department, error) {
+	department, err := s.store.Departments().Find(id)
+	return department, notFound(err, "Department not found")
+}
+
+// List returns every department.
+func (s *Departments) List() ([]models.Department, error) {
+	return s.store.Departments().List()
+}
+
+// Replace stores every field of a department, which had previousCommander
+// as its commander. The department cannot be moved under itself or one of
+// its sub-departments, and only administrators may appoint its commander.
+func (s *Departments) Replace(department *models.Department, previousCommander *uint, admin bool) error {
+	if err := s.check(*department, previousCommander, admin); err != nil {
+		return err
+	}
+	return s.store.Departments().Save(department)
+}
+
+// Update stores the non-zero fields of changes on a department, under the
+// rules of Replace.
+func (s *Departments) Update(id uint, changes models.Department, admin bool) (models.Department, error) {
+	department, err := s.Get(id)
+	if err != nil {
+		return department, err
+	}
+	changes.ID = id
+	if err := s.check(changes, department.CommanderID, admin); err != nil {
+		return department, err
+	}
+	err = s.store.Departments().Update(&department, changes)
+	return department, err
+}
+
+// Delete removes a department. Departments with sub-departments cannot be
+// deleted, which would leave them detached from the hierarchy.
+func (s *Departments) Delete(id uint) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	children, err := s.store.Departments().HasChildren(id)
+	if err != nil {
+		return err
+	}
+	if children {
+		return &Error{KindConflict, "The department has sub-departments"}
+	}
+	return notFound(s.store.Departments().Delete(id), "Department not found")
+}
+
+// Scope returns the IDs of the department, and of the departments under it
+// with subdepartments, that a list is restricted to.
+func (s *Departments) Scope(id uint, subdepartments bool) ([]uint, error) {
+	if !subdepartments {
+		return []uint{id}, nil
+	}
+	ids, err := s.store.Departments().SubtreeIDs(id)
+	if ids == nil {
+		ids = []uint{}
+	}
+	return ids, err
+}
+
+// Tree returns a department with its sub-departments nested in Children,
+// down to the lowest level, ordered by label.
+func (s *Departments) Tree(id uint) (models.Department, error) {
+	root, err := s.Get(id)
+	if err != nil {
+		return root, err
+	}
+	departments, err := s.store.Departments().Descendants(id)
+	if err != nil {
+		return root, err
+	}
+	children := make(map[uint][]models.Department)
+	for _, department := range departments {
+		children[*department.ParentID] = append(children[*department.ParentID], department)
+	}
+	var build func(department models.Department) models.Department
+	build = func(department models.Department) models.Department {
+		department.Children = []models.Department{}
+		for _, child := range children[department.ID] {
+			department.Children = append(department.Children, build(child))
+		}
+		return department
+	}
+	return build(root), nil
+}
+
+// Ancestors returns the departments above a department, from the root of
+// the hierarchy down to its parent.
+func (s *Departments) Ancestors(id uint) ([]models.Department, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return s.store.Departments().Ancestors(id)
+}
+
+// Descendants returns every department under a department, at any depth,
+// ordered by label.
+func (s *Departments) Descendants(id uint) ([]models.Department, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return s.store.Departments().Descendants(id)
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Duties manages duties.
+type Duties struct {
+	store repository.Store
+}
+
+// NewDuties returns the duty service of the store.
+func NewDuties(store repository.Store) *Duties {
+	return &Duties{store: store}
+}
+
+// Create stores a new duty.
+func (s *Duties) Create(duty *models.Duty) error {
+	return s.store.Duties().Create(duty)
+}
+
+// Get returns a duty.
+func (s *Duties) Get(id uint) (models.Duty, error) {
+	duty, err := s.store.Duties().Find(id)
+	return duty, notFound(err, "Duty not found")
+}
+
+// GetWithQualifications returns a duty with the qualifications it requires.
+func (s *Duties) GetWithQualifications(id uint) (models.Duty, error) {
+	duty, err := s.store.Duties().FindWithQualifications(id)
+	return duty, notFound(err, "Duty not found")
+}
+
+// List returns every duty.
+func (s *Duties) List() ([]models.Duty, error) {
+	return s.store.Duties().List()
+}
+
+// Replace stores every field of a duty.
+func (s *Duties) Replace(duty *models.Duty) error {
+	return s.store.Duties().Save(duty)
+}
+
+// Update stores the non-zero fields of changes on a duty.
+func (s *Duties) Update(id uint, changes models.Duty) (models.Duty, error) {
+	duty, err := s.Get(id)
+	if err != nil {
+		return duty, err
+	}
+	err = s.store.Duties().Update(&duty, changes)
+	return duty, err
+}
+
+// Delete removes a duty.
+func (s *Duties) Delete(id uint) error {
+	return notFound(s.store.Duties().Delete(id), "Duty not found")
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"time"
+
+	"github.com/alexandrosraikos/pixis/fairness"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Fairness reports the duty points earned by conscripts, weighed by the
+// rules of the fairness package.
+type Fairness struct {
+	store repository.Store
+}
+
+// NewFairness returns the fairness service of the store.
+func NewFairness(store repository.Store) *Fairness {
+	return &Fairness{store: store}
+}
+
+// loadScorer returns the scorer of the stored duties and holidays in the
+// location, UTC when nil.
+func loadScorer(store repository.Store, location *time.Location) (*fairness.Scorer, error) {
+	duties, err := store.Duties().List()
+	if err != nil {
+		return nil, err
+	}
+	holidays, err := store.Holidays().List()
+	if err != nil {
+		return nil, err
+	}
+	return fairness.NewScorer(duties, fairness.NewHolidays(holidays), location), nil
+}
+
+// Report compares the points earned by the conscripts of a department with
+// assignments starting within [from, to), see fairness.Compare, telling
+// weekends and nights apart in the location, UTC when nil.
+func (s *Fairness) Report(departmentID uint, from, to time.Time, threshold float64, location *time.Location) (*fairness.Report, error) {
+	if _, err := s.store.Departments().Find(departmentID); err != nil {
+		return nil, notFound(err, "Department not found")
+	}
+	conscripts, err := s.store.Conscripts().List([]uint{departmentID})
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := s.store.Assignments().List(repository.AssignmentFilter{From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	scorer, err := loadScorer(s.store, location)
+	if err != nil {
+		return nil, err
+	}
+	return fairness.Compare(scorer, conscripts, assignments, from, to, threshold), nil
+}
+
+// Ledger returns the points history of a conscript with the assignments
+// starting within [from, to), scored in the location like Report.
+func (s *Fairness) Ledger(conscriptID uint, from, to time.Time, location *time.Location) (*fairness.Ledger, error) {
+	if _, err := s.store.Conscripts().Find(conscriptID); err != nil {
+		return nil, notFound(err, "Conscript not found")
+	}
+	assignments, err := s.store.Assignments().List(repository.AssignmentFilter{ConscriptID: conscriptID, From: &from, To: &to})
+	if err != nil {
+		return nil, err
+	}
+	scorer, err := loadScorer(s.store, location)
+	if err != nil {
+		return nil, err
+	}
+	return fairness.ConscriptLedger(scorer, conscriptID, assignments, from, to), nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// errHolidaysAdminOnly refuses changes to the holidays by conscripts who are
+// not administrators.
+var errHolidaysAdminOnly = &Error{KindForbidden, "Only administrators can manage holidays"}
+
+// Holidays manages the public holidays, on which assignments earn the
+// holiday multiplier of their duty. Everyone may list the holidays, but only
+// administrators change them.
+type Holidays struct {
+	store repository.Store
+}
+
+// NewHolidays returns the holiday service of the store.
+func NewHolidays(store repository.Store) *Holidays {
+	return &Holidays{store: store}
+}
+
+// checkHoliday validates the date of a holiday, given as YYYY-MM-DD.
+func checkHoliday(holiday models.Holiday) error {
+	if _, err := time.Parse(time.DateOnly, holiday.Date); err != nil {
+		return &Error{KindInvalid, "Date must be given as YYYY-MM-DD"}
+	}
+	return nil
+}
+
+// Create stores a new holiday.
+func (s *Holidays) Create(holiday *models.Holiday, admin bool) error {
+	if !admin {
+		return errHolidaysAdminOnly
+	}
+	if err := checkHoliday(*holiday); err != nil {
+		return err
+	}
+	return s.store.Holidays().Create(holiday)
+}
+
+// Get returns a holiday.
+func (s *Holidays) Get(id uint) (models.Holiday, error) {
+	holiday, err := s.store.Holidays().Find(id)
+	return holiday, notFound(err, "Holiday not found")
+}
+
+// List returns every holiday by date.
+func (s *Holidays) List() ([]models.Holiday, error) {
+	return s.store.Holidays().List()
+}
+
+// Replace stores every field of a holiday.
+func (s *Holidays) Replace(holiday *models.Holiday, admin bool) error {
+	if !admin {
+		return errHolidaysAdminOnly
+	}
+	if err := checkHoliday(*holiday); err != nil {
+		return err
+	}
+	return s.store.Holidays().Save(holiday)
+}
+
+// Delete removes a holiday.
+func (s *Holidays) Delete(id uint, admin bool) error {
+	if !admin {
+		return errHolidaysAdminOnly
+	}
+	return notFound(s.store.Holidays().Delete(id), "Holiday not found")
+}
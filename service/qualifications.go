@@ -0,0 +1,197 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Qualifications manages the qualifications catalogue, the qualifications
+// conscripts hold and those duties require. Only administrators grant and
+// revoke qualifications.
+type Qualifications struct {
+	store repository.Store
+}
+
+// NewQualifications returns the qualification service of the store.
+func NewQualifications(store repository.Store) *Qualifications {
+	return &Qualifications{store: store}
+}
+
+// checkQualification validates the label of a qualification.
+func checkQualification(qualification models.Qualification) error {
+	if qualification.Label == "" {
+		return &Error{KindInvalid, "Label is required"}
+	}
+	return nil
+}
+
+// Create stores a new qualification.
+func (s *Qualifications) Create(qualification *models.Qualification) error {
+	if err := checkQualification(*qualification); err != nil {
+		return err
+	}
+	return s.store.Qualifications().Create(qualification)
+}
+
+// Get returns a qualification.
+func (s *Qualifications) Get(id uint) (models.Qualification, error) {
+	qualification, err := s.store.Qualifications().Find(id)
+	return qualification, notFound(err, "Qualification not found")
+}
+
+// List returns the qualifications ordered by label.
+func (s *Qualifications) List() ([]models.Qualification, error) {
+	return s.store.Qualifications().List()
+}
+
+// Replace stores every field of a qualification.
+func (s *Qualifications) Replace(qualification *models.Qualification) error {
+	if err := checkQualification(*qualification); err != nil {
+		return err
+	}
+	return s.store.Qualifications().Save(qualification)
+}
+
+// Delete removes a qualification from the catalogue, the conscripts holding
+// it and the duties requiring it.
+func (s *Qualifications) Delete(id uint) error {
+	return notFound(s.store.Qualifications().Delete(id), "Qualification not found")
+}
+
+// Held returns the qualifications held by the conscript, or only those valid
+// at the time unless at is nil, ordered by qualification.
+func (s *Qualifications) Held(conscriptID uint, at *time.Time) ([]models.ConscriptQualification, error) {
+	held, err := s.store.Qualifications().Held([]uint{conscriptID})
+	if err != nil || at == nil {
+		return held, err
+	}
+	valid := []models.ConscriptQualification{}
+	for _, q := range held {
+		if q.ValidAt(*at) {
+			valid = append(valid, q)
+		}
+	}
+	return valid, nil
+}
+
+// Grant grants a qualification to a conscript from validFrom until
+// validUntil, or indefinitely when it is nil, renewing it if the conscript
+// already holds it.
+func (s *Qualifications) Grant(conscriptID, qualificationID uint, validFrom time.Time, validUntil *time.Time, admin bool) (models.ConscriptQualification, error) {
+	held := models.ConscriptQualification{ConscriptID: conscriptID, QualificationID: qualificationID, ValidFrom: validFrom, ValidUntil: validUntil}
+	if !admin {
+		return held, &Error{KindForbidden, "Only administrators can grant qualifications"}
+	}
+	if validUntil != nil && !validUntil.After(validFrom) {
+		return held, &Error{KindInvalid, "valid_until must be after valid_from"}
+	}
+	if _, err := s.store.Conscripts().Find(conscriptID); err != nil {
+		return held, notFound(err, "Conscript not found")
+	}
+	qualification, err := s.Get(qualificationID)
+	if err != nil {
+		return held, err
+	}
+	if err := s.store.Qualifications().Grant(&held); err != nil {
+		return held, err
+	}
+	held.Qualification = qualification
+	return held, nil
+}
+
+// Revoke removes a qualification from a conscript.
+func (s *Qualifications) Revoke(conscriptID, qualificationID uint, admin bool) error {
+	if !admin {
+		return &Error{KindForbidden, "Only administrators can revoke qualifications"}
+	}
+	return notFound(s.store.Qualifications().Revoke(conscriptID, qualificationID), "The conscript does not hold this qualification")
+}
+
+// SetRequired replaces the qualifications the duty requires and returns
+// them ordered by label.
+func (s *Qualifications) SetRequired(dutyID uint, qualificationIDs []uint) ([]models.Qualification, error) {
+	if _, err := s.store.Duties().Find(dutyID); err != nil {
+		return nil, notFound(err, "Duty not found")
+	}
+	catalogue, err := s.store.Qualifications().List()
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[uint]bool, len(qualificationIDs))
+	for _, id := range qualificationIDs {
+		wanted[id] = true
+	}
+	qualifications := []models.Qualification{}
+	for _, q := range catalogue {
+		if wanted[q.ID] {
+			qualifications = append(qualifications, q)
+			delete(wanted, q.ID)
+		}
+	}
+	for _, id := range qualificationIDs {
+		if wanted[id] {
+			return nil, &Error{KindNotFound, fmt.Sprintf("Qualification %d not found", id)}
+		}
+	}
+	return qualifications, s.store.Qualifications().SetRequired(dutyID, qualifications)
+}
+
+// Eligible returns the conscripts, of the department unless departmentID is
+// 0, holding every qualification the duty requires throughout [from, to),
+// ordered by ID. When available is set, the conscripts who could not be
+// assigned the duty for the period because of other duties, rest or
+// approved absences are left out too.
+func (s *Qualifications) Eligible(dutyID uint, from, to time.Time, departmentID uint, available bool) ([]models.Conscript, error) {
+	duty, err := s.store.Duties().FindWithQualifications(dutyID)
+	if err != nil {
+		return nil, notFound(err, "Duty not found")
+	}
+	var departmentIDs []uint
+	if departmentID != 0 {
+		departmentIDs = []uint{departmentID}
+	}
+	conscripts, err := s.store.Conscripts().List(departmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	var held []models.ConscriptQualification
+	if len(duty.Qualifications) > 0 {
+		if held, err = s.store.Qualifications().Held(nil); err != nil {
+			return nil, err
+		}
+	}
+	assignments := NewAssignments(s.store)
+	eligible := []models.Conscript{}
+	for _, conscript := range conscripts {
+		assignment := models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: dutyID, StartTime: from, EndTime: to}
+		if len(conflicts.Missing(duty, assignment, held)) > 0 {
+			continue
+		}
+		if available {
+			found, err := assignments.Conflicts(assignment)
+			if err != nil {
+				return nil, err
+			}
+			if personalConflict(found) {
+				continue
+			}
+		}
+		eligible = append(eligible, conscript)
+	}
+	return eligible, nil
+}
+
+// personalConflict reports whether any of the conflicts concerns the
+// conscript rather than the staffing of the duty.
+func personalConflict(found []conflicts.Conflict) bool {
+	for _, conflict := range found {
+		if conflict.Kind != conflicts.KindCapacity {
+			return true
+		}
+	}
+	return false
+}
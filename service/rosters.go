@@ -0,0 +1,149 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/roster"
+)
+
+// Rosters generates duty rosters by the rules of the roster package and
+// commits them as assignments.
+type Rosters struct {
+	store repository.Store
+}
+
+// NewRosters returns the roster service of the store.
+func NewRosters(store repository.Store) *Rosters {
+	return &Rosters{store: store}
+}
+
+// Candidates returns the IDs of the active conscripts of the department, of
+// the department of the service, each with the departments under it, or
+// with the given IDs, ordered by ID. Filters that are not zero are
+// combined.
+func (s *Rosters) Candidates(departmentID, serviceID uint, conscriptIDs []uint) ([]uint, error) {
+	var departmentIDs []uint
+	if departmentID != 0 {
+		if _, err := s.store.Departments().Find(departmentID); err != nil {
+			return nil, notFound(err, "Department not found")
+		}
+		ids, err := s.store.Departments().SubtreeIDs(departmentID)
+		if err != nil {
+			return nil, err
+		}
+		departmentIDs = ids
+	}
+	if serviceID != 0 {
+		service, err := s.store.Services().Find(serviceID)
+		if err != nil {
+			return nil, notFound(err, "Service not found")
+		}
+		ids, err := s.store.Departments().SubtreeIDs(service.DepartmentID)
+		if err != nil {
+			return nil, err
+		}
+		if departmentID != 0 {
+			within := make(map[uint]bool, len(departmentIDs))
+			for _, id := range departmentIDs {
+				within[id] = true
+			}
+			departmentIDs = nil
+			for _, id := range ids {
+				if within[id] {
+					departmentIDs = append(departmentIDs, id)
+				}
+			}
+		} else {
+			departmentIDs = ids
+		}
+	}
+	if (departmentID != 0 || serviceID != 0) && len(departmentIDs) == 0 {
+		return []uint{}, nil
+	}
+	conscripts, err := s.store.Conscripts().List(departmentIDs)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[uint]bool, len(conscriptIDs))
+	for _, id := range conscriptIDs {
+		wanted[id] = true
+	}
+	candidates := []uint{}
+	for _, conscript := range conscripts {
+		if conscript.Status == models.StatusActive && (len(conscriptIDs) == 0 || wanted[conscript.ID]) {
+			candidates = append(candidates, conscript.ID)
+		}
+	}
+	return candidates, nil
+}
+
+// Generate previews the roster of the request among the stored records,
+// which are loaded as documented by roster.Window. Nothing is stored.
+func (s *Rosters) Generate(req roster.Request) (*roster.Plan, error) {
+	if err := req.Validate(); err != nil {
+		return nil, &Error{KindInvalid, err.Error()}
+	}
+	var records roster.Records
+	loaded := map[uint]bool{}
+	for _, slot := range req.Slots {
+		if loaded[slot.DutyID] {
+			continue
+		}
+		loaded[slot.DutyID] = true
+		// Generate reports the duties that are not found.
+		duty, err := s.store.Duties().FindWithQualifications(slot.DutyID)
+		if errors.Is(err, repository.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		records.Duties = append(records.Duties, duty)
+	}
+	start, end := roster.Window(req)
+	var err error
+	if records.Assignments, err = s.store.Assignments().List(repository.AssignmentFilter{From: &start, To: &end}); err != nil {
+		return nil, err
+	}
+	if records.Absences, err = s.store.Absences().List(repository.AbsenceFilter{ConscriptIDs: req.Candidates, Status: models.AbsenceApproved, From: &start, To: &end}); err != nil {
+		return nil, err
+	}
+	if records.Qualifications, err = s.store.Qualifications().Held(req.Candidates); err != nil {
+		return nil, err
+	}
+	scorer, err := loadScorer(s.store, req.Location)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := roster.Generate(req, records, scorer)
+	if errors.Is(err, roster.ErrInvalidRequest) {
+		return nil, &Error{KindInvalid, err.Error()}
+	}
+	return plan, err
+}
+
+// Commit books the assignments of a roster in one transaction, each one
+// checked for conflicts with the stored assignments and the earlier
+// assignments of the roster. Nothing is stored if the actor may not book
+// any of them, see Assignments.Create, or if any conflicts, unless adminID
+// identifies the administrator overriding the conflicts, see
+// Assignments.Check.
+func (s *Rosters) Commit(assignments []models.ConscriptDuty, actorID uint, admin bool, adminID uint) error {
+	return s.store.Transaction(func(tx repository.Store) error {
+		create := NewAssignments(tx).Create
+		for i := range assignments {
+			if err := create(&assignments[i], actorID, admin, adminID); err != nil {
+				var ce *ConflictError
+				var se *Error
+				if errors.As(err, &ce) || errors.As(err, &se) {
+					return fmt.Errorf("Assignment %d: %w", i, err)
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
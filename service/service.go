@@ -0,0 +1,44 @@
+// Package service holds the business rules of Pixis over the aggregates of
+// a repository.Store, independently of HTTP, so that the handlers, the
+// commands and the unit tests share them.
+package service
+
+import (
+	"errors"
+
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Kind is the kind of a rule violated by a request.
+type Kind int
+
+const (
+	// KindInvalid is a request that is malformed or breaks a rule.
+	KindInvalid Kind = iota + 1
+	// KindNotFound is a request referring to a record that does not exist.
+	KindNotFound
+	// KindForbidden is a request the actor is not allowed to make.
+	KindForbidden
+	// KindConflict is a request at odds with the stored records.
+	KindConflict
+)
+
+// Error is a request rejected by a business rule, with a message meant for
+// the user.
+type Error struct {
+	Kind    Kind
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// notFound reports a missing record with the message, passing other errors
+// through.
+func notFound(err error, message string) error {
+	if errors.Is(err, repository.ErrNotFound) {
+		return &Error{KindNotFound, message}
+	}
+	return err
+}
@@ -0,0 +1,643 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/conflicts"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/passwords"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/roster"
+)
+
+// kindOf returns the kind of a service error, failing the test for other
+// errors.
+func kindOf(t *testing.T, err error) Kind {
+	t.Helper()
+	var se *Error
+	if !errors.As(err, &se) {
+		t.Fatalf("expected a service error, got %v", err)
+	}
+	return se.Kind
+}
+
+func TestConscriptRoles(t *testing.T) {
+	t.Parallel()
+	conscripts := NewConscripts(repository.NewMemoryStore())
+
+	admin := models.Conscript{Username: "admin", Role: models.RoleAdmin}
+	if err := conscripts.Create(&admin, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected non-administrators not to create administrators, got %v", err)
+	}
+	if err := conscripts.Create(&admin, true); err != nil {
+		t.Fatalf("expected administrators to create administrators, got %v", err)
+	}
+	if err := conscripts.Create(&models.Conscript{Role: "general"}, true); kindOf(t, err) != KindInvalid {
+		t.Errorf("expected an invalid role to be rejected, got %v", err)
+	}
+
+	conscript := models.Conscript{Username: "conscript"}
+	if err := conscripts.Create(&conscript, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conscripts.Update(conscript.ID, models.Conscript{Status: models.StatusDischarged}, admin.ID, true); kindOf(t, err) != KindInvalid {
+		t.Errorf("expected discharges to be rejected, got %v", err)
+	}
+	updated, err := conscripts.Update(conscript.ID, models.Conscript{Rank: "Corporal"}, admin.ID, true)
+	if err != nil || updated.Rank != "Corporal" || updated.Username != "conscript" {
+		t.Errorf("expected the rank to be updated and the username kept, got %+v, %v", updated, err)
+	}
+	if _, err := conscripts.Update(conscript.ID, models.Conscript{Rank: "Sergeant"}, conscript.ID, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected conscripts not to change their own rank, got %v", err)
+	}
+	if _, err := conscripts.Update(admin.ID, models.Conscript{FirstName: "Mallory"}, conscript.ID, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected conscripts not to update others, got %v", err)
+	}
+	if updated, err := conscripts.Update(conscript.ID, models.Conscript{FirstName: "Nikos"}, conscript.ID, false); err != nil || updated.FirstName != "Nikos" {
+		t.Errorf("expected conscripts to change their own name, got %+v, %v", updated, err)
+	}
+	if err := conscripts.Delete(conscript.ID, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected conscripts not to delete conscripts, got %v", err)
+	}
+	if err := conscripts.Delete(conscript.ID, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conscripts.Get(conscript.ID); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected the deleted conscript not to be found, got %v", err)
+	}
+}
+
+func TestDepartmentHierarchy(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	departments := NewDepartments(store)
+
+	root := models.Department{Label: "Battalion"}
+	if err := departments.Create(&root, false); err != nil {
+		t.Fatal(err)
+	}
+	company := models.Department{Label: "Company", ParentID: &root.ID}
+	if err := departments.Create(&company, false); err != nil {
+		t.Fatal(err)
+	}
+	platoon := models.Department{Label: "Platoon", ParentID: &company.ID}
+	if err := departments.Create(&platoon, false); err != nil {
+		t.Fatal(err)
+	}
+
+	missing := uint(99)
+	if err := departments.Create(&models.Department{Label: "Orphan", ParentID: &missing}, false); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected a missing parent to be rejected, got %v", err)
+	}
+	if _, err := departments.Update(root.ID, models.Department{ParentID: &platoon.ID}, false); kindOf(t, err) != KindConflict {
+		t.Errorf("expected a cycle to be rejected, got %v", err)
+	}
+	if err := departments.Delete(company.ID); kindOf(t, err) != KindConflict {
+		t.Errorf("expected a department with sub-departments not to be deleted, got %v", err)
+	}
+
+	tree, err := departments.Tree(root.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tree.Children) != 1 || len(tree.Children[0].Children) != 1 || tree.Children[0].Children[0].ID != platoon.ID {
+		t.Errorf("expected the tree to nest the platoon under the company, got %+v", tree)
+	}
+	ancestors, err := departments.Ancestors(platoon.ID)
+	if err != nil || len(ancestors) != 2 || ancestors[0].ID != root.ID {
+		t.Errorf("expected the ancestors of the platoon from the root down, got %+v, %v", ancestors, err)
+	}
+
+	conscript := models.Conscript{Username: "commander"}
+	if err := store.Conscripts().Create(&conscript); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := departments.Update(platoon.ID, models.Department{CommanderID: &conscript.ID}, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected non-administrators not to appoint commanders, got %v", err)
+	}
+	updated, err := departments.Update(platoon.ID, models.Department{CommanderID: &conscript.ID}, true)
+	if err != nil || updated.CommanderID == nil || *updated.CommanderID != conscript.ID {
+		t.Errorf("expected administrators to appoint commanders, got %+v, %v", updated, err)
+	}
+}
+
+func TestAssignmentConflicts(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	assignments := NewAssignments(store)
+	at := func(hour int) time.Time {
+		return time.Date(2025, 3, 12, hour, 0, 0, 0, time.UTC)
+	}
+
+	first := models.ConscriptDuty{ConscriptID: 1, DutyID: 1, StartTime: at(8), EndTime: at(16)}
+	if err := assignments.Create(&first, 0, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignments.Create(&models.ConscriptDuty{ConscriptID: 1, DutyID: 2, StartTime: at(10), EndTime: at(8)}, 0, true, 0); kindOf(t, err) != KindInvalid {
+		t.Errorf("expected an assignment ending before it starts to be rejected, got %v", err)
+	}
+
+	overlapping := models.ConscriptDuty{ConscriptID: 1, DutyID: 2, StartTime: at(12), EndTime: at(20)}
+	var ce *ConflictError
+	if err := assignments.Create(&overlapping, 0, true, 0); !errors.As(err, &ce) || len(ce.Conflicts) != 1 {
+		t.Fatalf("expected the overlap to be reported, got %v", err)
+	}
+	if list, _ := assignments.List(repository.AssignmentFilter{}); len(list) != 1 {
+		t.Errorf("expected the rejected assignment not to be stored, got %d assignments", len(list))
+	}
+
+	if err := assignments.Create(&overlapping, 7, true, 7); err != nil {
+		t.Fatalf("expected the administrator to override the overlap, got %v", err)
+	}
+	overrides := store.Overrides()
+	if len(overrides) != 1 || overrides[0].AdminID != 7 || overrides[0].Conflicts != "overlap" {
+		t.Errorf("expected the override to be recorded, got %+v", overrides)
+	}
+
+	if _, err := assignments.Update(first.ID, models.ConscriptDuty{EndTime: at(11)}, 0, true, 0); !errors.As(err, &ce) || ce.Conflicts[0].Kind != conflicts.KindRest {
+		t.Errorf("expected shortening the first assignment to leave too little rest, got %v", err)
+	}
+	if _, err := assignments.Update(first.ID, models.ConscriptDuty{StartTime: at(0), EndTime: at(4)}, 0, true, 0); err != nil {
+		t.Errorf("expected moving the first assignment to clear the overlap, got %v", err)
+	}
+	report, err := assignments.Report(at(0), at(23))
+	if err != nil || len(report) != 0 {
+		t.Errorf("expected no conflicts left, got %+v, %v", report, err)
+	}
+
+	duty := models.Duty{Capacity: 1}
+	if err := store.Duties().Create(&duty); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignments.Create(&models.ConscriptDuty{ConscriptID: 2, DutyID: duty.ID, StartTime: at(8), EndTime: at(12)}, 0, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignments.Create(&models.ConscriptDuty{ConscriptID: 3, DutyID: duty.ID, StartTime: at(10), EndTime: at(14)}, 0, true, 0); !errors.As(err, &ce) || ce.Conflicts[0].Kind != conflicts.KindCapacity {
+		t.Errorf("expected the full duty to be reported, got %v", err)
+	}
+}
+
+func TestBootstrap(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	conscripts := NewConscripts(store)
+
+	taken := models.Conscript{Username: "taken"}
+	if err := conscripts.Create(&taken, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conscripts.Bootstrap("taken", "long-enough-password"); kindOf(t, err) != KindConflict {
+		t.Errorf("expected the username of a conscript to be rejected, got %v", err)
+	}
+
+	created, err := conscripts.Bootstrap("admin", "long-enough-password")
+	if err != nil || !created {
+		t.Fatalf("expected the first administrator to be created, got %v, %v", created, err)
+	}
+	admin, err := conscripts.GetByUsername("admin")
+	if err != nil || admin.Role != models.RoleAdmin || !passwords.Check(admin.Password, "long-enough-password") {
+		t.Errorf("expected an administrator with the hash of the password, got %+v, %v", admin, err)
+	}
+
+	if created, err := conscripts.Bootstrap("other", "long-enough-password"); err != nil || created {
+		t.Errorf("expected no administrator to be created once one exists, got %v, %v", created, err)
+	}
+	if _, err := conscripts.GetByUsername("other"); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected no second administrator, got %v", err)
+	}
+}
+
+func TestAbsenceAuthority(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	commander := models.Conscript{Username: "commander"}
+	store.Conscripts().Create(&commander)
+	battalion := models.Department{Label: "Battalion", CommanderID: &commander.ID}
+	store.Departments().Create(&battalion)
+	company := models.Department{Label: "Company", ParentID: &battalion.ID}
+	store.Departments().Create(&company)
+	soldier := models.Conscript{Username: "soldier", DepartmentID: company.ID}
+	other := models.Conscript{Username: "other"}
+	store.Conscripts().Create(&soldier)
+	store.Conscripts().Create(&other)
+	absences := NewAbsences(store)
+	start := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	absence := models.Absence{Type: models.AbsenceLeave, StartTime: start, EndTime: start.AddDate(0, 0, 2)}
+	if err := absences.Create(&absence, soldier.ID, false); err != nil || absence.ConscriptID != soldier.ID || absence.Status != models.AbsencePending {
+		t.Fatalf("expected a pending absence of the soldier, got %+v, %v", absence, err)
+	}
+	if err := absences.Create(&models.Absence{ConscriptID: soldier.ID, Type: models.AbsenceSick, StartTime: start, EndTime: start.Add(time.Hour)}, other.ID, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected conscripts not to request absences for others, got %v", err)
+	}
+	if _, err := absences.Get(absence.ID, other.ID, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected other conscripts not to see the absence, got %v", err)
+	}
+	if listed, err := absences.List(repository.AbsenceFilter{}, commander.ID, false); err != nil || len(listed) != 1 {
+		t.Errorf("expected the commander to see the absence of a sub-department, got %+v, %v", listed, err)
+	}
+	if listed, err := absences.List(repository.AbsenceFilter{}, other.ID, false); err != nil || len(listed) != 0 {
+		t.Errorf("expected other conscripts to see no absences, got %+v, %v", listed, err)
+	}
+	if _, err := absences.Decide(absence.ID, models.AbsenceApproved, soldier.ID, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected conscripts not to approve their own absences, got %v", err)
+	}
+	decided, err := absences.Decide(absence.ID, models.AbsenceApproved, commander.ID, false)
+	if err != nil || decided.Status != models.AbsenceApproved || decided.ApproverID != commander.ID {
+		t.Fatalf("expected the commander to approve the absence, got %+v, %v", decided, err)
+	}
+	if err := absences.Delete(absence.ID, soldier.ID, false); kindOf(t, err) != KindConflict {
+		t.Errorf("expected approved absences not to be cancelled, got %v", err)
+	}
+
+	strengths, err := absences.Strength(start.Add(time.Hour), company.ID)
+	if err != nil || len(strengths) != 1 || strengths[0].Absent != 1 || strengths[0].AbsentByType[models.AbsenceLeave] != 1 {
+		t.Errorf("expected the soldier to be absent on leave, got %+v, %v", strengths, err)
+	}
+}
+
+func TestQualificationEligibility(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	qualifications := NewQualifications(store)
+	for _, username := range []string{"licensed", "unlicensed"} {
+		if err := store.Conscripts().Create(&models.Conscript{Username: username}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	duty := models.Duty{Label: "Driver"}
+	if err := store.Duties().Create(&duty); err != nil {
+		t.Fatal(err)
+	}
+	licence := models.Qualification{Label: "Driving licence"}
+	if err := qualifications.Create(&licence); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := qualifications.SetRequired(duty.ID, []uint{licence.ID}); err != nil {
+		t.Fatal(err)
+	}
+	from, until := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := qualifications.Grant(1, licence.ID, from, &until, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected non-administrators not to grant qualifications, got %v", err)
+	}
+	if _, err := qualifications.Grant(1, licence.ID, from, &until, true); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)
+	eligible, err := qualifications.Eligible(duty.ID, start, start.Add(8*time.Hour), 0, false)
+	if err != nil || len(eligible) != 1 || eligible[0].ID != 1 {
+		t.Errorf("expected only the licensed driver, got %+v, %v", eligible, err)
+	}
+	var ce *ConflictError
+	if err := NewAssignments(store).Create(&models.ConscriptDuty{ConscriptID: 2, DutyID: duty.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)}, 0, true, 0); !errors.As(err, &ce) || ce.Conflicts[0].Kind != conflicts.KindQualification {
+		t.Errorf("expected the missing licence to be reported, got %v", err)
+	}
+
+	if err := qualifications.Delete(licence.ID); err != nil {
+		t.Fatal(err)
+	}
+	if held, _ := qualifications.Held(1, nil); len(held) != 0 {
+		t.Errorf("expected the deleted qualification not to be held, got %+v", held)
+	}
+	if eligible, _ := qualifications.Eligible(duty.ID, start, start.Add(8*time.Hour), 0, false); len(eligible) != 2 {
+		t.Errorf("expected everyone once the requirement is deleted, got %+v", eligible)
+	}
+}
+
+func TestConscriptLifecycle(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	conscripts := NewConscripts(store)
+	for _, label := range []string{"First company", "Second company"} {
+		if err := store.Departments().Create(&models.Department{Label: label}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	enlisted := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	conscript := models.Conscript{Username: "conscript", EnlistmentDate: &enlisted, DepartmentID: 1}
+	if err := conscripts.Create(&conscript, true); err != nil {
+		t.Fatal(err)
+	}
+
+	transferred := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := conscripts.Transfer(conscript.ID, 2, transferred, "", false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected non-administrators not to transfer conscripts, got %v", err)
+	}
+	if _, err := conscripts.Transfer(conscript.ID, 3, transferred, "", true); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected an unknown department to be rejected, got %v", err)
+	}
+	if updated, err := conscripts.Transfer(conscript.ID, 2, transferred, "Reinforcement", true); err != nil || updated.DepartmentID != 2 {
+		t.Fatalf("expected the conscript to be transferred, got %+v, %v", updated, err)
+	}
+	memberships, _ := conscripts.Memberships(conscript.ID)
+	if len(memberships) != 2 || !memberships[0].StartDate.Equal(enlisted) || !memberships[0].EndDate.Equal(transferred) || memberships[1].EndDate != nil {
+		t.Errorf("expected the first department from enlistment until the transfer, then the second, got %+v", memberships)
+	}
+
+	start := time.Date(2025, 5, 10, 8, 0, 0, 0, time.UTC)
+	if err := store.Assignments().Create(&models.ConscriptDuty{ConscriptID: conscript.ID, DutyID: 1, StartTime: start, EndTime: start.Add(8 * time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+	discharged, future, err := conscripts.Discharge(conscript.ID, time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC), "", true)
+	if err != nil || discharged.Status != models.StatusDischarged || len(future) != 1 {
+		t.Errorf("expected a discharged conscript with one assignment to reassign, got %+v, %+v, %v", discharged, future, err)
+	}
+	if _, _, err := conscripts.Discharge(conscript.ID, time.Now(), "", true); kindOf(t, err) != KindConflict {
+		t.Errorf("expected a second discharge to be rejected, got %v", err)
+	}
+}
+
+func TestAssignmentAuthority(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	assignments := NewAssignments(store)
+	commander, soldier := uint(1), uint(2)
+	company := models.Department{Label: "Company", CommanderID: &commander}
+	if err := store.Departments().Create(&company); err != nil {
+		t.Fatal(err)
+	}
+	guard := models.Service{Label: "Guard", DepartmentID: company.ID}
+	if err := store.Services().Create(&guard); err != nil {
+		t.Fatal(err)
+	}
+	gate, kitchen := models.Duty{Label: "Gate", ServiceID: guard.ID}, models.Duty{Label: "Kitchen"}
+	for _, duty := range []*models.Duty{&gate, &kitchen} {
+		if err := store.Duties().Create(duty); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Date(2025, 3, 12, 8, 0, 0, 0, time.UTC)
+
+	assignment := models.ConscriptDuty{ConscriptID: soldier, DutyID: gate.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)}
+	if err := assignments.Create(&assignment, soldier, false, 0); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected conscripts not to assign duties, got %v", err)
+	}
+	own := models.ConscriptDuty{ConscriptID: commander, DutyID: gate.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)}
+	if err := assignments.Create(&own, commander, false, 0); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected commanders not to assign duties to themselves, got %v", err)
+	}
+	if err := assignments.Create(&assignment, commander, false, 0); err != nil {
+		t.Fatalf("expected the commander to assign the duty, got %v", err)
+	}
+	if _, err := assignments.Update(assignment.ID, models.ConscriptDuty{ConscriptID: 3}, soldier, false, 0); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected the conscript not to hand the assignment over, got %v", err)
+	}
+	if _, err := assignments.Update(assignment.ID, models.ConscriptDuty{DutyID: kitchen.ID}, commander, false, 0); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected the commander not to move the assignment to a duty of another department, got %v", err)
+	}
+	if err := assignments.Delete(assignment.ID, soldier, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected the conscript not to delete the assignment, got %v", err)
+	}
+	if err := assignments.Delete(assignment.ID, commander, false); err != nil {
+		t.Errorf("expected the commander to delete the assignment, got %v", err)
+	}
+}
+
+func TestSwapWorkflow(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	swaps := NewSwaps(store)
+	commander := uint(4)
+	company := models.Department{Label: "Company", CommanderID: &commander}
+	if err := store.Departments().Create(&company); err != nil {
+		t.Fatal(err)
+	}
+	guard := models.Service{Label: "Guard", DepartmentID: company.ID}
+	if err := store.Services().Create(&guard); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Duties().Create(&models.Duty{Label: "Gate", ServiceID: guard.ID}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	assignments := []models.ConscriptDuty{
+		{ConscriptID: 1, DutyID: 1, StartTime: start, EndTime: start.Add(8 * time.Hour)},
+		{ConscriptID: 2, DutyID: 1, StartTime: start.Add(24 * time.Hour), EndTime: start.Add(32 * time.Hour)},
+	}
+	for i := range assignments {
+		if err := store.Assignments().Create(&assignments[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := swaps.Create(assignments[0].ID, assignments[1].ID, "", nil, 2); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected only the assigned conscript to propose a swap, got %v", err)
+	}
+	swap, err := swaps.Create(assignments[0].ID, assignments[1].ID, "", nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := swaps.Create(assignments[0].ID, assignments[1].ID, "", nil, 1); kindOf(t, err) != KindConflict {
+		t.Errorf("expected a second swap of the assignments to be rejected, got %v", err)
+	}
+	if _, err := swaps.Get(swap.ID, 3, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected others not to see the swap, got %v", err)
+	}
+	if _, err := swaps.Approve(swap.ID, 9, true, 0); kindOf(t, err) != KindConflict {
+		t.Errorf("expected a swap not yet accepted not to be approved, got %v", err)
+	}
+	if _, err := swaps.Respond(swap.ID, models.SwapAccepted, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := swaps.Approve(swap.ID, 2, false, 0); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected the counterparty not to approve the swap, got %v", err)
+	}
+	if _, err := swaps.Approve(swap.ID, 3, false, 0); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected others not to approve the swap, got %v", err)
+	}
+	if approved, err := swaps.Approve(swap.ID, commander, false, 0); err != nil || approved.Status != models.SwapApproved {
+		t.Fatalf("expected the swap to be approved, got %+v, %v", approved, err)
+	}
+	if exchanged, _ := store.Assignments().Find(assignments[0].ID); exchanged.ConscriptID != 2 {
+		t.Errorf("expected the assignments to be exchanged, got %+v", exchanged)
+	}
+	if pending, _ := swaps.Pending(1, 1, false); len(pending) != 0 {
+		t.Errorf("expected no pending swaps left, got %+v", pending)
+	}
+}
+
+func TestCalendarFeeds(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	cal := NewCalendar(store)
+	first, err := cal.IssueToken(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := cal.IssueToken(1)
+	if _, err := cal.Authenticate(first); err == nil {
+		t.Error("expected issuing a token to revoke the previous one")
+	}
+	if id, err := cal.Authenticate(second); err != nil || id != 1 {
+		t.Errorf("expected the token to authenticate conscript 1, got %d, %v", id, err)
+	}
+
+	for _, conscript := range []models.Conscript{{Username: "owner"}, {Username: "other"}} {
+		if err := store.Conscripts().Create(&conscript); err != nil {
+			t.Fatal(err)
+		}
+	}
+	guard := models.Service{Label: "Guard"}
+	if err := store.Services().Create(&guard); err != nil {
+		t.Fatal(err)
+	}
+	for _, duty := range []models.Duty{{Label: "Gate", ServiceID: guard.ID}, {Label: "Kitchen"}} {
+		if err := store.Duties().Create(&duty); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Truncate(time.Hour)
+	for _, dutyID := range []uint{1, 2} {
+		if err := store.Assignments().Create(&models.ConscriptDuty{ConscriptID: 1, DutyID: dutyID, StartTime: start, EndTime: start.Add(time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if feed, err := cal.ServiceFeed(guard.ID, 0, true); err != nil || feed.Name != "Guard" || len(feed.Assignments) != 1 || feed.Assignments[0].DutyID != 1 {
+		t.Errorf("expected the assignment to the gate, got %+v, %v", feed, err)
+	}
+	if _, err := cal.ServiceFeed(guard.ID, 1, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected the service feed to be refused to a conscript, got %v", err)
+	}
+	if feed, err := cal.ConscriptFeed(1, 1, false); err != nil || len(feed.Assignments) != 2 {
+		t.Errorf("expected both assignments in the own feed, got %+v, %v", feed, err)
+	}
+	if _, err := cal.ConscriptFeed(1, 2, false); kindOf(t, err) != KindForbidden {
+		t.Errorf("expected the feed of another conscript to be refused, got %v", err)
+	}
+
+	if err := cal.RevokeToken(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cal.RevokeToken(1); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected no token left to revoke, got %v", err)
+	}
+}
+
+func TestRosters(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	company := models.Department{Label: "Company"}
+	if err := store.Departments().Create(&company); err != nil {
+		t.Fatal(err)
+	}
+	platoon := models.Department{Label: "Platoon", ParentID: &company.ID}
+	if err := store.Departments().Create(&platoon); err != nil {
+		t.Fatal(err)
+	}
+	var conscripts []models.Conscript
+	for _, conscript := range []models.Conscript{
+		{DepartmentID: company.ID, Status: models.StatusActive},
+		{DepartmentID: company.ID, Status: models.StatusActive},
+		{DepartmentID: company.ID, Status: models.StatusDischarged},
+		{DepartmentID: platoon.ID, Status: models.StatusActive},
+	} {
+		if err := store.Conscripts().Create(&conscript); err != nil {
+			t.Fatal(err)
+		}
+		conscripts = append(conscripts, conscript)
+	}
+	guard := models.Service{Label: "Guard", DepartmentID: platoon.ID}
+	if err := store.Services().Create(&guard); err != nil {
+		t.Fatal(err)
+	}
+	gate := models.Duty{Label: "Gate", Points: 1, Capacity: 1}
+	if err := store.Duties().Create(&gate); err != nil {
+		t.Fatal(err)
+	}
+	rosters := NewRosters(store)
+	if _, err := rosters.Candidates(0, 99, nil); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected a missing service to be reported, got %v", err)
+	}
+	if _, err := rosters.Candidates(99, 0, nil); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected a missing department to be reported, got %v", err)
+	}
+	if candidates, err := rosters.Candidates(company.ID, guard.ID, nil); err != nil || len(candidates) != 1 || candidates[0] != conscripts[3].ID {
+		t.Errorf("expected the conscript of the platoon of the service, got %v, %v", candidates, err)
+	}
+	candidates, err := rosters.Candidates(company.ID, 0, []uint{conscripts[0].ID, conscripts[1].ID, conscripts[2].ID})
+	if err != nil || len(candidates) != 2 {
+		t.Fatalf("expected the active conscripts to be candidates, got %v, %v", candidates, err)
+	}
+	if all, err := rosters.Candidates(company.ID, 0, nil); err != nil || len(all) != 3 {
+		t.Errorf("expected the conscripts of the platoon under the company too, got %v, %v", all, err)
+	}
+
+	// The first conscript has served recently and should be spared.
+	now := time.Now().Truncate(time.Hour)
+	past := models.ConscriptDuty{ConscriptID: conscripts[0].ID, DutyID: gate.ID, StartTime: now.AddDate(0, 0, -10), EndTime: now.AddDate(0, 0, -10).Add(8 * time.Hour)}
+	if err := store.Assignments().Create(&past); err != nil {
+		t.Fatal(err)
+	}
+	start := now.AddDate(0, 0, 10)
+	req := roster.Request{
+		Slots:        []roster.Slot{{DutyID: gate.ID, StartTime: start, EndTime: start.Add(8 * time.Hour)}},
+		Candidates:   candidates,
+		HistorySince: now.AddDate(0, 0, -90),
+	}
+	plan, err := rosters.Generate(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Assignments) != 1 || plan.Assignments[0].ConscriptID != conscripts[1].ID {
+		t.Fatalf("expected the rested conscript to be assigned, got %+v", plan.Assignments)
+	}
+	if plan.Load[0].HistoryPoints != 1 {
+		t.Errorf("expected 1 history point for the first conscript, got %g", plan.Load[0].HistoryPoints)
+	}
+	req.Slots[0].DutyID = 99
+	if _, err := rosters.Generate(req); kindOf(t, err) != KindInvalid {
+		t.Errorf("expected a missing duty to be invalid, got %v", err)
+	}
+
+	if err := rosters.Commit(plan.Assignments, 0, true, 0); err != nil {
+		t.Fatal(err)
+	}
+	var ce *ConflictError
+	if err := rosters.Commit(plan.Assignments, 0, true, 0); !errors.As(err, &ce) {
+		t.Errorf("expected committing the roster twice to conflict, got %v", err)
+	}
+}
+
+func TestFairness(t *testing.T) {
+	t.Parallel()
+	store := repository.NewMemoryStore()
+	company := models.Department{Label: "Company"}
+	if err := store.Departments().Create(&company); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := store.Conscripts().Create(&models.Conscript{DepartmentID: company.ID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gate := models.Duty{Label: "Gate", Points: 2}
+	if err := store.Duties().Create(&gate); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2025, 3, 12, 8, 0, 0, 0, time.UTC)
+	for _, at := range []time.Time{start, start.AddDate(0, 0, 1), start.AddDate(0, 0, 30)} {
+		if err := store.Assignments().Create(&models.ConscriptDuty{ConscriptID: 1, DutyID: gate.ID, StartTime: at, EndTime: at.Add(8 * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reports := NewFairness(store)
+	from, to := start.AddDate(0, 0, -1), start.AddDate(0, 0, 7)
+	report, err := reports.Report(company.ID, from, to, 1.5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Standings) != 2 || report.Standings[0].Points != 4 || report.Mean != 2 {
+		t.Errorf("expected 4 points for the first conscript and a mean of 2, got %+v", report)
+	}
+	if _, err := reports.Report(99, from, to, 1.5, nil); kindOf(t, err) != KindNotFound {
+		t.Errorf("expected a missing department to be reported, got %v", err)
+	}
+	ledger, err := reports.Ledger(1, from, to, nil)
+	if err != nil || ledger.Total != 4 || len(ledger.Entries) != 2 || ledger.Entries[1].Balance != 4 {
+		t.Errorf("expected two entries totalling 4 points, got %+v, %v", ledger, err)
+	}
+}
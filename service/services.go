@@ -0,0 +1,53 @@
+package service
+
+import (
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// Services manages the services of departments.
+type Services struct {
+	store repository.Store
+}
+
+// NewServices returns the service managing the services of the store.
+func NewServices(store repository.Store) *Services {
+	return &Services{store: store}
+}
+
+// Create stores a new service.
+func (s *Services) Create(service *models.Service) error {
+	return s.store.Services().Create(service)
+}
+
+// Get returns a service.
+func (s *Services) Get(id uint) (models.Service, error) {
+	service, err := s.store.Services().Find(id)
+	return service, notFound(err, "Service not found")
+}
+
+// List returns the services of the departments, or of every department
+// when departmentIDs is nil.
+func (s *Services) List(departmentIDs []uint) ([]models.Service, error) {
+	return s.store.Services().List(departmentIDs)
+}
+
+// Replace stores every field of a service.
+func (s *Services) Replace(service *models.Service) error {
+	return s.store.Services().Save(service)
+}
+
+// Update stores the non-zero fields of changes on a service.
+func (s *Services) Update(id uint, changes models.Service) (models.Service, error) {
+	service, err := s.Get(id)
+	if err != nil {
+		return service, err
+	}
+	err = s.store.Services().Update(&service, changes)
+	return service, err
+}
+
+// Delete removes a service.
+func (s *Services) Delete(id uint) error {
+	return notFound(s.store.Services().Delete(id), "Service not found")
+}
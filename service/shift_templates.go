@@ -0,0 +1,120 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/recurrence"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/roster"
+)
+
+// OpenSlot is a shift of a template with its staffing. The embedded slot
+// can be passed as is to POST /rosters/generate.
+type OpenSlot struct {
+	roster.Slot
+	TemplateID uint `json:"template_id"`
+	Staffed    int  `json:"staffed"`
+	Open       int  `json:"open"`
+}
+
+// ShiftTemplates manages the recurring shifts of duties and expands them
+// into the slots to staff.
+type ShiftTemplates struct {
+	store repository.Store
+}
+
+// NewShiftTemplates returns the shift template service of the store.
+func NewShiftTemplates(store repository.Store) *ShiftTemplates {
+	return &ShiftTemplates{store: store}
+}
+
+// checkShiftTemplate validates that a template can be expanded.
+func checkShiftTemplate(template models.ShiftTemplate) error {
+	if err := recurrence.Validate(template); err != nil {
+		return &Error{KindInvalid, err.Error()}
+	}
+	return nil
+}
+
+// Create stores a new shift template.
+func (s *ShiftTemplates) Create(template *models.ShiftTemplate) error {
+	if err := checkShiftTemplate(*template); err != nil {
+		return err
+	}
+	return s.store.ShiftTemplates().Create(template)
+}
+
+// Get returns a shift template.
+func (s *ShiftTemplates) Get(id uint) (models.ShiftTemplate, error) {
+	template, err := s.store.ShiftTemplates().Find(id)
+	return template, notFound(err, "Shift template not found")
+}
+
+// List returns the shift templates of the filter.
+func (s *ShiftTemplates) List(filter repository.ShiftTemplateFilter) ([]models.ShiftTemplate, error) {
+	return s.store.ShiftTemplates().List(filter)
+}
+
+// Replace stores every field of a shift template.
+func (s *ShiftTemplates) Replace(template *models.ShiftTemplate) error {
+	if err := checkShiftTemplate(*template); err != nil {
+		return err
+	}
+	return s.store.ShiftTemplates().Save(template)
+}
+
+// Delete removes a shift template. Assignments made for its shifts are
+// kept.
+func (s *ShiftTemplates) Delete(id uint) error {
+	return notFound(s.store.ShiftTemplates().Delete(id), "Shift template not found")
+}
+
+// Slots expands the templates of the filter into the shifts overlapping
+// [from, to) in chronological order, counting the assignments that already
+// staff each one.
+func (s *ShiftTemplates) Slots(filter repository.ShiftTemplateFilter, from, to time.Time) ([]OpenSlot, error) {
+	templates, err := s.store.ShiftTemplates().List(filter)
+	if err != nil {
+		return nil, err
+	}
+	capacities := map[uint]int{}
+	slots := []OpenSlot{}
+	for _, t := range templates {
+		if _, ok := capacities[t.DutyID]; !ok {
+			duty, err := s.store.Duties().Find(t.DutyID)
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+			capacities[t.DutyID] = duty.Capacity
+		}
+		shifts, err := recurrence.Expand(t, from, to)
+		if err != nil {
+			return nil, &Error{KindInvalid, fmt.Sprintf("Shift template %d: %v", t.ID, err)}
+		}
+		for _, shift := range shifts {
+			count := shift.Count
+			if count == 0 {
+				count = max(capacities[shift.DutyID], 1)
+			}
+			start, end := shift.StartTime, shift.EndTime
+			staffing, err := s.store.Assignments().List(repository.AssignmentFilter{DutyID: shift.DutyID, From: &start, To: &end})
+			if err != nil {
+				return nil, err
+			}
+			slots = append(slots, OpenSlot{
+				Slot:       roster.Slot{DutyID: shift.DutyID, StartTime: shift.StartTime, EndTime: shift.EndTime, Count: count},
+				TemplateID: t.ID,
+				Staffed:    len(staffing),
+				Open:       max(count-len(staffing), 0),
+			})
+		}
+	}
+	sort.SliceStable(slots, func(i, j int) bool {
+		return slots[i].StartTime.Before(slots[j].StartTime)
+	})
+	return slots, nil
+}
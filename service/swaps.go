@@ -0,0 +1,303 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+)
+
+// defaultSwapExpiry is how long a swap request stays open when no expiry
+// is given, unless one of the assignments starts earlier.
+const defaultSwapExpiry = 72 * time.Hour
+
+// errSwapStale aborts a swap whose assignments changed after it was proposed.
+var errSwapStale = &Error{KindConflict, "The assignments have changed since the swap was proposed"}
+
+// Swaps manages the requests of conscripts to exchange assignments: the
+// requester proposes, the counterparty accepts or declines and an
+// administrator, or a commander of both duties, approves or rejects the swap.
+// Swaps still pending at their expiry expire whenever swaps are read.
+type Swaps struct {
+	store repository.Store
+}
+
+// NewSwaps returns the swap service of the store.
+func NewSwaps(store repository.Store) *Swaps {
+	return &Swaps{store: store}
+}
+
+// Create proposes to exchange an assignment of the actor with an assignment
+// of another conscript. Both assignments must not have started and must not
+// be part of another pending swap. The swap expires at expiresAt, by default
+// in 72 hours, and at the latest when the first of the assignments starts.
+func (s *Swaps) Create(assignmentID, counterpartID uint, message string, expiresAt *time.Time, actorID uint) (models.SwapRequest, error) {
+	var swap models.SwapRequest
+	assignment, err := s.store.Assignments().Find(assignmentID)
+	if err != nil {
+		return swap, notFound(err, "Assignment not found")
+	}
+	counterpart, err := s.store.Assignments().Find(counterpartID)
+	if err != nil {
+		return swap, notFound(err, "Counterpart assignment not found")
+	}
+	if actorID != assignment.ConscriptID {
+		return swap, &Error{KindForbidden, "Only the assigned conscript can propose a swap"}
+	}
+	if assignment.ConscriptID == counterpart.ConscriptID {
+		return swap, &Error{KindInvalid, "Both assignments belong to the same conscript"}
+	}
+	now := time.Now()
+	latest := assignment.StartTime
+	if counterpart.StartTime.Before(latest) {
+		latest = counterpart.StartTime
+	}
+	if !latest.After(now) {
+		return swap, &Error{KindConflict, "Only assignments that have not started can be swapped"}
+	}
+	expiry := now.Add(defaultSwapExpiry)
+	if expiresAt != nil {
+		if !expiresAt.After(now) {
+			return swap, &Error{KindInvalid, "expires_at must be in the future"}
+		}
+		expiry = *expiresAt
+	}
+	if expiry.After(latest) {
+		expiry = latest
+	}
+
+	if err := s.store.Swaps().Expire(now); err != nil {
+		return swap, err
+	}
+	pending, err := s.store.Swaps().List(repository.SwapFilter{Pending: true, AssignmentIDs: []uint{assignment.ID, counterpart.ID}})
+	if err != nil {
+		return swap, err
+	}
+	if len(pending) > 0 {
+		return swap, &Error{KindConflict, "One of the assignments is already part of a pending swap"}
+	}
+
+	swap = models.SwapRequest{
+		RequesterID:             assignment.ConscriptID,
+		AssignmentID:            assignment.ID,
+		CounterpartyID:          counterpart.ConscriptID,
+		CounterpartAssignmentID: counterpart.ID,
+		Message:                 message,
+		Status:                  models.SwapProposed,
+		ExpiresAt:               expiry,
+	}
+	return swap, s.store.Swaps().Create(&swap)
+}
+
+// list returns the swaps of the filter, oldest first, after expiring the
+// stale ones.
+func (s *Swaps) list(filter repository.SwapFilter) ([]models.SwapRequest, error) {
+	if err := s.store.Swaps().Expire(time.Now()); err != nil {
+		return nil, err
+	}
+	return s.store.Swaps().List(filter)
+}
+
+// List returns the swaps of the filter that the actor takes part in, or
+// anyone's as an administrator.
+func (s *Swaps) List(filter repository.SwapFilter, actorID uint, admin bool) ([]models.SwapRequest, error) {
+	if !admin {
+		filter.Involving = append(filter.Involving, actorID)
+	}
+	return s.list(filter)
+}
+
+// Pending returns the pending swaps a conscript takes part in, both those
+// they proposed and those awaiting their answer.
+func (s *Swaps) Pending(conscriptID, actorID uint, admin bool) ([]models.SwapRequest, error) {
+	allowed, err := canActFor(s.store, actorID, admin, conscriptID)
+	if err != nil {
+		return nil, err
+	}
+	if !allowed {
+		return nil, &Error{KindForbidden, "Not allowed to access the swaps of this conscript"}
+	}
+	return s.list(repository.SwapFilter{Pending: true, Involving: []uint{conscriptID}})
+}
+
+// PendingOfService returns the pending swaps of assignments to duties of a
+// service, for administrators and commanders of its department to review.
+func (s *Swaps) PendingOfService(serviceID, actorID uint, admin bool) ([]models.SwapRequest, error) {
+	service, err := s.store.Services().Find(serviceID)
+	if err != nil {
+		return nil, notFound(err, "Service not found")
+	}
+	if !admin {
+		commands, err := commandsDepartment(s.store, actorID, service.DepartmentID)
+		if err != nil {
+			return nil, err
+		}
+		if !commands {
+			return nil, &Error{KindForbidden, "Only administrators and commanders can review the swaps of a service"}
+		}
+	}
+	return s.list(repository.SwapFilter{Pending: true, ServiceID: serviceID})
+}
+
+// Get returns a swap the actor may access: one they or a conscript under
+// their command take part in, or any as an administrator.
+func (s *Swaps) Get(id, actorID uint, admin bool) (models.SwapRequest, error) {
+	if err := s.store.Swaps().Expire(time.Now()); err != nil {
+		return models.SwapRequest{}, err
+	}
+	swap, err := s.store.Swaps().Find(id)
+	if err != nil {
+		return swap, notFound(err, "Swap not found")
+	}
+	for _, conscriptID := range []uint{swap.RequesterID, swap.CounterpartyID} {
+		allowed, err := canActFor(s.store, actorID, admin, conscriptID)
+		if err != nil || allowed {
+			return swap, err
+		}
+	}
+	return swap, &Error{KindForbidden, "Not allowed to access this swap"}
+}
+
+// Respond lets the counterparty accept or decline a proposed swap.
+func (s *Swaps) Respond(id uint, status models.SwapStatus, actorID uint, admin bool) (models.SwapRequest, error) {
+	swap, err := s.Get(id, actorID, admin)
+	if err != nil {
+		return swap, err
+	}
+	if actorID != swap.CounterpartyID {
+		return swap, &Error{KindForbidden, "Only the counterparty can accept or decline the swap"}
+	}
+	if swap.Status != models.SwapProposed {
+		return swap, &Error{KindConflict, "The swap is " + string(swap.Status)}
+	}
+	now := time.Now()
+	swap.Status = status
+	swap.RespondedAt = &now
+	return swap, s.store.Swaps().Save(&swap)
+}
+
+// Cancel lets the requester withdraw a pending swap.
+func (s *Swaps) Cancel(id, actorID uint, admin bool) (models.SwapRequest, error) {
+	swap, err := s.Get(id, actorID, admin)
+	if err != nil {
+		return swap, err
+	}
+	if actorID != swap.RequesterID {
+		return swap, &Error{KindForbidden, "Only the requester can cancel the swap"}
+	}
+	if !swap.Status.Pending() {
+		return swap, &Error{KindConflict, "The swap is " + string(swap.Status)}
+	}
+	swap.Status = models.SwapCancelled
+	return swap, s.store.Swaps().Save(&swap)
+}
+
+// reviewable returns a pending swap the actor may approve or reject.
+// Administrators review any swap, and commanders the swaps of assignments to
+// duties of the departments under their command, other than their own.
+func (s *Swaps) reviewable(id, actorID uint, admin bool) (models.SwapRequest, error) {
+	if err := s.store.Swaps().Expire(time.Now()); err != nil {
+		return models.SwapRequest{}, err
+	}
+	swap, err := s.store.Swaps().Find(id)
+	if err != nil || admin {
+		return swap, notFound(err, "Swap not found")
+	}
+	forbidden := &Error{KindForbidden, "Only administrators and commanders of both duties can approve or reject the swap"}
+	if actorID == swap.RequesterID || actorID == swap.CounterpartyID {
+		return swap, forbidden
+	}
+	for _, assignmentID := range []uint{swap.AssignmentID, swap.CounterpartAssignmentID} {
+		commands, err := commandsAssignment(s.store, actorID, assignmentID)
+		if err != nil {
+			return swap, err
+		}
+		if !commands {
+			return swap, forbidden
+		}
+	}
+	return swap, nil
+}
+
+// exchange swaps the conscripts of the two assignments of an approved swap
+// and re-runs the conflict checks on the result, see Assignments.Check.
+func exchange(store repository.Store, swap models.SwapRequest, adminID uint) error {
+	assignment, err := store.Assignments().Find(swap.AssignmentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errSwapStale
+	}
+	if err != nil {
+		return err
+	}
+	counterpart, err := store.Assignments().Find(swap.CounterpartAssignmentID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return errSwapStale
+	}
+	if err != nil {
+		return err
+	}
+	if assignment.ConscriptID != swap.RequesterID || counterpart.ConscriptID != swap.CounterpartyID {
+		return errSwapStale
+	}
+	assignment.ConscriptID, counterpart.ConscriptID = counterpart.ConscriptID, assignment.ConscriptID
+	// Both assignments are moved before checking, so that each is checked
+	// against the other in its new place.
+	if err := store.Assignments().Save(&assignment); err != nil {
+		return err
+	}
+	if err := store.Assignments().Save(&counterpart); err != nil {
+		return err
+	}
+	assignments := NewAssignments(store)
+	if err := assignments.Check(assignment, adminID); err != nil {
+		return err
+	}
+	return assignments.Check(counterpart, adminID)
+}
+
+// Approve approves a swap accepted by the counterparty, exchanging the
+// conscripts of the two assignments atomically. Conflicts of the exchanged
+// assignments are overridden when adminID identifies the administrator
+// overriding them, see Assignments.Check.
+func (s *Swaps) Approve(id, actorID uint, admin bool, adminID uint) (models.SwapRequest, error) {
+	swap, err := s.reviewable(id, actorID, admin)
+	if err != nil {
+		return swap, err
+	}
+	switch swap.Status {
+	case models.SwapAccepted:
+	case models.SwapProposed:
+		return swap, &Error{KindConflict, "The swap has not been accepted by the counterparty"}
+	default:
+		return swap, &Error{KindConflict, "The swap is " + string(swap.Status)}
+	}
+	now := time.Now()
+	swap.Status = models.SwapApproved
+	swap.ApproverID = actorID
+	swap.DecidedAt = &now
+	err = s.store.Transaction(func(tx repository.Store) error {
+		if err := exchange(tx, swap, adminID); err != nil {
+			return err
+		}
+		return tx.Swaps().Save(&swap)
+	})
+	return swap, err
+}
+
+// Reject rejects a pending swap, whether or not the counterparty accepted
+// it. Approve and Reject are open to the reviewers documented by reviewable.
+func (s *Swaps) Reject(id, actorID uint, admin bool) (models.SwapRequest, error) {
+	swap, err := s.reviewable(id, actorID, admin)
+	if err != nil {
+		return swap, err
+	}
+	if !swap.Status.Pending() {
+		return swap, &Error{KindConflict, "The swap is " + string(swap.Status)}
+	}
+	now := time.Now()
+	swap.Status = models.SwapRejected
+	swap.ApproverID = actorID
+	swap.DecidedAt = &now
+	return swap, s.store.Swaps().Save(&swap)
+}
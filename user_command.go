@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alexandrosraikos/pixis/config"
+	"github.com/alexandrosraikos/pixis/models"
+	"github.com/alexandrosraikos/pixis/repository"
+	"github.com/alexandrosraikos/pixis/service"
+)
+
+const userUsage = "usage: pixis user create [flags] USERNAME | reset-password [-password-stdin] USERNAME | disable USERNAME | enable USERNAME"
+
+// runUser implements `pixis user create|reset-password|disable|enable`.
+func runUser(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, userUsage)
+		return 2
+	}
+	flags := flag.NewFlagSet("user "+args[0], flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var conscript models.Conscript
+	var admin, passwordStdin bool
+	switch args[0] {
+	case "create":
+		flags.StringVar(&conscript.FirstName, "first-name", "", "first name")
+		flags.StringVar(&conscript.LastName, "last-name", "", "last name")
+		flags.StringVar(&conscript.RegistryNumber, "registry-number", "", "registry number (default the username)")
+		flags.StringVar(&conscript.Rank, "rank", "", "rank")
+		flags.UintVar(&conscript.DepartmentID, "department", 0, "ID of the department")
+		flags.BoolVar(&admin, "admin", false, "grant the administrator role")
+		fallthrough
+	case "reset-password":
+		flags.BoolVar(&passwordStdin, "password-stdin", false, "read the password from the standard input instead of generating one")
+	case "disable", "enable":
+	default:
+		fmt.Fprintln(stderr, userUsage)
+		return 2
+	}
+	loader := config.Bind(flags)
+	if err := flags.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, userUsage)
+		return 2
+	}
+	username := flags.Arg(0)
+
+	var password string
+	if passwordStdin {
+		var err error
+		if password, err = readPassword(stdin); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+	db, err := connect(loader)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	conscripts := service.NewConscripts(repository.NewGormStore(db))
+
+	switch args[0] {
+	case "create":
+		generated := password == ""
+		if generated {
+			password = generatePassword()
+		}
+		conscript.Username = username
+		conscript.Password = password
+		if conscript.RegistryNumber == "" {
+			conscript.RegistryNumber = username
+		}
+		if admin {
+			conscript.Role = models.RoleAdmin
+		}
+		if err := conscripts.Create(&conscript, true); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "created %s %s with ID %d\n", roleOf(conscript), username, conscript.ID)
+		if generated {
+			fmt.Fprintf(stdout, "password: %s\n", password)
+		}
+		return 0
+	}
+
+	existing, err := conscripts.GetByUsername(username)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	switch args[0] {
+	case "reset-password":
+		generated := password == ""
+		if generated {
+			password = generatePassword()
+		}
+		if _, err := conscripts.Update(existing.ID, models.Conscript{Password: password}, 0, true); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "reset the password of %s\n", username)
+		if generated {
+			fmt.Fprintf(stdout, "password: %s\n", password)
+		}
+	case "disable", "enable":
+		disable := args[0] == "disable"
+		if _, err := conscripts.SetDisabled(existing.ID, disable); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%sd %s\n", args[0], username)
+	}
+	return 0
+}
+
+func roleOf(conscript models.Conscript) string {
+	if conscript.Role == models.RoleAdmin {
+		return "administrator"
+	}
+	return "conscript"
+}
+
+// readPassword reads a password from the first line of the input.
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("empty password")
+	}
+	return password, nil
+}
+
+// generatePassword returns a random password of 128 bits.
+func generatePassword() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}